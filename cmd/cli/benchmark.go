@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+func runBenchmark(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	db := flags.String("db", storage.DefaultStoragePath, "storage path, the app must be closed to open it")
+	size := flags.String("size", "64MB", "number of bytes pushed and pulled")
+	probes := flags.Int("n", adbclient.DefaultBenchmarkProbes, "number of commands run to measure the latency")
+	history := flags.Bool("history", false, "print the average of the saved benchmarks by USB port instead of running one")
+	quiet := flags.Bool("q", false, "don't print the progress")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: benchmark [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	s, err := storage.NewStorage(*db, cli.log)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	if *history {
+		return printPortBenchmarks(s)
+	}
+
+	var bytes datasize.ByteSize
+	if err := bytes.UnmarshalText([]byte(*size)); err != nil {
+		return fmt.Errorf("invalid size %q: %w", *size, err)
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	opts := []adbclient.BenchmarkOption{adbclient.WithBenchmarkSize(int64(bytes.Bytes())), adbclient.WithBenchmarkProbes(*probes)}
+	if !*quiet {
+		opts = append(opts, adbclient.WithBenchmarkProgress(progress()))
+	}
+
+	result, err := cli.client.Benchmark(ctx, device, opts...)
+	if !*quiet {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s on %s: %s\n", result.Serial, result.USB, result)
+	return s.SaveBenchmark(result)
+}
+
+// printPortBenchmarks prints the average throughput and latency of each USB port, the slowest pushing ports first.
+func printPortBenchmarks(s *storage.Storage) error {
+	ports, err := s.GetPortBenchmarks()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT\tRUNS\tPUSH MB/s\tPULL MB/s\tLATENCY\tLAST DEVICE")
+	for _, summary := range storage.SummarizePorts(ports) {
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.1f\t%s\t%s\n", summary.Port, summary.Runs, summary.Push/1e6, summary.Pull/1e6, summary.Latency.Round(100*time.Microsecond), summary.Device)
+	}
+
+	return w.Flush()
+}
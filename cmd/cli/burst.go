@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/animation"
+)
+
+func runBurst(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("burst", flag.ExitOnError)
+	frames := flags.Int("frames", adbclient.DefaultBurstFrames, "number of frames to capture")
+	fps := flags.Float64("fps", adbclient.DefaultBurstFPS, "target frame rate")
+	scale := flags.Float64("scale", 0.5, "downscale factor in range (0, 1]")
+	quality := flags.String("quality", animation.PaletteHigh.String(), "GIF palette quality: low, medium or high")
+	loops := flags.Int("loops", 0, "number of times the animation is played, 0 means forever")
+	output := flags.String("o", "./screenshot.gif", "output file, the format is chosen by extension (.gif or .png)")
+	flags.Parse(args)
+
+	format, err := animation.FormatFromPath(*output)
+	if err != nil {
+		return err
+	}
+
+	paletteQuality, err := animation.ParsePaletteQuality(*quality)
+	if err != nil {
+		return err
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	burst, err := cli.client.ScreenshotBurst(
+		ctx,
+		device,
+		adbclient.WithBurstFrames(*frames),
+		adbclient.WithBurstFPS(*fps),
+		adbclient.WithBurstProgress(func(frame, total int) {
+			fmt.Printf("\rCaptured %d/%d frames", frame, total)
+		}),
+	)
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	animFrames := make([]animation.Frame, len(burst))
+	for i, frame := range burst {
+		animFrames[i] = animation.Frame{Image: frame.Image, Delay: frame.Delay}
+	}
+
+	if err := animation.Encode(
+		file,
+		format,
+		animFrames,
+		animation.WithScale(*scale),
+		animation.WithPaletteQuality(paletteQuality),
+		animation.WithLoopCount(*loops),
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %s to %s\n", format, *output)
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/johnnyipcom/androidtool/pkg/screendiff"
+)
+
+// masksFlag collects repeated -mask flags.
+type masksFlag []image.Rectangle
+
+func (m *masksFlag) String() string {
+	return fmt.Sprint([]image.Rectangle(*m))
+}
+
+func (m *masksFlag) Set(s string) error {
+	r, err := screendiff.ParseMask(s)
+	if err != nil {
+		return err
+	}
+
+	*m = append(*m, r)
+	return nil
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func runDiff(ctx context.Context, cli *cli, args []string) error {
+	var masks masksFlag
+
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	baselines := flags.String("baselines", screendiff.DefaultBaselinePath, "baseline store directory")
+	results := flags.String("results", screendiff.DefaultResultsPath, "directory for actual and diff images")
+	app := flags.String("app", "", "application package")
+	version := flags.String("version", "", "application version, read from the device if empty")
+	locale := flags.String("locale", "", "device locale, read from the device if empty")
+	names := flags.String("name", "", "comma separated screenshot names")
+	input := flags.String("i", "", "comma separated image files to compare instead of capturing the screen, one per name")
+	tolerance := flags.Uint("tolerance", screendiff.DefaultTolerance, "per-channel color tolerance")
+	maxRatio := flags.Float64("max-ratio", screendiff.DefaultMaxDiffRatio, "maximum ratio of differing pixels")
+	statusBar := flags.Bool("mask-status-bar", true, "exclude the status bar from the comparison")
+	update := flags.Bool("update", false, "save the screenshots as new baselines instead of comparing")
+	flags.Var(&masks, "mask", "region excluded from the comparison as x0,y0,x1,y1, can be repeated")
+	flags.Parse(args)
+
+	if *app == "" || *names == "" {
+		return fmt.Errorf("-app and -name are required")
+	}
+
+	if *tolerance > math.MaxUint8 {
+		return fmt.Errorf("-tolerance must be between 0 and %d", math.MaxUint8)
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	if *version == "" {
+		pkg, err := cli.client.GetPackage(device, *app)
+		if err != nil {
+			return err
+		}
+
+		*version = pkg.VersionName
+	}
+
+	if *locale == "" {
+		if *locale, err = cli.client.Locale(device); err != nil {
+			return err
+		}
+	}
+
+	opts := []screendiff.CompareOption{
+		screendiff.WithTolerance(uint8(*tolerance)),
+		screendiff.WithMaxDiffRatio(*maxRatio),
+		screendiff.WithMask(masks...),
+	}
+
+	if *statusBar {
+		opts = append(opts, screendiff.WithMask(screendiff.StatusBarMask(device.Display.Width, device.Display.Density)))
+	}
+
+	var inputs []string
+	if *input != "" {
+		inputs = strings.Split(*input, ",")
+	}
+
+	store := screendiff.NewStore(*baselines)
+	report := &screendiff.Report{}
+	for i, name := range strings.Split(*names, ",") {
+		key := screendiff.Key{App: *app, Version: *version, Model: device.Model, Locale: *locale, Name: name}
+
+		var actual image.Image
+		if i < len(inputs) {
+			actual, err = loadImage(inputs[i])
+		} else {
+			actual, err = cli.client.CaptureScreen(device)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if *update {
+			if err := store.Save(key, actual); err != nil {
+				return err
+			}
+
+			fmt.Printf("Saved baseline %s\n", store.Path(key))
+			continue
+		}
+
+		result, err := store.Compare(key, actual, opts...)
+		if err != nil {
+			return err
+		}
+
+		report.Add(key, result)
+		if result.Passed() {
+			continue
+		}
+
+		_, diffPath, err := screendiff.SaveResult(*results, key, actual, result)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Diff saved to %s\n", diffPath)
+	}
+
+	if *update {
+		return nil
+	}
+
+	fmt.Println(report)
+	if !report.Passed() {
+		return fmt.Errorf("%d screenshot(s) differ from baseline", report.Failed())
+	}
+
+	return nil
+}
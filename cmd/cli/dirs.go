@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// progressInterval is the minimum interval between two progress lines.
+const progressInterval = 200 * time.Millisecond
+
+// progress returns a progress function printing the transferred bytes to stderr.
+func progress() func(sentBytes int64, totalBytes int64) {
+	var mu sync.Mutex
+	var last time.Time
+	return func(sentBytes int64, totalBytes int64) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if sentBytes < totalBytes && time.Since(last) < progressInterval {
+			return
+		}
+
+		last = time.Now()
+		fmt.Fprintf(os.Stderr, "\r%s / %s   ", datasize.ByteSize(sentBytes).HumanReadable(), datasize.ByteSize(totalBytes).HumanReadable())
+	}
+}
+
+// dirFlags adds the flags common to the directory transfers.
+func dirFlags(flags *flag.FlagSet) func() []adbclient.DirOption {
+	parallelism := flags.Int("j", adbclient.DefaultTransferParallelism, "number of files transferred at once")
+	quiet := flags.Bool("q", false, "don't print the progress")
+
+	return func() []adbclient.DirOption {
+		opts := []adbclient.DirOption{adbclient.WithDirParallelism(*parallelism)}
+		if !*quiet {
+			opts = append(opts, adbclient.WithDirProgress(progress()))
+		}
+
+		return opts
+	}
+}
+
+func runPush(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("push", flag.ExitOnError)
+	options := dirFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: push [options] <local dir> <device dir>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a local and a device directory")
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	if err := cli.client.PushDir(ctx, device, flags.Arg(0), flags.Arg(1), options()...); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
+func runPull(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("pull", flag.ExitOnError)
+	options := dirFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: pull [options] <device dir> <local dir>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a device and a local directory")
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	if err := cli.client.PullDir(ctx, device, flags.Arg(0), flags.Arg(1), options()...); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
+func runSync(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	options := dirFlags(flags)
+	checksum := flags.Bool("c", false, "compare files of the same size by md5sum instead of modification time")
+	del := flags.Bool("delete", false, "delete files of the device directory missing in the local directory")
+	verbose := flags.Bool("v", false, "print the transferred and deleted files")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: sync [options] <local dir> <device dir>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a local and a device directory")
+	}
+
+	opts := options()
+	if *checksum {
+		opts = append(opts, adbclient.WithDirChecksum())
+	}
+
+	if *del {
+		opts = append(opts, adbclient.WithDirDelete())
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	result, err := cli.client.SyncDir(ctx, device, flags.Arg(0), flags.Arg(1), opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Transferred) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if *verbose {
+		for _, name := range result.Transferred {
+			fmt.Println("+", name)
+		}
+
+		for _, name := range result.Deleted {
+			fmt.Println("-", name)
+		}
+	}
+
+	for _, name := range result.Conflicts {
+		fmt.Fprintf(os.Stderr, "skipped %s: a directory of the device has this path, sync with -delete to replace it\n", name)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d transferred (%s), %d deleted, %d unchanged\n",
+		len(result.Transferred), datasize.ByteSize(result.Bytes).HumanReadable(), len(result.Deleted), result.Unchanged)
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/logexport"
+)
+
+func runHistory(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	db := flags.String("db", storage.DefaultStoragePath, "storage path, the app must be closed to open it")
+	from := flags.String("from", "1h", "start time: a duration before now, e.g. 1h, '2006-01-02 15:04:05' or RFC 3339")
+	to := flags.String("to", "", "end time, now if empty")
+	priority := flags.String("p", adbclient.Verbose.String(), "minimum priority: V, D, I, W, E or F")
+	tag := flags.String("t", "", "exact tag")
+	text := flags.String("text", "", "case-insensitive text of the message")
+	limit := flags.Int("n", 0, "maximum number of messages, the newest are printed, 0 is unlimited")
+	format := flags.String("v", adbclient.FormatThreadtime.String(), "output format: threadtime, brief, time, long, year, epoch, uid")
+	output := flags.String("o", "", "export to a file in the format of its extension: .log, .jsonl, .csv or .html")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: history [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	// the archive of an offline device can be queried by serial
+	serial := cli.serial
+	if serial == "" {
+		device, err := cli.device()
+		if err != nil {
+			return err
+		}
+
+		serial = device.Serial
+	}
+
+	now := time.Now()
+	query := storage.LogcatQuery{
+		Serial: serial,
+		Tag:    *tag,
+		Text:   *text,
+		Limit:  *limit,
+	}
+
+	var err error
+	if query.From, err = storage.ParseLogcatTime(*from, now); err != nil {
+		return err
+	}
+
+	if query.To, err = storage.ParseLogcatTime(*to, now); err != nil {
+		return err
+	}
+
+	if query.Priority, err = adbclient.ParseLogcatPriority(*priority); err != nil {
+		return err
+	}
+
+	logcatFormat, err := adbclient.ParseLogcatFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	s, err := storage.NewStorage(*db, cli.log)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	msgs, err := s.QueryLogcat(query)
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return logexport.Export(*output, msgs, "Logcat history: "+serial)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	for _, msg := range msgs {
+		fmt.Fprintln(w, msg.Format(logcatFormat))
+	}
+
+	return nil
+}
+
+func runArchive(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("archive", flag.ExitOnError)
+	db := flags.String("db", storage.DefaultStoragePath, "storage path, the app must be closed to open it")
+	retention := flags.Duration("retention", storage.DefaultLogcatRetention, "age after which messages are deleted, 0 keeps them forever")
+	maxMessages := flags.Int("max", storage.DefaultLogcatMaxMessages, "maximum number of messages of the device, 0 is unlimited")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: archive [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	s, err := storage.NewStorage(*db, cli.log)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	archiver, err := storage.NewLogcatArchiver(cli.client, s, storage.WithRetention(*retention), storage.WithMaxMessages(*maxMessages))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Archiving the log of %s, press Ctrl+C to stop\n", device.Serial)
+	archiver.Update(device)
+
+	<-ctx.Done()
+	archiver.Close()
+	return nil
+}
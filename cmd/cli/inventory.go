@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnnyipcom/androidtool/internal/storage"
+)
+
+func runInventory(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("inventory", flag.ExitOnError)
+	db := flags.String("db", storage.DefaultStoragePath, "storage path, the app must be closed to open it")
+	export := flags.String("export", "", "write the inventory to the file, '-' for stdout")
+	importPath := flags.String("import", "", "merge the inventory of the file into the storage")
+	format := flags.String("format", "", "csv or json, guessed from the file extension by default")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: inventory [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if (*export == "") == (*importPath == "") {
+		flags.Usage()
+		return fmt.Errorf("one of -export or -import is required")
+	}
+
+	s, err := storage.NewStorage(*db, cli.log)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	if *importPath != "" {
+		f, err := os.Open(*importPath)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		var entries []storage.InventoryEntry
+		if inventoryFormat(*format, *importPath) == "json" {
+			entries, err = storage.ReadInventoryJSON(f)
+		} else {
+			entries, err = storage.ReadInventoryCSV(f)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.ImportInventory(entries); err != nil {
+			return err
+		}
+
+		fmt.Printf("imported %d devices\n", len(entries))
+		return nil
+	}
+
+	entries, err := s.GetInventory()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *export != "-" {
+		f, err := os.Create(*export)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	if inventoryFormat(*format, *export) == "json" {
+		return storage.WriteInventoryJSON(w, entries)
+	}
+
+	return storage.WriteInventoryCSV(w, entries)
+}
+
+// inventoryFormat returns the format if set, json for .json files and csv otherwise.
+func inventoryFormat(format, path string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return "json"
+	}
+
+	return "csv"
+}
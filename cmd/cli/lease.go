@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+func runLease(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("lease", flag.ExitOnError)
+	db := flags.String("db", storage.DefaultStoragePath, "storage path, the app must be closed to open it")
+	owner := flags.String("owner", adbclient.DefaultLeaseOwner(), "owner of the lease, e.g. a CI job name")
+	ttl := flags.Duration("ttl", time.Hour, "duration of the lease, it is extended if the owner already has it")
+	note := flags.String("note", "", "note shown to the other users of the device")
+	release := flags.Bool("release", false, "release the lease of the owner")
+	force := flags.Bool("force", false, "with -release, release the lease whoever owns it")
+	list := flags.Bool("list", false, "list the active leases")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lease [options]\n\nReserves the device, the operations changing it from other owners fail until the lease expires or is released.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	s, err := storage.NewStorage(*db, cli.log)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	if *list {
+		leases, err := s.GetLeases()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, lease := range leases {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", lease.Serial, lease.Owner, lease.Expires.Format("2006-01-02 15:04:05"), lease.Note)
+		}
+
+		return w.Flush()
+	}
+
+	// the device may be offline, e.g. to reserve it while it reboots
+	serial := cli.serial
+	if serial == "" {
+		device, err := cli.device()
+		if err != nil {
+			return err
+		}
+
+		serial = device.Serial
+	}
+
+	switch {
+	case *release && *force:
+		return s.ForceReleaseLease(serial)
+	case *release:
+		return s.ReleaseLease(serial, *owner)
+	}
+
+	lease, err := s.AcquireLease(serial, *owner, *note, *ttl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s leased by %s\n", serial, lease)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/logexport"
+)
+
+func runLogcat(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("logcat", flag.ExitOnError)
+	buffers := flags.String("b", "", "comma separated buffers: main, radio, events, system, crash, stats, security, kernel or all")
+	since := flags.String("T", "", "print messages since a duration before now, e.g. 5m, or the last N lines")
+	regex := flags.String("regex", "", "print only messages matching the regular expression")
+	uids := flags.String("uid", "", "comma separated uids")
+	dump := flags.Bool("d", false, "dump the log and exit")
+	format := flags.String("v", adbclient.FormatThreadtime.String(), "output format: threadtime, brief, time, long, year, epoch, uid")
+	output := flags.String("o", "", "output file, stdout if empty; .jsonl, .csv and .html files are exported when the log ends or on Ctrl+C")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: logcat [options] [filterspecs]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var opts []adbclient.LogcatOption
+	for _, name := range strings.Split(*buffers, ",") {
+		switch name {
+		case "":
+		case "all":
+			opts = append(opts, adbclient.WithLogcatAllBuffers())
+		default:
+			buffer, err := adbclient.ParseLogcatBuffer(name)
+			if err != nil {
+				return err
+			}
+
+			opts = append(opts, adbclient.WithLogcatBuffers(buffer))
+		}
+	}
+
+	specs, err := adbclient.ParseLogcatFilterSpecs(strings.Join(flags.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	if len(specs) > 0 {
+		opts = append(opts, adbclient.WithLogcatFilterSpecs(specs...))
+	}
+
+	if *since != "" {
+		if count, err := strconv.Atoi(*since); err == nil {
+			opts = append(opts, adbclient.WithLogcatTail(count))
+		} else if d, err := time.ParseDuration(*since); err == nil {
+			opts = append(opts, adbclient.WithLogcatSince(time.Now().Add(-d)))
+		} else {
+			return fmt.Errorf("invalid -T: %s", *since)
+		}
+	}
+
+	if *regex != "" {
+		opts = append(opts, adbclient.WithLogcatRegex(*regex))
+	}
+
+	for _, field := range strings.Split(*uids, ",") {
+		if field == "" {
+			continue
+		}
+
+		uid, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("invalid uid: %s", field)
+		}
+
+		opts = append(opts, adbclient.WithLogcatUIDs(uid))
+	}
+
+	logcatFormat, err := adbclient.ParseLogcatFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts, adbclient.WithLogcatFormat(logcatFormat))
+
+	if *dump {
+		opts = append(opts, adbclient.WithLogcatDump())
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	if *output != "" && logexport.FormatFromPath(*output) != logexport.Text {
+		return exportLogcat(ctx, cli, device, *output, opts)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	logcat, err := cli.client.Logcat(device, opts...)
+	if err != nil {
+		return err
+	}
+
+	// closing the watcher stops the copy when the context is cancelled
+	go func() {
+		<-ctx.Done()
+		logcat.Close()
+	}()
+
+	if _, err := io.Copy(w, logcat); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
+
+// exportLogcat collects the messages until the log ends or the context is cancelled and exports them.
+func exportLogcat(ctx context.Context, cli *cli, device *adbclient.Device, path string, opts []adbclient.LogcatOption) error {
+	logcat, err := cli.client.Logcat(device, opts...)
+	if err != nil {
+		return err
+	}
+
+	defer logcat.Close()
+
+	var msgs []adbclient.LogcatMessage
+	messages := logcat.C(ctx)
+
+collect:
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				break collect
+			}
+
+			msgs = append(msgs, msg)
+
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	if err := logexport.Export(path, msgs, "Logcat: "+device.String()); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d messages exported to %s\n", len(msgs), path)
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/logger"
+	"github.com/johnnyipcom/androidtool/pkg/logger/logrus"
+)
+
+const (
+	// DefaultLogPath is the default log file path of the cli.
+	DefaultLogPath = "./androidtool-cli.log"
+)
+
+// command is a cli subcommand.
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, cli *cli, args []string) error
+}
+
+var commands = []command{
+	{"archive", "archive the log of a device to the storage until interrupted", runArchive},
+	{"benchmark", "measure the push and pull throughput and latency of a device, saved by USB port", runBenchmark},
+	{"burst", "capture an animated GIF/APNG from a screenshot burst", runBurst},
+	{"diff", "compare screenshots against baselines", runDiff},
+	{"history", "query the archived log of a device", runHistory},
+	{"inventory", "import or export the device inventory as CSV or JSON", runInventory},
+	{"lease", "reserve a device for a user or a job, or release it", runLease},
+	{"logcat", "print or save the log with buffers, filterspecs and formats", runLogcat},
+	{"matrix", "capture a screen in every locale, night mode, font scale and display size", runMatrix},
+	{"profile", "print the hardware profile or all the system properties of a device", runProfile},
+	{"pull", "download a directory of the device recursively", runPull},
+	{"push", "upload a local directory recursively", runPush},
+	{"snapshot", "save the properties, features and settings of a device and diff them with an earlier snapshot", runSnapshot},
+	{"symbolicate", "symbolicate native backtraces of a tombstone or log with local symbol files", runSymbolicate},
+	{"sync", "upload only the new and changed files of a local directory", runSync},
+	{"timeline", "merge the logs of several devices by time, corrected for their clock offsets", runTimeline},
+}
+
+// cli is the state shared by all subcommands.
+type cli struct {
+	client *adbclient.Client
+	log    logger.Logger
+	serial string
+}
+
+// device returns the device selected with -s, or any online device.
+func (c *cli) device() (*adbclient.Device, error) {
+	if c.serial != "" {
+		return c.client.GetDevice(c.serial)
+	}
+
+	return c.client.GetAnyOnlineDevice()
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] <command> [command options]\n\nOptions:\n", os.Args[0])
+	flag.PrintDefaults()
+
+	fmt.Fprintf(flag.CommandLine.Output(), "\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-12s %s\n", cmd.name, cmd.description)
+	}
+}
+
+func main() {
+	port := flag.Int("port", adbclient.DefaultPort, "adb server port")
+	logPath := flag.String("log", DefaultLogPath, "log file path")
+	serial := flag.String("s", "", "device serial, any online device is used if empty")
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	log := logrus.New(*logPath)
+	client, err := adbclient.NewClient(*port, log)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := client.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	c := &cli{
+		client: client,
+		log:    log.WithField("component", "cli"),
+		serial: *serial,
+	}
+
+	if err := cmd.run(ctx, c, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/johnnyipcom/androidtool/pkg/screenmatrix"
+)
+
+func runMatrix(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("matrix", flag.ExitOnError)
+	app := flags.String("app", "", "application package")
+	activity := flags.String("activity", "", "activity to start, the launcher activity is used if empty")
+	link := flags.String("link", "", "deep link opened in the app instead of starting an activity")
+	locales := flags.String("locales", "", "comma separated per-app locales, e.g. en-US,de-DE (requires Android 13)")
+	nightModes := flags.String("night", "", "comma separated night modes: no, yes, auto")
+	fontScales := flags.String("font-scales", "", "comma separated font scales, e.g. 1,1.3")
+	displays := flags.String("displays", "", "comma separated display overrides: WIDTHxHEIGHT[@DENSITY], @DENSITY or default")
+	delay := flags.Duration("delay", screenmatrix.DefaultSettleDelay, "delay between launching the app and capturing the screen")
+	output := flags.String("o", screenmatrix.DefaultOutputPath, "output directory")
+	flags.Parse(args)
+
+	matrix := screenmatrix.Matrix{Locales: screenmatrix.ParseLocales(*locales)}
+
+	var err error
+	if matrix.NightModes, err = screenmatrix.ParseNightModes(*nightModes); err != nil {
+		return err
+	}
+
+	if matrix.FontScales, err = screenmatrix.ParseFontScales(*fontScales); err != nil {
+		return err
+	}
+
+	if matrix.Displays, err = screenmatrix.ParseDisplays(*displays); err != nil {
+		return err
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	report, err := screenmatrix.Run(
+		ctx,
+		cli.client,
+		device,
+		screenmatrix.Target{Package: *app, Activity: *activity, DeepLink: *link},
+		matrix,
+		*output,
+		screenmatrix.WithSettleDelay(*delay),
+		screenmatrix.WithProgress(func(i, total int, config screenmatrix.Config) {
+			fmt.Printf("[%d/%d] %s\n", i+1, total, config)
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, shot := range report.Shots {
+		if shot.Error != "" {
+			fmt.Printf("FAIL %s: %s\n", shot.Config, shot.Error)
+		}
+	}
+
+	fmt.Printf("%d configurations, %d failed, contact sheet: %s\n", len(report.Shots), report.Failed(), filepath.Join(*output, screenmatrix.ContactSheetFileName))
+	if report.Failed() > 0 {
+		return fmt.Errorf("%d configurations failed", report.Failed())
+	}
+
+	return nil
+}
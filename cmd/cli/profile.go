@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+func runProfile(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	props := flags.Bool("props", false, "print all the system properties instead of the profile")
+	asJSON := flags.Bool("json", false, "print as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: profile [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if *props {
+		properties, err := cli.client.Properties(device)
+		if err != nil {
+			return err
+		}
+
+		if !*asJSON {
+			return printProperties(properties)
+		}
+
+		value = properties
+	} else {
+		profile, err := cli.client.Profile(device)
+		if err != nil {
+			return err
+		}
+
+		value = profile
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printProperties prints the properties sorted by name.
+func printProperties(properties map[string]string) error {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key, properties[key])
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+func runSnapshot(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	db := flags.String("db", storage.DefaultStoragePath, "storage path, the app must be closed to open it")
+	label := flags.String("label", "", "label of the snapshot, e.g. before update")
+	list := flags.Bool("list", false, "list the saved snapshots instead of taking one")
+	diff := flags.String("diff", "", "compare two saved snapshots by their numbers in -list, e.g. 1,3")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: snapshot [options]\n\nTakes a snapshot of the properties, features and settings of the device and prints the changes since its previous snapshot.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	s, err := storage.NewStorage(*db, cli.log)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	snapshots, err := s.GetSnapshots()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *list:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for i, snapshot := range snapshots {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, snapshot.Serial, snapshot.Time.Format("2006-01-02 15:04:05"), snapshot.Label, snapshot.Fingerprint())
+		}
+
+		return w.Flush()
+
+	case *diff != "":
+		from, to, err := parseSnapshotPair(*diff, len(snapshots))
+		if err != nil {
+			return err
+		}
+
+		return printSnapshotDiff(&snapshots[from], &snapshots[to])
+	}
+
+	device, err := cli.device()
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := cli.client.Snapshot(device, *label)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SaveSnapshot(snapshot); err != nil {
+		return err
+	}
+
+	fmt.Printf("saved snapshot %s\n", snapshot)
+
+	var previous *adbclient.Snapshot
+	for i := range snapshots {
+		if snapshots[i].Serial == device.Serial {
+			previous = &snapshots[i]
+		}
+	}
+
+	if previous == nil {
+		return nil
+	}
+
+	return printSnapshotDiff(previous, snapshot)
+}
+
+// parseSnapshotPair parses two 1-based snapshot numbers separated by a comma and returns them 0-based.
+func parseSnapshotPair(s string, n int) (int, int, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid snapshot pair: %s", s)
+	}
+
+	var pair [2]int
+	for i, part := range parts {
+		number, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || number < 1 || number > n {
+			return 0, 0, fmt.Errorf("invalid snapshot number %q, there are %d snapshots", part, n)
+		}
+
+		pair[i] = number - 1
+	}
+
+	return pair[0], pair[1], nil
+}
+
+// printSnapshotDiff prints the changes from the snapshot a to the snapshot b, like a unified diff.
+func printSnapshotDiff(a, b *adbclient.Snapshot) error {
+	fmt.Printf("--- %s\n+++ %s\n", a, b)
+
+	changes := adbclient.DiffSnapshots(a, b)
+	for _, change := range changes {
+		switch change.Kind {
+		case adbclient.ChangeAdded:
+			fmt.Printf("+ [%s] %s=%s\n", change.Section, change.Key, change.New)
+		case adbclient.ChangeRemoved:
+			fmt.Printf("- [%s] %s=%s\n", change.Section, change.Key, change.Old)
+		case adbclient.ChangeModified:
+			fmt.Printf("- [%s] %s=%s\n+ [%s] %s=%s\n", change.Section, change.Key, change.Old, change.Section, change.Key, change.New)
+		}
+	}
+
+	fmt.Printf("%d changes\n", len(changes))
+	return nil
+}
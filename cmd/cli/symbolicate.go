@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/johnnyipcom/androidtool/pkg/symbolicate"
+)
+
+func runSymbolicate(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("symbolicate", flag.ExitOnError)
+	symbols := flags.String("sym", "", "directories with unstripped libraries, separated by "+string(os.PathListSeparator))
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: symbolicate -sym <dirs> [tombstone or log file, stdin if not set]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	dirs := symbolicate.ParsePaths(*symbols)
+	if len(dirs) == 0 {
+		return fmt.Errorf("no symbols directories set")
+	}
+
+	symbolicator, err := symbolicate.NewSymbolicator(dirs...)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		r = file
+	}
+
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Println(symbolicator.SymbolicateText(string(text)))
+	return err
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/timeline"
+)
+
+// ansiColors are the colours of the devices with -color.
+var ansiColors = []string{"\033[34m", "\033[33m", "\033[32m", "\033[31m", "\033[35m", "\033[36m"}
+
+const ansiReset = "\033[0m"
+
+func runTimeline(ctx context.Context, cli *cli, args []string) error {
+	flags := flag.NewFlagSet("timeline", flag.ExitOnError)
+	since := flags.Duration("T", 0, "print messages since a duration before now, only new messages if 0")
+	format := flags.String("v", adbclient.FormatThreadtime.String(), "output format: threadtime, brief, time, long, year, epoch, uid")
+	output := flags.String("o", "", "output file, stdout if empty")
+	colors := flags.Bool("color", false, "colour the lines of each device")
+	window := flags.Duration("window", timeline.DefaultWindow, "time a message is held back for late messages of other devices")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: timeline [options] serial[=filterspecs] serial[=filterspecs]...\n")
+		fmt.Fprintf(flags.Output(), "\nfilterspecs are comma separated, e.g. R58M=GameNet:D,*:S\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no devices")
+	}
+
+	logcatFormat, err := adbclient.ParseLogcatFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var sources []timeline.Source
+	colorOf := make(map[string]string)
+	for i, arg := range flags.Args() {
+		serial, specs, _ := strings.Cut(arg, "=")
+
+		device, err := cli.client.GetDevice(serial)
+		if err != nil {
+			return err
+		}
+
+		offset, err := cli.client.ClockOffset(device)
+		if err != nil {
+			return fmt.Errorf("%s: %w", serial, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: clock offset %+.3fs\n", serial, offset.Seconds())
+
+		// the since time is in the clock of the device
+		opts := []adbclient.LogcatOption{adbclient.WithLogcatTail(1)}
+		if *since > 0 {
+			opts = []adbclient.LogcatOption{adbclient.WithLogcatSince(time.Now().Add(-*since).Add(offset))}
+		}
+
+		filterSpecs, err := adbclient.ParseLogcatFilterSpecs(specs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", serial, err)
+		}
+
+		if len(filterSpecs) > 0 {
+			opts = append(opts, adbclient.WithLogcatFilterSpecs(filterSpecs...))
+		}
+
+		logcat, err := cli.client.Logcat(device, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", serial, err)
+		}
+
+		defer logcat.Close()
+
+		sources = append(sources, timeline.Source{Serial: serial, Offset: offset, Messages: logcat.C(ctx)})
+		colorOf[serial] = ansiColors[i%len(ansiColors)]
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	// flush regularly, the timeline is followed live
+	flushTicker := time.NewTicker(time.Second)
+	defer flushTicker.Stop()
+
+	entries := timeline.Merge(ctx, *window, sources...)
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return nil
+			}
+
+			line := entry.Format(logcatFormat)
+			if *colors {
+				line = colorOf[entry.Serial] + line + ansiReset
+			}
+
+			if _, err := fmt.Fprintln(bw, line); err != nil {
+				return err
+			}
+
+		case <-flushTicker.C:
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
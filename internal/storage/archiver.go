@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/logger"
+)
+
+const (
+	// DefaultLogcatRetention is the default age after which archived messages are deleted.
+	DefaultLogcatRetention = 7 * 24 * time.Hour
+	// DefaultLogcatMaxMessages is the default maximum number of archived messages of a device.
+	DefaultLogcatMaxMessages = 1000000
+
+	// archiverFlushInterval is the interval at which received messages are written in one transaction.
+	archiverFlushInterval = time.Second
+	// archiverPruneInterval is the interval at which the retention limits are applied.
+	archiverPruneInterval = 5 * time.Minute
+)
+
+type archiverOptions struct {
+	retention   time.Duration
+	maxMessages int
+}
+
+// ArchiverOption is an option of NewLogcatArchiver.
+type ArchiverOption interface {
+	apply(*archiverOptions) error
+}
+
+type retentionOption time.Duration
+
+func (o retentionOption) apply(opts *archiverOptions) error {
+	if o < 0 {
+		return fmt.Errorf("invalid retention: %s", time.Duration(o))
+	}
+
+	opts.retention = time.Duration(o)
+	return nil
+}
+
+type maxMessagesOption int
+
+func (o maxMessagesOption) apply(opts *archiverOptions) error {
+	if o < 0 {
+		return fmt.Errorf("invalid maximum number of messages: %d", o)
+	}
+
+	opts.maxMessages = int(o)
+	return nil
+}
+
+// WithRetention sets the age after which archived messages are deleted, zero keeps them forever.
+func WithRetention(d time.Duration) ArchiverOption {
+	return retentionOption(d)
+}
+
+// WithMaxMessages sets the maximum number of archived messages of a device, zero is unlimited.
+func WithMaxMessages(n int) ArchiverOption {
+	return maxMessagesOption(n)
+}
+
+// LogcatArchiver writes the log of every online device to the storage.
+type LogcatArchiver struct {
+	client  *adbclient.Client
+	storage *Storage
+	log     logger.Logger
+	options archiverOptions
+	watches *adbclient.DeviceWatches
+}
+
+// NewLogcatArchiver creates an archiver, devices are archived once passed to Update.
+func NewLogcatArchiver(client *adbclient.Client, storage *Storage, opts ...ArchiverOption) (*LogcatArchiver, error) {
+	options := archiverOptions{
+		retention:   DefaultLogcatRetention,
+		maxMessages: DefaultLogcatMaxMessages,
+	}
+
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	a := &LogcatArchiver{
+		client:  client,
+		storage: storage,
+		log:     storage.log.WithField("component", "archiver"),
+		options: options,
+	}
+
+	a.watches = adbclient.NewDeviceWatches(a.watch)
+	return a, nil
+}
+
+// Update starts archiving the device when it comes online and stops when it goes away.
+func (a *LogcatArchiver) Update(device *adbclient.Device) {
+	a.watches.Update(device)
+}
+
+// Close stops archiving all devices and waits for the pending messages to be written.
+func (a *LogcatArchiver) Close() {
+	a.watches.Close()
+}
+
+// watch archives the device until the context is done or the connection is lost.
+func (a *LogcatArchiver) watch(ctx context.Context, device *adbclient.Device) {
+	if err := a.archive(ctx, device); err != nil {
+		a.log.Errorf("Failed to archive the log of %s: %s", device.Serial, err)
+	}
+}
+
+// archive writes the log of the device until the context is done or the connection is lost.
+func (a *LogcatArchiver) archive(ctx context.Context, device *adbclient.Device) error {
+	a.prune(device.Serial)
+
+	// continue after the newest archived message, the device keeps older ones in its buffers
+	last, err := a.storage.LastLogcatTime(device.Serial)
+	if err != nil {
+		return err
+	}
+
+	opts := []adbclient.LogcatOption{
+		adbclient.WithLogcatBuffers(adbclient.BufferMain, adbclient.BufferSystem, adbclient.BufferCrash, adbclient.BufferEvents),
+	}
+
+	if !last.IsZero() {
+		opts = append(opts, adbclient.WithLogcatSince(last))
+	}
+
+	logcat, err := a.client.Logcat(device, opts...)
+	if err != nil {
+		return err
+	}
+
+	defer logcat.Close()
+
+	flushTicker := time.NewTicker(archiverFlushInterval)
+	defer flushTicker.Stop()
+
+	pruneTicker := time.NewTicker(archiverPruneInterval)
+	defer pruneTicker.Stop()
+
+	var batch []adbclient.LogcatMessage
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := a.storage.SaveLogcat(device.Serial, batch)
+		batch = batch[:0]
+		return err
+	}
+
+	msgs := logcat.C(ctx)
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return flush()
+			}
+
+			// the messages at the time of the newest archived one are already archived
+			if !msg.Timestamp.After(last) {
+				continue
+			}
+
+			batch = append(batch, msg)
+
+		case <-ctx.Done():
+			return flush()
+
+		case <-flushTicker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+
+		case <-pruneTicker.C:
+			a.prune(device.Serial)
+		}
+	}
+}
+
+// prune applies the retention limits to the archive of the device.
+func (a *LogcatArchiver) prune(serial string) {
+	var before time.Time
+	if a.options.retention > 0 {
+		before = time.Now().Add(-a.options.retention)
+	}
+
+	if _, err := a.storage.PruneLogcat(serial, before, a.options.maxMessages); err != nil {
+		a.log.Errorf("Failed to prune the log archive of %s: %s", serial, err)
+	}
+}
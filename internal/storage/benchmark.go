@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"go.etcd.io/bbolt"
+)
+
+// BenchmarkBucket is the name of the bucket for transfer benchmarks, it has a nested bucket for each device.
+const BenchmarkBucket = "benchmarks"
+
+// benchmarkKey is the time of the benchmark, so the keys sort by time.
+func benchmarkKey(result *adbclient.BenchmarkResult) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(result.Time.UnixNano()))
+	return key
+}
+
+// SaveBenchmark adds the benchmark to the history of its device.
+func (s *Storage) SaveBenchmark(result *adbclient.BenchmarkResult) error {
+	s.log.Infof("New benchmark of %s on %s: %s", result.Serial, result.USB, result)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BenchmarkBucket))
+		if b == nil {
+			return nil
+		}
+
+		device, err := b.CreateBucketIfNotExists([]byte(result.Serial))
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		return device.Put(benchmarkKey(result), data)
+	})
+}
+
+// GetBenchmarks returns the benchmarks of the device, oldest first.
+func (s *Storage) GetBenchmarks(serial string) ([]adbclient.BenchmarkResult, error) {
+	s.log.Infof("Getting benchmarks: %s", serial)
+
+	var results []adbclient.BenchmarkResult
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BenchmarkBucket))
+		if b == nil {
+			return nil
+		}
+
+		device := b.Bucket([]byte(serial))
+		if device == nil {
+			return nil
+		}
+
+		return device.ForEach(func(k, v []byte) error {
+			var result adbclient.BenchmarkResult
+			if err := json.Unmarshal(v, &result); err != nil {
+				return err
+			}
+
+			results = append(results, result)
+			return nil
+		})
+	})
+
+	return results, err
+}
+
+// GetPortBenchmarks returns the benchmarks of all devices by USB port, oldest first.
+// Benchmarks of devices connected over the network have an empty port.
+func (s *Storage) GetPortBenchmarks() (map[string][]adbclient.BenchmarkResult, error) {
+	s.log.Info("Getting benchmarks by port")
+
+	ports := make(map[string][]adbclient.BenchmarkResult)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BenchmarkBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(serial, _ []byte) error {
+			device := b.Bucket(serial)
+			if device == nil {
+				return nil
+			}
+
+			return device.ForEach(func(k, v []byte) error {
+				var result adbclient.BenchmarkResult
+				if err := json.Unmarshal(v, &result); err != nil {
+					return err
+				}
+
+				ports[result.USB] = append(ports[result.USB], result)
+				return nil
+			})
+		})
+	})
+
+	for _, results := range ports {
+		sort.Slice(results, func(i, j int) bool { return results[i].Time.Before(results[j].Time) })
+	}
+
+	return ports, err
+}
+
+// DeleteBenchmarks deletes the benchmarks of the device.
+func (s *Storage) DeleteBenchmarks(serial string) error {
+	s.log.Infof("Deleting benchmarks: %s", serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BenchmarkBucket))
+		if b == nil {
+			return nil
+		}
+
+		if b.Bucket([]byte(serial)) == nil {
+			return nil
+		}
+
+		return b.DeleteBucket([]byte(serial))
+	})
+}
+
+// PortSummary is the average of the benchmarks of a USB port.
+type PortSummary struct {
+	// Port is the USB port, "network" for devices connected over the network.
+	Port string
+	Runs int
+	// Push and Pull are the average throughputs in bytes per second.
+	Push    float64
+	Pull    float64
+	Latency time.Duration
+	// Device is the serial of the last benchmarked device.
+	Device string
+}
+
+// SummarizePorts averages the benchmarks of each port, the slowest pushing ports first.
+func SummarizePorts(ports map[string][]adbclient.BenchmarkResult) []PortSummary {
+	summaries := make([]PortSummary, 0, len(ports))
+	for port, results := range ports {
+		if len(results) == 0 {
+			continue
+		}
+
+		summary := PortSummary{Port: port, Runs: len(results), Device: results[len(results)-1].Serial}
+		if port == "" {
+			summary.Port = "network"
+		}
+
+		var latency time.Duration
+		for _, result := range results {
+			summary.Push += result.PushThroughput()
+			summary.Pull += result.PullThroughput()
+			latency += result.Latency
+		}
+
+		summary.Push /= float64(len(results))
+		summary.Pull /= float64(len(results))
+		summary.Latency = latency / time.Duration(len(results))
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Push < summaries[j].Push })
+	return summaries
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/johnnyipcom/androidtool/pkg/crash"
+	"go.etcd.io/bbolt"
+)
+
+// CrashBucket is the name of the bucket for crashes, it has a nested bucket for each device.
+const CrashBucket = "crashes"
+
+// SaveCrash adds the crash to the history of its device.
+func (s *Storage) SaveCrash(record crash.Record) error {
+	s.log.Infof("New crash on %s: %s", record.Serial, record.Summary())
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(CrashBucket))
+		if b == nil {
+			return nil
+		}
+
+		device, err := b.CreateBucketIfNotExists([]byte(record.Serial))
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		return device.Put([]byte(record.Key()), data)
+	})
+}
+
+// GetCrashes returns the crash history of the device, oldest first.
+func (s *Storage) GetCrashes(serial string) ([]crash.Record, error) {
+	s.log.Infof("Getting crashes: %s", serial)
+
+	var records []crash.Record
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(CrashBucket))
+		if b == nil {
+			return nil
+		}
+
+		device := b.Bucket([]byte(serial))
+		if device == nil {
+			return nil
+		}
+
+		return device.ForEach(func(k, v []byte) error {
+			var record crash.Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+
+			records = append(records, record)
+			return nil
+		})
+	})
+
+	return records, err
+}
+
+// DeleteCrashes deletes the crash history of the device.
+func (s *Storage) DeleteCrashes(serial string) error {
+	s.log.Infof("Deleting crashes: %s", serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(CrashBucket))
+		if b == nil {
+			return nil
+		}
+
+		if b.Bucket([]byte(serial)) == nil {
+			return nil
+		}
+
+		return b.DeleteBucket([]byte(serial))
+	})
+}
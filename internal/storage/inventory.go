@@ -0,0 +1,378 @@
+package storage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// InventoryBucket is the name of the bucket for the inventory of the devices, keyed by serial.
+	InventoryBucket = "inventory"
+
+	// DefaultConnectionHistorySize is the number of state changes kept in the connection history of a device.
+	DefaultConnectionHistorySize = 200
+)
+
+// inventoryHeader is the header of the CSV inventory, labels are separated by semicolons.
+var inventoryHeader = []string{"serial", "name", "labels", "owner", "location", "notes", "first_seen", "last_seen"}
+
+// Connection is a state change of a device.
+type Connection struct {
+	Time  time.Time `json:"time"`
+	State string    `json:"state"`
+	USB   string    `json:"usb"`
+}
+
+// InventoryEntry is what is known of a device for asset tracking.
+type InventoryEntry struct {
+	Serial string `json:"serial"`
+	// Name is a custom display name, the serial is shown if empty.
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+	Owner  string   `json:"owner"`
+	// Location is the physical location of the device, e.g. a rack slot.
+	Location string `json:"location"`
+	Notes    string `json:"notes"`
+
+	FirstSeen time.Time    `json:"first_seen"`
+	LastSeen  time.Time    `json:"last_seen"`
+	History   []Connection `json:"history,omitempty"`
+}
+
+// HasLabel returns true if the device has the label.
+func (e *InventoryEntry) HasLabel(label string) bool {
+	for _, l := range e.Labels {
+		if l == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetLabels sets the labels from a comma separated list, without blanks and duplicates.
+func (e *InventoryEntry) SetLabels(labels string) {
+	e.Labels = nil
+	for _, label := range strings.FieldsFunc(labels, func(r rune) bool { return r == ',' || r == ';' }) {
+		if label = strings.TrimSpace(label); label != "" && !e.HasLabel(label) {
+			e.Labels = append(e.Labels, label)
+		}
+	}
+
+	sort.Strings(e.Labels)
+}
+
+// merge copies the fields edited by the user, and widens the seen times.
+func (e *InventoryEntry) merge(o *InventoryEntry) {
+	e.Name, e.Labels, e.Owner, e.Location, e.Notes = o.Name, o.Labels, o.Owner, o.Location, o.Notes
+
+	if !o.FirstSeen.IsZero() && (e.FirstSeen.IsZero() || o.FirstSeen.Before(e.FirstSeen)) {
+		e.FirstSeen = o.FirstSeen
+	}
+
+	if o.LastSeen.After(e.LastSeen) {
+		e.LastSeen = o.LastSeen
+	}
+}
+
+// InventoryLabels returns the labels used by the entries, sorted.
+func InventoryLabels(entries []InventoryEntry) []string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, entry := range entries {
+		for _, label := range entry.Labels {
+			if !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	}
+
+	sort.Strings(labels)
+	return labels
+}
+
+func getInventoryEntry(b *bbolt.Bucket, serial string) (*InventoryEntry, error) {
+	entry := &InventoryEntry{Serial: serial}
+	if data := b.Get([]byte(serial)); data != nil {
+		if err := json.Unmarshal(data, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+func putInventoryEntry(b *bbolt.Bucket, entry *InventoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(entry.Serial), data)
+}
+
+// UpdateInventoryEntry updates the entry of the device in a transaction, it is created if needed.
+func (s *Storage) UpdateInventoryEntry(serial string, update func(entry *InventoryEntry) error) error {
+	s.log.Infof("Updating inventory: %s", serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(InventoryBucket))
+		if b == nil {
+			return nil
+		}
+
+		entry, err := getInventoryEntry(b, serial)
+		if err != nil {
+			return err
+		}
+
+		if err := update(entry); err != nil {
+			return err
+		}
+
+		entry.Serial = serial
+		return putInventoryEntry(b, entry)
+	})
+}
+
+// RecordConnection adds a state change of the device to its connection history and updates the seen times.
+func (s *Storage) RecordConnection(serial string, connection Connection) error {
+	return s.UpdateInventoryEntry(serial, func(entry *InventoryEntry) error {
+		if entry.FirstSeen.IsZero() {
+			entry.FirstSeen = connection.Time
+		}
+
+		entry.LastSeen = connection.Time
+		entry.History = append(entry.History, connection)
+		if len(entry.History) > DefaultConnectionHistorySize {
+			entry.History = entry.History[len(entry.History)-DefaultConnectionHistorySize:]
+		}
+
+		return nil
+	})
+}
+
+// GetInventoryEntry returns the entry of the device, an empty entry if it is unknown.
+func (s *Storage) GetInventoryEntry(serial string) (*InventoryEntry, error) {
+	s.log.Infof("Getting inventory: %s", serial)
+
+	var entry *InventoryEntry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(InventoryBucket))
+		if b == nil {
+			entry = &InventoryEntry{Serial: serial}
+			return nil
+		}
+
+		var err error
+		entry, err = getInventoryEntry(b, serial)
+		return err
+	})
+
+	return entry, err
+}
+
+// GetInventory returns the entries of all devices sorted by serial.
+func (s *Storage) GetInventory() ([]InventoryEntry, error) {
+	s.log.Info("Getting inventory")
+
+	var entries []InventoryEntry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(InventoryBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var entry InventoryEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+
+	return entries, err
+}
+
+// DeleteInventoryEntry deletes the entry of the device.
+func (s *Storage) DeleteInventoryEntry(serial string) error {
+	s.log.Infof("Deleting inventory: %s", serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(InventoryBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete([]byte(serial))
+	})
+}
+
+// ImportInventory merges the entries into the inventory. The fields edited by the user are replaced,
+// the seen times are widened and the connection history is kept.
+func (s *Storage) ImportInventory(entries []InventoryEntry) error {
+	s.log.Infof("Importing %d inventory entries", len(entries))
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(InventoryBucket))
+		if b == nil {
+			return nil
+		}
+
+		for i := range entries {
+			if entries[i].Serial == "" {
+				return fmt.Errorf("inventory entry %d without a serial", i+1)
+			}
+
+			entry, err := getInventoryEntry(b, entries[i].Serial)
+			if err != nil {
+				return err
+			}
+
+			entry.merge(&entries[i])
+			if err := putInventoryEntry(b, entry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// formatInventoryTime formats a seen time for the CSV inventory, empty if unknown.
+func formatInventoryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// WriteInventoryCSV writes the entries as CSV with a header, without the connection history.
+func WriteInventoryCSV(w io.Writer, entries []InventoryEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(inventoryHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		record := []string{
+			entry.Serial,
+			entry.Name,
+			strings.Join(entry.Labels, ";"),
+			entry.Owner,
+			entry.Location,
+			entry.Notes,
+			formatInventoryTime(entry.FirstSeen),
+			formatInventoryTime(entry.LastSeen),
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadInventoryCSV reads entries written by WriteInventoryCSV. The columns are found by the header,
+// so they may be reordered or missing, except the serial.
+func ReadInventoryCSV(r io.Reader) ([]InventoryEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["serial"]; !ok {
+		return nil, fmt.Errorf("no serial column in the CSV header: %v", header)
+	}
+
+	var entries []InventoryEntry
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		entry := InventoryEntry{
+			Serial:   field("serial"),
+			Name:     field("name"),
+			Owner:    field("owner"),
+			Location: field("location"),
+			Notes:    field("notes"),
+		}
+
+		if entry.Serial == "" {
+			return nil, fmt.Errorf("line %d: empty serial", line)
+		}
+
+		entry.SetLabels(field("labels"))
+
+		for name, t := range map[string]*time.Time{"first_seen": &entry.FirstSeen, "last_seen": &entry.LastSeen} {
+			if value := field(name); value != "" {
+				if *t, err = time.Parse(time.RFC3339, value); err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s: %w", line, name, err)
+				}
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// WriteInventoryJSON writes the entries as a JSON array, with the connection history.
+func WriteInventoryJSON(w io.Writer, entries []InventoryEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// ReadInventoryJSON reads entries written by WriteInventoryJSON.
+func ReadInventoryJSON(r io.Reader) ([]InventoryEntry, error) {
+	var entries []InventoryEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].Serial == "" {
+			return nil, fmt.Errorf("inventory entry %d without a serial", i+1)
+		}
+	}
+
+	return entries, nil
+}
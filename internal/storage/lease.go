@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"go.etcd.io/bbolt"
+)
+
+// LeaseBucket is the name of the bucket for device leases, keyed by serial.
+const LeaseBucket = "leases"
+
+// getLease returns the lease of the device, nil if it has none or if it expired.
+func getLease(b *bbolt.Bucket, serial string, now time.Time) (*adbclient.Lease, error) {
+	data := b.Get([]byte(serial))
+	if data == nil {
+		return nil, nil
+	}
+
+	var lease adbclient.Lease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return nil, err
+	}
+
+	if !lease.Active(now) {
+		return nil, nil
+	}
+
+	return &lease, nil
+}
+
+// GetLease returns the active lease of the device, nil if it has none. It implements adbclient.LeaseStore.
+func (s *Storage) GetLease(serial string) (*adbclient.Lease, error) {
+	var lease *adbclient.Lease
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LeaseBucket))
+		if b == nil {
+			return nil
+		}
+
+		var err error
+		lease, err = getLease(b, serial, time.Now())
+		return err
+	})
+
+	return lease, err
+}
+
+// GetLeases returns the active leases sorted by serial.
+func (s *Storage) GetLeases() ([]adbclient.Lease, error) {
+	s.log.Info("Getting leases")
+
+	now := time.Now()
+	var leases []adbclient.Lease
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LeaseBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var lease adbclient.Lease
+			if err := json.Unmarshal(v, &lease); err != nil {
+				return err
+			}
+
+			if lease.Active(now) {
+				leases = append(leases, lease)
+			}
+
+			return nil
+		})
+	})
+
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Serial < leases[j].Serial })
+	return leases, err
+}
+
+// AcquireLease reserves the device for the owner for the duration, adbclient.ErrDeviceLeased is returned if
+// it is leased by someone else. The lease is extended if the owner already has it.
+func (s *Storage) AcquireLease(serial, owner, note string, ttl time.Duration) (*adbclient.Lease, error) {
+	s.log.Infof("Leasing %s to %s for %s", serial, owner, ttl)
+
+	if owner == "" {
+		return nil, fmt.Errorf("lease without an owner")
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid lease duration: %s", ttl)
+	}
+
+	now := time.Now()
+	lease := &adbclient.Lease{Serial: serial, Owner: owner, Note: note, Acquired: now, Expires: now.Add(ttl)}
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LeaseBucket))
+		if b == nil {
+			return nil
+		}
+
+		current, err := getLease(b, serial, now)
+		if err != nil {
+			return err
+		}
+
+		if current != nil {
+			if current.Owner != owner {
+				return fmt.Errorf("%s: %w by %s", serial, adbclient.ErrDeviceLeased, current)
+			}
+
+			lease.Acquired = current.Acquired
+		}
+
+		data, err := json.Marshal(lease)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(serial), data)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lease, nil
+}
+
+// ReleaseLease releases the lease of the owner on the device, adbclient.ErrDeviceLeased is returned if
+// it is leased by someone else.
+func (s *Storage) ReleaseLease(serial, owner string) error {
+	s.log.Infof("Releasing lease of %s by %s", serial, owner)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LeaseBucket))
+		if b == nil {
+			return nil
+		}
+
+		current, err := getLease(b, serial, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if current != nil && current.Owner != owner {
+			return fmt.Errorf("%s: %w by %s", serial, adbclient.ErrDeviceLeased, current)
+		}
+
+		return b.Delete([]byte(serial))
+	})
+}
+
+// ForceReleaseLease releases the lease on the device whoever owns it.
+func (s *Storage) ForceReleaseLease(serial string) error {
+	s.log.Infof("Force releasing lease of %s", serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LeaseBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete([]byte(serial))
+	})
+}
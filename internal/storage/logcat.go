@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// LogcatBucket is the name of the bucket for the logcat archive, it has a nested bucket for each device.
+	LogcatBucket = "logcat"
+
+	// logcatMessagesBucket has the messages of a device keyed by time.
+	logcatMessagesBucket = "messages"
+	// logcatTagsBucket has a nested bucket for each tag with the keys of its messages.
+	logcatTagsBucket = "tags"
+)
+
+// LogcatQuery selects archived messages of a device.
+type LogcatQuery struct {
+	Serial string
+	// From and To limit the time range, zero values are unbounded. To is exclusive.
+	From time.Time
+	To   time.Time
+	// Priority is the minimum priority.
+	Priority adbclient.LogcatPriority
+	// Tag is the exact tag of the messages.
+	Tag string
+	// Text is a case-insensitive substring of the message.
+	Text string
+	// Limit is the maximum number of messages, the newest ones are kept. Zero is unlimited.
+	Limit int
+}
+
+// match returns true if the message matches the non-indexed conditions of the query.
+func (q LogcatQuery) match(msg adbclient.LogcatMessage) bool {
+	if msg.Priority < q.Priority {
+		return false
+	}
+
+	if q.Tag != "" && msg.Tag != q.Tag {
+		return false
+	}
+
+	return q.Text == "" || strings.Contains(strings.ToLower(msg.Message), strings.ToLower(q.Text))
+}
+
+// logcatKey is the time of the message followed by a sequence number, so that messages logged
+// at the same time don't overwrite each other and the keys sort by time.
+func logcatKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(t.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// logcatTimeKey is the first possible key at the time.
+func logcatTimeKey(t time.Time) []byte {
+	return logcatKey(t, 0)
+}
+
+func logcatKeyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+}
+
+// logcatDeviceBuckets returns the messages and tags buckets of the device, nil if it has no archive.
+func logcatDeviceBuckets(tx *bbolt.Tx, serial string) (*bbolt.Bucket, *bbolt.Bucket) {
+	b := tx.Bucket([]byte(LogcatBucket))
+	if b == nil {
+		return nil, nil
+	}
+
+	device := b.Bucket([]byte(serial))
+	if device == nil {
+		return nil, nil
+	}
+
+	return device.Bucket([]byte(logcatMessagesBucket)), device.Bucket([]byte(logcatTagsBucket))
+}
+
+// SaveLogcat adds the messages to the archive of the device.
+func (s *Storage) SaveLogcat(serial string, msgs []adbclient.LogcatMessage) error {
+	s.log.Debugf("Archiving %d messages of %s", len(msgs), serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LogcatBucket))
+		if b == nil {
+			return nil
+		}
+
+		device, err := b.CreateBucketIfNotExists([]byte(serial))
+		if err != nil {
+			return err
+		}
+
+		messages, err := device.CreateBucketIfNotExists([]byte(logcatMessagesBucket))
+		if err != nil {
+			return err
+		}
+
+		tags, err := device.CreateBucketIfNotExists([]byte(logcatTagsBucket))
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			seq, err := messages.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+
+			key := logcatKey(msg.Timestamp, seq)
+			if err := messages.Put(key, data); err != nil {
+				return err
+			}
+
+			// an empty tag can't be queried, a query without a tag scans the messages
+			if msg.Tag == "" {
+				continue
+			}
+
+			tag, err := tags.CreateBucketIfNotExists([]byte(msg.Tag))
+			if err != nil {
+				return err
+			}
+
+			if err := tag.Put(key, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// QueryLogcat returns the archived messages matching the query, oldest first.
+// The tag index is used if the query has a tag, otherwise the time range is scanned.
+func (s *Storage) QueryLogcat(q LogcatQuery) ([]adbclient.LogcatMessage, error) {
+	s.log.Infof("Querying logcat of %s", q.Serial)
+
+	var msgs []adbclient.LogcatMessage
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		messages, tags := logcatDeviceBuckets(tx, q.Serial)
+		if messages == nil {
+			return nil
+		}
+
+		// the keys to scan, the tag index has the same keys as the messages
+		keys := messages
+		if q.Tag != "" {
+			if tags == nil {
+				return nil
+			}
+
+			keys = tags.Bucket([]byte(q.Tag))
+			if keys == nil {
+				return nil
+			}
+		}
+
+		// scan backwards from the end of the range, so that the limit keeps the newest messages
+		c := keys.Cursor()
+		var k []byte
+		if q.To.IsZero() {
+			k, _ = c.Last()
+		} else if k, _ = c.Seek(logcatTimeKey(q.To)); k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+
+		var from []byte
+		if !q.From.IsZero() {
+			from = logcatTimeKey(q.From)
+		}
+
+		for ; k != nil; k, _ = c.Prev() {
+			if from != nil && bytes.Compare(k, from) < 0 {
+				break
+			}
+
+			var msg adbclient.LogcatMessage
+			if err := json.Unmarshal(messages.Get(k), &msg); err != nil {
+				return err
+			}
+
+			if !q.match(msg) {
+				continue
+			}
+
+			msgs = append(msgs, msg)
+			if q.Limit > 0 && len(msgs) >= q.Limit {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	// oldest first
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+
+	return msgs, err
+}
+
+// LastLogcatTime returns the time of the newest archived message of the device, zero if there is none.
+func (s *Storage) LastLogcatTime(serial string) (time.Time, error) {
+	var last time.Time
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		messages, _ := logcatDeviceBuckets(tx, serial)
+		if messages == nil {
+			return nil
+		}
+
+		if k, _ := messages.Cursor().Last(); k != nil {
+			last = logcatKeyTime(k)
+		}
+
+		return nil
+	})
+
+	return last, err
+}
+
+// PruneLogcat deletes the archived messages of the device older than before, and the oldest ones
+// above maxMessages. Zero values disable the limits. It returns the number of deleted messages.
+func (s *Storage) PruneLogcat(serial string, before time.Time, maxMessages int) (int, error) {
+	deleted := 0
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		messages, tags := logcatDeviceBuckets(tx, serial)
+		if messages == nil {
+			return nil
+		}
+
+		excess := 0
+		if maxMessages > 0 {
+			excess = messages.Stats().KeyN - maxMessages
+		}
+
+		var beforeKey []byte
+		if !before.IsZero() {
+			beforeKey = logcatTimeKey(before)
+		}
+
+		c := messages.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			if deleted >= excess && (beforeKey == nil || bytes.Compare(k, beforeKey) >= 0) {
+				break
+			}
+
+			var msg adbclient.LogcatMessage
+			if err := json.Unmarshal(v, &msg); err != nil {
+				return err
+			}
+
+			if tag := tags.Bucket([]byte(msg.Tag)); msg.Tag != "" && tag != nil {
+				if err := tag.Delete(k); err != nil {
+					return err
+				}
+			}
+
+			if err := c.Delete(); err != nil {
+				return err
+			}
+
+			deleted++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		s.log.Infof("Pruned %d archived messages of %s", deleted, serial)
+	}
+
+	return deleted, nil
+}
+
+// DeleteLogcat deletes the logcat archive of the device.
+func (s *Storage) DeleteLogcat(serial string) error {
+	s.log.Infof("Deleting logcat archive: %s", serial)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(LogcatBucket))
+		if b == nil {
+			return nil
+		}
+
+		if b.Bucket([]byte(serial)) == nil {
+			return nil
+		}
+
+		return b.DeleteBucket([]byte(serial))
+	})
+}
+
+// ParseLogcatTime parses a query time: a duration before now like 1h30m, a date and time
+// like 2006-01-02 15:04:05 in the local zone, or RFC 3339. An empty string is the zero time.
+func ParseLogcatTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: %s", s)
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// SnippetBucket is the name of the bucket for the saved shell commands, keyed by name.
+	SnippetBucket = "snippets"
+
+	// ShellHistoryBucket is the name of the bucket for the history of the shell commands, keyed by sequence.
+	ShellHistoryBucket = "shell_history"
+
+	// DefaultShellHistorySize is the number of commands kept in the history.
+	DefaultShellHistorySize = 500
+)
+
+// Snippet is a saved shell command.
+type Snippet struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// SaveSnippet creates or replaces the snippet with the same name.
+func (s *Storage) SaveSnippet(snippet Snippet) error {
+	s.log.Infof("Saving snippet: %s", snippet.Name)
+
+	if snippet.Name == "" {
+		return fmt.Errorf("snippet without a name")
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(SnippetBucket))
+		if b == nil {
+			return nil
+		}
+
+		data, err := json.Marshal(snippet)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(snippet.Name), data)
+	})
+}
+
+// GetSnippets returns the snippets sorted by name.
+func (s *Storage) GetSnippets() ([]Snippet, error) {
+	s.log.Info("Getting snippets")
+
+	var snippets []Snippet
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(SnippetBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var snippet Snippet
+			if err := json.Unmarshal(v, &snippet); err != nil {
+				return err
+			}
+
+			snippets = append(snippets, snippet)
+			return nil
+		})
+	})
+
+	return snippets, err
+}
+
+// DeleteSnippet deletes the snippet with the given name.
+func (s *Storage) DeleteSnippet(name string) error {
+	s.log.Infof("Deleting snippet: %s", name)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(SnippetBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete([]byte(name))
+	})
+}
+
+// AddShellHistory adds the command to the history, unless it repeats the last one.
+// The oldest commands are deleted when the history has more than DefaultShellHistorySize commands.
+func (s *Storage) AddShellHistory(command string) error {
+	s.log.Debugf("Adding to the shell history: %s", command)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(ShellHistoryBucket))
+		if b == nil {
+			return nil
+		}
+
+		if _, last := b.Cursor().Last(); string(last) == command {
+			return nil
+		}
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := b.Put(key, []byte(command)); err != nil {
+			return err
+		}
+
+		n := 0
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+
+		for k, _ := c.First(); k != nil && n > DefaultShellHistorySize; k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+
+			n--
+		}
+
+		return nil
+	})
+}
+
+// GetShellHistory returns the history of the shell commands, oldest first.
+func (s *Storage) GetShellHistory() ([]string, error) {
+	s.log.Info("Getting shell history")
+
+	var commands []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(ShellHistoryBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			commands = append(commands, string(v))
+			return nil
+		})
+	})
+
+	return commands, err
+}
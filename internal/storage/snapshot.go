@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"go.etcd.io/bbolt"
+)
+
+// SnapshotBucket is the name of the bucket for device snapshots, it has a nested bucket for each device.
+const SnapshotBucket = "snapshots"
+
+// snapshotKey is the time of the snapshot, so the keys sort by time.
+func snapshotKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// SaveSnapshot adds the snapshot to the snapshots of its device.
+func (s *Storage) SaveSnapshot(snapshot *adbclient.Snapshot) error {
+	s.log.Infof("Saving snapshot: %s", snapshot)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(SnapshotBucket))
+		if b == nil {
+			return nil
+		}
+
+		device, err := b.CreateBucketIfNotExists([]byte(snapshot.Serial))
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+
+		return device.Put(snapshotKey(snapshot.Time), data)
+	})
+}
+
+// GetSnapshots returns the snapshots of all devices by serial, oldest first.
+func (s *Storage) GetSnapshots() ([]adbclient.Snapshot, error) {
+	s.log.Info("Getting snapshots")
+
+	var snapshots []adbclient.Snapshot
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(SnapshotBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(serial, _ []byte) error {
+			device := b.Bucket(serial)
+			if device == nil {
+				return nil
+			}
+
+			return device.ForEach(func(k, v []byte) error {
+				var snapshot adbclient.Snapshot
+				if err := json.Unmarshal(v, &snapshot); err != nil {
+					return err
+				}
+
+				snapshots = append(snapshots, snapshot)
+				return nil
+			})
+		})
+	})
+
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Serial < snapshots[j].Serial })
+	return snapshots, err
+}
+
+// DeleteSnapshot deletes the snapshot of the device taken at the time.
+func (s *Storage) DeleteSnapshot(serial string, t time.Time) error {
+	s.log.Infof("Deleting snapshot: %s %s", serial, t)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(SnapshotBucket))
+		if b == nil {
+			return nil
+		}
+
+		device := b.Bucket([]byte(serial))
+		if device == nil {
+			return nil
+		}
+
+		return device.Delete(snapshotKey(t))
+	})
+}
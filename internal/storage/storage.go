@@ -34,8 +34,13 @@ func NewStorage(path string, log logger.Logger) (*Storage, error) {
 	}
 
 	if err := db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(DeviceBucket))
-		return err
+		for _, bucket := range []string{DeviceBucket, CrashBucket, LogcatBucket, BenchmarkBucket, SnippetBucket, ShellHistoryBucket, SnapshotBucket, InventoryBucket, LeaseBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	}); err != nil {
 		return nil, err
 	}
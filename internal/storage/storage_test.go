@@ -1,11 +1,17 @@
 package storage_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/johnnyipcom/androidtool/internal/storage"
 	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/crash"
 	"github.com/johnnyipcom/androidtool/pkg/logger/empty"
 )
 
@@ -107,3 +113,530 @@ func TestStorage(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageCrashes(t *testing.T) {
+	storage, err := storage.NewStorage(filepath.Join(t.TempDir(), "crashes.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer storage.Close()
+
+	now := time.Now()
+	records := []crash.Record{
+		{Kind: crash.ANR, Serial: "123456789", Time: now.Add(time.Minute), PID: 2, Package: "com.b"},
+		{Kind: crash.Java, Serial: "123456789", Time: now, PID: 1, Package: "com.a", Reason: "boom"},
+		{Kind: crash.Native, Serial: "987654321", Time: now, PID: 3, Package: "com.c"},
+	}
+
+	for _, record := range records {
+		if err := storage.SaveCrash(record); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// saving the same crash again must not duplicate it
+	if err := storage.SaveCrash(records[1]); err != nil {
+		t.Error(err)
+	}
+
+	crashes, err := storage.GetCrashes("123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(crashes) != 2 {
+		t.Fatalf("Expected 2 crashes, got %d", len(crashes))
+	}
+
+	if crashes[0].Package != "com.a" || crashes[0].Kind != crash.Java || crashes[0].Reason != "boom" {
+		t.Errorf("Expected the oldest crash first, got %+v", crashes[0])
+	}
+
+	if err := storage.DeleteCrashes("123456789"); err != nil {
+		t.Error(err)
+	}
+
+	if crashes, _ := storage.GetCrashes("123456789"); len(crashes) != 0 {
+		t.Errorf("Expected no crashes, got %d", len(crashes))
+	}
+
+	if crashes, _ := storage.GetCrashes("987654321"); len(crashes) != 1 {
+		t.Errorf("Expected 1 crash, got %d", len(crashes))
+	}
+}
+
+func TestStorageLogcat(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "logcat.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	const serial = "123456789"
+
+	start := time.Date(2022, 5, 18, 12, 0, 0, 0, time.UTC)
+	var msgs []adbclient.LogcatMessage
+	for i := 0; i < 10; i++ {
+		msg := adbclient.LogcatMessage{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Priority:  adbclient.Info,
+			Tag:       "Game",
+			ProcessID: 100,
+			Message:   fmt.Sprintf("frame %d", i),
+		}
+
+		if i%2 == 1 {
+			msg.Priority = adbclient.Error
+			msg.Tag = "ActivityManager"
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	// two messages at the same time must both be kept
+	msgs = append(msgs, adbclient.LogcatMessage{Timestamp: start, Priority: adbclient.Warning, Tag: "Game", Message: "same time"})
+
+	if err := s.SaveLogcat(serial, msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := s.LastLogcatTime(serial)
+	if err != nil || !last.Equal(start.Add(9*time.Minute)) {
+		t.Errorf("Expected last time %s, got %s (%v)", start.Add(9*time.Minute), last, err)
+	}
+
+	tests := []struct {
+		name     string
+		query    storage.LogcatQuery
+		expected []string
+	}{
+		{"all", storage.LogcatQuery{}, []string{"frame 0", "same time", "frame 1", "frame 2", "frame 3", "frame 4", "frame 5", "frame 6", "frame 7", "frame 8", "frame 9"}},
+		{"time range", storage.LogcatQuery{From: start.Add(2 * time.Minute), To: start.Add(4 * time.Minute)}, []string{"frame 2", "frame 3"}},
+		{"priority", storage.LogcatQuery{Priority: adbclient.Warning, To: start.Add(4 * time.Minute)}, []string{"same time", "frame 1", "frame 3"}},
+		{"tag", storage.LogcatQuery{Tag: "ActivityManager", From: start.Add(4 * time.Minute)}, []string{"frame 5", "frame 7", "frame 9"}},
+		{"text", storage.LogcatQuery{Text: "FRAME 8"}, []string{"frame 8"}},
+		{"limit", storage.LogcatQuery{Tag: "Game", Limit: 2}, []string{"frame 6", "frame 8"}},
+	}
+
+	for _, test := range tests {
+		test.query.Serial = serial
+		result, err := s.QueryLogcat(test.query)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		var actual []string
+		for _, msg := range result {
+			actual = append(actual, msg.Message)
+		}
+
+		if fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+
+	// the retention removes the messages before frame 3, the limit frame 3 and frame 4
+	deleted, err := s.PruneLogcat(serial, start.Add(3*time.Minute), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted != 6 {
+		t.Errorf("Expected 6 deleted messages, got %d", deleted)
+	}
+
+	if result, _ := s.QueryLogcat(storage.LogcatQuery{Serial: serial, Tag: "ActivityManager"}); len(result) != 3 {
+		t.Errorf("Expected 3 indexed messages after pruning, got %d", len(result))
+	}
+
+	if err := s.DeleteLogcat(serial); err != nil {
+		t.Error(err)
+	}
+
+	if result, _ := s.QueryLogcat(storage.LogcatQuery{Serial: serial}); len(result) != 0 {
+		t.Errorf("Expected no messages, got %d", len(result))
+	}
+}
+
+func TestParseLogcatTime(t *testing.T) {
+	now := time.Date(2022, 5, 18, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		s        string
+		expected time.Time
+	}{
+		{"", time.Time{}},
+		{"1h30m", now.Add(-90 * time.Minute)},
+		{"2022-05-18 10:15:00", time.Date(2022, 5, 18, 10, 15, 0, 0, time.Local)},
+		{"2022-05-17", time.Date(2022, 5, 17, 0, 0, 0, 0, time.Local)},
+		{"2022-05-18T10:15:00Z", time.Date(2022, 5, 18, 10, 15, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		actual, err := storage.ParseLogcatTime(test.s, now)
+		if err != nil {
+			t.Errorf("%q: %s", test.s, err)
+			continue
+		}
+
+		if !actual.Equal(test.expected) {
+			t.Errorf("%q: expected %s, got %s", test.s, test.expected, actual)
+		}
+	}
+
+	if _, err := storage.ParseLogcatTime("yesterday", now); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+}
+
+func TestStorageBenchmarks(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "benchmarks.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	now := time.Now()
+	results := []*adbclient.BenchmarkResult{
+		{Serial: "123456789", USB: "1-1.2", Time: now.Add(time.Minute), Size: 100, Push: time.Second, Pull: time.Second},
+		{Serial: "123456789", USB: "1-1.3", Time: now, Size: 100, Push: 2 * time.Second, Pull: time.Second},
+		{Serial: "987654321", USB: "1-1.2", Time: now.Add(-time.Minute), Size: 100, Push: 3 * time.Second, Pull: time.Second},
+	}
+
+	for _, result := range results {
+		if err := s.SaveBenchmark(result); err != nil {
+			t.Error(err)
+		}
+	}
+
+	benchmarks, err := s.GetBenchmarks("123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(benchmarks) != 2 || benchmarks[0].USB != "1-1.3" || benchmarks[0].Push != 2*time.Second {
+		t.Fatalf("Expected 2 benchmarks, oldest first, got %+v", benchmarks)
+	}
+
+	ports, err := s.GetPortBenchmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ports) != 2 || len(ports["1-1.2"]) != 2 || len(ports["1-1.3"]) != 1 {
+		t.Fatalf("Unexpected benchmarks by port: %+v", ports)
+	}
+
+	if ports["1-1.2"][0].Serial != "987654321" {
+		t.Errorf("Expected the oldest benchmark of the port first, got %+v", ports["1-1.2"][0])
+	}
+
+	summaries := storage.SummarizePorts(ports)
+	if len(summaries) != 2 || summaries[0].Port != "1-1.3" || summaries[1].Port != "1-1.2" {
+		t.Fatalf("Expected the slowest port first, got %+v", summaries)
+	}
+
+	if summaries[1].Runs != 2 || fmt.Sprintf("%.1f", summaries[1].Push) != "66.7" || summaries[1].Device != "123456789" {
+		t.Errorf("Unexpected summary: %+v", summaries[1])
+	}
+
+	if err := s.DeleteBenchmarks("123456789"); err != nil {
+		t.Error(err)
+	}
+
+	if benchmarks, _ := s.GetBenchmarks("123456789"); len(benchmarks) != 0 {
+		t.Errorf("Expected no benchmarks, got %d", len(benchmarks))
+	}
+}
+
+func TestStorageSnippets(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "snippets.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	for _, snippet := range []storage.Snippet{
+		{Name: "top", Command: "top -n 1"},
+		{Name: "activity", Command: "dumpsys activity top"},
+		{Name: "top", Command: "top -n 1 -m 10"},
+	} {
+		if err := s.SaveSnippet(snippet); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if err := s.SaveSnippet(storage.Snippet{Command: "ls"}); err == nil {
+		t.Error("Expected an error for a snippet without a name")
+	}
+
+	snippets, err := s.GetSnippets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snippets) != 2 || snippets[0].Name != "activity" || snippets[1].Command != "top -n 1 -m 10" {
+		t.Fatalf("Expected 2 snippets sorted by name, got %+v", snippets)
+	}
+
+	if err := s.DeleteSnippet("activity"); err != nil {
+		t.Error(err)
+	}
+
+	if snippets, _ := s.GetSnippets(); len(snippets) != 1 {
+		t.Errorf("Expected 1 snippet, got %d", len(snippets))
+	}
+}
+
+func TestStorageShellHistory(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "history.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	for _, command := range []string{"ls", "ls", "cd /sdcard", "ls"} {
+		if err := s.AddShellHistory(command); err != nil {
+			t.Error(err)
+		}
+	}
+
+	history, err := s.GetShellHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(history) != "[ls cd /sdcard ls]" {
+		t.Errorf("Unexpected history: %q", history)
+	}
+
+	for i := 0; i < storage.DefaultShellHistorySize; i++ {
+		if err := s.AddShellHistory(fmt.Sprintf("echo %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, _ = s.GetShellHistory()
+	if len(history) != storage.DefaultShellHistorySize || history[0] != "echo 0" {
+		t.Errorf("Expected the %d newest commands, got %d starting with %q", storage.DefaultShellHistorySize, len(history), history[0])
+	}
+}
+
+func TestStorageSnapshots(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "snapshots.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	now := time.Now()
+	snapshots := []*adbclient.Snapshot{
+		{Serial: "987654321", Time: now, Sections: map[string]map[string]string{adbclient.SectionProperties: {"ro.build.version.sdk": "33"}}},
+		{Serial: "123456789", Time: now, Label: "after update", Sections: map[string]map[string]string{adbclient.SectionProperties: {"ro.build.version.sdk": "33"}}},
+		{Serial: "123456789", Time: now.Add(-time.Hour), Label: "before update", Sections: map[string]map[string]string{adbclient.SectionProperties: {"ro.build.version.sdk": "32"}}},
+	}
+
+	for _, snapshot := range snapshots {
+		if err := s.SaveSnapshot(snapshot); err != nil {
+			t.Error(err)
+		}
+	}
+
+	saved, err := s.GetSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saved) != 3 || saved[0].Label != "before update" || saved[1].Label != "after update" || saved[2].Serial != "987654321" {
+		t.Fatalf("Expected the snapshots by serial, oldest first, got %+v", saved)
+	}
+
+	if changes := adbclient.DiffSnapshots(&saved[0], &saved[1]); len(changes) != 1 || changes[0].New != "33" {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+
+	if err := s.DeleteSnapshot("123456789", saved[0].Time); err != nil {
+		t.Error(err)
+	}
+
+	if saved, _ := s.GetSnapshots(); len(saved) != 2 {
+		t.Errorf("Expected 2 snapshots, got %d", len(saved))
+	}
+}
+
+func TestStorageInventory(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "inventory.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	now := time.Now().Truncate(time.Second)
+	for i, state := range []string{"online", "offline", "online"} {
+		if err := s.RecordConnection("123456789", storage.Connection{Time: now.Add(time.Duration(i) * time.Minute), State: state, USB: "1-1.2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.UpdateInventoryEntry("123456789", func(entry *storage.InventoryEntry) error {
+		entry.Name = "Lab phone"
+		entry.SetLabels("rack-a, ci, rack-a,")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := s.GetInventoryEntry("123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Name != "Lab phone" || fmt.Sprint(entry.Labels) != "[ci rack-a]" || len(entry.History) != 3 {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	if !entry.FirstSeen.Equal(now) || !entry.LastSeen.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("Unexpected seen times: %s, %s", entry.FirstSeen, entry.LastSeen)
+	}
+
+	if entry, _ := s.GetInventoryEntry("unknown"); entry == nil || entry.Serial != "unknown" || entry.Name != "" {
+		t.Errorf("Expected an empty entry, got %+v", entry)
+	}
+
+	entries, err := s.GetInventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := storage.WriteInventoryCSV(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := storage.ReadInventoryCSV(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 1 || imported[0].Name != "Lab phone" || fmt.Sprint(imported[0].Labels) != "[ci rack-a]" || !imported[0].LastSeen.Equal(entry.LastSeen) {
+		t.Errorf("Unexpected CSV round trip: %+v", imported)
+	}
+
+	// the columns are found by name
+	imported, err = storage.ReadInventoryCSV(bytes.NewBufferString("Owner,Serial,Labels\nalice,987654321,lab\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported[0].FirstSeen = now.Add(-time.Hour)
+	imported = append(imported, storage.InventoryEntry{Serial: "123456789", Owner: "bob", FirstSeen: now.Add(-time.Hour)})
+	if err := s.ImportInventory(imported); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ = s.GetInventory()
+	if len(entries) != 2 || entries[0].Owner != "bob" || entries[1].Owner != "alice" || fmt.Sprint(entries[1].Labels) != "[lab]" {
+		t.Fatalf("Unexpected inventory after import: %+v", entries)
+	}
+
+	if len(entries[0].History) != 3 || !entries[0].FirstSeen.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected the history to be kept and the first seen time widened, got %+v", entries[0])
+	}
+
+	if fmt.Sprint(storage.InventoryLabels(entries)) != "[lab]" {
+		t.Errorf("Unexpected labels: %v", storage.InventoryLabels(entries))
+	}
+
+	buf.Reset()
+	if err := storage.WriteInventoryJSON(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if imported, err := storage.ReadInventoryJSON(&buf); err != nil || len(imported) != 2 || len(imported[0].History) != 3 {
+		t.Errorf("Unexpected JSON round trip: %+v, %v", imported, err)
+	}
+
+	if _, err := storage.ReadInventoryCSV(bytes.NewBufferString("name\nphone\n")); err == nil {
+		t.Error("Expected error without a serial column")
+	}
+
+	if err := s.DeleteInventoryEntry("987654321"); err != nil {
+		t.Error(err)
+	}
+
+	if entries, _ := s.GetInventory(); len(entries) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(entries))
+	}
+}
+
+func TestStorageLeases(t *testing.T) {
+	s, err := storage.NewStorage(filepath.Join(t.TempDir(), "leases.db"), empty.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	if _, err := s.AcquireLease("123456789", "alice", "", 0); err == nil {
+		t.Fatal("expected an error for an invalid duration")
+	}
+
+	lease, err := s.AcquireLease("123456789", "alice", "perf run", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lease.Owner != "alice" || lease.Note != "perf run" || !lease.Active(time.Now()) {
+		t.Fatalf("unexpected lease: %+v", lease)
+	}
+
+	if _, err := s.AcquireLease("123456789", "bob", "", time.Hour); !errors.Is(err, adbclient.ErrDeviceLeased) {
+		t.Fatalf("expected ErrDeviceLeased, got %v", err)
+	}
+
+	if err := s.ReleaseLease("123456789", "bob"); !errors.Is(err, adbclient.ErrDeviceLeased) {
+		t.Fatalf("expected ErrDeviceLeased, got %v", err)
+	}
+
+	renewed, err := s.AcquireLease("123456789", "alice", "", 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !renewed.Acquired.Equal(lease.Acquired) || !renewed.Expires.After(lease.Expires) {
+		t.Fatalf("lease was not renewed: %+v", renewed)
+	}
+
+	leases, err := s.GetLeases()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(leases) != 1 || leases[0].Serial != "123456789" {
+		t.Fatalf("unexpected leases: %+v", leases)
+	}
+
+	if err := s.ForceReleaseLease("123456789"); err != nil {
+		t.Fatal(err)
+	}
+
+	if lease, err := s.GetLease("123456789"); err != nil || lease != nil {
+		t.Fatalf("expected no lease, got %+v, %v", lease, err)
+	}
+
+	if _, err := s.AcquireLease("123456789", "bob", "", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ReleaseLease("123456789", "bob"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,402 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/annotate"
+)
+
+const (
+	// DefaultAnnotatedScreenshotPath is the default annotated screenshot file path.
+	DefaultAnnotatedScreenshotPath = "./screenshot_annotated.png"
+)
+
+// AnnotationTool is a tool of the screenshot annotator.
+type AnnotationTool int
+
+const (
+	AnnotationToolArrow AnnotationTool = iota
+	AnnotationToolRectangle
+	AnnotationToolBlur
+	AnnotationToolText
+)
+
+func (t AnnotationTool) String() string {
+	switch t {
+	case AnnotationToolArrow:
+		return "Arrow"
+	case AnnotationToolRectangle:
+		return "Rectangle"
+	case AnnotationToolBlur:
+		return "Blur"
+	case AnnotationToolText:
+		return "Text"
+	default:
+		return "Unknown"
+	}
+}
+
+var annotationColors = map[string]color.Color{
+	"Red":    color.NRGBA{R: 0xf4, G: 0x43, B: 0x36, A: 0xff},
+	"Yellow": color.NRGBA{R: 0xff, G: 0xeb, B: 0x3b, A: 0xff},
+	"Green":  color.NRGBA{R: 0x4c, G: 0xaf, B: 0x50, A: 0xff},
+	"Blue":   color.NRGBA{R: 0x21, G: 0x96, B: 0xf3, A: 0xff},
+	"White":  color.White,
+	"Black":  color.Black,
+}
+
+// Annotator is a widget for drawing annotations over a screenshot.
+type Annotator struct {
+	widget.BaseWidget
+
+	Tool  AnnotationTool
+	Color color.Color
+	Text  string
+
+	src      image.Image
+	shapes   []annotate.Shape
+	dragging bool
+	start    fyne.Position
+	end      fyne.Position
+
+	image       *canvas.Image
+	previewRect *canvas.Rectangle
+	previewLine *canvas.Line
+}
+
+// NewAnnotator creates a new annotator for the image.
+func NewAnnotator(src image.Image) *Annotator {
+	a := &Annotator{
+		Tool:  AnnotationToolArrow,
+		Color: annotationColors["Red"],
+		src:   src,
+	}
+
+	a.image = canvas.NewImageFromImage(src)
+	a.image.FillMode = canvas.ImageFillContain
+
+	a.previewRect = canvas.NewRectangle(color.Transparent)
+	a.previewRect.StrokeWidth = 2
+	a.previewRect.Hide()
+
+	a.previewLine = canvas.NewLine(a.Color)
+	a.previewLine.StrokeWidth = 2
+	a.previewLine.Hide()
+
+	a.ExtendBaseWidget(a)
+	return a
+}
+
+// CreateRenderer implements fyne.Widget.
+func (a *Annotator) CreateRenderer() fyne.WidgetRenderer {
+	return &annotatorRenderer{annotator: a}
+}
+
+// MinSize implements fyne.Widget.
+func (a *Annotator) MinSize() fyne.Size {
+	return fyne.NewSize(256, 256)
+}
+
+// strokeWidth returns the stroke width in image pixels suitable for the image size.
+func (a *Annotator) strokeWidth() int {
+	w := a.src.Bounds().Dx() / 150
+	if w < 2 {
+		return 2
+	}
+
+	return w
+}
+
+// toImage converts a widget position to image coordinates.
+func (a *Annotator) toImage(pos fyne.Position) image.Point {
+	size := a.Size()
+	bounds := a.src.Bounds()
+
+	scale := size.Width / float32(bounds.Dx())
+	if s := size.Height / float32(bounds.Dy()); s < scale {
+		scale = s
+	}
+
+	offsetX := (size.Width - float32(bounds.Dx())*scale) / 2
+	offsetY := (size.Height - float32(bounds.Dy())*scale) / 2
+	return image.Pt(int((pos.X-offsetX)/scale), int((pos.Y-offsetY)/scale))
+}
+
+// Dragged implements fyne.Draggable.
+func (a *Annotator) Dragged(e *fyne.DragEvent) {
+	if a.Tool == AnnotationToolText {
+		return
+	}
+
+	if !a.dragging {
+		a.dragging = true
+		a.start = e.Position.Subtract(e.Dragged)
+	}
+
+	a.end = e.Position
+	a.updatePreview()
+}
+
+// DragEnd implements fyne.Draggable.
+func (a *Annotator) DragEnd() {
+	if !a.dragging {
+		return
+	}
+
+	a.dragging = false
+	a.previewRect.Hide()
+	a.previewLine.Hide()
+
+	from, to := a.toImage(a.start), a.toImage(a.end)
+	switch a.Tool {
+	case AnnotationToolArrow:
+		a.Add(annotate.Arrow{From: from, To: to, Color: a.Color, Width: a.strokeWidth()})
+	case AnnotationToolRectangle:
+		a.Add(annotate.Rect{Rect: image.Rectangle{Min: from, Max: to}, Color: a.Color, Width: a.strokeWidth()})
+	case AnnotationToolBlur:
+		a.Add(annotate.Blur{Rect: image.Rectangle{Min: from, Max: to}, Radius: a.strokeWidth() * 4})
+	}
+}
+
+// Tapped implements fyne.Tappable.
+func (a *Annotator) Tapped(e *fyne.PointEvent) {
+	if a.Tool != AnnotationToolText || a.Text == "" {
+		return
+	}
+
+	a.Add(annotate.Text{At: a.toImage(e.Position), Text: a.Text, Color: a.Color, Scale: a.strokeWidth()})
+}
+
+func (a *Annotator) updatePreview() {
+	switch a.Tool {
+	case AnnotationToolArrow:
+		a.previewLine.StrokeColor = a.Color
+		a.previewLine.Position1 = a.start
+		a.previewLine.Position2 = a.end
+		a.previewLine.Show()
+		a.previewLine.Refresh()
+
+	case AnnotationToolRectangle, AnnotationToolBlur:
+		min := fyne.NewPos(fyMin(a.start.X, a.end.X), fyMin(a.start.Y, a.end.Y))
+		max := fyne.NewPos(fyMax(a.start.X, a.end.X), fyMax(a.start.Y, a.end.Y))
+
+		a.previewRect.StrokeColor = a.Color
+		a.previewRect.Move(min)
+		a.previewRect.Resize(fyne.NewSize(max.X-min.X, max.Y-min.Y))
+		a.previewRect.Show()
+		a.previewRect.Refresh()
+	}
+}
+
+// Add adds a shape and redraws the image.
+func (a *Annotator) Add(shape annotate.Shape) {
+	a.shapes = append(a.shapes, shape)
+	a.redraw()
+}
+
+// Undo removes the last shape.
+func (a *Annotator) Undo() {
+	if len(a.shapes) == 0 {
+		return
+	}
+
+	a.shapes = a.shapes[:len(a.shapes)-1]
+	a.redraw()
+}
+
+// Clear removes all shapes.
+func (a *Annotator) Clear() {
+	a.shapes = nil
+	a.redraw()
+}
+
+// Image returns the screenshot with all annotations.
+func (a *Annotator) Image() image.Image {
+	return annotate.Render(a.src, a.shapes...)
+}
+
+func (a *Annotator) redraw() {
+	a.image.Image = a.Image()
+	a.image.Refresh()
+}
+
+func fyMin(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func fyMax(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+type annotatorRenderer struct {
+	annotator *Annotator
+}
+
+var _ fyne.WidgetRenderer = &annotatorRenderer{}
+
+func (r *annotatorRenderer) Destroy() {
+}
+
+func (r *annotatorRenderer) Layout(size fyne.Size) {
+	r.annotator.image.Resize(size)
+}
+
+func (r *annotatorRenderer) MinSize() fyne.Size {
+	return r.annotator.MinSize()
+}
+
+func (r *annotatorRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.annotator.image, r.annotator.previewRect, r.annotator.previewLine}
+}
+
+func (r *annotatorRenderer) Refresh() {
+	r.annotator.image.Refresh()
+}
+
+// Annotate shows the annotation editor for the screenshot.
+func Annotate(device *adbclient.Device, img image.Image, parent fyne.Window) {
+	annotator := NewAnnotator(img)
+
+	var tools []string
+	for tool := AnnotationToolArrow; tool <= AnnotationToolText; tool++ {
+		tools = append(tools, tool.String())
+	}
+
+	textEntry := widget.NewEntry()
+	textEntry.SetPlaceHolder("Text, tap to place")
+	textEntry.OnChanged = func(text string) {
+		annotator.Text = text
+	}
+	textEntry.Disable()
+
+	toolRadio := widget.NewRadioGroup(tools, func(selected string) {
+		for tool := AnnotationToolArrow; tool <= AnnotationToolText; tool++ {
+			if tool.String() == selected {
+				annotator.Tool = tool
+			}
+		}
+
+		if annotator.Tool == AnnotationToolText {
+			textEntry.Enable()
+		} else {
+			textEntry.Disable()
+		}
+	})
+	toolRadio.Horizontal = true
+	toolRadio.Required = true
+	toolRadio.SetSelected(AnnotationToolArrow.String())
+
+	colorSelect := widget.NewSelect([]string{"Red", "Yellow", "Green", "Blue", "White", "Black"}, func(selected string) {
+		annotator.Color = annotationColors[selected]
+	})
+	colorSelect.SetSelected("Red")
+
+	undoButton := widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), annotator.Undo)
+	clearButton := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), annotator.Clear)
+
+	exportPathEntry := widget.NewEntry()
+	exportPathEntry.SetText(DefaultAnnotatedScreenshotPath)
+
+	exportPathButton := widget.NewButtonWithIcon("Select", theme.DocumentSaveIcon(), func() {
+		fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+			if err != nil {
+				return
+			}
+
+			if file == nil {
+				return
+			}
+
+			defer file.Close()
+			exportPathEntry.SetText(file.URI().Path())
+		}, parent)
+
+		fsaveDialog.SetFileName("screenshot_annotated.png")
+		fsaveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".png", ".jpg", ".jpeg"}))
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	})
+
+	deviceFrameCheck := widget.NewCheck("Device frame", nil)
+	labelCheck := widget.NewCheck("Timestamp and device label", nil)
+	labelCheck.SetChecked(true)
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		path := exportPathEntry.Text
+		format, err := annotate.FormatFromPath(path)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		result := annotator.Image()
+		if deviceFrameCheck.Checked {
+			result = annotate.DeviceFrame(result, annotate.Display{
+				Width:   device.Display.Width,
+				Height:  device.Display.Height,
+				Density: device.Display.Density,
+			})
+		}
+
+		if labelCheck.Checked {
+			result = annotate.Caption(result, fmt.Sprintf("%s  %s", device, time.Now().Format("2006-01-02 15:04:05")))
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		defer f.Close()
+
+		if err := annotate.Export(f, result, format); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		GetApp().ShowInformation("Screenshot exported", path, parent)
+	})
+
+	d := dialog.NewCustom(
+		"Annotate",
+		"Close",
+		container.NewBorder(
+			container.NewVBox(
+				container.NewHBox(toolRadio, colorSelect, undoButton, clearButton),
+				textEntry,
+			),
+			container.NewVBox(
+				container.New(&alignToRightLayout{}, exportPathEntry, exportPathButton),
+				container.NewBorder(nil, nil, container.NewHBox(deviceFrameCheck, labelCheck), exportButton),
+			),
+			nil,
+			nil,
+			annotator,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/c2h5oh/datasize"
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+var (
+	benchmarkPushColor = color.NRGBA{R: 0x42, G: 0xa5, B: 0xf5, A: 0xff}
+	benchmarkPullColor = color.NRGBA{R: 0xff, G: 0xa7, B: 0x26, A: 0xff}
+
+	// benchmarkSizes are the sizes offered for a benchmark, larger ones are more stable but slower.
+	benchmarkSizes = []string{"16 MB", "64 MB", "256 MB", "1 GB"}
+)
+
+// benchmarkColumns are the columns of the port table with their widths.
+var benchmarkColumns = []struct {
+	title string
+	width float32
+}{
+	{"USB port", 120},
+	{"Runs", 50},
+	{"Push", 90},
+	{"Pull", 90},
+	{"Latency", 80},
+	{"Last device", 140},
+}
+
+// throughputChart is a line chart of the push and pull throughput of benchmarks, oldest first.
+type throughputChart struct {
+	widget.BaseWidget
+
+	results []adbclient.BenchmarkResult
+}
+
+func newThroughputChart() *throughputChart {
+	c := &throughputChart{}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetResults replaces the charted benchmarks.
+func (c *throughputChart) SetResults(results []adbclient.BenchmarkResult) {
+	c.results = results
+	c.Refresh()
+}
+
+func (c *throughputChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &throughputChartRenderer{
+		chart:      c,
+		background: canvas.NewRectangle(theme.InputBackgroundColor()),
+		maxLabel:   canvas.NewText("", theme.ForegroundColor()),
+		legend:     canvas.NewText("", theme.ForegroundColor()),
+	}
+
+	r.maxLabel.TextSize = theme.CaptionTextSize()
+	r.legend.TextSize = theme.CaptionTextSize()
+	r.rebuild()
+	return r
+}
+
+type throughputChartRenderer struct {
+	chart      *throughputChart
+	background *canvas.Rectangle
+	maxLabel   *canvas.Text
+	legend     *canvas.Text
+	push       []*canvas.Line
+	pull       []*canvas.Line
+	objects    []fyne.CanvasObject
+}
+
+// rebuild creates a line between each pair of benchmarks.
+func (r *throughputChartRenderer) rebuild() {
+	r.push, r.pull = nil, nil
+	r.objects = []fyne.CanvasObject{r.background, r.maxLabel, r.legend}
+
+	for i := 1; i < len(r.chart.results); i++ {
+		push, pull := canvas.NewLine(benchmarkPushColor), canvas.NewLine(benchmarkPullColor)
+		push.StrokeWidth, pull.StrokeWidth = 2, 2
+
+		r.push, r.pull = append(r.push, push), append(r.pull, pull)
+		r.objects = append(r.objects, push, pull)
+	}
+}
+
+func (r *throughputChartRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+
+	results := r.chart.results
+	if len(results) == 0 {
+		r.maxLabel.Text = "No benchmarks yet"
+		r.legend.Text = ""
+		r.maxLabel.Move(fyne.NewPos(theme.Padding(), theme.Padding()))
+		return
+	}
+
+	max := 0.0
+	for _, result := range results {
+		if v := result.PushThroughput(); v > max {
+			max = v
+		}
+
+		if v := result.PullThroughput(); v > max {
+			max = v
+		}
+	}
+
+	if max == 0 {
+		max = 1
+	}
+
+	r.maxLabel.Text = fmt.Sprintf("%.1f MB/s", max/1e6)
+	r.maxLabel.Move(fyne.NewPos(theme.Padding(), theme.Padding()))
+
+	last := results[len(results)-1]
+	r.legend.Text = fmt.Sprintf("push (blue), pull (orange), %d runs, last %s", len(results), last.Time.Format("2006-01-02 15:04"))
+	r.legend.Move(fyne.NewPos(theme.Padding(), size.Height-r.legend.MinSize().Height-theme.Padding()))
+
+	top := r.maxLabel.MinSize().Height + 2*theme.Padding()
+	bottom := size.Height - r.legend.MinSize().Height - 2*theme.Padding()
+	left, right := theme.Padding(), size.Width-theme.Padding()
+
+	point := func(i int, v float64) fyne.Position {
+		x := left + (right-left)*float32(i)/float32(len(results)-1)
+		y := bottom - (bottom-top)*float32(v/max)
+		return fyne.NewPos(x, y)
+	}
+
+	for i := 1; i < len(results); i++ {
+		r.push[i-1].Position1, r.push[i-1].Position2 = point(i-1, results[i-1].PushThroughput()), point(i, results[i].PushThroughput())
+		r.pull[i-1].Position1, r.pull[i-1].Position2 = point(i-1, results[i-1].PullThroughput()), point(i, results[i].PullThroughput())
+	}
+}
+
+func (r *throughputChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(300, 150)
+}
+
+func (r *throughputChartRenderer) Refresh() {
+	// a line joins each benchmark to the previous one
+	if len(r.chart.results) != len(r.push)+1 {
+		r.rebuild()
+	}
+
+	r.background.FillColor = theme.InputBackgroundColor()
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *throughputChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *throughputChartRenderer) Destroy() {
+}
+
+// Benchmark measures the transfer throughput and latency of a device and shows the history of the device and of the USB ports.
+func Benchmark(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	var summaries []storage.PortSummary
+
+	sizeSelect := widget.NewSelect(benchmarkSizes, nil)
+	sizeSelect.SetSelected(benchmarkSizes[1])
+
+	bar := NewProgressBar(parent)
+	resultLabel := widget.NewLabel("")
+	chart := newThroughputChart()
+
+	portTable := widget.NewTable(
+		func() (int, int) {
+			return len(summaries) + 1, len(benchmarkColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(benchmarkColumns[id.Col].title)
+				return
+			}
+
+			summary := summaries[id.Row-1]
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText([]string{
+				summary.Port,
+				fmt.Sprint(summary.Runs),
+				fmt.Sprintf("%.1f MB/s", summary.Push/1e6),
+				fmt.Sprintf("%.1f MB/s", summary.Pull/1e6),
+				summary.Latency.Round(100 * time.Microsecond).String(),
+				summary.Device,
+			}[id.Col])
+		},
+	)
+
+	for i, column := range benchmarkColumns {
+		portTable.SetColumnWidth(i, column.width)
+	}
+
+	load := func() {
+		results, err := GetApp().storage.GetBenchmarks(device.Serial)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		chart.SetResults(results)
+
+		ports, err := GetApp().storage.GetPortBenchmarks()
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		summaries = storage.SummarizePorts(ports)
+		portTable.Refresh()
+	}
+
+	port := device.USB
+	if port == "" {
+		port = "network"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runButton *widget.Button
+	runButton = widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), func() {
+		var size datasize.ByteSize
+		if err := size.UnmarshalText([]byte(sizeSelect.Selected)); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		runButton.Disable()
+		bar.SetValue(0)
+		bar.SetText("Benchmarking " + port + "...")
+
+		go func() {
+			defer runButton.Enable()
+			defer bar.SetText("")
+
+			result, err := client.Benchmark(ctx, device, adbclient.WithBenchmarkSize(int64(size.Bytes())), adbclient.WithBenchmarkProgress(func(sentBytes, totalBytes int64) {
+				bar.Max = float64(totalBytes)
+				bar.SetValue(float64(sentBytes))
+			}))
+
+			if err != nil {
+				if ctx.Err() == nil {
+					GetApp().ShowError(err, nil, parent)
+				}
+
+				return
+			}
+
+			bar.Done()
+			resultLabel.SetText(result.String())
+
+			if err := GetApp().storage.SaveBenchmark(result); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			load()
+		}()
+	})
+
+	d := dialog.NewCustom(
+		"Benchmark: "+device.String(),
+		"Close",
+		container.NewBorder(
+			container.NewVBox(
+				container.NewBorder(nil, nil, widget.NewLabel("USB port "+port+", size:"), runButton, sizeSelect),
+				bar,
+				resultLabel,
+			),
+			nil,
+			nil,
+			nil,
+			container.NewAppTabs(
+				container.NewTabItem("Device history", chart),
+				container.NewTabItem("Ports", portTable),
+			),
+		),
+		parent,
+	)
+
+	d.SetOnClosed(cancel)
+	d.Resize(DialogSize(parent))
+	d.Show()
+
+	load()
+}
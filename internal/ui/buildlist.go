@@ -7,12 +7,16 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/johnnyipcom/androidtool/internal/assets"
 	"github.com/johnnyipcom/androidtool/pkg/aabclient"
 	"github.com/johnnyipcom/androidtool/pkg/aapt"
 	"github.com/johnnyipcom/androidtool/pkg/apk"
 	"github.com/johnnyipcom/androidtool/pkg/generic"
+	"github.com/johnnyipcom/androidtool/pkg/retrace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -51,12 +55,15 @@ type Build struct {
 	UnpackedPath string
 	APK          *apk.APK
 	Icon         image.Image
+	// Mapping is the path of the R8/ProGuard mapping file of the build, if any.
+	Mapping string
 
 	typeIcon *widget.Icon
 	icon     *canvas.Image
 	abi      *widget.Button
 	sizes    *widget.Button
 	manifest *widget.Button
+	mapping  *widget.Button
 }
 
 type BuildList struct {
@@ -91,6 +98,7 @@ func (b *BuildList) CreateItem() fyne.CanvasObject {
 			widget.NewButtonWithIcon("", assets.ABIIcon, nil),
 			widget.NewButtonWithIcon("", assets.SizesIcon, nil),
 			widget.NewButtonWithIcon("", assets.ManifestIcon, nil),
+			widget.NewButtonWithIcon("", theme.FileTextIcon(), nil),
 		),
 	)
 }
@@ -158,6 +166,52 @@ func (b *BuildList) UpdateItem(id int, item fyne.CanvasObject) {
 			}
 		}()
 	}
+
+	buildItem.mapping = c.Objects[1].(*fyne.Container).Objects[3].(*widget.Button)
+	buildItem.mapping.Importance = widget.MediumImportance
+	if buildItem.Mapping != "" {
+		buildItem.mapping.Importance = widget.HighImportance
+	}
+
+	buildItem.mapping.OnTapped = func() {
+		b.onLoadMapping(buildItem)
+	}
+
+	buildItem.mapping.Refresh()
+}
+
+// onLoadMapping associates a mapping file with the build, crash records and logs of the same package
+// and version code are retraced with it.
+func (b *BuildList) onLoadMapping(buildItem *Build) {
+	fopenDialog := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+		if err != nil {
+			GetApp().ShowError(err, nil, b.parent)
+			return
+		}
+
+		if file == nil {
+			return
+		}
+
+		defer file.Close()
+
+		mapping, err := retrace.ParseMapping(file)
+		if err != nil {
+			GetApp().ShowError(err, nil, b.parent)
+			return
+		}
+
+		pkg, versionCode := buildItem.APK.Identifier(), int(buildItem.APK.VersionCode())
+		GetApp().mappings.Set(pkg, versionCode, mapping)
+		buildItem.Mapping = file.URI().Path()
+
+		GetApp().ShowInformation("Mapping", fmt.Sprintf("%d classes mapped for %s (%d)", mapping.Len(), pkg, versionCode), b.parent)
+		b.Refresh()
+	}, b.parent)
+
+	fopenDialog.Resize(DialogSize(b.parent))
+	fopenDialog.SetFilter(storage.NewExtensionFileFilter([]string{".txt", ".map"}))
+	fopenDialog.Show()
 }
 
 func (b *BuildList) OnSelected(id int) {
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/screendiff"
+)
+
+// ScreenshotCompare shows the baseline, the actual screenshot and the diff image side by side.
+func ScreenshotCompare(key screendiff.Key, baseline image.Image, actual image.Image, result *screendiff.Result, parent fyne.Window) {
+	column := func(title string, img image.Image) fyne.CanvasObject {
+		screenshotImage, _ := NewScreenshotImageFromImage(img)
+		screenshotImage.SetMinSize(fyne.NewSize(128, 256))
+
+		return container.NewBorder(
+			widget.NewLabelWithStyle(title, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+			nil,
+			nil,
+			nil,
+			screenshotImage,
+		)
+	}
+
+	resultLabel := widget.NewLabelWithStyle(result.String(), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	resultLabel.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustom(
+		"Compare: "+key.String(),
+		"Close",
+		container.NewBorder(
+			nil,
+			resultLabel,
+			nil,
+			nil,
+			container.NewGridWithColumns(
+				3,
+				column("Baseline", baseline),
+				column("Actual", actual),
+				column("Diff", result.Diff),
+			),
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
+
+// newBaselineUI creates the controls for saving and comparing baselines of the captured screenshot.
+func newBaselineUI(client *adbclient.Client, device *adbclient.Device, captured func() image.Image, parent fyne.Window) fyne.CanvasObject {
+	appEntry := widget.NewEntry()
+	appEntry.SetPlaceHolder("com.example.app")
+
+	versionEntry := widget.NewEntry()
+	versionEntry.SetPlaceHolder("Read from device")
+
+	localeEntry := widget.NewEntry()
+	localeEntry.SetPlaceHolder("Read from device")
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("main_menu")
+
+	toleranceEntry := widget.NewEntry()
+	toleranceEntry.SetText(strconv.Itoa(screendiff.DefaultTolerance))
+	toleranceEntry.Validator = func(s string) error {
+		tolerance, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+
+		if tolerance < 0 || tolerance > 255 {
+			return fmt.Errorf("tolerance must be between 0 and 255")
+		}
+
+		return nil
+	}
+
+	maskStatusBarCheck := widget.NewCheck("Mask status bar", nil)
+	maskStatusBarCheck.SetChecked(true)
+
+	// key returns the baseline key, reading missing values from the device.
+	key := func() (screendiff.Key, error) {
+		key := screendiff.Key{
+			App:     appEntry.Text,
+			Version: versionEntry.Text,
+			Model:   device.Model,
+			Locale:  localeEntry.Text,
+			Name:    nameEntry.Text,
+		}
+
+		if key.Version == "" && key.App != "" {
+			pkg, err := client.GetPackage(device, key.App)
+			if err != nil {
+				return key, err
+			}
+
+			key.Version = pkg.VersionName
+			versionEntry.SetText(key.Version)
+		}
+
+		if key.Locale == "" {
+			locale, err := client.Locale(device)
+			if err != nil {
+				return key, err
+			}
+
+			key.Locale = locale
+			localeEntry.SetText(key.Locale)
+		}
+
+		return key, key.Validate()
+	}
+
+	store := func() *screendiff.Store {
+		return screendiff.NewStore(fyne.CurrentApp().Preferences().StringWithFallback("baselines_path", screendiff.DefaultBaselinePath))
+	}
+
+	saveButton := widget.NewButtonWithIcon("Save baseline", theme.DocumentSaveIcon(), func() {
+		img := captured()
+		if img == nil {
+			GetApp().ShowError(fmt.Errorf("take a screenshot first"), nil, parent)
+			return
+		}
+
+		key, err := key()
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		s := store()
+		if err := s.Save(key, img); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		GetApp().ShowInformation("Baseline saved", s.Path(key), parent)
+	})
+
+	compareButton := widget.NewButtonWithIcon("Compare", theme.SearchIcon(), func() {
+		img := captured()
+		if img == nil {
+			GetApp().ShowError(fmt.Errorf("take a screenshot first"), nil, parent)
+			return
+		}
+
+		key, err := key()
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		tolerance, err := strconv.ParseUint(toleranceEntry.Text, 10, 8)
+		if err != nil {
+			GetApp().ShowError(fmt.Errorf("tolerance must be between 0 and 255: %w", err), nil, parent)
+			return
+		}
+
+		opts := []screendiff.CompareOption{screendiff.WithTolerance(uint8(tolerance))}
+		if maskStatusBarCheck.Checked {
+			opts = append(opts, screendiff.WithMask(screendiff.StatusBarMask(img.Bounds().Dx(), device.Display.Density)))
+		}
+
+		baseline, err := store().Load(key)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		result, err := screendiff.Compare(baseline, img, opts...)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		ScreenshotCompare(key, baseline, img, result, parent)
+	})
+
+	return container.NewVBox(
+		container.NewGridWithColumns(
+			4,
+			NewBoldLabel("App:"),
+			appEntry,
+			NewBoldLabel("Version:"),
+			versionEntry,
+			NewBoldLabel("Locale:"),
+			localeEntry,
+			NewBoldLabel("Name:"),
+			nameEntry,
+			NewBoldLabel("Tolerance:"),
+			toleranceEntry,
+			maskStatusBarCheck,
+		),
+		container.NewCenter(
+			container.NewHBox(
+				saveButton,
+				compareButton,
+			),
+		),
+	)
+}
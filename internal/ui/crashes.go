@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	fynestorage "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/crash"
+	"github.com/johnnyipcom/androidtool/pkg/symbolicate"
+)
+
+const (
+	// DefaultCrashArtifactsPeriod is the period of the collected artifacts when no crash is selected.
+	DefaultCrashArtifactsPeriod = 24 * time.Hour
+)
+
+// crashMonitor watches the log of every online device for crashes and ANRs.
+type crashMonitor struct {
+	client  *adbclient.Client
+	storage *storage.Storage
+	watches *adbclient.DeviceWatches
+}
+
+func newCrashMonitor(client *adbclient.Client, storage *storage.Storage) *crashMonitor {
+	m := &crashMonitor{
+		client:  client,
+		storage: storage,
+	}
+
+	m.watches = adbclient.NewDeviceWatches(m.watch)
+	return m
+}
+
+// update starts watching the device when it comes online and stops when it goes away.
+func (m *crashMonitor) update(device *adbclient.Device) {
+	m.watches.Update(device)
+}
+
+// watch runs the detector on the device until the context is done or the connection is lost.
+func (m *crashMonitor) watch(ctx context.Context, device *adbclient.Device) {
+	// only the newest message of the existing log, old crashes were already reported
+	logcat, err := m.client.Logcat(
+		device,
+		adbclient.WithLogcatBuffers(adbclient.BufferMain, adbclient.BufferSystem, adbclient.BufferCrash, adbclient.BufferEvents),
+		adbclient.WithLogcatTail(1),
+	)
+	if err != nil {
+		GetApp().log.Errorf("Failed to watch %s for crashes: %s", device.Serial, err)
+		return
+	}
+
+	defer logcat.Close()
+
+	detector, err := crash.NewDetector(device.Serial)
+	if err != nil {
+		return
+	}
+
+	for record := range detector.Watch(ctx, logcat.C(ctx)) {
+		m.retrace(device, &record)
+		if err := m.storage.SaveCrash(record); err != nil {
+			GetApp().log.Error(err)
+		}
+
+		app := fyne.CurrentApp()
+		if app.Preferences().BoolWithFallback("crash_notifications", true) {
+			app.SendNotification(fyne.NewNotification(
+				fmt.Sprintf("%s on %s", strings.ToUpper(record.Kind.String()), device.Serial),
+				record.Summary(),
+			))
+		}
+	}
+}
+
+// retrace fills the version code of the crashed package and retraces the record
+// if a mapping is associated with the build in the Builds tab.
+func (m *crashMonitor) retrace(device *adbclient.Device, record *crash.Record) {
+	if record.Package == "" || record.Kind == crash.Native {
+		return
+	}
+
+	pkg, err := m.client.GetPackage(device, record.Package)
+	if err != nil {
+		// the package may have been uninstalled meanwhile
+		return
+	}
+
+	record.VersionCode = pkg.VersionCode
+	record.Retrace(GetApp().mappings.Get(record.Package, record.VersionCode))
+}
+
+// Crashes shows the crash history of the device.
+func Crashes(device *adbclient.Device, parent fyne.Window) {
+	storage := GetApp().storage
+
+	records, err := storage.GetCrashes(device.Serial)
+	if err != nil {
+		GetApp().ShowError(err, nil, parent)
+		return
+	}
+
+	// newest first
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	// the mapping may have been associated with the build after the crash was recorded
+	for i := range records {
+		record := &records[i]
+		if record.Retraced || record.VersionCode == 0 {
+			continue
+		}
+
+		if mapping := GetApp().mappings.Get(record.Package, record.VersionCode); mapping != nil {
+			record.Retrace(mapping)
+			if err := storage.SaveCrash(*record); err != nil {
+				GetApp().log.Error(err)
+			}
+		}
+	}
+
+	details := widget.NewMultiLineEntry()
+	details.TextStyle = fyne.TextStyle{Monospace: true}
+	details.Wrapping = fyne.TextWrapOff
+
+	selected := -1
+
+	list := widget.NewList(
+		func() int {
+			return len(records)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			record := records[id]
+			o.(*widget.Label).SetText(fmt.Sprintf("%s  %s", record.Time.Format("2006-01-02 15:04:05"), record.Summary()))
+		},
+	)
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+
+		var b strings.Builder
+		records[id].WriteText(&b)
+		details.SetText(b.String())
+	}
+
+	export := func(records []crash.Record) {
+		if len(records) == 0 {
+			return
+		}
+
+		fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			path := file.URI().Path()
+			file.Close()
+
+			if err := crash.Export(path, records); err != nil {
+				GetApp().ShowError(err, nil, parent)
+			}
+		}, parent)
+
+		fsaveDialog.SetFileName(fmt.Sprintf("crashes_%s.txt", device.Serial))
+		fsaveDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".txt", ".json"}))
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	}
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		if selected < 0 {
+			GetApp().ShowError(fmt.Errorf("no crash selected"), nil, parent)
+			return
+		}
+
+		export(records[selected : selected+1])
+	})
+
+	exportAllButton := widget.NewButtonWithIcon("Export all", theme.DocumentSaveIcon(), func() {
+		export(records)
+	})
+
+	collectButton := widget.NewButtonWithIcon("Collect artifacts", theme.DownloadIcon(), func() {
+		// the artifacts of the selected crash, or of all crashes of the last day
+		pkg := ""
+		since := time.Now().Add(-DefaultCrashArtifactsPeriod)
+		if selected >= 0 {
+			pkg = records[selected].Package
+			since = records[selected].Time.Add(-time.Minute)
+		}
+
+		fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			path := file.URI().Path()
+			file.Close()
+
+			progress := dialog.NewCustom("Collecting crash artifacts", "Cancel", widget.NewProgressBarInfinite(), parent)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			progress.SetOnClosed(cancel)
+			progress.Show()
+
+			go func() {
+				defer cancel()
+
+				artifacts, err := GetApp().adbClient.CollectCrashArtifacts(ctx, device, pkg, since)
+				progress.Hide()
+				if err != nil {
+					if ctx.Err() == nil {
+						GetApp().ShowError(err, nil, parent)
+					}
+
+					return
+				}
+
+				if err := artifacts.SaveZip(path); err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				message := fmt.Sprintf("Collected %d artifacts", len(artifacts.Artifacts))
+				if len(artifacts.Skipped) > 0 {
+					message += "\n\nSkipped:\n" + strings.Join(artifacts.Skipped, "\n")
+				}
+
+				GetApp().ShowInformation("Crash artifacts", message, parent)
+			}()
+		}, parent)
+
+		fsaveDialog.SetFileName(fmt.Sprintf("crash_artifacts_%s.zip", device.Serial))
+		fsaveDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".zip"}))
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	})
+
+	symbolicateButton := widget.NewButtonWithIcon("Symbolicate", theme.SearchIcon(), func() {
+		if selected < 0 {
+			GetApp().ShowError(fmt.Errorf("no crash selected"), nil, parent)
+			return
+		}
+
+		symbolsPath := fyne.CurrentApp().Preferences().String("symbols_path")
+		if symbolsPath == "" {
+			GetApp().ShowError(fmt.Errorf("symbols paths are not set in the settings"), nil, parent)
+			return
+		}
+
+		symbolicator, err := symbolicate.NewSymbolicatorFromPaths(symbolsPath)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		details.SetText(symbolicator.SymbolicateText(details.Text))
+	})
+
+	clearButton := widget.NewButtonWithIcon("Clear history", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("Clear history", "Delete all crashes of "+device.Serial+"?", func(ok bool) {
+			if !ok {
+				return
+			}
+
+			if err := storage.DeleteCrashes(device.Serial); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			records = nil
+			selected = -1
+			list.UnselectAll()
+			list.Refresh()
+			details.SetText("")
+		}, parent)
+	})
+
+	split := container.NewHSplit(list, details)
+	split.Offset = 0.4
+
+	d := dialog.NewCustom(
+		"Crashes: "+device.String(),
+		"Close",
+		container.NewBorder(
+			nil,
+			container.NewHBox(exportButton, exportAllButton, symbolicateButton, collectButton, clearButton),
+			nil,
+			nil,
+			split,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
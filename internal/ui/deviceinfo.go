@@ -1,17 +1,69 @@
 package ui
 
 import (
-	"image/color"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/c2h5oh/datasize"
 	"github.com/johnnyipcom/androidtool/pkg/adbclient"
 )
 
+// newKeyValueTable creates a table of two columns showing the rows.
+func newKeyValueTable(rows *[][]string, keyWidth, valueWidth float32) *widget.Table {
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(*rows), 2
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("wide content")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText((*rows)[i.Row][i.Col])
+		},
+	)
+	table.SetColumnWidth(0, keyWidth)
+	table.SetColumnWidth(1, valueWidth)
+	return table
+}
+
+// profileRows returns the rows of the profile table.
+func profileRows(profile *adbclient.DeviceProfile) [][]string {
+	var frequencies []string
+	for _, f := range profile.CPUFrequencies() {
+		frequencies = append(frequencies, fmt.Sprintf("%.2f GHz", float64(f)/1e9))
+	}
+
+	vulkan := "not supported"
+	if profile.Vulkan != 0 {
+		vulkan = profile.Vulkan.String()
+	}
+
+	return [][]string{
+		{"Manufacturer", profile.Manufacturer},
+		{"Brand", profile.Brand},
+		{"SoC", profile.SoC},
+		{"Board", profile.Board},
+		{"Hardware", profile.Hardware},
+		{"ABIs", strings.Join(profile.ABIs, ", ")},
+		{"CPU cores", strconv.Itoa(profile.CPUCores)},
+		{"CPU frequencies", strings.Join(frequencies, ", ")},
+		{"RAM", datasize.ByteSize(profile.RAM).HumanReadable()},
+		{"Storage", fmt.Sprintf("%s free of %s", datasize.ByteSize(profile.StorageFree).HumanReadable(), datasize.ByteSize(profile.StorageTotal).HumanReadable())},
+		{"GPU", strings.TrimSpace(profile.GPUVendor + " " + profile.GPURenderer)},
+		{"OpenGL ES", profile.GLESVersion},
+		{"Vulkan", vulkan},
+		{"Security patch", profile.SecurityPatch},
+		{"Fingerprint", profile.Fingerprint},
+	}
+}
+
 func DeviceInfo(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
 	rows := [][]string{
 		{"Serial", device.Serial},
@@ -26,23 +78,61 @@ func DeviceInfo(client *adbclient.Client, device *adbclient.Device, parent fyne.
 		{"EGL Version", device.EGLVersion},
 	}
 
-	table := widget.NewTable(
-		func() (int, int) {
-			return len(rows), 2
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("wide content")
-		},
-		func(i widget.TableCellID, o fyne.CanvasObject) {
-			o.(*widget.Label).SetText(rows[i.Row][i.Col])
-		},
-	)
-	table.SetColumnWidth(0, 100)
-	table.SetColumnWidth(1, 200)
+	table := newKeyValueTable(&rows, 130, 400)
+
+	var props map[string]string
+	var propRows [][]string
+	propTable := newKeyValueTable(&propRows, 300, 400)
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("Filter properties")
+	filterEntry.OnChanged = func(filter string) {
+		keys := make([]string, 0, len(props))
+		for key := range props {
+			if strings.Contains(key, filter) || strings.Contains(props[key], filter) {
+				keys = append(keys, key)
+			}
+		}
 
-	rect := canvas.NewRectangle(color.Transparent)
-	rect.SetMinSize(fyne.NewSize(310, 300))
+		sort.Strings(keys)
 
-	d := dialog.NewCustom("Device Info", "OK", container.NewMax(rect, table), parent)
+		propRows = propRows[:0]
+		for _, key := range keys {
+			propRows = append(propRows, []string{key, props[key]})
+		}
+
+		propTable.Refresh()
+	}
+
+	d := dialog.NewCustom("Device Info", "OK", container.NewAppTabs(
+		container.NewTabItem("Device", table),
+		container.NewTabItem("Properties", container.NewBorder(
+			container.NewBorder(nil, nil, nil, widget.NewButtonWithIcon("Snapshots", theme.HistoryIcon(), func() {
+				go Snapshots(client, device, parent)
+			}), filterEntry),
+			nil,
+			nil,
+			nil,
+			propTable,
+		)),
+	), parent)
+
+	d.Resize(DialogSize(parent))
 	d.Show()
+
+	profile, err := client.Profile(device)
+	if err != nil {
+		GetApp().ShowError(err, nil, parent)
+	} else {
+		rows = append(rows, profileRows(profile)...)
+		table.Refresh()
+	}
+
+	props, err = client.Properties(device)
+	if err != nil {
+		GetApp().ShowError(err, nil, parent)
+		return
+	}
+
+	filterEntry.OnChanged(filterEntry.Text)
 }
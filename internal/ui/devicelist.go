@@ -2,10 +2,14 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/johnnyipcom/androidtool/internal/assets"
 	"github.com/johnnyipcom/androidtool/internal/storage"
@@ -23,23 +27,212 @@ type DeviceItem struct {
 	video      *widget.Button
 	send       *widget.Button
 	zeroing    *widget.Button
+	display    *widget.Button
+	files      *widget.Button
+	benchmark  *widget.Button
+	lease      *widget.Button
 	delete     *widget.Button
 }
 
+// deviceListLeaseInterval is the interval between reloads of the leases, which other processes may change
+const deviceListLeaseInterval = 30 * time.Second
+
 // DeviceList is a list of devices
 type DeviceList struct {
 	widget.List
 
-	client   *adbclient.Client
-	storage  *storage.Storage
-	items    *generic.Slice[*DeviceItem]
-	selected *DeviceItem
-	parent   fyne.Window
+	client    *adbclient.Client
+	storage   *storage.Storage
+	crashes   *crashMonitor
+	items     *generic.Slice[*DeviceItem]
+	inventory *generic.Map[string, storage.InventoryEntry]
+	leases    *generic.Map[string, adbclient.Lease]
+	selected  *DeviceItem
+	parent    fyne.Window
+
+	// view is the visible items, filtered by label and grouped by label if enabled
+	viewMu sync.RWMutex
+	view   []*DeviceItem
+	label  string
+	group  bool
 }
 
 // Length returns the number of items in the list
 func (d *DeviceList) Length() int {
-	return d.items.Len()
+	d.viewMu.RLock()
+	defer d.viewMu.RUnlock()
+
+	return len(d.view)
+}
+
+// item returns the visible item with the given id, nil if there is none
+func (d *DeviceList) item(id int) *DeviceItem {
+	d.viewMu.RLock()
+	defer d.viewMu.RUnlock()
+
+	if id < 0 || id >= len(d.view) {
+		return nil
+	}
+
+	return d.view[id]
+}
+
+// entry returns the inventory entry of the device, empty if it is unknown
+func (d *DeviceList) entry(serial string) storage.InventoryEntry {
+	entry, ok := d.inventory.Load(serial)
+	if !ok {
+		entry.Serial = serial
+	}
+
+	return entry
+}
+
+// group returns the label a device is grouped by, its first label
+func (d *DeviceList) groupOf(item *DeviceItem) string {
+	if entry := d.entry(item.Serial); len(entry.Labels) > 0 {
+		return entry.Labels[0]
+	}
+
+	return ""
+}
+
+// updateView computes the visible items, the devices without a label are last when grouping
+func (d *DeviceList) updateView() {
+	d.viewMu.RLock()
+	label, group := d.label, d.group
+	d.viewMu.RUnlock()
+
+	var view []*DeviceItem
+	d.items.Each(func(i int, item *DeviceItem) bool {
+		if entry := d.entry(item.Serial); label == "" || entry.HasLabel(label) {
+			view = append(view, item)
+		}
+
+		return true
+	})
+
+	if group {
+		sort.SliceStable(view, func(i, j int) bool {
+			gi, gj := d.groupOf(view[i]), d.groupOf(view[j])
+			if (gi == "") != (gj == "") {
+				return gj == ""
+			}
+
+			return gi < gj
+		})
+	}
+
+	d.viewMu.Lock()
+	d.view = view
+	d.viewMu.Unlock()
+}
+
+// Refresh updates the visible items and redraws the list
+func (d *DeviceList) Refresh() {
+	d.updateView()
+	d.List.Refresh()
+}
+
+// SetLabelFilter shows only the devices with the label, all devices if empty
+func (d *DeviceList) SetLabelFilter(label string) {
+	d.viewMu.Lock()
+	d.label = label
+	d.viewMu.Unlock()
+
+	d.Refresh()
+}
+
+// SetGroupByLabel groups the devices by their first label
+func (d *DeviceList) SetGroupByLabel(group bool) {
+	d.viewMu.Lock()
+	d.group = group
+	d.viewMu.Unlock()
+
+	d.Refresh()
+}
+
+// Labels returns the labels of the devices in the inventory
+func (d *DeviceList) Labels() []string {
+	return storage.InventoryLabels(d.inventory.Values())
+}
+
+// ReloadInventory reloads the inventory after it was edited
+func (d *DeviceList) ReloadInventory() error {
+	entries, err := d.storage.GetInventory()
+	if err != nil {
+		return err
+	}
+
+	d.inventory.Clear()
+	for _, entry := range entries {
+		d.inventory.Store(entry.Serial, entry)
+	}
+
+	d.Refresh()
+	return nil
+}
+
+// reloadLeases reloads the leases after they were changed, and shows the error if any
+func (d *DeviceList) reloadLeases() {
+	if err := d.ReloadLeases(); err != nil {
+		GetApp().ShowError(err, nil, d.parent)
+	}
+}
+
+// ReloadLeases reloads the leases of the devices
+func (d *DeviceList) ReloadLeases() error {
+	leases, err := d.storage.GetLeases()
+	if err != nil {
+		return err
+	}
+
+	d.leases.Clear()
+	for _, lease := range leases {
+		d.leases.Store(lease.Serial, lease)
+	}
+
+	d.Refresh()
+	return nil
+}
+
+// leaseWatcher reloads the leases periodically, to show the leases taken and expired elsewhere
+func (d *DeviceList) leaseWatcher() {
+	ticker := time.NewTicker(deviceListLeaseInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := d.ReloadLeases(); err != nil {
+			GetApp().log.Warnf("Could not reload the leases: %v", err)
+		}
+	}
+}
+
+// stateText returns the state of the device with the owner of its lease, if any
+func (d *DeviceList) stateText(deviceItem *DeviceItem) string {
+	state := strings.ToUpper(deviceItem.State.String())
+	lease, ok := d.leases.Load(deviceItem.Serial)
+	if !ok || !lease.Active(time.Now()) {
+		return state
+	}
+
+	return fmt.Sprintf("%s, leased by %s until %s", state, lease.Owner, lease.Expires.Format("15:04"))
+}
+
+// recordConnection adds the state change to the connection history of the device
+func (d *DeviceList) recordConnection(device *adbclient.Device, state adbclient.DeviceState) {
+	err := d.storage.RecordConnection(device.Serial, storage.Connection{Time: time.Now(), State: state.String(), USB: device.USB})
+	if err != nil {
+		GetApp().log.Warnf("Could not record the connection of %s: %v", device.Serial, err)
+		return
+	}
+
+	entry, err := d.storage.GetInventoryEntry(device.Serial)
+	if err != nil {
+		GetApp().log.Warnf("Could not get the inventory of %s: %v", device.Serial, err)
+		return
+	}
+
+	d.inventory.Store(device.Serial, *entry)
 }
 
 // CreateItem creates a new empty entry in the list
@@ -51,6 +244,7 @@ func (d *DeviceList) CreateItem() fyne.CanvasObject {
 			widget.NewCheck("", nil),
 			widget.NewIcon(assets.StatusIcons["invalid"]),
 			widget.NewLabel("<SERIAL>"),
+			widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
 		),
 		container.NewHBox(
 			widget.NewLabel("INVALID"),
@@ -59,6 +253,10 @@ func (d *DeviceList) CreateItem() fyne.CanvasObject {
 			widget.NewButtonWithIcon("", assets.VideoIcon, nil),
 			widget.NewButtonWithIcon("", assets.SendIcon, nil),
 			widget.NewButtonWithIcon("", assets.ZeroingIcon, nil),
+			widget.NewButtonWithIcon("", theme.ViewFullScreenIcon(), nil),
+			widget.NewButtonWithIcon("", theme.FolderOpenIcon(), nil),
+			widget.NewButtonWithIcon("", theme.MediaFastForwardIcon(), nil),
+			widget.NewButtonWithIcon("", theme.AccountIcon(), nil),
 			widget.NewButtonWithIcon("", assets.DeleteIcon, nil),
 		),
 	)
@@ -68,11 +266,21 @@ func (d *DeviceList) CreateItem() fyne.CanvasObject {
 func (d *DeviceList) UpdateItem(id int, item fyne.CanvasObject) {
 	container := item.(*fyne.Container)
 
-	deviceItem := d.items.Load(id)
+	deviceItem := d.item(id)
+	if deviceItem == nil {
+		return
+	}
+
+	entry := d.entry(deviceItem.Serial)
 	deviceName := fmt.Sprintf("%s (%s)", strings.ToUpper(deviceItem.Serial), deviceItem.Model)
+	if entry.Name != "" {
+		deviceName = fmt.Sprintf("%s - %s", entry.Name, deviceName)
+	}
+
 	container.Objects[0].(*fyne.Container).Objects[1].(*widget.Icon).SetResource(assets.StatusIcons[deviceItem.State.String()])
 	container.Objects[0].(*fyne.Container).Objects[2].(*widget.Label).SetText(deviceName)
-	container.Objects[1].(*fyne.Container).Objects[0].(*widget.Label).SetText(strings.ToUpper(deviceItem.State.String()))
+	container.Objects[0].(*fyne.Container).Objects[3].(*widget.Label).SetText(strings.Join(entry.Labels, ", "))
+	container.Objects[1].(*fyne.Container).Objects[0].(*widget.Label).SetText(d.stateText(deviceItem))
 	deviceItem.check = container.Objects[0].(*fyne.Container).Objects[0].(*widget.Check)
 	deviceItem.check.OnChanged = func(checked bool) {
 		d.OnCheckChanged(id, checked)
@@ -103,7 +311,27 @@ func (d *DeviceList) UpdateItem(id int, item fyne.CanvasObject) {
 		go Zeroing(d.client, deviceItem.Device, d.parent)
 	}
 
-	deviceItem.delete = container.Objects[1].(*fyne.Container).Objects[6].(*widget.Button)
+	deviceItem.display = container.Objects[1].(*fyne.Container).Objects[6].(*widget.Button)
+	deviceItem.display.OnTapped = func() {
+		go Display(d.client, deviceItem.Device, d.parent)
+	}
+
+	deviceItem.files = container.Objects[1].(*fyne.Container).Objects[7].(*widget.Button)
+	deviceItem.files.OnTapped = func() {
+		go FileExplorer(d.client, deviceItem.Device, d.parent)
+	}
+
+	deviceItem.benchmark = container.Objects[1].(*fyne.Container).Objects[8].(*widget.Button)
+	deviceItem.benchmark.OnTapped = func() {
+		go Benchmark(d.client, deviceItem.Device, d.parent)
+	}
+
+	deviceItem.lease = container.Objects[1].(*fyne.Container).Objects[9].(*widget.Button)
+	deviceItem.lease.OnTapped = func() {
+		Lease(deviceItem.Device, d.reloadLeases, d.parent)
+	}
+
+	deviceItem.delete = container.Objects[1].(*fyne.Container).Objects[10].(*widget.Button)
 	deviceItem.delete.OnTapped = func() {
 		d.OnDelete(id)
 	}
@@ -114,12 +342,18 @@ func (d *DeviceList) UpdateItem(id int, item fyne.CanvasObject) {
 		deviceItem.video.Enable()
 		deviceItem.send.Enable()
 		deviceItem.zeroing.Enable()
+		deviceItem.display.Enable()
+		deviceItem.files.Enable()
+		deviceItem.benchmark.Enable()
 	} else {
 		deviceItem.logs.Disable()
 		deviceItem.screenshot.Disable()
 		deviceItem.video.Disable()
 		deviceItem.send.Disable()
 		deviceItem.zeroing.Disable()
+		deviceItem.display.Disable()
+		deviceItem.files.Disable()
+		deviceItem.benchmark.Disable()
 	}
 
 	// If no device is selected, select the first one
@@ -136,19 +370,26 @@ func (d *DeviceList) UpdateItem(id int, item fyne.CanvasObject) {
 
 // OnSelected is called when the user selects an item
 func (d *DeviceList) OnSelected(id int) {
-	go DeviceInfo(d.client, d.items.Load(id).Device, d.parent)
+	if deviceItem := d.item(id); deviceItem != nil {
+		go DeviceInfo(d.client, deviceItem.Device, d.parent)
+	}
+
 	d.Unselect(id)
 }
 
 // OnDelete is called when the user deletes an item
 func (d *DeviceList) OnDelete(id int) {
-	deviceItem := d.items.Load(id)
+	deviceItem := d.item(id)
+	if deviceItem == nil {
+		return
+	}
+
 	if deviceItem.check.Checked {
 		d.selected = nil
 
 		// Find another online device to select
 		d.items.Each(func(i int, item *DeviceItem) bool {
-			if item.Device.State == adbclient.StateOnline && i != id {
+			if item.Device.State == adbclient.StateOnline && item != deviceItem {
 				item.check.SetChecked(true)
 				return false
 			}
@@ -159,7 +400,7 @@ func (d *DeviceList) OnDelete(id int) {
 		// If no online devices, select first device
 		if d.selected == nil {
 			d.items.Each(func(i int, item *DeviceItem) bool {
-				if i != id {
+				if item != deviceItem {
 					item.check.SetChecked(true)
 					return false
 				}
@@ -170,13 +411,30 @@ func (d *DeviceList) OnDelete(id int) {
 	}
 
 	d.storage.DeleteDevice(deviceItem.Serial)
-	d.items.Delete(id)
+	index := -1
+	d.items.Each(func(i int, item *DeviceItem) bool {
+		if item == deviceItem {
+			index = i
+			return false
+		}
+
+		return true
+	})
+
+	if index >= 0 {
+		d.items.Delete(index)
+	}
+
 	d.Refresh()
 }
 
 // OnCheckChanged is called when the user checks or unchecks a device
 func (d *DeviceList) OnCheckChanged(id int, checked bool) {
-	deviceItem := d.items.Load(id)
+	deviceItem := d.item(id)
+	if deviceItem == nil {
+		return
+	}
+
 	if checked {
 		// Unselect all other items
 		d.items.Each(func(i int, item *DeviceItem) bool {
@@ -215,10 +473,13 @@ func (d *DeviceList) deviceWatcher() {
 		if oldItem == nil {
 			newDevice, err := d.client.GetDevice(event.Serial)
 			if err != nil {
+				GetApp().log.Warnf("Could not get device %s: %v", event.Serial, err)
 				continue
 			}
 
 			d.storage.SaveDevice(newDevice)
+			d.recordConnection(newDevice, event.State)
+			d.track(newDevice)
 			d.items.Store(
 				&DeviceItem{
 					Device: newDevice,
@@ -230,15 +491,31 @@ func (d *DeviceList) deviceWatcher() {
 
 		if oldItem.Device.State == adbclient.StateInvalid {
 			// if device is invalid, refresh it
-			oldItem.Device, _ = d.client.GetDevice(event.Serial)
-			d.storage.SaveDevice(oldItem.Device)
+			device, err := d.client.GetDevice(event.Serial)
+			if err != nil {
+				GetApp().log.Warnf("Could not refresh device %s: %v", event.Serial, err)
+			} else {
+				oldItem.Device = device
+				d.storage.SaveDevice(device)
+			}
 		}
 
 		oldItem.SetState(event.State)
+		d.recordConnection(oldItem.Device, event.State)
+		d.track(oldItem.Device)
 		d.Refresh()
 	}
 }
 
+// track starts or stops the crash monitor and the logcat archiver of the device after a state change.
+func (d *DeviceList) track(device *adbclient.Device) {
+	d.crashes.update(device)
+
+	if archiver := GetApp().archiver; archiver != nil {
+		archiver.Update(device)
+	}
+}
+
 // SelectDevice selects a device
 func (d *DeviceList) SelectedDevice() (*adbclient.Device, error) {
 	if d.selected == nil {
@@ -253,12 +530,15 @@ func (d *DeviceList) SelectedDevice() (*adbclient.Device, error) {
 }
 
 // NewDeviceList creates a new device list
-func NewDeviceList(client *adbclient.Client, storage *storage.Storage, parent fyne.Window) (*DeviceList, error) {
+func NewDeviceList(client *adbclient.Client, store *storage.Storage, parent fyne.Window) (*DeviceList, error) {
 	d := &DeviceList{
-		parent:  parent,
-		client:  client,
-		storage: storage,
-		items:   generic.NewSlice[*DeviceItem](),
+		parent:    parent,
+		client:    client,
+		storage:   store,
+		crashes:   newCrashMonitor(client, store),
+		items:     generic.NewSlice[*DeviceItem](),
+		inventory: generic.NewMap[string, storage.InventoryEntry](),
+		leases:    generic.NewMap[string, adbclient.Lease](),
 	}
 
 	d.List.Length = d.Length
@@ -267,7 +547,7 @@ func NewDeviceList(client *adbclient.Client, storage *storage.Storage, parent fy
 	d.List.OnSelected = d.OnSelected
 	d.ExtendBaseWidget(d)
 
-	devices, err := storage.GetDevices()
+	devices, err := store.GetDevices()
 	if err != nil {
 		return nil, err
 	}
@@ -280,6 +560,15 @@ func NewDeviceList(client *adbclient.Client, storage *storage.Storage, parent fy
 		)
 	}
 
+	if err := d.ReloadInventory(); err != nil {
+		return nil, err
+	}
+
+	if err := d.ReloadLeases(); err != nil {
+		return nil, err
+	}
+
 	go d.deviceWatcher()
+	go d.leaseWatcher()
 	return d, nil
 }
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// displayPreset is a display override emulating a device class.
+type displayPreset struct {
+	name     string
+	override adbclient.DisplayOverride
+}
+
+var displayPresets = []displayPreset{
+	{"Small phone", adbclient.DisplayOverride{Width: 720, Height: 1280, Density: 320}},
+	{"Phone", adbclient.DisplayOverride{Width: 1080, Height: 1920, Density: 420}},
+	{"Large phone", adbclient.DisplayOverride{Width: 1440, Height: 3040, Density: 560}},
+	{"7\" tablet", adbclient.DisplayOverride{Width: 1200, Height: 1920, Density: 320}},
+	{"10\" tablet", adbclient.DisplayOverride{Width: 1600, Height: 2560, Density: 320}},
+}
+
+const rotationAuto = "Auto-rotate"
+
+// Display shows the display override controls of the device.
+func Display(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	currentLabel := widget.NewLabel(device.Display.String())
+
+	positiveInt := func(s string) error {
+		if s == "" {
+			return nil
+		}
+
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+
+		if v <= 0 {
+			return fmt.Errorf("value must be positive")
+		}
+
+		return nil
+	}
+
+	widthEntry := widget.NewEntry()
+	widthEntry.SetPlaceHolder("Default")
+	widthEntry.Validator = positiveInt
+
+	heightEntry := widget.NewEntry()
+	heightEntry.SetPlaceHolder("Default")
+	heightEntry.Validator = positiveInt
+
+	densityEntry := widget.NewEntry()
+	densityEntry.SetPlaceHolder("Default")
+	densityEntry.Validator = positiveInt
+
+	setOverride := func(override adbclient.DisplayOverride) {
+		widthEntry.SetText("")
+		heightEntry.SetText("")
+		densityEntry.SetText("")
+
+		if override.Width > 0 && override.Height > 0 {
+			widthEntry.SetText(strconv.Itoa(override.Width))
+			heightEntry.SetText(strconv.Itoa(override.Height))
+		}
+
+		if override.Density > 0 {
+			densityEntry.SetText(strconv.Itoa(override.Density))
+		}
+	}
+
+	var presets []string
+	for _, preset := range displayPresets {
+		presets = append(presets, fmt.Sprintf("%s (%s)", preset.name, preset.override))
+	}
+
+	presetSelect := widget.NewSelect(presets, func(selected string) {
+		for i, preset := range presets {
+			if preset == selected {
+				setOverride(displayPresets[i].override)
+			}
+		}
+	})
+	presetSelect.PlaceHolder = "Presets"
+
+	rotations := []string{rotationAuto}
+	for rotation := adbclient.Rotation0; rotation <= adbclient.Rotation270; rotation++ {
+		rotations = append(rotations, rotation.String())
+	}
+
+	rotationSelect := widget.NewSelect(rotations, nil)
+	rotationSelect.SetSelected(rotationAuto)
+
+	// load the current overrides from the device
+	if override, err := client.GetDisplayOverride(device); err == nil {
+		setOverride(override)
+	}
+
+	if lock, err := client.GetRotationLock(device); err == nil && lock.Locked {
+		rotationSelect.SetSelected(lock.Rotation.String())
+	}
+
+	refresh := func() {
+		if err := client.RefreshDisplay(device); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		currentLabel.SetText(device.Display.String())
+	}
+
+	applyButton := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		var override adbclient.DisplayOverride
+		for _, entry := range []*widget.Entry{widthEntry, heightEntry, densityEntry} {
+			if err := entry.Validate(); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+		}
+
+		override.Width, _ = strconv.Atoi(widthEntry.Text)
+		override.Height, _ = strconv.Atoi(heightEntry.Text)
+		override.Density, _ = strconv.Atoi(densityEntry.Text)
+		if (override.Width > 0) != (override.Height > 0) {
+			GetApp().ShowError(fmt.Errorf("both width and height must be set"), nil, parent)
+			return
+		}
+
+		lock := adbclient.RotationLock{}
+		if rotationSelect.Selected != rotationAuto {
+			rotation, err := adbclient.ParseRotation(rotationSelect.Selected)
+			if err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			lock = adbclient.RotationLock{Locked: true, Rotation: rotation}
+		}
+
+		if err := client.SetDisplayOverride(device, override); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		if err := client.SetRotationLock(device, lock); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		refresh()
+	})
+
+	resetButton := widget.NewButtonWithIcon("Reset", theme.ContentUndoIcon(), func() {
+		if err := client.ResetDisplay(device); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		if override, err := client.GetDisplayOverride(device); err == nil {
+			setOverride(override)
+		}
+
+		rotationSelect.SetSelected(rotationAuto)
+		if lock, err := client.GetRotationLock(device); err == nil && lock.Locked {
+			rotationSelect.SetSelected(lock.Rotation.String())
+		}
+
+		refresh()
+	})
+
+	d := dialog.NewCustom(
+		"Display",
+		"Close",
+		container.NewVBox(
+			container.NewGridWithColumns(
+				2,
+				NewBoldLabel("Current:"),
+				currentLabel,
+				NewBoldLabel("Preset:"),
+				presetSelect,
+				NewBoldLabel("Width:"),
+				widthEntry,
+				NewBoldLabel("Height:"),
+				heightEntry,
+				NewBoldLabel("Density:"),
+				densityEntry,
+				NewBoldLabel("Rotation:"),
+				rotationSelect,
+			),
+			widget.NewLabel("Overrides are reset after the device disconnects or when the application exits."),
+			container.NewCenter(
+				container.NewHBox(
+					applyButton,
+					resetButton,
+				),
+			),
+		),
+		parent,
+	)
+
+	d.Show()
+}
@@ -0,0 +1,568 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/c2h5oh/datasize"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// DefaultFileExplorerPath is the directory the file explorer opens.
+	DefaultFileExplorerPath = "/sdcard"
+
+	// maxPreviewSize is the size of the largest file that is previewed.
+	maxPreviewSize = 16 << 20
+	// maxTextPreviewSize is the number of bytes of a text file that are shown.
+	maxTextPreviewSize = 256 << 10
+)
+
+var previewImageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+}
+
+// isText returns true if the data looks like text.
+func isText(data []byte) bool {
+	// a truncated preview may end in the middle of a rune
+	for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+
+	return utf8.Valid(data) && !strings.ContainsRune(string(data), 0)
+}
+
+// FileExplorer browses the files of the device.
+// Private directories of debuggable packages are accessed with run-as.
+// Files are uploaded with the file dialog, as Fyne doesn't deliver files dropped on the window.
+func FileExplorer(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	var (
+		dir      = DefaultFileExplorerPath
+		files    []adbclient.FileInfo
+		selected = -1
+	)
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(dir)
+
+	runAsEntry := widget.NewEntry()
+	runAsEntry.SetPlaceHolder("run-as package, for private files")
+
+	resumeCheck := widget.NewCheck("Resume downloads", nil)
+	verifyCheck := widget.NewCheck("Verify checksums", nil)
+
+	statusLabel := widget.NewLabel("")
+	progressBar := NewProgressBar(parent)
+	progressBar.Hide()
+
+	runAs := func() string {
+		return strings.TrimSpace(runAsEntry.Text)
+	}
+
+	fileOpts := func() []adbclient.FileOption {
+		if pkg := runAs(); pkg != "" {
+			return []adbclient.FileOption{adbclient.WithRunAs(pkg)}
+		}
+
+		return nil
+	}
+
+	list := widget.NewList(
+		func() int {
+			return len(files)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(
+				nil,
+				nil,
+				widget.NewIcon(theme.FileIcon()),
+				container.NewHBox(widget.NewLabel(""), widget.NewLabel(""), widget.NewLabel("")),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			file := files[id]
+			row := o.(*fyne.Container)
+
+			icon := theme.FileIcon()
+			if file.IsDir() {
+				icon = theme.FolderIcon()
+			}
+
+			row.Objects[0].(*widget.Label).SetText(file.Name)
+			row.Objects[1].(*widget.Icon).SetResource(icon)
+
+			details := row.Objects[2].(*fyne.Container)
+			size := ""
+			if !file.IsDir() {
+				size = datasize.ByteSize(file.Size).HumanReadable()
+			}
+
+			details.Objects[0].(*widget.Label).SetText(size)
+			details.Objects[1].(*widget.Label).SetText(file.ModTime.Format("2006-01-02 15:04"))
+			details.Objects[2].(*widget.Label).SetText(file.Mode.String())
+		},
+	)
+
+	var load func(string)
+	load = func(newDir string) {
+		newDir = path.Clean(newDir)
+
+		entries, err := client.ListDir(device, newDir, fileOpts()...)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			pathEntry.SetText(dir)
+			return
+		}
+
+		dir, files, selected = newDir, entries, -1
+		pathEntry.SetText(dir)
+		statusLabel.SetText(fmt.Sprintf("%d files", len(files)))
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	pathEntry.OnSubmitted = func(text string) {
+		go load(text)
+	}
+
+	// the private directory of the package is opened when run-as is set
+	runAsEntry.OnSubmitted = func(pkg string) {
+		if pkg = strings.TrimSpace(pkg); pkg != "" {
+			go load("/data/data/" + pkg)
+		} else {
+			go load(DefaultFileExplorerPath)
+		}
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		if files[id].IsDir() {
+			go load(files[id].Path)
+			return
+		}
+
+		selected = id
+		statusLabel.SetText(fmt.Sprintf("%s, %s", files[id].Path, datasize.ByteSize(files[id].Size).HumanReadable()))
+	}
+
+	selectedFile := func() (adbclient.FileInfo, bool) {
+		if selected < 0 || selected >= len(files) {
+			return adbclient.FileInfo{}, false
+		}
+
+		return files[selected], true
+	}
+
+	transfer := func(text string, f func(ctx context.Context) error) {
+		progressBar.SetValue(0)
+		progressBar.SetText("")
+		progressBar.Show()
+		statusLabel.SetText(text)
+
+		if err := f(context.Background()); err != nil {
+			progressBar.Hide()
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		progressBar.Done()
+		progressBar.Hide()
+		load(dir)
+	}
+
+	upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		go load(path.Dir(dir))
+	})
+
+	refreshButton := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		go load(dir)
+	})
+
+	mkdirButton := widget.NewButtonWithIcon("New folder", theme.FolderNewIcon(), func() {
+		nameEntry := widget.NewEntry()
+		dialog.ShowForm("New folder", "Create", "Cancel", []*widget.FormItem{
+			{Text: "Name:", Widget: nameEntry},
+		}, func(ok bool) {
+			if !ok || nameEntry.Text == "" {
+				return
+			}
+
+			go func() {
+				if err := client.Mkdir(device, path.Join(dir, nameEntry.Text), fileOpts()...); err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				load(dir)
+			}()
+		}, parent)
+	})
+
+	uploadButton := widget.NewButtonWithIcon("Upload", theme.UploadIcon(), func() {
+		fopenDialog := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			src := file.URI().Path()
+			file.Close()
+
+			opts := []adbclient.UploadOption{progressBar.WithUploadProgress()}
+			if pkg := runAs(); pkg != "" {
+				opts = append(opts, adbclient.WithUploadRunAs(pkg))
+			}
+
+			if verifyCheck.Checked {
+				opts = append(opts, adbclient.WithUploadVerify())
+			}
+
+			go transfer("Uploading "+filepath.Base(src)+"...", func(ctx context.Context) error {
+				return client.UploadFile(ctx, device, src, path.Join(dir, filepath.Base(src)), opts...)
+			})
+		}, parent)
+
+		fopenDialog.Resize(DialogSize(parent))
+		fopenDialog.Show()
+	})
+
+	downloadButton := widget.NewButtonWithIcon("Download", theme.DownloadIcon(), func() {
+		file, ok := selectedFile()
+		if !ok {
+			return
+		}
+
+		fsaveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+
+			dst := w.URI().Path()
+			w.Close()
+
+			opts := []adbclient.DownloadOption{progressBar.WithDownloadProgress()}
+			if pkg := runAs(); pkg != "" {
+				opts = append(opts, adbclient.WithDownloadRunAs(pkg))
+			}
+
+			if resumeCheck.Checked {
+				opts = append(opts, adbclient.WithDownloadResume())
+			}
+
+			if verifyCheck.Checked {
+				opts = append(opts, adbclient.WithDownloadVerify())
+			}
+
+			go transfer("Downloading "+file.Name+"...", func(ctx context.Context) error {
+				return client.DownloadFile(ctx, device, file.Path, dst, opts...)
+			})
+		}, parent)
+
+		fsaveDialog.SetFileName(file.Name)
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	})
+
+	renameButton := widget.NewButtonWithIcon("Rename", theme.DocumentCreateIcon(), func() {
+		file, ok := selectedFile()
+		if !ok {
+			return
+		}
+
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(file.Name)
+
+		modeEntry := widget.NewEntry()
+		modeEntry.SetText(fmt.Sprintf("%o", file.Mode.Perm()))
+
+		dialog.ShowForm("Rename", "Apply", "Cancel", []*widget.FormItem{
+			{Text: "Name:", Widget: nameEntry},
+			{Text: "Mode:", Widget: modeEntry},
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+
+			go func() {
+				mode, err := strconv.ParseUint(modeEntry.Text, 8, 32)
+				if err != nil {
+					GetApp().ShowError(fmt.Errorf("invalid mode: %s", modeEntry.Text), nil, parent)
+					return
+				}
+
+				name := file.Path
+				if nameEntry.Text != file.Name {
+					name = path.Join(dir, nameEntry.Text)
+					if err := client.Rename(device, file.Path, name, fileOpts()...); err != nil {
+						GetApp().ShowError(err, nil, parent)
+						return
+					}
+				}
+
+				if os.FileMode(mode) != file.Mode.Perm() {
+					if err := client.Chmod(device, name, os.FileMode(mode), fileOpts()...); err != nil {
+						GetApp().ShowError(err, nil, parent)
+					}
+				}
+
+				load(dir)
+			}()
+		}, parent)
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		file, ok := selectedFile()
+		if !ok {
+			return
+		}
+
+		dialog.ShowConfirm("Delete", "Delete "+file.Path+"?", func(ok bool) {
+			if !ok {
+				return
+			}
+
+			go func() {
+				if err := client.RemoveAll(device, file.Path, fileOpts()...); err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				load(dir)
+			}()
+		}, parent)
+	})
+
+	// folders are transferred with the sync protocol, which has no access to private directories
+	checkNoRunAs := func() bool {
+		if runAs() != "" {
+			GetApp().ShowError(fmt.Errorf("folders can't be transferred with run-as"), nil, parent)
+			return false
+		}
+
+		return true
+	}
+
+	pushDirButton := widget.NewButtonWithIcon("Push folder", theme.UploadIcon(), func() {
+		if !checkNoRunAs() {
+			return
+		}
+
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+
+			src := uri.Path()
+			go transfer("Pushing "+filepath.Base(src)+"...", func(ctx context.Context) error {
+				return client.PushDir(ctx, device, src, path.Join(dir, filepath.Base(src)), progressBar.WithDirProgress())
+			})
+		}, parent)
+
+		folderDialog.Resize(DialogSize(parent))
+		folderDialog.Show()
+	})
+
+	pullDirButton := widget.NewButtonWithIcon("Pull folder", theme.DownloadIcon(), func() {
+		if !checkNoRunAs() {
+			return
+		}
+
+		src := dir
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+
+			dst := filepath.Join(uri.Path(), path.Base(src))
+			go transfer("Pulling "+src+"...", func(ctx context.Context) error {
+				return client.PullDir(ctx, device, src, dst, progressBar.WithDirProgress())
+			})
+		}, parent)
+
+		folderDialog.Resize(DialogSize(parent))
+		folderDialog.Show()
+	})
+
+	syncDirButton := widget.NewButtonWithIcon("Sync folder", theme.ViewRefreshIcon(), func() {
+		if !checkNoRunAs() {
+			return
+		}
+
+		dst := dir
+		checksumCheck := widget.NewCheck("Compare by md5sum", nil)
+		deleteCheck := widget.NewCheck("Delete files missing locally", nil)
+
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+
+			opts := []adbclient.DirOption{progressBar.WithDirProgress()}
+			if checksumCheck.Checked {
+				opts = append(opts, adbclient.WithDirChecksum())
+			}
+
+			if deleteCheck.Checked {
+				opts = append(opts, adbclient.WithDirDelete())
+			}
+
+			src := uri.Path()
+			go transfer("Syncing "+filepath.Base(src)+"...", func(ctx context.Context) error {
+				result, err := client.SyncDir(ctx, device, src, dst, opts...)
+				if err != nil {
+					return err
+				}
+
+				msg := fmt.Sprintf("%d files transferred (%s), %d deleted, %d unchanged",
+					len(result.Transferred), datasize.ByteSize(result.Bytes).HumanReadable(), len(result.Deleted), result.Unchanged)
+				if len(result.Conflicts) > 0 {
+					msg += fmt.Sprintf("\n%d files skipped as the device has directories at their paths, check \"Delete files missing locally\" to replace them: %s",
+						len(result.Conflicts), strings.Join(result.Conflicts, ", "))
+				}
+
+				GetApp().ShowInformation("Sync folder", msg, parent)
+				return nil
+			})
+		}, parent)
+
+		dialog.ShowCustomConfirm("Sync folder", "Select", "Cancel", container.NewVBox(
+			widget.NewLabel("Sync a local folder to "+dst),
+			checksumCheck,
+			deleteCheck,
+		), func(ok bool) {
+			if ok {
+				folderDialog.Resize(DialogSize(parent))
+				folderDialog.Show()
+			}
+		}, parent)
+	})
+
+	obbButton := widget.NewButtonWithIcon("Expansion files", theme.StorageIcon(), func() {
+		go OBBManager(client, device, parent)
+	})
+
+	previewButton := widget.NewButtonWithIcon("Preview", theme.VisibilityIcon(), func() {
+		file, ok := selectedFile()
+		if !ok {
+			return
+		}
+
+		go previewFile(client, device, file, runAs(), parent)
+	})
+
+	d := dialog.NewCustom(
+		"Files: "+device.String(),
+		"Close",
+		container.NewBorder(
+			container.NewVBox(
+				container.NewBorder(nil, nil, container.NewHBox(upButton, refreshButton), nil, pathEntry),
+				container.NewBorder(nil, nil, nil, container.NewHBox(pushDirButton, pullDirButton, syncDirButton, obbButton), runAsEntry),
+			),
+			container.NewVBox(
+				progressBar,
+				container.NewBorder(
+					nil,
+					nil,
+					container.NewHBox(resumeCheck, verifyCheck),
+					container.NewHBox(mkdirButton, uploadButton, downloadButton, previewButton, renameButton, deleteButton),
+					statusLabel,
+				),
+			),
+			nil,
+			nil,
+			list,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+
+	load(dir)
+}
+
+// previewFile shows an image or the beginning of a text file.
+func previewFile(client *adbclient.Client, device *adbclient.Device, file adbclient.FileInfo, runAs string, parent fyne.Window) {
+	if file.Size > maxPreviewSize {
+		GetApp().ShowInformation("Preview", fmt.Sprintf("%s is too large to preview", file.Name), parent)
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "preview_*"+path.Ext(file.Name))
+	if err != nil {
+		GetApp().ShowError(err, nil, parent)
+		return
+	}
+
+	tmp.Close()
+
+	var opts []adbclient.DownloadOption
+	if runAs != "" {
+		opts = append(opts, adbclient.WithDownloadRunAs(runAs))
+	}
+
+	if err := client.DownloadFile(context.Background(), device, file.Path, tmp.Name(), opts...); err != nil {
+		os.Remove(tmp.Name())
+		GetApp().ShowError(err, nil, parent)
+		return
+	}
+
+	var content fyne.CanvasObject
+	if previewImageExtensions[strings.ToLower(path.Ext(file.Name))] {
+		image := canvas.NewImageFromFile(tmp.Name())
+		image.FillMode = canvas.ImageFillContain
+		content = image
+	} else {
+		f, err := os.Open(tmp.Name())
+		if err != nil {
+			os.Remove(tmp.Name())
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(f, maxTextPreviewSize))
+		f.Close()
+
+		if err != nil {
+			os.Remove(tmp.Name())
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		if !isText(data) {
+			os.Remove(tmp.Name())
+			GetApp().ShowInformation("Preview", fmt.Sprintf("%s is not an image or a text file", file.Name), parent)
+			return
+		}
+
+		text := widget.NewMultiLineEntry()
+		text.TextStyle = fyne.TextStyle{Monospace: true}
+		text.Wrapping = fyne.TextWrapOff
+		text.SetText(string(data))
+		content = text
+	}
+
+	d := dialog.NewCustom(file.Path, "Close", content, parent)
+	d.SetOnClosed(func() {
+		os.Remove(tmp.Name())
+	})
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
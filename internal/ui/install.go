@@ -44,6 +44,7 @@ func humanizeError(out string) string {
 }
 
 // InstallAPK installs an APK file to a device.
+// If the expansion files of the version of the APK are next to it, pushing them to the device is offered.
 func InstallAPK(client *adbclient.Client, serial string, file fyne.URIReadCloser, parent fyne.Window) {
 	bar := NewProgressBar(parent)
 
@@ -88,6 +89,12 @@ func InstallAPK(client *adbclient.Client, serial string, file fyne.URIReadCloser
 	}
 
 	d.Hide()
+
+	// expansion files of the build are offered only for a successful install
+	if strings.Contains(result, "Success") && offerOBBs(client, device, file.URI().Path(), result, parent) {
+		return
+	}
+
 	GetApp().ShowInformation("Installation result", result, parent)
 }
 
@@ -0,0 +1,324 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	fynestorage "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/internal/storage"
+)
+
+// inventoryColumns are the columns of the inventory table with their widths.
+var inventoryColumns = []struct {
+	title string
+	width float32
+}{
+	{"Serial", 130},
+	{"Name", 150},
+	{"Labels", 150},
+	{"Owner", 100},
+	{"Location", 100},
+	{"Last seen", 130},
+	{"Notes", 300},
+}
+
+// formatSeen formats a seen time of the inventory.
+func formatSeen(entry storage.InventoryEntry) string {
+	if entry.LastSeen.IsZero() {
+		return "never"
+	}
+
+	return entry.LastSeen.Format("2006-01-02 15:04")
+}
+
+// editInventoryEntry shows a form to edit the fields of the entry set by the user.
+func editInventoryEntry(entry storage.InventoryEntry, saved func(), parent fyne.Window) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(entry.Name)
+
+	labelsEntry := widget.NewEntry()
+	labelsEntry.SetText(strings.Join(entry.Labels, ", "))
+	labelsEntry.SetPlaceHolder("Comma separated, e.g. rack-a, ci")
+
+	ownerEntry := widget.NewEntry()
+	ownerEntry.SetText(entry.Owner)
+
+	locationEntry := widget.NewEntry()
+	locationEntry.SetText(entry.Location)
+	locationEntry.SetPlaceHolder("e.g. rack A, slot 3")
+
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetText(entry.Notes)
+
+	form := dialog.NewForm("Inventory: "+entry.Serial, "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Labels", labelsEntry),
+		widget.NewFormItem("Owner", ownerEntry),
+		widget.NewFormItem("Location", locationEntry),
+		widget.NewFormItem("Notes", notesEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		err := GetApp().storage.UpdateInventoryEntry(entry.Serial, func(e *storage.InventoryEntry) error {
+			e.Name = strings.TrimSpace(nameEntry.Text)
+			e.SetLabels(labelsEntry.Text)
+			e.Owner = strings.TrimSpace(ownerEntry.Text)
+			e.Location = strings.TrimSpace(locationEntry.Text)
+			e.Notes = notesEntry.Text
+			return nil
+		})
+
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		saved()
+	}, parent)
+
+	form.Resize(fyne.NewSize(500, 350))
+	form.Show()
+}
+
+// showConnectionHistory shows the state changes of the device, the most recent first.
+func showConnectionHistory(entry storage.InventoryEntry, parent fyne.Window) {
+	list := widget.NewList(
+		func() int {
+			return len(entry.History)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			connection := entry.History[len(entry.History)-1-id]
+			text := fmt.Sprintf("%s  %s", connection.Time.Format("2006-01-02 15:04:05"), connection.State)
+			if connection.USB != "" {
+				text += " on USB " + connection.USB
+			}
+
+			o.(*widget.Label).SetText(text)
+		},
+	)
+
+	seen := widget.NewLabel(fmt.Sprintf("First seen %s, last seen %s", entry.FirstSeen.Format("2006-01-02 15:04"), formatSeen(entry)))
+	if entry.FirstSeen.IsZero() {
+		seen.SetText("Never seen")
+	}
+
+	d := dialog.NewCustom("Connections: "+entry.Serial, "Close", container.NewBorder(seen, nil, nil, nil, list), parent)
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
+
+// Inventory shows the inventory of the devices for asset tracking, with import and export as CSV or JSON.
+// changed is called after the inventory was edited.
+func Inventory(changed func(), parent fyne.Window) {
+	var entries []storage.InventoryEntry
+	selected := -1
+
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(entries) + 1, len(inventoryColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(inventoryColumns[id.Col].title)
+				return
+			}
+
+			entry := entries[id.Row-1]
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText([]string{
+				entry.Serial,
+				entry.Name,
+				strings.Join(entry.Labels, ", "),
+				entry.Owner,
+				entry.Location,
+				formatSeen(entry),
+				strings.ReplaceAll(entry.Notes, "\n", " "),
+			}[id.Col])
+		},
+	)
+
+	for i, column := range inventoryColumns {
+		table.SetColumnWidth(i, column.width)
+	}
+
+	table.OnSelected = func(id widget.TableCellID) {
+		selected = id.Row - 1
+	}
+
+	// load lists the inventory and the known devices not in it yet
+	load := func() {
+		var err error
+		entries, err = GetApp().storage.GetInventory()
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		devices, err := GetApp().storage.GetDevices()
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		known := make(map[string]bool)
+		for _, entry := range entries {
+			known[entry.Serial] = true
+		}
+
+		for _, device := range devices {
+			if !known[device.Serial] {
+				entries = append(entries, storage.InventoryEntry{Serial: device.Serial})
+			}
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Serial < entries[j].Serial })
+		table.Refresh()
+	}
+
+	reload := func() {
+		load()
+		changed()
+	}
+
+	// current returns the selected entry
+	current := func() (storage.InventoryEntry, bool) {
+		if selected < 0 || selected >= len(entries) {
+			GetApp().ShowError(fmt.Errorf("no device selected"), nil, parent)
+			return storage.InventoryEntry{}, false
+		}
+
+		return entries[selected], true
+	}
+
+	editButton := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		if entry, ok := current(); ok {
+			editInventoryEntry(entry, reload, parent)
+		}
+	})
+
+	historyButton := widget.NewButtonWithIcon("Connections", theme.HistoryIcon(), func() {
+		if entry, ok := current(); ok {
+			showConnectionHistory(entry, parent)
+		}
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		entry, ok := current()
+		if !ok {
+			return
+		}
+
+		dialog.ShowConfirm("Delete", "Delete the inventory of "+entry.Serial+", with its connection history?", func(ok bool) {
+			if !ok {
+				return
+			}
+
+			if err := GetApp().storage.DeleteInventoryEntry(entry.Serial); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			selected = -1
+			table.UnselectAll()
+			reload()
+		}, parent)
+	})
+
+	importButton := widget.NewButtonWithIcon("Import", theme.FolderOpenIcon(), func() {
+		fopenDialog := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			defer file.Close()
+
+			imported, err := readInventory(file, file.URI().Extension())
+			if err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			if err := GetApp().storage.ImportInventory(imported); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			reload()
+			GetApp().ShowInformation("Import", fmt.Sprintf("Imported %d devices", len(imported)), parent)
+		}, parent)
+
+		fopenDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".csv", ".json"}))
+		fopenDialog.Resize(DialogSize(parent))
+		fopenDialog.Show()
+	})
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			defer file.Close()
+
+			if strings.EqualFold(filepath.Ext(file.URI().Path()), ".json") {
+				err = storage.WriteInventoryJSON(file, entries)
+			} else {
+				err = storage.WriteInventoryCSV(file, entries)
+			}
+
+			if err != nil {
+				GetApp().ShowError(err, nil, parent)
+			}
+		}, parent)
+
+		fsaveDialog.SetFileName("inventory.csv")
+		fsaveDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".csv", ".json"}))
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	})
+
+	d := dialog.NewCustom(
+		"Inventory",
+		"Close",
+		container.NewBorder(
+			container.NewHBox(editButton, historyButton, deleteButton, importButton, exportButton),
+			nil,
+			nil,
+			nil,
+			table,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+
+	load()
+}
+
+// readInventory reads an inventory as JSON if the extension is .json, as CSV otherwise.
+func readInventory(r io.Reader, ext string) ([]storage.InventoryEntry, error) {
+	if strings.EqualFold(ext, ".json") {
+		return storage.ReadInventoryJSON(r)
+	}
+
+	return storage.ReadInventoryCSV(r)
+}
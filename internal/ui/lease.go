@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// leaseDurations are the durations a device may be leased for.
+var leaseDurations = []struct {
+	title    string
+	duration time.Duration
+}{
+	{"30 minutes", 30 * time.Minute},
+	{"1 hour", time.Hour},
+	{"4 hours", 4 * time.Hour},
+	{"1 day", 24 * time.Hour},
+}
+
+// Lease reserves the device for the lease owner set in the settings, or releases its lease. A lease of
+// someone else may be force released after a confirmation. changed is called after the lease was changed.
+func Lease(device *adbclient.Device, changed func(), parent fyne.Window) {
+	owner := GetApp().adbClient.GetLeaseOwner()
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	noteEntry := widget.NewEntry()
+	noteEntry.SetPlaceHolder("e.g. nightly perf run")
+
+	durations := make([]string, len(leaseDurations))
+	for i, d := range leaseDurations {
+		durations[i] = d.title
+	}
+
+	durationSelect := widget.NewSelect(durations, nil)
+	durationSelect.SetSelectedIndex(1)
+
+	var reserveButton, releaseButton, forceButton *widget.Button
+
+	// update shows the current lease of the device and the actions allowed on it
+	update := func() {
+		lease, err := GetApp().storage.GetLease(device.Serial)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		reserveButton.Enable()
+		releaseButton.Disable()
+		forceButton.Disable()
+
+		switch {
+		case lease == nil:
+			statusLabel.SetText("The device is not leased.")
+		case lease.Owner == owner:
+			statusLabel.SetText(fmt.Sprintf("Leased by you (%s) until %s.", owner, lease.Expires.Format("2006-01-02 15:04")))
+			reserveButton.SetText("Extend")
+			releaseButton.Enable()
+		default:
+			statusLabel.SetText(fmt.Sprintf("Leased by %s until %s.", lease.Owner, lease.Expires.Format("2006-01-02 15:04")))
+			if lease.Note != "" {
+				statusLabel.SetText(statusLabel.Text + " " + lease.Note)
+			}
+
+			reserveButton.Disable()
+			forceButton.Enable()
+		}
+	}
+
+	reserveButton = widget.NewButtonWithIcon("Reserve", theme.LoginIcon(), func() {
+		i := durationSelect.SelectedIndex()
+		if i < 0 {
+			return
+		}
+
+		if _, err := GetApp().storage.AcquireLease(device.Serial, owner, noteEntry.Text, leaseDurations[i].duration); err != nil {
+			GetApp().ShowError(err, nil, parent)
+		}
+
+		update()
+		changed()
+	})
+
+	releaseButton = widget.NewButtonWithIcon("Release", theme.LogoutIcon(), func() {
+		if err := GetApp().storage.ReleaseLease(device.Serial, owner); err != nil {
+			GetApp().ShowError(err, nil, parent)
+		}
+
+		update()
+		changed()
+	})
+
+	forceButton = widget.NewButtonWithIcon("Force release", theme.WarningIcon(), func() {
+		dialog.ShowConfirm("Force release", "Release the lease of "+device.Serial+" taken by someone else? Their jobs may fail.", func(ok bool) {
+			if !ok {
+				return
+			}
+
+			if err := GetApp().storage.ForceReleaseLease(device.Serial); err != nil {
+				GetApp().ShowError(err, nil, parent)
+			}
+
+			update()
+			changed()
+		}, parent)
+	})
+
+	d := dialog.NewCustom(
+		"Lease: "+device.String(),
+		"Close",
+		container.NewVBox(
+			statusLabel,
+			container.NewGridWithColumns(
+				2,
+				NewBoldLabel("Owner:"),
+				widget.NewLabel(owner),
+				NewBoldLabel("Note:"),
+				noteEntry,
+				NewBoldLabel("Duration:"),
+				durationSelect,
+			),
+			container.NewHBox(reserveButton, releaseButton, forceButton),
+		),
+		parent,
+	)
+
+	d.Resize(fyne.NewSize(500, 250))
+	d.Show()
+
+	update()
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// DefaultLogHistoryLimit is the default maximum number of messages shown by the log history.
+	DefaultLogHistoryLimit = 10000
+)
+
+// LogHistory queries the logcat archive of the device.
+func LogHistory(device *adbclient.Device, parent fyne.Window) {
+	var msgs []adbclient.LogcatMessage
+
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(msgs) + 1, len(logColumns)
+		},
+		func() fyne.CanvasObject {
+			text := canvas.NewText("", theme.ForegroundColor())
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			return text
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			text := o.(*canvas.Text)
+
+			// the first row is the header
+			if id.Row == 0 {
+				text.Text = logColumns[id.Col].title
+				text.Color = theme.ForegroundColor()
+				text.TextStyle.Bold = true
+				text.Refresh()
+				return
+			}
+
+			text.TextStyle.Bold = false
+			if id.Row > len(msgs) {
+				text.Text = ""
+				text.Refresh()
+				return
+			}
+
+			msg := msgs[id.Row-1]
+			switch id.Col {
+			case 0:
+				text.Text = msg.Timestamp.Format("01-02 15:04:05.000")
+			case 1:
+				text.Text = strconv.Itoa(msg.ProcessID)
+			case 2:
+				text.Text = strconv.Itoa(msg.ThreadID)
+			case 3:
+				text.Text = msg.Priority.String()
+			case 4:
+				text.Text = msg.Tag
+			case 5:
+				text.Text = msg.Message
+			}
+
+			text.Color = logcatPriorityColors[msg.Priority]
+			text.Refresh()
+		},
+	)
+
+	for i, column := range logColumns {
+		table.SetColumnWidth(i, column.width)
+	}
+
+	// the history spans days, the time has the date
+	table.SetColumnWidth(0, 150)
+
+	statusLabel := widget.NewLabel("")
+
+	table.OnSelected = func(id widget.TableCellID) {
+		table.Unselect(id)
+		if id.Row == 0 || id.Row > len(msgs) {
+			return
+		}
+
+		parent.Clipboard().SetContent(msgs[id.Row-1].String())
+		statusLabel.SetText("Copied to clipboard")
+	}
+
+	timeValidator := func(s string) error {
+		_, err := storage.ParseLogcatTime(s, time.Now())
+		return err
+	}
+
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("From, e.g. 1h or 2006-01-02 15:04")
+	fromEntry.SetText("1h")
+	fromEntry.Validator = timeValidator
+
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("To, now if empty")
+	toEntry.Validator = timeValidator
+
+	var priorities []string
+	for priority := adbclient.Verbose; priority <= adbclient.Fatal; priority++ {
+		priorities = append(priorities, priority.String())
+	}
+
+	prioritySelect := widget.NewSelect(priorities, nil)
+	prioritySelect.SetSelected(adbclient.Verbose.String())
+
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder("Exact tag")
+
+	textEntry := widget.NewEntry()
+	textEntry.SetPlaceHolder("Text")
+
+	limitEntry := widget.NewEntry()
+	limitEntry.SetPlaceHolder("Limit")
+	limitEntry.SetText(strconv.Itoa(DefaultLogHistoryLimit))
+	limitEntry.Validator = func(s string) error {
+		_, err := strconv.Atoi(s)
+		return err
+	}
+
+	search := func() {
+		now := time.Now()
+		query := storage.LogcatQuery{
+			Serial: device.Serial,
+			Tag:    strings.TrimSpace(tagEntry.Text),
+			Text:   textEntry.Text,
+		}
+
+		var err error
+		if query.From, err = storage.ParseLogcatTime(fromEntry.Text, now); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		if query.To, err = storage.ParseLogcatTime(toEntry.Text, now); err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		query.Priority, _ = adbclient.ParseLogcatPriority(prioritySelect.Selected)
+		query.Limit, _ = strconv.Atoi(limitEntry.Text)
+
+		result, err := GetApp().storage.QueryLogcat(query)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		msgs = result
+		table.Refresh()
+		table.ScrollToBottom()
+
+		status := fmt.Sprintf("%d messages", len(msgs))
+		if query.Limit > 0 && len(msgs) == query.Limit {
+			status += " (limit reached, the newest are shown)"
+		}
+
+		statusLabel.SetText(status)
+	}
+
+	for _, entry := range []*widget.Entry{fromEntry, toEntry, tagEntry, textEntry, limitEntry} {
+		entry.OnSubmitted = func(string) { search() }
+	}
+
+	searchButton := widget.NewButtonWithIcon("Search", theme.SearchIcon(), search)
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		if len(msgs) == 0 {
+			return
+		}
+
+		exportLogcat(msgs, "Logcat history: "+device.String(), fmt.Sprintf("logcat_%s.log", device.Serial), parent)
+	})
+
+	d := dialog.NewCustom(
+		"Logcat history: "+device.String(),
+		"Close",
+		container.NewBorder(
+			container.NewGridWithColumns(
+				7,
+				fromEntry,
+				toEntry,
+				prioritySelect,
+				tagEntry,
+				textEntry,
+				limitEntry,
+				searchButton,
+			),
+			container.NewBorder(
+				nil,
+				nil,
+				statusLabel,
+				exportButton,
+			),
+			nil,
+			nil,
+			table,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+
+	search()
+}
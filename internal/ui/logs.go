@@ -1,6 +1,11 @@
 package ui
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
@@ -43,10 +48,53 @@ func Logs(client *adbclient.Client, device *adbclient.Device, parent fyne.Window
 		fsaveDialog.Show()
 	})
 
+	bufferCheck := widget.NewCheckGroup(logcatBufferNames(), nil)
+	bufferCheck.Horizontal = true
+
+	filterSpecsEntry := widget.NewEntry()
+	filterSpecsEntry.SetPlaceHolder("Filterspecs, e.g. ActivityManager:I *:S")
+
+	sinceEntry := widget.NewEntry()
+	sinceEntry.SetPlaceHolder("Since, e.g. 5m, or last N lines")
+
+	regexEntry := widget.NewEntry()
+	regexEntry.SetPlaceHolder("Regex")
+
+	uidEntry := widget.NewEntry()
+	uidEntry.SetPlaceHolder("UIDs, e.g. 1000,10123")
+
+	var formats []string
+	for _, format := range adbclient.LogcatFormats {
+		formats = append(formats, format.String())
+	}
+
+	formatSelect := widget.NewSelect(formats, nil)
+	formatSelect.SetSelected(adbclient.FormatThreadtime.String())
+
+	dumpCheck := widget.NewCheck("Dump and stop", nil)
+	clearCheck := widget.NewCheck("Clear before start", nil)
+	clearCheck.SetChecked(true)
+
 	logsStartButton := widget.NewButtonWithIcon("Start", assets.LogsIcon, nil)
 	logsStopButton := widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), nil)
 	logsStopButton.Disable()
 
+	logsViewButton := widget.NewButtonWithIcon("Live view", theme.SearchIcon(), func() {
+		go LogViewer(client, device, parent)
+	})
+
+	logsCrashesButton := widget.NewButtonWithIcon("Crashes", theme.WarningIcon(), func() {
+		go Crashes(device, parent)
+	})
+
+	logsHistoryButton := widget.NewButtonWithIcon("History", theme.HistoryIcon(), func() {
+		go LogHistory(device, parent)
+	})
+
+	logsTimelineButton := widget.NewButtonWithIcon("Timeline", theme.ListIcon(), func() {
+		go Timeline(client, device, parent)
+	})
+
 	dialog := dialog.NewCustom(
 		"Logs",
 		"Close",
@@ -58,10 +106,24 @@ func Logs(client *adbclient.Client, device *adbclient.Device, parent fyne.Window
 					"",
 					container.New(&alignToRightLayout{}, logsPathEntry, logsPathButton),
 				),
+				widget.NewCard(
+					"",
+					"",
+					container.NewVBox(
+						bufferCheck,
+						filterSpecsEntry,
+						container.NewGridWithColumns(3, sinceEntry, regexEntry, uidEntry),
+						container.NewHBox(NewBoldLabel("Format:"), formatSelect, dumpCheck, clearCheck),
+					),
+				),
 				container.NewCenter(
 					container.NewHBox(
 						logsStartButton,
 						logsStopButton,
+						logsViewButton,
+						logsCrashesButton,
+						logsHistoryButton,
+						logsTimelineButton,
 					),
 				),
 			),
@@ -69,29 +131,52 @@ func Logs(client *adbclient.Client, device *adbclient.Device, parent fyne.Window
 		parent,
 	)
 
-	logcat, err := client.Logcat(device)
-	if err != nil {
-		GetApp().ShowError(err, nil, parent)
-		return
-	}
+	var logcat *adbclient.LogcatWatcher
+	var fileSaver *util.FileSaver
 
-	dialog.SetOnClosed(func() {
-		logcat.Close()
-	})
+	stop := func() {
+		if fileSaver != nil {
+			fileSaver.Stop()
+			fileSaver = nil
+		}
 
-	fileSaver, err := util.NewFileSaver(logcat)
-	if err != nil {
-		GetApp().ShowError(err, nil, parent)
-		return
+		if logcat != nil {
+			logcat.Close()
+			logcat = nil
+		}
 	}
 
+	dialog.SetOnClosed(stop)
+
 	logsStartButton.OnTapped = func() {
-		if err := client.ClearLogcat(device); err != nil {
+		opts, err := logcatFormOptions(bufferCheck.Selected, filterSpecsEntry.Text, sinceEntry.Text, regexEntry.Text, uidEntry.Text, formatSelect.Selected, dumpCheck.Checked)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		if clearCheck.Checked {
+			if err := client.ClearLogcat(device); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+		}
+
+		logcat, err = client.Logcat(device, opts...)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		fileSaver, err = util.NewFileSaver(logcat)
+		if err != nil {
+			stop()
 			GetApp().ShowError(err, nil, parent)
 			return
 		}
 
 		if err := fileSaver.Start(logsPathEntry.Text); err != nil {
+			stop()
 			GetApp().ShowError(err, nil, parent)
 			return
 		}
@@ -103,8 +188,78 @@ func Logs(client *adbclient.Client, device *adbclient.Device, parent fyne.Window
 	logsStopButton.OnTapped = func() {
 		logsStartButton.Enable()
 		logsStopButton.Disable()
-		fileSaver.Stop()
+		stop()
 	}
 
 	dialog.Show()
 }
+
+// logcatBufferNames returns the names of the log buffers selectable in the logs dialog.
+func logcatBufferNames() []string {
+	var names []string
+	for buffer := adbclient.BufferMain; buffer <= adbclient.BufferKernel; buffer++ {
+		names = append(names, buffer.String())
+	}
+
+	return names
+}
+
+// logcatFormOptions converts the fields of the logs dialog to logcat options.
+// The since field is either a duration before now or a number of recent lines.
+func logcatFormOptions(buffers []string, filterSpecs, since, regex, uids, format string, dump bool) ([]adbclient.LogcatOption, error) {
+	var opts []adbclient.LogcatOption
+
+	for _, name := range buffers {
+		buffer, err := adbclient.ParseLogcatBuffer(name)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, adbclient.WithLogcatBuffers(buffer))
+	}
+
+	specs, err := adbclient.ParseLogcatFilterSpecs(filterSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(specs) > 0 {
+		opts = append(opts, adbclient.WithLogcatFilterSpecs(specs...))
+	}
+
+	if since = strings.TrimSpace(since); since != "" {
+		if count, err := strconv.Atoi(since); err == nil {
+			opts = append(opts, adbclient.WithLogcatTail(count))
+		} else if d, err := time.ParseDuration(since); err == nil {
+			opts = append(opts, adbclient.WithLogcatSince(time.Now().Add(-d)))
+		} else {
+			return nil, fmt.Errorf("invalid since: %s", since)
+		}
+	}
+
+	if regex != "" {
+		opts = append(opts, adbclient.WithLogcatRegex(regex))
+	}
+
+	for _, field := range strings.FieldsFunc(uids, func(r rune) bool { return r == ',' || r == ' ' }) {
+		uid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uid: %s", field)
+		}
+
+		opts = append(opts, adbclient.WithLogcatUIDs(uid))
+	}
+
+	logcatFormat, err := adbclient.ParseLogcatFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, adbclient.WithLogcatFormat(logcatFormat))
+
+	if dump {
+		opts = append(opts, adbclient.WithLogcatDump())
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,565 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	fynestorage "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/logexport"
+	"github.com/johnnyipcom/androidtool/pkg/retrace"
+)
+
+const (
+	// DefaultLogViewerCapacity is the maximum number of messages kept by the log viewer.
+	DefaultLogViewerCapacity = 50000
+
+	logViewerRefreshInterval = 250 * time.Millisecond
+	logViewerPidInterval     = 2 * time.Second
+)
+
+var logcatPriorityColors = map[adbclient.LogcatPriority]color.Color{
+	adbclient.Verbose: color.NRGBA{R: 0x9e, G: 0x9e, B: 0x9e, A: 0xff},
+	adbclient.Debug:   color.NRGBA{R: 0x42, G: 0xa5, B: 0xf5, A: 0xff},
+	adbclient.Info:    color.NRGBA{R: 0x66, G: 0xbb, B: 0x6a, A: 0xff},
+	adbclient.Warning: color.NRGBA{R: 0xff, G: 0xa7, B: 0x26, A: 0xff},
+	adbclient.Error:   color.NRGBA{R: 0xef, G: 0x53, B: 0x50, A: 0xff},
+	adbclient.Fatal:   color.NRGBA{R: 0xd5, G: 0x00, B: 0xf9, A: 0xff},
+}
+
+// logColumns are the columns of the log table with their widths.
+var logColumns = []struct {
+	title string
+	width float32
+}{
+	{"Time", 110},
+	{"PID", 55},
+	{"TID", 55},
+	{"P", 25},
+	{"Tag", 180},
+	{"Message", 1200},
+}
+
+// logBuffer is a bounded buffer of logcat messages with a filtered view.
+type logBuffer struct {
+	mu       sync.RWMutex
+	capacity int
+	messages []adbclient.LogcatMessage
+	view     []adbclient.LogcatMessage
+	filter   adbclient.LogcatFilter
+	paused   bool
+}
+
+func newLogBuffer(capacity int) *logBuffer {
+	return &logBuffer{capacity: capacity}
+}
+
+// add adds a message and returns true if the view changed.
+func (b *logBuffer) add(msg adbclient.LogcatMessage) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, msg)
+
+	// trim in batches so that the view is not rebuilt for every message
+	if len(b.messages) > b.capacity+b.capacity/10 {
+		b.messages = append([]adbclient.LogcatMessage(nil), b.messages[len(b.messages)-b.capacity:]...)
+		if !b.paused {
+			b.rebuild()
+		}
+
+		return !b.paused
+	}
+
+	if b.paused || !b.filter.Match(msg) {
+		return false
+	}
+
+	b.view = append(b.view, msg)
+	return true
+}
+
+// rebuild rebuilds the filtered view, the lock must be held.
+func (b *logBuffer) rebuild() {
+	b.view = b.view[:0]
+	for _, msg := range b.messages {
+		if b.filter.Match(msg) {
+			b.view = append(b.view, msg)
+		}
+	}
+}
+
+func (b *logBuffer) setFilter(filter adbclient.LogcatFilter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.filter = filter
+	b.rebuild()
+}
+
+func (b *logBuffer) setPaused(paused bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.paused = paused
+	if !paused {
+		b.rebuild()
+	}
+}
+
+func (b *logBuffer) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = nil
+	b.view = nil
+}
+
+func (b *logBuffer) len() (int, int) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.view), len(b.messages)
+}
+
+func (b *logBuffer) at(i int) (adbclient.LogcatMessage, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if i < 0 || i >= len(b.view) {
+		return adbclient.LogcatMessage{}, false
+	}
+
+	return b.view[i], true
+}
+
+// snapshot returns a copy of the filtered view.
+func (b *logBuffer) snapshot() []adbclient.LogcatMessage {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return append([]adbclient.LogcatMessage(nil), b.view...)
+}
+
+// logcatExportExtensions are the file extensions of the logcat export formats.
+var logcatExportExtensions = []string{".log", ".txt", ".jsonl", ".csv", ".html"}
+
+// exportLogcat asks for a file and exports the messages in the format of its extension.
+func exportLogcat(msgs []adbclient.LogcatMessage, title, fileName string, parent fyne.Window) {
+	fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+		if err != nil || file == nil {
+			return
+		}
+
+		path := file.URI().Path()
+		file.Close()
+
+		if err := logexport.Export(path, msgs, title); err != nil {
+			GetApp().ShowError(err, nil, parent)
+		}
+	}, parent)
+
+	fsaveDialog.SetFileName(fileName)
+	fsaveDialog.SetFilter(fynestorage.NewExtensionFileFilter(logcatExportExtensions))
+	fsaveDialog.Resize(DialogSize(parent))
+	fsaveDialog.Show()
+}
+
+// retraceMessage deobfuscates the stack trace lines of the message with the mapping of the build.
+// A frame of a single line message expands to one message per inlined or ambiguous frame.
+func retraceMessage(mapping *retrace.Mapping, msg adbclient.LogcatMessage) []adbclient.LogcatMessage {
+	if mapping == nil {
+		return []adbclient.LogcatMessage{msg}
+	}
+
+	if strings.Contains(msg.Message, "\n") {
+		msg.Message = mapping.RetraceText(msg.Message)
+		return []adbclient.LogcatMessage{msg}
+	}
+
+	var messages []adbclient.LogcatMessage
+	for _, line := range mapping.RetraceLine(msg.Message) {
+		retraced := msg
+		retraced.Message = line
+		messages = append(messages, retraced)
+	}
+
+	return messages
+}
+
+func containsPid(pids []int, pid int) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LogViewer shows a live, filterable view of the logcat of the device.
+func LogViewer(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	buffer := newLogBuffer(DefaultLogViewerCapacity)
+
+	table := widget.NewTable(
+		func() (int, int) {
+			rows, _ := buffer.len()
+			return rows + 1, len(logColumns)
+		},
+		func() fyne.CanvasObject {
+			text := canvas.NewText("", theme.ForegroundColor())
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			return text
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			text := o.(*canvas.Text)
+
+			// the first row is the header
+			if id.Row == 0 {
+				text.Text = logColumns[id.Col].title
+				text.Color = theme.ForegroundColor()
+				text.TextStyle.Bold = true
+				text.Refresh()
+				return
+			}
+
+			text.TextStyle.Bold = false
+			msg, ok := buffer.at(id.Row - 1)
+			if !ok {
+				text.Text = ""
+				text.Refresh()
+				return
+			}
+
+			switch id.Col {
+			case 0:
+				text.Text = msg.Timestamp.Format("15:04:05.000")
+			case 1:
+				text.Text = strconv.Itoa(msg.ProcessID)
+			case 2:
+				text.Text = strconv.Itoa(msg.ThreadID)
+			case 3:
+				text.Text = msg.Priority.String()
+			case 4:
+				text.Text = msg.Tag
+			case 5:
+				text.Text = msg.Message
+			}
+
+			text.Color = logcatPriorityColors[msg.Priority]
+			text.Refresh()
+		},
+	)
+
+	for i, column := range logColumns {
+		table.SetColumnWidth(i, column.width)
+	}
+
+	statusLabel := widget.NewLabel("")
+
+	table.OnSelected = func(id widget.TableCellID) {
+		table.Unselect(id)
+
+		msg, ok := buffer.at(id.Row - 1)
+		if !ok {
+			return
+		}
+
+		parent.Clipboard().SetContent(msg.String())
+		statusLabel.SetText("Copied to clipboard")
+	}
+
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder("Tag")
+
+	pidEntry := widget.NewEntry()
+	pidEntry.SetPlaceHolder("PID")
+	pidEntry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+
+		_, err := strconv.Atoi(s)
+		return err
+	}
+
+	var priorities []string
+	for priority := adbclient.Verbose; priority <= adbclient.Fatal; priority++ {
+		priorities = append(priorities, priority.String())
+	}
+
+	prioritySelect := widget.NewSelect(priorities, nil)
+	prioritySelect.SetSelected(adbclient.Verbose.String())
+
+	regexEntry := widget.NewEntry()
+	regexEntry.SetPlaceHolder("Message regex")
+	regexEntry.Validator = func(s string) error {
+		_, err := regexp.Compile(s)
+		return err
+	}
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search")
+
+	packageEntry := widget.NewEntry()
+	packageEntry.SetPlaceHolder("com.example.app")
+	packageCheck := widget.NewCheck("Only this package", nil)
+
+	// packagePids are the process ids of the package, resolved with pidof
+	var pidMu sync.Mutex
+	var packagePids []int
+	// packageVersion is the installed version code of the package, packageMapping the mapping of its build
+	var packageVersion int
+	var packageMapping *retrace.Mapping
+
+	applyFilter := func() {
+		filter := adbclient.LogcatFilter{
+			Tag:    tagEntry.Text,
+			Search: searchEntry.Text,
+		}
+
+		filter.Priority, _ = adbclient.ParseLogcatPriority(prioritySelect.Selected)
+
+		if pid, err := strconv.Atoi(pidEntry.Text); err == nil {
+			filter.PIDs = []int{pid}
+		}
+
+		if regex, err := regexp.Compile(regexEntry.Text); err == nil && regexEntry.Text != "" {
+			filter.Regex = regex
+		}
+
+		if packageCheck.Checked {
+			pidMu.Lock()
+			filter.PIDs = packagePids
+			pidMu.Unlock()
+
+			// match nothing until the package is running
+			if len(filter.PIDs) == 0 {
+				filter.PIDs = []int{-1}
+			}
+		}
+
+		buffer.setFilter(filter)
+		table.Refresh()
+	}
+
+	for _, entry := range []*widget.Entry{tagEntry, pidEntry, regexEntry, searchEntry} {
+		entry.OnChanged = func(string) { applyFilter() }
+	}
+
+	prioritySelect.OnChanged = func(string) { applyFilter() }
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// resolvePids updates the process ids of the package, they change when the app restarts
+	resolvePids := func() {
+		pids, err := client.PidOf(device, packageEntry.Text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+
+		pidMu.Lock()
+		changed := fmt.Sprint(pids) != fmt.Sprint(packagePids)
+		packagePids = pids
+		version := packageVersion
+		pidMu.Unlock()
+
+		// the app restarts when it's reinstalled, the version code only needs to be read again then
+		if changed || version == 0 {
+			if pkg, err := client.GetPackage(device, packageEntry.Text); err == nil {
+				version = pkg.VersionCode
+			}
+		}
+
+		// the mapping may be associated with the build while the viewer is open
+		mapping := GetApp().mappings.Get(packageEntry.Text, version)
+
+		pidMu.Lock()
+		packageVersion = version
+		packageMapping = mapping
+		pidMu.Unlock()
+
+		if changed {
+			applyFilter()
+		}
+	}
+
+	packageCheck.OnChanged = func(checked bool) {
+		if checked {
+			if packageEntry.Text == "" {
+				packageCheck.SetChecked(false)
+				GetApp().ShowError(fmt.Errorf("package is not set"), nil, parent)
+				return
+			}
+
+			packageEntry.Disable()
+			resolvePids()
+		} else {
+			packageEntry.Enable()
+
+			pidMu.Lock()
+			packageVersion = 0
+			packageMapping = nil
+			pidMu.Unlock()
+		}
+
+		applyFilter()
+	}
+
+	go func() {
+		ticker := time.NewTicker(logViewerPidInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if packageCheck.Checked {
+					resolvePids()
+				}
+			}
+		}
+	}()
+
+	autoScrollCheck := widget.NewCheck("Auto-scroll", nil)
+	autoScrollCheck.SetChecked(true)
+
+	var pauseButton *widget.Button
+	pauseButton = widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), func() {
+		if pauseButton.Text == "Pause" {
+			buffer.setPaused(true)
+			pauseButton.SetText("Resume")
+			pauseButton.SetIcon(theme.MediaPlayIcon())
+		} else {
+			buffer.setPaused(false)
+			pauseButton.SetText("Pause")
+			pauseButton.SetIcon(theme.MediaPauseIcon())
+			table.Refresh()
+		}
+	})
+
+	clearButton := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), func() {
+		buffer.clear()
+		table.Refresh()
+	})
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		msgs := buffer.snapshot()
+		if len(msgs) == 0 {
+			return
+		}
+
+		exportLogcat(msgs, "Logcat: "+device.String(), fmt.Sprintf("logcat_%s.html", device.Serial), parent)
+	})
+
+	d := dialog.NewCustom(
+		"Logcat: "+device.String(),
+		"Close",
+		container.NewBorder(
+			container.NewVBox(
+				container.NewGridWithColumns(
+					5,
+					tagEntry,
+					pidEntry,
+					prioritySelect,
+					regexEntry,
+					searchEntry,
+				),
+				container.NewBorder(nil, nil, nil, packageCheck, packageEntry),
+			),
+			container.NewBorder(
+				nil,
+				nil,
+				statusLabel,
+				container.NewHBox(autoScrollCheck, pauseButton, exportButton, clearButton),
+			),
+			nil,
+			nil,
+			table,
+		),
+		parent,
+	)
+
+	logcat, err := client.Logcat(device)
+	if err != nil {
+		cancel()
+		GetApp().ShowError(err, nil, parent)
+		return
+	}
+
+	d.SetOnClosed(func() {
+		cancel()
+		logcat.Close()
+	})
+
+	var changedMu sync.Mutex
+	changed := false
+
+	go func() {
+		for msg := range logcat.C(ctx) {
+			pidMu.Lock()
+			mapping := packageMapping
+			if mapping != nil && !containsPid(packagePids, msg.ProcessID) {
+				mapping = nil
+			}
+			pidMu.Unlock()
+
+			added := false
+			for _, msg := range retraceMessage(mapping, msg) {
+				added = buffer.add(msg) || added
+			}
+
+			if added {
+				changedMu.Lock()
+				changed = true
+				changedMu.Unlock()
+			}
+		}
+	}()
+
+	// refresh the table periodically instead of on every message
+	go func() {
+		ticker := time.NewTicker(logViewerRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changedMu.Lock()
+				refresh := changed
+				changed = false
+				changedMu.Unlock()
+
+				if !refresh {
+					continue
+				}
+
+				visible, total := buffer.len()
+				statusLabel.SetText(fmt.Sprintf("%d of %d messages", visible, total))
+				table.Refresh()
+				if autoScrollCheck.Checked {
+					table.ScrollToBottom()
+				}
+			}
+		}
+	}()
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
@@ -85,12 +85,47 @@ func (m *main) buildUI() *fyne.Container {
 			widget.NewCard(
 				"",
 				"Devices:",
-				m.deviceList,
+				container.NewBorder(m.buildFilterUI(), nil, nil, nil, m.deviceList),
 			),
 		),
 	)
 }
 
+// buildFilterUI creates the controls to filter and group the devices by label, and to edit the inventory.
+func (m *main) buildFilterUI() fyne.CanvasObject {
+	const allLabels = "All labels"
+
+	labelSelect := widget.NewSelect(nil, func(label string) {
+		if label == allLabels {
+			label = ""
+		}
+
+		m.deviceList.SetLabelFilter(label)
+	})
+
+	updateLabels := func() {
+		labelSelect.Options = append([]string{allLabels}, m.deviceList.Labels()...)
+		labelSelect.Refresh()
+	}
+
+	updateLabels()
+	labelSelect.SetSelected(allLabels)
+
+	groupCheck := widget.NewCheck("Group by label", m.deviceList.SetGroupByLabel)
+
+	inventoryButton := widget.NewButtonWithIcon("Inventory", theme.ListIcon(), func() {
+		Inventory(func() {
+			if err := m.deviceList.ReloadInventory(); err != nil {
+				GetApp().ShowError(err, nil, m.parent)
+			}
+
+			updateLabels()
+		}, m.parent)
+	})
+
+	return container.NewHBox(labelSelect, groupCheck, inventoryButton)
+}
+
 func (m *main) onUseCustomKeystoreChecked(checked bool) {
 }
 
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	fynestorage "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/c2h5oh/datasize"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/apk"
+)
+
+// obbItem is an expansion file on the device with the result of its validation.
+type obbItem struct {
+	pkg    string
+	file   adbclient.FileInfo
+	status string
+}
+
+// pushOBBs pushes the expansion files of the package with a progress dialog.
+func pushOBBs(client *adbclient.Client, device *adbclient.Device, pkg string, paths []string, parent fyne.Window) error {
+	bar := NewProgressBar(parent)
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(200, 0))
+
+	d := dialog.NewCustom("Expansion files", "Cancel", container.NewMax(rect, bar), parent)
+	d.Show()
+	defer d.Hide()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.SetOnClosed(cancel)
+
+	for _, src := range paths {
+		bar.SetValue(0)
+		bar.SetText("Pushing " + filepath.Base(src) + "...")
+		if err := client.PushOBB(ctx, device, pkg, src, bar.WithUploadProgress()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// offerOBBs offers to push the expansion files found next to the installed APK.
+func offerOBBs(client *adbclient.Client, device *adbclient.Device, apkPath, result string, parent fyne.Window) bool {
+	pkg, err := apk.NewAPK(apkPath)
+	if err != nil {
+		GetApp().log.Warnf("Could not look for expansion files: %v", err)
+		return false
+	}
+
+	defer pkg.Close()
+
+	paths := pkg.FindOBBs(apkPath)
+	if len(paths) == 0 {
+		return false
+	}
+
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+
+	name := pkg.Identifier()
+	message := fmt.Sprintf("%s\n\nPush the expansion files to %s?\n%s", strings.TrimSpace(result), filepath.ToSlash(filepath.Join(adbclient.OBBDir, name)), strings.Join(names, "\n"))
+	dialog.ShowConfirm("Installation result", message, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		go func() {
+			if err := pushOBBs(client, device, name, paths, parent); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			GetApp().ShowInformation("Expansion files", fmt.Sprintf("%d expansion files pushed", len(paths)), parent)
+		}()
+	}, parent)
+
+	return true
+}
+
+// validateOBB checks the name of an expansion file against the version of the installed package.
+func validateOBB(client *adbclient.Client, device *adbclient.Device, pkg, name string) error {
+	installed, err := client.GetPackage(device, pkg)
+	if err != nil {
+		if errors.Is(err, adbclient.ErrPackageNotFound) {
+			return fmt.Errorf("%s is not installed", pkg)
+		}
+
+		return err
+	}
+
+	_, err = apk.ValidateOBBName(name, pkg, int32(installed.VersionCode))
+	return err
+}
+
+// OBBManager shows the expansion files on the device, to add, replace and delete them.
+func OBBManager(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	var (
+		items    []obbItem
+		selected = -1
+	)
+
+	statusLabel := widget.NewLabel("")
+
+	list := widget.NewList(
+		func() int {
+			return len(items)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(
+				nil,
+				nil,
+				widget.NewIcon(theme.FileIcon()),
+				container.NewHBox(widget.NewLabel(""), widget.NewLabel("")),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			item := items[id]
+			row := o.(*fyne.Container)
+
+			icon := theme.ConfirmIcon()
+			if item.status != "" {
+				icon = theme.WarningIcon()
+			}
+
+			row.Objects[0].(*widget.Label).SetText(item.file.Name)
+			row.Objects[1].(*widget.Icon).SetResource(icon)
+
+			details := row.Objects[2].(*fyne.Container)
+			details.Objects[0].(*widget.Label).SetText(datasize.ByteSize(item.file.Size).HumanReadable())
+			details.Objects[1].(*widget.Label).SetText(item.file.ModTime.Format("2006-01-02 15:04"))
+		},
+	)
+
+	load := func() {
+		obbs, err := client.ListOBBs(device)
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		pkgs := make([]string, 0, len(obbs))
+		for pkg := range obbs {
+			pkgs = append(pkgs, pkg)
+		}
+
+		sort.Strings(pkgs)
+
+		var newItems []obbItem
+		for _, pkg := range pkgs {
+			for _, file := range obbs[pkg] {
+				item := obbItem{pkg: pkg, file: file}
+				if err := validateOBB(client, device, pkg, file.Name); err != nil {
+					item.status = err.Error()
+				}
+
+				newItems = append(newItems, item)
+			}
+		}
+
+		items, selected = newItems, -1
+		statusLabel.SetText(fmt.Sprintf("%d expansion files of %d packages", len(items), len(pkgs)))
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+
+		item := items[id]
+		if item.status != "" {
+			statusLabel.SetText(item.status)
+		} else {
+			statusLabel.SetText(fmt.Sprintf("%s, matches the installed %s", item.file.Path, item.pkg))
+		}
+	}
+
+	// push validates the selected file against the installed package, the replaced file is removed if its name differs
+	push := func(replaced *obbItem) {
+		fopenDialog := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			src := file.URI().Path()
+			file.Close()
+
+			go func() {
+				obb, err := apk.ParseOBBName(src)
+				if err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				if replaced != nil && obb.Package != replaced.pkg {
+					GetApp().ShowError(fmt.Errorf("%s is not an expansion file of %s", filepath.Base(src), replaced.pkg), nil, parent)
+					return
+				}
+
+				if err := validateOBB(client, device, obb.Package, src); err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				if err := pushOBBs(client, device, obb.Package, []string{src}, parent); err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				if replaced != nil && replaced.file.Name != filepath.Base(src) {
+					if err := client.DeleteOBB(device, replaced.pkg, replaced.file.Name); err != nil {
+						GetApp().ShowError(err, nil, parent)
+					}
+				}
+
+				load()
+			}()
+		}, parent)
+
+		fopenDialog.Resize(DialogSize(parent))
+		fopenDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".obb"}))
+		fopenDialog.Show()
+	}
+
+	refreshButton := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		go load()
+	})
+
+	addButton := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		push(nil)
+	})
+
+	replaceButton := widget.NewButtonWithIcon("Replace", theme.UploadIcon(), func() {
+		if selected < 0 || selected >= len(items) {
+			return
+		}
+
+		item := items[selected]
+		push(&item)
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		if selected < 0 || selected >= len(items) {
+			return
+		}
+
+		item := items[selected]
+		dialog.ShowConfirm("Delete", "Delete "+item.file.Path+"?", func(ok bool) {
+			if !ok {
+				return
+			}
+
+			go func() {
+				if err := client.DeleteOBB(device, item.pkg, item.file.Name); err != nil {
+					GetApp().ShowError(err, nil, parent)
+					return
+				}
+
+				load()
+			}()
+		}, parent)
+	})
+
+	d := dialog.NewCustom(
+		"Expansion files: "+device.String(),
+		"Close",
+		container.NewBorder(
+			nil,
+			container.NewBorder(nil, nil, refreshButton, container.NewHBox(addButton, replaceButton, deleteButton), statusLabel),
+			nil,
+			nil,
+			list,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+
+	load()
+}
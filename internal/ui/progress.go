@@ -30,6 +30,14 @@ func (p *ProgressBar) WithDownloadProgress() adbclient.DownloadOption {
 	})
 }
 
+func (p *ProgressBar) WithDirProgress() adbclient.DirOption {
+	once := sync.Once{}
+	return adbclient.WithDirProgress(func(sentBytes int64, totalBytes int64) {
+		once.Do(func() { p.Max = float64(totalBytes) })
+		p.SetValue(float64(sentBytes))
+	})
+}
+
 func (p *ProgressBar) WithMax(max float64) aabclient.DownloadOption {
 	return aabclient.WithProgress(func(sentBytes int64) {
 		p.SetValue(p.Value + float64(sentBytes))
@@ -7,6 +7,7 @@ import (
 	"image/color"
 	"io"
 	"os"
+	"strconv"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
@@ -21,11 +22,15 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/johnnyipcom/androidtool/internal/assets"
 	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/animation"
 )
 
 const (
 	// DefaultScreenshotPath is the default screenshot file path.
 	DefaultScreenshotPath = "./screenshot.png"
+
+	// DefaultBurstPath is the default animated screenshot file path.
+	DefaultBurstPath = "./screenshot.gif"
 )
 
 func previewImage(size fyne.Size, color color.Color, textColor color.Color, text string) image.Image {
@@ -176,12 +181,111 @@ func Screenshot(client *adbclient.Client, device *adbclient.Device, parent fyne.
 
 	makeScreenshotButton := widget.NewButtonWithIcon("Screenshot", assets.ScreenshotIcon, nil)
 
+	burstPathEntry := widget.NewEntry()
+	burstPathEntry.SetText(DefaultBurstPath)
+
+	burstPathButton := widget.NewButtonWithIcon("Select", theme.DocumentSaveIcon(), func() {
+		fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+			if err != nil {
+				return
+			}
+
+			if file == nil {
+				return
+			}
+
+			defer file.Close()
+			burstPathEntry.SetText(file.URI().Path())
+		}, parent)
+
+		fsaveDialog.SetFileName("screenshot.gif")
+		fsaveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".gif", ".png"}))
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	})
+
+	burstFramesEntry := widget.NewEntry()
+	burstFramesEntry.SetText(strconv.Itoa(adbclient.DefaultBurstFrames))
+	burstFramesEntry.Validator = func(s string) error {
+		frames, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+
+		if frames < 1 || frames > 300 {
+			return fmt.Errorf("frames must be between 1 and 300")
+		}
+
+		return nil
+	}
+
+	burstFPSEntry := widget.NewEntry()
+	burstFPSEntry.SetText(strconv.Itoa(adbclient.DefaultBurstFPS))
+	burstFPSEntry.Validator = func(s string) error {
+		fps, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+
+		if fps <= 0 || fps > 30 {
+			return fmt.Errorf("fps must be between 0 and 30")
+		}
+
+		return nil
+	}
+
+	burstScaleSlider := widget.NewSlider(0.1, 1)
+	burstScaleSlider.Step = 0.05
+	burstScaleSlider.SetValue(0.5)
+
+	burstQualitySelect := widget.NewSelect([]string{
+		animation.PaletteLow.String(),
+		animation.PaletteMedium.String(),
+		animation.PaletteHigh.String(),
+	}, nil)
+	burstQualitySelect.SetSelected(animation.PaletteHigh.String())
+
+	burstProgress := NewProgressBar(parent)
+	makeBurstButton := widget.NewButtonWithIcon("Burst", assets.ScreenshotIcon, nil)
+
+	annotateButton := widget.NewButtonWithIcon("Annotate", theme.DocumentCreateIcon(), nil)
+	annotateButton.Disable()
+
+	var captured image.Image
+	baselineUI := newBaselineUI(client, device, func() image.Image { return captured }, parent)
+
 	d := dialog.NewCustom(
 		"Screenshot",
 		"Close",
 		container.NewBorder(
 			container.New(&alignToRightLayout{}, screenshotPathEntry, screenshotPathButton),
-			container.NewCenter(makeScreenshotButton),
+			container.NewVBox(
+				container.NewCenter(container.NewHBox(makeScreenshotButton, annotateButton)),
+				widget.NewAccordion(
+					widget.NewAccordionItem(
+						"Burst",
+						container.NewVBox(
+							container.New(&alignToRightLayout{}, burstPathEntry, burstPathButton),
+							container.NewGridWithColumns(
+								4,
+								NewBoldLabel("Frames:"),
+								burstFramesEntry,
+								NewBoldLabel("FPS:"),
+								burstFPSEntry,
+								NewBoldLabel("Scale:"),
+								burstScaleSlider,
+								NewBoldLabel("Palette quality:"),
+								burstQualitySelect,
+							),
+							container.NewBorder(nil, nil, nil, makeBurstButton, burstProgress),
+						),
+					),
+					widget.NewAccordionItem(
+						"Baseline",
+						baselineUI,
+					),
+				),
+			),
 			nil,
 			nil,
 			container.NewMax(screenshotImage),
@@ -224,7 +328,102 @@ func Screenshot(client *adbclient.Client, device *adbclient.Device, parent fyne.
 			return
 		}
 
+		captured = image
 		screenshotImage.LoadFromImage(image)
+		annotateButton.Enable()
+	}
+
+	annotateButton.OnTapped = func() {
+		Annotate(device, captured, parent)
+	}
+
+	makeBurstButton.OnTapped = func() {
+		onError := func(err error) {
+			burstProgress.SetText("Failed")
+			GetApp().ShowError(err, nil, parent)
+		}
+
+		frames, err := strconv.Atoi(burstFramesEntry.Text)
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		fps, err := strconv.ParseFloat(burstFPSEntry.Text, 64)
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		quality, err := animation.ParsePaletteQuality(burstQualitySelect.Selected)
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		path := burstPathEntry.Text
+		format, err := animation.FormatFromPath(path)
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		makeBurstButton.Disable()
+		makeScreenshotButton.Disable()
+
+		go func() {
+			defer makeBurstButton.Enable()
+			defer makeScreenshotButton.Enable()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			burstProgress.Max = float64(frames)
+			burstProgress.SetText("")
+
+			burst, err := client.ScreenshotBurst(
+				ctx,
+				device,
+				adbclient.WithBurstFrames(frames),
+				adbclient.WithBurstFPS(fps),
+				adbclient.WithBurstProgress(func(frame, total int) {
+					burstProgress.SetValue(float64(frame))
+				}),
+			)
+			if err != nil {
+				onError(err)
+				return
+			}
+
+			screenshotImage.LoadFromImage(burst[0].Image)
+			burstProgress.SetText(fmt.Sprintf("Encoding %s...", format))
+
+			f, err := os.Create(path)
+			if err != nil {
+				onError(err)
+				return
+			}
+
+			defer f.Close()
+
+			animFrames := make([]animation.Frame, len(burst))
+			for i, frame := range burst {
+				animFrames[i] = animation.Frame{Image: frame.Image, Delay: frame.Delay}
+			}
+
+			if err := animation.Encode(
+				f,
+				format,
+				animFrames,
+				animation.WithScale(burstScaleSlider.Value),
+				animation.WithPaletteQuality(quality),
+			); err != nil {
+				onError(err)
+				return
+			}
+
+			burstProgress.SetText("Done")
+		}()
 	}
 
 	d.Resize(DialogSize(parent))
@@ -3,8 +3,10 @@ package ui
 import (
 	"fmt"
 	"image/color"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -18,6 +20,7 @@ import (
 	"github.com/johnnyipcom/androidtool/pkg/aabclient"
 	"github.com/johnnyipcom/androidtool/pkg/adbclient"
 	"github.com/johnnyipcom/androidtool/pkg/logger"
+	"github.com/johnnyipcom/androidtool/pkg/screendiff"
 )
 
 type settings struct {
@@ -33,10 +36,16 @@ type settings struct {
 	logPathEntry                *widget.Entry
 	storagePathButton           *widget.Button
 	storagePathEntry            *widget.Entry
+	baselinesPathEntry          *widget.Entry
+	crashNotificationsCheck     *widget.Check
+	symbolsPathEntry            *widget.Entry
+	logcatArchiveCheck          *widget.Check
+	logcatRetentionEntry        *widget.Entry
 	installPathEntry            *widget.Entry
 	screenshotPathEntry         *widget.Entry
 	videoPathEntry              *widget.Entry
 	adbPortEntry                *widget.Entry
+	leaseOwnerEntry             *widget.Entry
 	bundletoolVersionEntry      *widget.Entry
 	bundletoolJavaSettingsEntry *widget.Entry
 }
@@ -82,6 +91,16 @@ func (s *settings) onADBPortSubmitted(port string) {
 	GetApp().ShowInformation("ADB port changed", "You must restart the application for the new port to take effect.", s.parent)
 }
 
+func (s *settings) onLeaseOwnerSubmitted(owner string) {
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		owner = adbclient.DefaultLeaseOwner()
+	}
+
+	s.prefs.SetString("lease_owner", owner)
+	s.adbClient.SetLeases(s.storage, owner)
+}
+
 func (s *settings) onBundleToolVersionSubmitted(version string) {
 	if s.aabClient.BundleToolVersion() == version {
 		return
@@ -162,6 +181,37 @@ func (s *settings) onStoragePathButtonClicked() {
 	fsaveDialog.Show()
 }
 
+func (s *settings) onBaselinesPathSubmitted(path string) {
+	s.prefs.SetString("baselines_path", path)
+}
+
+func (s *settings) onSymbolsPathSubmitted(path string) {
+	s.prefs.SetString("symbols_path", path)
+}
+
+func (s *settings) onCrashNotificationsChanged(checked bool) {
+	s.prefs.SetBool("crash_notifications", checked)
+}
+
+func (s *settings) onLogcatArchiveChanged(checked bool) {
+	if s.prefs.BoolWithFallback("logcat_archive", true) == checked {
+		return
+	}
+
+	s.prefs.SetBool("logcat_archive", checked)
+	GetApp().ShowInformation("Logcat archive changed", "You must restart the application for the change to take effect.", s.parent)
+}
+
+func (s *settings) onLogcatRetentionSubmitted(days string) {
+	daysInt, err := strconv.Atoi(days)
+	if err != nil || daysInt < 0 {
+		return
+	}
+
+	s.prefs.SetInt("logcat_retention_days", daysInt)
+	GetApp().ShowInformation("Logcat retention changed", "You must restart the application for the new retention to take effect.", s.parent)
+}
+
 func (s *settings) applyPreferences() {
 	installPath := s.prefs.StringWithFallback("install_path", adbclient.DefaultInstallPath)
 	s.installPathEntry.SetText(installPath)
@@ -175,8 +225,26 @@ func (s *settings) applyPreferences() {
 	adbPort := s.prefs.IntWithFallback("adb_port", adbclient.DefaultPort)
 	s.adbPortEntry.SetText(strconv.FormatInt(int64(adbPort), 10))
 
+	leaseOwner := s.prefs.StringWithFallback("lease_owner", adbclient.DefaultLeaseOwner())
+	s.leaseOwnerEntry.SetText(leaseOwner)
+
 	bundletoolVersion := s.prefs.StringWithFallback("bundletool_version", aabclient.BundleToolDefaultVersion)
 	s.bundletoolVersionEntry.SetText(bundletoolVersion)
+
+	baselinesPath := s.prefs.StringWithFallback("baselines_path", screendiff.DefaultBaselinePath)
+	s.baselinesPathEntry.SetText(baselinesPath)
+
+	symbolsPath := s.prefs.String("symbols_path")
+	s.symbolsPathEntry.SetText(symbolsPath)
+
+	crashNotifications := s.prefs.BoolWithFallback("crash_notifications", true)
+	s.crashNotificationsCheck.SetChecked(crashNotifications)
+
+	logcatArchive := s.prefs.BoolWithFallback("logcat_archive", true)
+	s.logcatArchiveCheck.SetChecked(logcatArchive)
+
+	logcatRetention := s.prefs.IntWithFallback("logcat_retention_days", DefaultLogcatRetentionDays)
+	s.logcatRetentionEntry.SetText(strconv.Itoa(logcatRetention))
 }
 
 func (s *settings) buildAndroidToolUI() fyne.CanvasObject {
@@ -202,6 +270,38 @@ func (s *settings) buildAndroidToolUI() fyne.CanvasObject {
 		s.onStoragePathButtonClicked,
 	)
 
+	s.baselinesPathEntry = &widget.Entry{
+		PlaceHolder: screendiff.DefaultBaselinePath,
+		OnSubmitted: s.onBaselinesPathSubmitted,
+	}
+
+	s.symbolsPathEntry = &widget.Entry{
+		PlaceHolder: "Directories with unstripped *.so, separated by " + string(filepath.ListSeparator),
+		OnSubmitted: s.onSymbolsPathSubmitted,
+	}
+
+	s.crashNotificationsCheck = widget.NewCheck("Notify about crashes and ANRs", s.onCrashNotificationsChanged)
+
+	s.logcatArchiveCheck = widget.NewCheck("Archive the log of online devices", s.onLogcatArchiveChanged)
+
+	s.logcatRetentionEntry = &widget.Entry{
+		PlaceHolder: strconv.Itoa(DefaultLogcatRetentionDays),
+		OnSubmitted: s.onLogcatRetentionSubmitted,
+
+		Validator: func(s string) error {
+			days, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+
+			if days < 0 {
+				return fmt.Errorf("retention can't be negative")
+			}
+
+			return nil
+		},
+	}
+
 	return container.NewVBox(
 		container.NewGridWithColumns(
 			2,
@@ -209,6 +309,16 @@ func (s *settings) buildAndroidToolUI() fyne.CanvasObject {
 			container.New(&alignToRightLayout{}, s.logPathEntry, s.logPathButton),
 			NewBoldLabel("Storage path:"),
 			container.New(&alignToRightLayout{}, s.storagePathEntry, s.storagePathButton),
+			NewBoldLabel("Baselines path:"),
+			s.baselinesPathEntry,
+			NewBoldLabel("Symbols paths:"),
+			s.symbolsPathEntry,
+			NewBoldLabel("Crash notifications:"),
+			s.crashNotificationsCheck,
+			NewBoldLabel("Logcat archive:"),
+			s.logcatArchiveCheck,
+			NewBoldLabel("Logcat retention (days, 0 keeps all):"),
+			s.logcatRetentionEntry,
 		),
 	)
 }
@@ -247,6 +357,11 @@ func (s *settings) buildADBUI() fyne.CanvasObject {
 		},
 	}
 
+	s.leaseOwnerEntry = &widget.Entry{
+		PlaceHolder: adbclient.DefaultLeaseOwner(),
+		OnSubmitted: s.onLeaseOwnerSubmitted,
+	}
+
 	return container.NewVBox(
 		container.NewGridWithColumns(
 			2,
@@ -256,6 +371,8 @@ func (s *settings) buildADBUI() fyne.CanvasObject {
 			s.screenshotPathEntry,
 			NewBoldLabel("Video path:"),
 			s.videoPathEntry,
+			NewBoldLabel("Lease owner:"),
+			s.leaseOwnerEntry,
 		),
 		widget.NewAccordion(
 			widget.NewAccordionItem(
@@ -0,0 +1,361 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/internal/storage"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// shellSession is a shell of a device shown in a tab.
+type shellSession struct {
+	device   *adbclient.Device
+	shell    *adbclient.Shell
+	terminal *Terminal
+}
+
+func (s *shellSession) close() {
+	s.shell.Close()
+	s.terminal.Close()
+}
+
+type shell struct {
+	parent    fyne.Window
+	adbClient *adbclient.Client
+	storage   *storage.Storage
+
+	devices      []*adbclient.Device
+	deviceSelect *widget.Select
+	tabs         *container.DocTabs
+	sessions     map[*container.TabItem]*shellSession
+
+	snippets    []storage.Snippet
+	snippetList *widget.List
+	history     []string
+	historyList *widget.List
+}
+
+func uiShell(parent fyne.Window, adbClient *adbclient.Client, storage *storage.Storage) *shell {
+	return &shell{
+		parent:    parent,
+		adbClient: adbClient,
+		storage:   storage,
+		sessions:  make(map[*container.TabItem]*shellSession),
+	}
+}
+
+func (s *shell) buildUI() *fyne.Container {
+	s.deviceSelect = widget.NewSelect(nil, nil)
+	s.deviceSelect.PlaceHolder = "Select a device"
+
+	refreshButton := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), s.refreshDevices)
+	connectButton := widget.NewButtonWithIcon("Connect", theme.LoginIcon(), s.onConnect)
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), s.onCopy)
+	pasteButton := widget.NewButtonWithIcon("Paste", theme.ContentPasteIcon(), s.onPaste)
+
+	s.tabs = container.NewDocTabs()
+	s.tabs.OnClosed = func(item *container.TabItem) {
+		if session, ok := s.sessions[item]; ok {
+			session.close()
+			delete(s.sessions, item)
+		}
+	}
+
+	s.refreshDevices()
+
+	return container.NewBorder(
+		container.NewBorder(
+			nil,
+			nil,
+			nil,
+			container.NewHBox(refreshButton, connectButton, copyButton, pasteButton),
+			s.deviceSelect,
+		),
+		nil,
+		nil,
+		nil,
+		container.NewHSplit(
+			s.tabs,
+			container.NewAppTabs(
+				container.NewTabItem("Snippets", s.buildSnippets()),
+				container.NewTabItem("History", s.buildHistory()),
+			),
+		),
+	)
+}
+
+func (s *shell) buildSnippets() fyne.CanvasObject {
+	s.snippetList = widget.NewList(
+		func() int {
+			return len(s.snippets)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(s.snippets[id].Name)
+		},
+	)
+
+	selected := -1
+	s.snippetList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+
+	s.snippetList.OnUnselected = func(id widget.ListItemID) {
+		selected = -1
+	}
+
+	runButton := widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), func() {
+		if selected >= 0 && selected < len(s.snippets) {
+			s.run(s.snippets[selected].Command)
+		}
+	})
+
+	addButton := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		s.addSnippet("")
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		if selected < 0 || selected >= len(s.snippets) {
+			return
+		}
+
+		if err := s.storage.DeleteSnippet(s.snippets[selected].Name); err != nil {
+			GetApp().ShowError(err, nil, s.parent)
+			return
+		}
+
+		s.snippetList.UnselectAll()
+		s.loadSnippets()
+	})
+
+	s.loadSnippets()
+
+	return container.NewBorder(nil, container.NewGridWithColumns(3, runButton, addButton, deleteButton), nil, nil, s.snippetList)
+}
+
+func (s *shell) buildHistory() fyne.CanvasObject {
+	s.historyList = widget.NewList(
+		func() int {
+			return len(s.history)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			// the most recent command first
+			o.(*widget.Label).SetText(s.history[len(s.history)-1-id])
+		},
+	)
+
+	selected := ""
+	s.historyList.OnSelected = func(id widget.ListItemID) {
+		selected = s.history[len(s.history)-1-id]
+	}
+
+	s.historyList.OnUnselected = func(id widget.ListItemID) {
+		selected = ""
+	}
+
+	insertButton := widget.NewButtonWithIcon("Insert", theme.ContentPasteIcon(), func() {
+		if session := s.current(); session != nil && selected != "" {
+			session.terminal.Paste(selected)
+			s.parent.Canvas().Focus(session.terminal)
+		}
+	})
+
+	runButton := widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), func() {
+		if selected != "" {
+			s.run(selected)
+		}
+	})
+
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		if selected != "" {
+			s.addSnippet(selected)
+		}
+	})
+
+	s.loadHistory()
+
+	return container.NewBorder(nil, container.NewGridWithColumns(3, insertButton, runButton, saveButton), nil, nil, s.historyList)
+}
+
+func (s *shell) loadSnippets() {
+	snippets, err := s.storage.GetSnippets()
+	if err != nil {
+		GetApp().ShowError(err, nil, s.parent)
+		return
+	}
+
+	s.snippets = snippets
+	s.snippetList.Refresh()
+}
+
+func (s *shell) loadHistory() {
+	history, err := s.storage.GetShellHistory()
+	if err != nil {
+		GetApp().ShowError(err, nil, s.parent)
+		return
+	}
+
+	s.history = history
+	s.historyList.Refresh()
+}
+
+// addSnippet asks for the name of a new snippet.
+func (s *shell) addSnippet(command string) {
+	nameEntry := widget.NewEntry()
+	commandEntry := widget.NewMultiLineEntry()
+	commandEntry.SetText(command)
+
+	form := dialog.NewForm("New snippet", "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Command", commandEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		if err := s.storage.SaveSnippet(storage.Snippet{Name: nameEntry.Text, Command: commandEntry.Text}); err != nil {
+			GetApp().ShowError(err, nil, s.parent)
+			return
+		}
+
+		s.loadSnippets()
+	}, s.parent)
+
+	form.Resize(fyne.NewSize(500, 250))
+	form.Show()
+}
+
+func (s *shell) refreshDevices() {
+	devices, err := s.adbClient.GetOnlineDevices()
+	if err != nil {
+		GetApp().ShowError(err, nil, s.parent)
+		return
+	}
+
+	s.devices = devices
+
+	options := make([]string, len(devices))
+	for i, device := range devices {
+		options[i] = device.String()
+	}
+
+	s.deviceSelect.Options = options
+	if s.deviceSelect.SelectedIndex() >= len(options) || (s.deviceSelect.SelectedIndex() < 0 && len(options) > 0) {
+		s.deviceSelect.SetSelectedIndex(0)
+	}
+
+	s.deviceSelect.Refresh()
+}
+
+// current returns the session of the selected tab.
+func (s *shell) current() *shellSession {
+	return s.sessions[s.tabs.Selected()]
+}
+
+// run types the command in the terminal of the selected tab.
+func (s *shell) run(command string) {
+	session := s.current()
+	if session == nil {
+		GetApp().ShowInformation("Shell", "Connect to a device first", s.parent)
+		return
+	}
+
+	session.terminal.Paste(command + "\n")
+	s.parent.Canvas().Focus(session.terminal)
+}
+
+func (s *shell) onConnect() {
+	i := s.deviceSelect.SelectedIndex()
+	if i < 0 || i >= len(s.devices) {
+		return
+	}
+
+	device := s.devices[i]
+	terminal := NewTerminal(s.parent)
+	rows, cols := terminal.screen.Size()
+
+	sh, err := s.adbClient.OpenShell(device, adbclient.WithShellSize(rows, cols))
+	if err != nil {
+		terminal.Close()
+		GetApp().ShowError(err, nil, s.parent)
+		return
+	}
+
+	session := &shellSession{device: device, shell: sh, terminal: terminal}
+
+	terminal.Input = func(b []byte) {
+		if _, err := sh.Write(b); err != nil {
+			GetApp().log.Warnf("Could not write to the shell of %s: %v", device.Serial, err)
+		}
+	}
+
+	terminal.OnResize = func(rows, cols int) {
+		if err := sh.Resize(rows, cols); err != nil {
+			GetApp().log.Warnf("Could not resize the shell of %s: %v", device.Serial, err)
+		}
+	}
+
+	terminal.OnCommand = func(command string) {
+		if err := s.storage.AddShellHistory(command); err != nil {
+			GetApp().log.Warnf("Could not save the shell history: %v", err)
+			return
+		}
+
+		s.loadHistory()
+	}
+
+	item := container.NewTabItem(device.String(), terminal)
+	s.sessions[item] = session
+	s.tabs.Append(item)
+	s.tabs.Select(item)
+	s.parent.Canvas().Focus(terminal)
+
+	go func() {
+		_, err := io.Copy(terminal, sh)
+
+		status := "\r\n[shell closed"
+		if code, ok := sh.ExitCode(); ok {
+			status += fmt.Sprintf(", exit code %d", code)
+		} else if err != nil {
+			status += ": " + err.Error()
+		}
+
+		terminal.Write([]byte(status + "]\r\n"))
+	}()
+}
+
+func (s *shell) onCopy() {
+	session := s.current()
+	if session == nil {
+		return
+	}
+
+	text := session.terminal.SelectedText()
+	if text == "" {
+		text = session.terminal.ScreenText()
+	}
+
+	s.parent.Clipboard().SetContent(text)
+}
+
+func (s *shell) onPaste() {
+	if session := s.current(); session != nil {
+		session.terminal.Paste(s.parent.Clipboard().Content())
+		s.parent.Canvas().Focus(session.terminal)
+	}
+}
+
+func (s *shell) tabItem() *container.TabItem {
+	return &container.TabItem{Text: "Shell", Icon: theme.ComputerIcon(), Content: s.buildUI()}
+}
@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// snapshotChangeColors highlight the changes by kind.
+var snapshotChangeColors = map[adbclient.ChangeKind]color.Color{
+	adbclient.ChangeAdded:    color.NRGBA{R: 0x66, G: 0xbb, B: 0x6a, A: 0xff},
+	adbclient.ChangeRemoved:  color.NRGBA{R: 0xef, G: 0x53, B: 0x50, A: 0xff},
+	adbclient.ChangeModified: color.NRGBA{R: 0xff, G: 0xa7, B: 0x26, A: 0xff},
+}
+
+// snapshotColumns are the columns of the diff table with their widths.
+var snapshotColumns = []struct {
+	title string
+	width float32
+}{
+	{"Section", 120},
+	{"Key", 300},
+	{"Change", 80},
+	{"From", 250},
+	{"To", 250},
+}
+
+// Snapshots takes snapshots of the properties, features and settings of the device and shows the difference
+// between any two snapshots, of the same device or of two devices.
+func Snapshots(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	var snapshots []adbclient.Snapshot
+	var changes, visible []adbclient.SnapshotChange
+
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(visible) + 1, len(snapshotColumns)
+		},
+		func() fyne.CanvasObject {
+			text := canvas.NewText("", theme.ForegroundColor())
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			return text
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			text := o.(*canvas.Text)
+
+			// the first row is the header
+			if id.Row == 0 {
+				text.Text = snapshotColumns[id.Col].title
+				text.Color = theme.ForegroundColor()
+				text.TextStyle.Bold = true
+				text.Refresh()
+				return
+			}
+
+			change := visible[id.Row-1]
+			text.Text = []string{change.Section, change.Key, change.Kind.String(), change.Old, change.New}[id.Col]
+			text.Color = snapshotChangeColors[change.Kind]
+			text.TextStyle.Bold = false
+			text.Refresh()
+		},
+	)
+
+	for i, column := range snapshotColumns {
+		table.SetColumnWidth(i, column.width)
+	}
+
+	statusLabel := widget.NewLabel("")
+	fromSelect := widget.NewSelect(nil, nil)
+	toSelect := widget.NewSelect(nil, nil)
+	fromSelect.PlaceHolder = "From snapshot"
+	toSelect.PlaceHolder = "To snapshot"
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("Filter keys and values")
+
+	filter := func() {
+		text := filterEntry.Text
+		visible = visible[:0]
+		for _, change := range changes {
+			if strings.Contains(change.Key, text) || strings.Contains(change.Old, text) || strings.Contains(change.New, text) {
+				visible = append(visible, change)
+			}
+		}
+
+		table.Refresh()
+	}
+
+	filterEntry.OnChanged = func(string) { filter() }
+
+	diff := func() {
+		from, to := fromSelect.SelectedIndex(), toSelect.SelectedIndex()
+		if from < 0 || to < 0 || from >= len(snapshots) || to >= len(snapshots) {
+			changes = nil
+			statusLabel.SetText("Select two snapshots to compare")
+			filter()
+			return
+		}
+
+		a, b := &snapshots[from], &snapshots[to]
+		changes = adbclient.DiffSnapshots(a, b)
+
+		status := fmt.Sprintf("%d changes", len(changes))
+		if a.Fingerprint() != b.Fingerprint() {
+			status += fmt.Sprintf(", build %s -> %s", a.Fingerprint(), b.Fingerprint())
+		}
+
+		statusLabel.SetText(status)
+		filter()
+	}
+
+	fromSelect.OnChanged = func(string) { diff() }
+	toSelect.OnChanged = func(string) { diff() }
+
+	// load reloads the snapshots and selects the snapshots at the indices
+	load := func(from, to int) {
+		var err error
+		snapshots, err = GetApp().storage.GetSnapshots()
+		if err != nil {
+			GetApp().ShowError(err, nil, parent)
+			return
+		}
+
+		options := make([]string, len(snapshots))
+		for i := range snapshots {
+			options[i] = snapshots[i].String()
+		}
+
+		fromSelect.Options, toSelect.Options = options, options
+		fromSelect.ClearSelected()
+		toSelect.ClearSelected()
+
+		if from >= 0 && from < len(options) {
+			fromSelect.SetSelectedIndex(from)
+		}
+
+		if to >= 0 && to < len(options) {
+			toSelect.SetSelectedIndex(to)
+		}
+
+		diff()
+	}
+
+	labelEntry := widget.NewEntry()
+	labelEntry.SetPlaceHolder("Label, e.g. before update")
+
+	var takeButton *widget.Button
+	takeButton = widget.NewButtonWithIcon("Take snapshot", theme.ContentAddIcon(), func() {
+		takeButton.Disable()
+
+		go func() {
+			defer takeButton.Enable()
+
+			snapshot, err := client.Snapshot(device, labelEntry.Text)
+			if err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			if err := GetApp().storage.SaveSnapshot(snapshot); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			labelEntry.SetText("")
+
+			// compare the new snapshot with the previous snapshot of the device, if any
+			previous := -1
+			for i := range snapshots {
+				if snapshots[i].Serial == device.Serial {
+					previous = i
+				}
+			}
+
+			last := previous + 1
+			if previous < 0 {
+				for last < len(snapshots) && snapshots[last].Serial < device.Serial {
+					last++
+				}
+			}
+
+			load(previous, last)
+		}()
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		i := fromSelect.SelectedIndex()
+		if i < 0 || i >= len(snapshots) {
+			return
+		}
+
+		snapshot := snapshots[i]
+		dialog.ShowConfirm("Delete snapshot", "Delete the snapshot "+snapshot.String()+"?", func(ok bool) {
+			if !ok {
+				return
+			}
+
+			if err := GetApp().storage.DeleteSnapshot(snapshot.Serial, snapshot.Time); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			load(-1, -1)
+		}, parent)
+	})
+
+	d := dialog.NewCustom(
+		"Snapshots: "+device.String(),
+		"Close",
+		container.NewBorder(
+			container.NewVBox(
+				container.NewBorder(nil, nil, nil, takeButton, labelEntry),
+				container.NewBorder(nil, nil, widget.NewLabel("From:"), deleteButton, fromSelect),
+				container.NewBorder(nil, nil, widget.NewLabel("To:"), nil, toSelect),
+				filterEntry,
+				statusLabel,
+			),
+			nil,
+			nil,
+			nil,
+			table,
+		),
+		parent,
+	)
+
+	d.Resize(DialogSize(parent))
+	d.Show()
+
+	load(-1, -1)
+}
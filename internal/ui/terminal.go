@@ -0,0 +1,628 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/vt100"
+)
+
+// terminalRefreshInterval is the minimum interval between two redraws of the terminal.
+const terminalRefreshInterval = 30 * time.Millisecond
+
+// terminalColors are the 16 ANSI colors, the rest of the 256 colors palette is computed.
+var terminalColors = []color.Color{
+	color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+	color.NRGBA{R: 0xcd, G: 0x31, B: 0x31, A: 0xff},
+	color.NRGBA{R: 0x0d, G: 0xbc, B: 0x79, A: 0xff},
+	color.NRGBA{R: 0xe5, G: 0xe5, B: 0x10, A: 0xff},
+	color.NRGBA{R: 0x24, G: 0x72, B: 0xc8, A: 0xff},
+	color.NRGBA{R: 0xbc, G: 0x3f, B: 0xbc, A: 0xff},
+	color.NRGBA{R: 0x11, G: 0xa8, B: 0xcd, A: 0xff},
+	color.NRGBA{R: 0xe5, G: 0xe5, B: 0xe5, A: 0xff},
+	color.NRGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff},
+	color.NRGBA{R: 0xf1, G: 0x4c, B: 0x4c, A: 0xff},
+	color.NRGBA{R: 0x23, G: 0xd1, B: 0x8b, A: 0xff},
+	color.NRGBA{R: 0xf5, G: 0xf5, B: 0x43, A: 0xff},
+	color.NRGBA{R: 0x3b, G: 0x8e, B: 0xea, A: 0xff},
+	color.NRGBA{R: 0xd6, G: 0x70, B: 0xd6, A: 0xff},
+	color.NRGBA{R: 0x29, G: 0xb8, B: 0xdb, A: 0xff},
+	color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+}
+
+// terminalColor returns the color of a cell, nil for the default color of the theme.
+func terminalColor(c vt100.Color) color.Color {
+	switch c.Kind {
+	case vt100.ColorRGB:
+		return color.NRGBA{R: c.R, G: c.G, B: c.B, A: 0xff}
+	case vt100.ColorIndexed:
+		switch {
+		case c.Index < 16:
+			return terminalColors[c.Index]
+		case c.Index < 232:
+			// 6x6x6 color cube
+			i := int(c.Index) - 16
+			level := func(v int) uint8 {
+				if v == 0 {
+					return 0
+				}
+
+				return uint8(55 + v*40)
+			}
+
+			return color.NRGBA{R: level(i / 36), G: level(i / 6 % 6), B: level(i % 6), A: 0xff}
+		default:
+			gray := uint8(8 + (int(c.Index)-232)*10)
+			return color.NRGBA{R: gray, G: gray, B: gray, A: 0xff}
+		}
+	}
+
+	return nil
+}
+
+// terminalKeys are the sequences sent for the special keys, the cursor keys depend on the cursor keys mode.
+var terminalKeys = map[fyne.KeyName]string{
+	fyne.KeyReturn:    "\r",
+	fyne.KeyEnter:     "\r",
+	fyne.KeyBackspace: "\x7f",
+	fyne.KeyTab:       "\t",
+	fyne.KeyEscape:    "\x1b",
+	fyne.KeyHome:      "\x1b[H",
+	fyne.KeyEnd:       "\x1b[F",
+	fyne.KeyPageUp:    "\x1b[5~",
+	fyne.KeyPageDown:  "\x1b[6~",
+	fyne.KeyInsert:    "\x1b[2~",
+	fyne.KeyDelete:    "\x1b[3~",
+	fyne.KeyF1:        "\x1bOP",
+	fyne.KeyF2:        "\x1bOQ",
+	fyne.KeyF3:        "\x1bOR",
+	fyne.KeyF4:        "\x1bOS",
+	fyne.KeyF5:        "\x1b[15~",
+	fyne.KeyF6:        "\x1b[17~",
+	fyne.KeyF7:        "\x1b[18~",
+	fyne.KeyF8:        "\x1b[19~",
+	fyne.KeyF9:        "\x1b[20~",
+	fyne.KeyF10:       "\x1b[21~",
+	fyne.KeyF11:       "\x1b[23~",
+	fyne.KeyF12:       "\x1b[24~",
+}
+
+var terminalCursorKeys = map[fyne.KeyName]byte{
+	fyne.KeyUp:    'A',
+	fyne.KeyDown:  'B',
+	fyne.KeyRight: 'C',
+	fyne.KeyLeft:  'D',
+}
+
+// cellPos is the position of a cell in the lines of the scrollback followed by the lines of the screen.
+type cellPos struct {
+	line, col int
+}
+
+func (p cellPos) before(o cellPos) bool {
+	return p.line < o.line || (p.line == o.line && p.col < o.col)
+}
+
+// Terminal is a widget showing the screen of a terminal and sending the typed keys to it.
+// The lines scrolled off the screen are shown with the mouse wheel, and text is selected by dragging.
+type Terminal struct {
+	widget.BaseWidget
+
+	screen    *vt100.Screen
+	grid      *widget.TextGrid
+	clipboard fyne.Clipboard
+
+	// Input is called with the typed keys and the pasted text.
+	Input func([]byte)
+	// OnResize is called when the number of rows or columns changes.
+	OnResize func(rows, cols int)
+	// OnCommand is called with the lines typed in the terminal, when they can be known.
+	OnCommand func(command string)
+
+	mu         sync.Mutex
+	dirty      bool
+	focused    bool
+	offset     int
+	selecting  bool
+	selection  [2]cellPos
+	line       []rune
+	lineKnown  bool
+	styles     map[[2]color.Color]*widget.CustomTextGridStyle
+	stop       chan struct{}
+	closeOnce  sync.Once
+	cellSize   fyne.Size
+	rows, cols int
+}
+
+// NewTerminal creates a terminal widget, Close must be called when it is not used anymore.
+func NewTerminal(parent fyne.Window) *Terminal {
+	t := &Terminal{
+		screen:    vt100.New(24, 80),
+		grid:      widget.NewTextGrid(),
+		clipboard: parent.Clipboard(),
+		lineKnown: true,
+		styles:    make(map[[2]color.Color]*widget.CustomTextGridStyle),
+		stop:      make(chan struct{}),
+	}
+
+	t.screen.OnResponse(func(b []byte) {
+		if t.Input != nil {
+			t.Input(b)
+		}
+	})
+
+	t.ExtendBaseWidget(t)
+	go t.refreshLoop()
+	return t
+}
+
+// Write writes the output of the program to the screen.
+func (t *Terminal) Write(p []byte) (int, error) {
+	n, err := t.screen.Write(p)
+
+	t.mu.Lock()
+	t.dirty = true
+	t.mu.Unlock()
+
+	return n, err
+}
+
+// Close stops redrawing the terminal.
+func (t *Terminal) Close() {
+	t.closeOnce.Do(func() { close(t.stop) })
+}
+
+func (t *Terminal) refreshLoop() {
+	ticker := time.NewTicker(terminalRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			dirty := t.dirty
+			t.dirty = false
+			t.mu.Unlock()
+
+			if dirty {
+				t.redraw()
+			}
+		}
+	}
+}
+
+func (t *Terminal) style(fg, bg color.Color) widget.TextGridStyle {
+	if fg == nil && bg == nil {
+		return nil
+	}
+
+	key := [2]color.Color{fg, bg}
+	style, ok := t.styles[key]
+	if !ok {
+		style = &widget.CustomTextGridStyle{FGColor: fg, BGColor: bg}
+		t.styles[key] = style
+	}
+
+	return style
+}
+
+// lines returns the lines of the scrollback followed by the lines of the screen.
+func (t *Terminal) lines() ([][]vt100.Cell, int) {
+	scrollback := t.screen.Scrollback()
+	return append(scrollback, t.screen.Lines()...), len(scrollback)
+}
+
+func (t *Terminal) redraw() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	scrollback := t.screen.ScrollbackLen()
+	if t.offset > scrollback {
+		t.offset = scrollback
+	}
+
+	view := t.screen.View(t.offset)
+	row, col, visible := t.screen.Cursor()
+
+	first := scrollback - t.offset
+	start, end := t.selection[0], t.selection[1]
+	if end.before(start) {
+		start, end = end, start
+	}
+
+	hasSelection := start != end
+	gridRows := make([]widget.TextGridRow, len(view))
+	for i, line := range view {
+		cells := make([]widget.TextGridCell, len(line))
+		for j, cell := range line {
+			fg, bg := terminalColor(cell.FG), terminalColor(cell.BG)
+
+			pos := cellPos{first + i, j}
+			selected := hasSelection && !pos.before(start) && pos.before(end)
+			cursor := t.focused && visible && t.offset == 0 && i == row && j == col
+			if (cell.Attr&vt100.AttrReverse != 0) != (selected || cursor) {
+				if fg == nil {
+					fg = theme.ForegroundColor()
+				}
+
+				if bg == nil {
+					bg = theme.BackgroundColor()
+				}
+
+				fg, bg = bg, fg
+			}
+
+			r := cell.Rune
+			if r == 0 {
+				r = ' '
+			}
+
+			cells[j] = widget.TextGridCell{Rune: r, Style: t.style(fg, bg)}
+		}
+
+		gridRows[i] = widget.TextGridRow{Cells: cells}
+	}
+
+	t.grid.Rows = gridRows
+	t.grid.Refresh()
+}
+
+// cellAt returns the position of the cell under the point.
+func (t *Terminal) cellAt(pos fyne.Position) cellPos {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	scrollback := t.screen.ScrollbackLen()
+	rows, cols := t.screen.Size()
+
+	row := int(pos.Y / t.cellSize.Height)
+	col := int(pos.X/t.cellSize.Width + 0.5)
+	if row < 0 {
+		row = 0
+	} else if row >= rows {
+		row = rows - 1
+	}
+
+	if col < 0 {
+		col = 0
+	} else if col > cols {
+		col = cols
+	}
+
+	return cellPos{scrollback - t.offset + row, col}
+}
+
+// SelectedText returns the selected text, lines are joined with newlines.
+func (t *Terminal) SelectedText() string {
+	t.mu.Lock()
+	start, end := t.selection[0], t.selection[1]
+	t.mu.Unlock()
+
+	if end.before(start) {
+		start, end = end, start
+	}
+
+	if start == end {
+		return ""
+	}
+
+	all, _ := t.lines()
+	var texts []string
+	for i := start.line; i <= end.line && i < len(all); i++ {
+		line := all[i]
+		from, to := 0, len(line)
+		if i == start.line {
+			from = start.col
+		}
+
+		if i == end.line && end.col < to {
+			to = end.col
+		}
+
+		if from > to {
+			from = to
+		}
+
+		texts = append(texts, vt100.LineText(line[from:to]))
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+// ScreenText returns the text of the screen.
+func (t *Terminal) ScreenText() string {
+	return strings.TrimRight(t.screen.String(), "\n")
+}
+
+// ClearSelection removes the selection.
+func (t *Terminal) ClearSelection() {
+	t.mu.Lock()
+	t.selection = [2]cellPos{}
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// Paste sends the text as if it was typed.
+func (t *Terminal) Paste(text string) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\r"), "\n", "\r")
+	for _, r := range text {
+		t.trackRune(r)
+	}
+
+	t.send([]byte(text))
+}
+
+func (t *Terminal) send(b []byte) {
+	t.mu.Lock()
+	t.offset = 0
+	t.dirty = true
+	t.mu.Unlock()
+
+	if t.Input != nil {
+		t.Input(b)
+	}
+}
+
+// trackRune follows the typed line to report it to OnCommand, it stops when the line is edited with the cursor keys.
+func (t *Terminal) trackRune(r rune) {
+	t.mu.Lock()
+	var command string
+	switch r {
+	case '\r':
+		if t.lineKnown {
+			command = strings.TrimSpace(string(t.line))
+		}
+
+		t.line, t.lineKnown = nil, true
+	case 0x7f:
+		if len(t.line) > 0 {
+			t.line = t.line[:len(t.line)-1]
+		}
+	case 0x03, 0x15:
+		t.line, t.lineKnown = nil, true
+	default:
+		if r < 0x20 {
+			t.lineKnown = false
+		} else {
+			t.line = append(t.line, r)
+		}
+	}
+	t.mu.Unlock()
+
+	if command != "" && t.OnCommand != nil {
+		t.OnCommand(command)
+	}
+}
+
+// FocusGained shows the cursor.
+func (t *Terminal) FocusGained() {
+	t.mu.Lock()
+	t.focused = true
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// FocusLost hides the cursor.
+func (t *Terminal) FocusLost() {
+	t.mu.Lock()
+	t.focused = false
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// TypedRune sends a typed character.
+func (t *Terminal) TypedRune(r rune) {
+	t.trackRune(r)
+	t.send([]byte(string(r)))
+}
+
+// TypedKey sends the sequence of a special key.
+func (t *Terminal) TypedKey(e *fyne.KeyEvent) {
+	if final, ok := terminalCursorKeys[e.Name]; ok {
+		t.mu.Lock()
+		t.lineKnown = false
+		t.mu.Unlock()
+
+		if t.screen.AppCursorKeys() {
+			t.send([]byte{0x1b, 'O', final})
+		} else {
+			t.send([]byte{0x1b, '[', final})
+		}
+
+		return
+	}
+
+	seq, ok := terminalKeys[e.Name]
+	if !ok {
+		return
+	}
+
+	switch e.Name {
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeyBackspace:
+		t.trackRune(rune(seq[0]))
+	default:
+		t.mu.Lock()
+		t.lineKnown = false
+		t.mu.Unlock()
+	}
+
+	t.send([]byte(seq))
+}
+
+// AcceptsTab sends the tab key to the terminal for the completion of the shell.
+func (t *Terminal) AcceptsTab() bool {
+	return true
+}
+
+// TypedShortcut copies the selection with Ctrl+C, or sends it to the terminal if nothing is selected.
+// Ctrl+Shift+C and Ctrl+Shift+V always copy and paste, and the other Ctrl combinations are sent as control characters.
+func (t *Terminal) TypedShortcut(s fyne.Shortcut) {
+	switch s := s.(type) {
+	case *fyne.ShortcutCopy:
+		if text := t.SelectedText(); text != "" {
+			s.Clipboard.SetContent(text)
+			t.ClearSelection()
+			return
+		}
+
+		t.trackRune(0x03)
+		t.send([]byte{0x03})
+	case *fyne.ShortcutPaste:
+		t.Paste(s.Clipboard.Content())
+	case *fyne.ShortcutCut:
+		t.trackRune(0x18)
+		t.send([]byte{0x18})
+	case *fyne.ShortcutSelectAll:
+		t.trackRune(0x01)
+		t.send([]byte{0x01})
+	case *desktop.CustomShortcut:
+		if s.Modifier == desktop.ControlModifier|desktop.ShiftModifier {
+			switch s.KeyName {
+			case fyne.KeyC:
+				t.clipboard.SetContent(t.SelectedText())
+			case fyne.KeyV:
+				t.Paste(t.clipboard.Content())
+			}
+
+			return
+		}
+
+		if s.Modifier == desktop.ControlModifier && len(s.KeyName) == 1 {
+			// Ctrl+A is 0x01 to Ctrl+Z is 0x1a, Ctrl+[ is Escape
+			if c := s.KeyName[0]; c >= 'A' && c <= '_' {
+				t.trackRune(rune(c - '@'))
+				t.send([]byte{c - '@'})
+			}
+		}
+	}
+}
+
+// Tapped focuses the terminal and clears the selection.
+func (t *Terminal) Tapped(*fyne.PointEvent) {
+	t.ClearSelection()
+	if c := fyne.CurrentApp().Driver().CanvasForObject(t); c != nil {
+		c.Focus(t)
+	}
+}
+
+// Dragged selects text, from where the first drag event started.
+func (t *Terminal) Dragged(e *fyne.DragEvent) {
+	start, pos := t.cellAt(e.Position.Subtract(e.Dragged)), t.cellAt(e.Position)
+
+	t.mu.Lock()
+	if !t.selecting {
+		t.selecting = true
+		t.selection[0] = start
+	}
+
+	t.selection[1] = pos
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// DragEnd ends the selection.
+func (t *Terminal) DragEnd() {
+	t.mu.Lock()
+	t.selecting = false
+	t.mu.Unlock()
+}
+
+// Scrolled shows the lines scrolled off the screen.
+func (t *Terminal) Scrolled(e *fyne.ScrollEvent) {
+	lines := int(math.Round(float64(e.Scrolled.DY / t.cellSize.Height)))
+	if lines == 0 {
+		if e.Scrolled.DY > 0 {
+			lines = 1
+		} else if e.Scrolled.DY < 0 {
+			lines = -1
+		}
+	}
+
+	scrollback := t.screen.ScrollbackLen()
+
+	t.mu.Lock()
+	t.offset += lines
+	if t.offset < 0 {
+		t.offset = 0
+	} else if t.offset > scrollback {
+		t.offset = scrollback
+	}
+
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// Cursor shows the text cursor over the terminal.
+func (t *Terminal) Cursor() desktop.Cursor {
+	return desktop.TextCursor
+}
+
+// resize changes the number of rows and columns to fill the size.
+func (t *Terminal) resize(size fyne.Size) {
+	cellSize := fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true})
+	cellSize.Width = float32(math.Round(float64(cellSize.Width)))
+	cellSize.Height = float32(math.Round(float64(cellSize.Height)))
+
+	rows, cols := int(size.Height/cellSize.Height), int(size.Width/cellSize.Width)
+	if rows < 1 {
+		rows = 1
+	}
+
+	if cols < 1 {
+		cols = 1
+	}
+
+	t.mu.Lock()
+	t.cellSize = cellSize
+	changed := rows != t.rows || cols != t.cols
+	t.rows, t.cols = rows, cols
+	t.dirty = true
+	t.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	t.screen.Resize(rows, cols)
+	if t.OnResize != nil {
+		t.OnResize(rows, cols)
+	}
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer.
+func (t *Terminal) CreateRenderer() fyne.WidgetRenderer {
+	return &terminalRenderer{terminal: t}
+}
+
+type terminalRenderer struct {
+	terminal *Terminal
+}
+
+func (r *terminalRenderer) Layout(size fyne.Size) {
+	r.terminal.grid.Resize(size)
+	r.terminal.resize(size)
+}
+
+func (r *terminalRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(200, 100)
+}
+
+func (r *terminalRenderer) Refresh() {
+	r.terminal.redraw()
+}
+
+func (r *terminalRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.terminal.grid}
+}
+
+func (r *terminalRenderer) Destroy() {
+}
@@ -0,0 +1,474 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	fynestorage "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/timeline"
+)
+
+// timelineColors are the colours of the devices on the timeline.
+var timelineColors = []color.Color{
+	color.NRGBA{R: 0x42, G: 0xa5, B: 0xf5, A: 0xff},
+	color.NRGBA{R: 0xff, G: 0xa7, B: 0x26, A: 0xff},
+	color.NRGBA{R: 0x66, G: 0xbb, B: 0x6a, A: 0xff},
+	color.NRGBA{R: 0xef, G: 0x53, B: 0x50, A: 0xff},
+	color.NRGBA{R: 0xab, G: 0x47, B: 0xbc, A: 0xff},
+	color.NRGBA{R: 0x26, G: 0xc6, B: 0xda, A: 0xff},
+}
+
+// timelineColumns are the columns of the timeline table with their widths.
+var timelineColumns = []struct {
+	title string
+	width float32
+}{
+	{"Device", 130},
+	{"Time", 110},
+	{"PID", 55},
+	{"P", 25},
+	{"Tag", 180},
+	{"Message", 1200},
+}
+
+// timelineBuffer is a bounded buffer of timeline entries with a view filtered per device.
+type timelineBuffer struct {
+	mu       sync.RWMutex
+	capacity int
+	entries  []timeline.Entry
+	view     []timeline.Entry
+	filters  map[string]adbclient.LogcatFilter
+}
+
+func newTimelineBuffer(capacity int) *timelineBuffer {
+	return &timelineBuffer{capacity: capacity, filters: make(map[string]adbclient.LogcatFilter)}
+}
+
+func (b *timelineBuffer) match(entry timeline.Entry) bool {
+	return b.filters[entry.Serial].Match(entry.LogcatMessage)
+}
+
+// add adds the entry and returns true if it is visible.
+func (b *timelineBuffer) add(entry timeline.Entry) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, entry)
+	if len(b.entries) > b.capacity {
+		b.entries = b.entries[len(b.entries)-b.capacity:]
+		b.rebuild()
+		return true
+	}
+
+	if b.match(entry) {
+		b.view = append(b.view, entry)
+		return true
+	}
+
+	return false
+}
+
+func (b *timelineBuffer) rebuild() {
+	b.view = b.view[:0]
+	for _, entry := range b.entries {
+		if b.match(entry) {
+			b.view = append(b.view, entry)
+		}
+	}
+}
+
+func (b *timelineBuffer) setFilter(serial string, filter adbclient.LogcatFilter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.filters[serial] = filter
+	b.rebuild()
+}
+
+func (b *timelineBuffer) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = nil
+	b.view = nil
+}
+
+func (b *timelineBuffer) len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.view)
+}
+
+func (b *timelineBuffer) at(i int) (timeline.Entry, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if i < 0 || i >= len(b.view) {
+		return timeline.Entry{}, false
+	}
+
+	return b.view[i], true
+}
+
+// visible returns a copy of the visible entries.
+func (b *timelineBuffer) visible() []timeline.Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return append([]timeline.Entry(nil), b.view...)
+}
+
+// Timeline shows the logs of several devices merged by time, corrected for the clock offsets of the devices.
+func Timeline(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
+	devices, err := client.GetOnlineDevices()
+	if err != nil {
+		GetApp().ShowError(err, nil, parent)
+		return
+	}
+
+	buffer := newTimelineBuffer(DefaultLogViewerCapacity)
+	deviceColors := make(map[string]color.Color)
+
+	table := widget.NewTable(
+		func() (int, int) {
+			return buffer.len() + 1, len(timelineColumns)
+		},
+		func() fyne.CanvasObject {
+			text := canvas.NewText("", theme.ForegroundColor())
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			return text
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			text := o.(*canvas.Text)
+
+			// the first row is the header
+			if id.Row == 0 {
+				text.Text = timelineColumns[id.Col].title
+				text.Color = theme.ForegroundColor()
+				text.TextStyle.Bold = true
+				text.Refresh()
+				return
+			}
+
+			text.TextStyle.Bold = false
+			entry, ok := buffer.at(id.Row - 1)
+			if !ok {
+				text.Text = ""
+				text.Refresh()
+				return
+			}
+
+			text.Color = deviceColors[entry.Serial]
+			switch id.Col {
+			case 0:
+				text.Text = entry.Serial
+			case 1:
+				text.Text = entry.Time.Format("15:04:05.000")
+			case 2:
+				text.Text = strconv.Itoa(entry.ProcessID)
+			case 3:
+				text.Text = entry.Priority.String()
+				text.Color = logcatPriorityColors[entry.Priority]
+			case 4:
+				text.Text = entry.Tag
+			case 5:
+				text.Text = entry.Message
+			}
+
+			text.Refresh()
+		},
+	)
+
+	for i, column := range timelineColumns {
+		table.SetColumnWidth(i, column.width)
+	}
+
+	statusLabel := widget.NewLabel("")
+
+	table.OnSelected = func(id widget.TableCellID) {
+		table.Unselect(id)
+
+		entry, ok := buffer.at(id.Row - 1)
+		if !ok {
+			return
+		}
+
+		parent.Clipboard().SetContent(entry.Format(adbclient.FormatThreadtime))
+		statusLabel.SetText("Copied to clipboard")
+	}
+
+	var priorities []string
+	for priority := adbclient.Verbose; priority <= adbclient.Fatal; priority++ {
+		priorities = append(priorities, priority.String())
+	}
+
+	// a row for each device: selection, colour and filters
+	checks := make(map[string]*widget.Check)
+	var rows []fyne.CanvasObject
+	for i, d := range devices {
+		serial := d.Serial
+		deviceColors[serial] = timelineColors[i%len(timelineColors)]
+
+		swatch := canvas.NewRectangle(deviceColors[serial])
+		swatch.SetMinSize(fyne.NewSize(16, 16))
+
+		check := widget.NewCheck(d.String(), nil)
+		check.SetChecked(serial == device.Serial)
+		checks[serial] = check
+
+		tagEntry := widget.NewEntry()
+		tagEntry.SetPlaceHolder("Tag")
+
+		searchEntry := widget.NewEntry()
+		searchEntry.SetPlaceHolder("Search")
+
+		prioritySelect := widget.NewSelect(priorities, nil)
+		prioritySelect.SetSelected(adbclient.Verbose.String())
+
+		applyFilter := func() {
+			filter := adbclient.LogcatFilter{Tag: tagEntry.Text, Search: searchEntry.Text}
+			filter.Priority, _ = adbclient.ParseLogcatPriority(prioritySelect.Selected)
+
+			buffer.setFilter(serial, filter)
+			table.Refresh()
+		}
+
+		tagEntry.OnChanged = func(string) { applyFilter() }
+		searchEntry.OnChanged = func(string) { applyFilter() }
+		prioritySelect.OnChanged = func(string) { applyFilter() }
+
+		rows = append(rows, container.NewBorder(
+			nil,
+			nil,
+			container.NewHBox(swatch, check),
+			nil,
+			container.NewGridWithColumns(3, prioritySelect, tagEntry, searchEntry),
+		))
+	}
+
+	sinceEntry := widget.NewEntry()
+	sinceEntry.SetPlaceHolder("Since, e.g. 5m, new messages only if empty")
+	sinceEntry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+
+		_, err := time.ParseDuration(s)
+		return err
+	}
+
+	var mu sync.Mutex
+	var cancel context.CancelFunc
+	var watchers []*adbclient.LogcatWatcher
+
+	stop := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+			cancel = nil
+		}
+
+		for _, watcher := range watchers {
+			watcher.Close()
+		}
+
+		watchers = nil
+	}
+
+	var startButton, stopButton *widget.Button
+
+	start := func() {
+		var since time.Duration
+		if sinceEntry.Text != "" {
+			var err error
+			if since, err = time.ParseDuration(sinceEntry.Text); err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+		}
+
+		var sources []timeline.Source
+		var offsets []string
+		var started []*adbclient.LogcatWatcher
+
+		fail := func(err error) {
+			for _, watcher := range started {
+				watcher.Close()
+			}
+
+			GetApp().ShowError(err, nil, parent)
+		}
+
+		ctx, cancelFunc := context.WithCancel(context.Background())
+		for _, d := range devices {
+			if !checks[d.Serial].Checked {
+				continue
+			}
+
+			offset, err := client.ClockOffset(d)
+			if err != nil {
+				cancelFunc()
+				fail(fmt.Errorf("%s: %w", d.Serial, err))
+				return
+			}
+
+			// the since time is in the clock of the device
+			opts := []adbclient.LogcatOption{adbclient.WithLogcatTail(1)}
+			if since > 0 {
+				opts = []adbclient.LogcatOption{adbclient.WithLogcatSince(time.Now().Add(-since).Add(offset))}
+			}
+
+			watcher, err := client.Logcat(d, opts...)
+			if err != nil {
+				cancelFunc()
+				fail(fmt.Errorf("%s: %w", d.Serial, err))
+				return
+			}
+
+			started = append(started, watcher)
+			sources = append(sources, timeline.Source{Serial: d.Serial, Offset: offset, Messages: watcher.C(ctx)})
+			offsets = append(offsets, fmt.Sprintf("%s %+.3fs", d.Serial, offset.Seconds()))
+		}
+
+		if len(sources) == 0 {
+			cancelFunc()
+			GetApp().ShowError(fmt.Errorf("no device selected"), nil, parent)
+			return
+		}
+
+		mu.Lock()
+		cancel = cancelFunc
+		watchers = started
+		mu.Unlock()
+
+		buffer.clear()
+		table.Refresh()
+		statusLabel.SetText("Clock offsets: " + strings.Join(offsets, ", "))
+
+		startButton.Disable()
+		stopButton.Enable()
+
+		var changedMu sync.Mutex
+		changed := false
+
+		go func() {
+			for entry := range timeline.Merge(ctx, timeline.DefaultWindow, sources...) {
+				if buffer.add(entry) {
+					changedMu.Lock()
+					changed = true
+					changedMu.Unlock()
+				}
+			}
+		}()
+
+		// refresh the table periodically instead of on every message
+		go func() {
+			ticker := time.NewTicker(logViewerRefreshInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					changedMu.Lock()
+					refresh := changed
+					changed = false
+					changedMu.Unlock()
+
+					if refresh {
+						table.Refresh()
+						table.ScrollToBottom()
+					}
+				}
+			}
+		}()
+	}
+
+	startButton = widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), start)
+	stopButton = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), func() {
+		stop()
+		startButton.Enable()
+		stopButton.Disable()
+	})
+	stopButton.Disable()
+
+	exportButton := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		entries := buffer.visible()
+		if len(entries) == 0 {
+			return
+		}
+
+		fsaveDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+			if err != nil || file == nil {
+				return
+			}
+
+			path := file.URI().Path()
+			file.Close()
+
+			f, err := os.Create(path)
+			if err != nil {
+				GetApp().ShowError(err, nil, parent)
+				return
+			}
+
+			defer f.Close()
+
+			if err := timeline.WriteText(f, entries, adbclient.FormatThreadtime); err != nil {
+				GetApp().ShowError(err, nil, parent)
+			}
+		}, parent)
+
+		fsaveDialog.SetFileName("timeline.log")
+		fsaveDialog.SetFilter(fynestorage.NewExtensionFileFilter([]string{".log", ".txt"}))
+		fsaveDialog.Resize(DialogSize(parent))
+		fsaveDialog.Show()
+	})
+
+	clearButton := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), func() {
+		buffer.clear()
+		table.Refresh()
+	})
+
+	d := dialog.NewCustom(
+		"Timeline",
+		"Close",
+		container.NewBorder(
+			container.NewVBox(
+				container.NewVBox(rows...),
+				container.NewBorder(nil, nil, nil, container.NewHBox(startButton, stopButton), sinceEntry),
+			),
+			container.NewBorder(
+				nil,
+				nil,
+				statusLabel,
+				container.NewHBox(exportButton, clearButton),
+			),
+			nil,
+			nil,
+			table,
+		),
+		parent,
+	)
+
+	d.SetOnClosed(stop)
+	d.Resize(DialogSize(parent))
+	d.Show()
+}
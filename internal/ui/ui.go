@@ -3,6 +3,7 @@ package ui
 import (
 	"context"
 	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -19,11 +20,15 @@ import (
 	"github.com/johnnyipcom/androidtool/pkg/adbclient"
 	"github.com/johnnyipcom/androidtool/pkg/logger"
 	"github.com/johnnyipcom/androidtool/pkg/logger/logrus"
+	"github.com/johnnyipcom/androidtool/pkg/retrace"
 )
 
 const (
 	// DefaultLogPath is the default log file path.
 	DefaultLogPath = "./androidtool.log"
+
+	// DefaultLogcatRetentionDays is the default retention of the logcat archive in days.
+	DefaultLogcatRetentionDays = int(storage.DefaultLogcatRetention / (24 * time.Hour))
 )
 
 var (
@@ -39,6 +44,8 @@ type App struct {
 	adbClient *adbclient.Client
 	aabClient *aabclient.Client
 	aapt      *aapt.AAPT
+	mappings  *retrace.Registry
+	archiver  *storage.LogcatArchiver
 	log       logger.Logger
 }
 
@@ -49,8 +56,9 @@ func GetApp() *App {
 
 		w := a.NewWindow("Android tool")
 		instance = &App{
-			app:    a,
-			window: w,
+			app:      a,
+			window:   w,
+			mappings: retrace.NewRegistry(),
 		}
 	})
 
@@ -114,6 +122,8 @@ func (a *App) setupUI() fyne.CanvasObject {
 		log.Fatal(err)
 	}
 
+	a.adbClient.SetLeases(a.storage, prefs.StringWithFallback("lease_owner", adbclient.DefaultLeaseOwner()))
+
 	bundletoolVersion := prefs.StringWithFallback("bundletool_version", aabclient.BundleToolDefaultVersion)
 	a.aabClient, err = aabclient.NewClient(bundletoolVersion, log)
 	if err != nil {
@@ -137,8 +147,20 @@ func (a *App) setupUI() fyne.CanvasObject {
 		log.Fatal(err)
 	}
 
+	if prefs.BoolWithFallback("logcat_archive", true) {
+		retention := time.Duration(prefs.IntWithFallback("logcat_retention_days", DefaultLogcatRetentionDays)) * 24 * time.Hour
+		a.archiver, err = storage.NewLogcatArchiver(a.adbClient, a.storage, storage.WithRetention(retention))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	a.window.SetOnClosed(func() {
 		cancel()
+		if a.archiver != nil {
+			a.archiver.Close()
+		}
+
 		a.adbClient.Stop()
 		a.aabClient.Stop()
 		a.storage.Close()
@@ -147,6 +169,7 @@ func (a *App) setupUI() fyne.CanvasObject {
 	return &container.AppTabs{Items: []*container.TabItem{
 		uiMain(a.app, a.window, a.adbClient, a.aabClient, a.storage).tabItem(),
 		uiBuilds(a.app, a.window, a.aabClient, a.aapt).tabItem(),
+		uiShell(a.window, a.adbClient, a.storage).tabItem(),
 		uiSettings(a.app, a.window, a.adbClient, a.aabClient, a.storage, a.log).tabItem(),
 		uiAbout(a.adbClient).tabItem(),
 	}}
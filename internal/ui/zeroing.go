@@ -3,7 +3,6 @@ package ui
 import (
 	"context"
 	"fmt"
-	"io"
 	"path/filepath"
 	"strconv"
 
@@ -16,18 +15,6 @@ import (
 	"github.com/johnnyipcom/androidtool/pkg/adbclient"
 )
 
-// ZeroReader is a reader that reads zeros.
-type zeroReader struct {
-}
-
-func (r *zeroReader) Read(p []byte) (int, error) {
-	for i := range p {
-		p[i] = 0
-	}
-
-	return len(p), nil
-}
-
 func Zeroing(client *adbclient.Client, device *adbclient.Device, parent fyne.Window) {
 	zeroingPathEntry := widget.NewEntry()
 	zeroingPathEntry.SetText("/sdcard/zeroing%04d.dat")
@@ -94,8 +81,7 @@ func Zeroing(client *adbclient.Client, device *adbclient.Device, parent fyne.Win
 			}
 
 			if limit != 0 {
-				reader := io.LimitReader(&zeroReader{}, int64(limit))
-				if err := client.Upload(ctx, device, reader, limit, path, zeroingProgress.WithUploadProgress()); err != nil {
+				if err := client.Upload(ctx, device, adbclient.NewZeroReader(int64(limit)), limit, path, zeroingProgress.WithUploadProgress()); err != nil {
 					GetApp().ShowError(err, d.Hide, parent)
 					return
 				}
@@ -0,0 +1,371 @@
+package adbclient
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+const (
+	// TombstonesPath is the directory of native crash tombstones.
+	TombstonesPath = "/data/tombstones"
+	// ANRTracesPath is the directory of ANR stack traces.
+	ANRTracesPath = "/data/anr"
+
+	// dropBoxSeparator separates the entries of 'dumpsys dropbox --print'.
+	dropBoxSeparator = "========================================"
+)
+
+// DropBoxTags are the dropbox tags collected by CollectCrashArtifacts.
+var DropBoxTags = []string{"data_app_crash", "data_app_anr", "SYSTEM_TOMBSTONE"}
+
+// CrashArtifactKind is the kind of a crash artifact.
+type CrashArtifactKind int
+
+const (
+	// ArtifactTombstone is a native crash tombstone from /data/tombstones.
+	ArtifactTombstone CrashArtifactKind = iota
+	// ArtifactANRTrace is a stack trace dump from /data/anr.
+	ArtifactANRTrace
+	// ArtifactDropBox is an entry of the dropbox.
+	ArtifactDropBox
+)
+
+func (k CrashArtifactKind) String() string {
+	switch k {
+	case ArtifactTombstone:
+		return "tombstone"
+	case ArtifactANRTrace:
+		return "anr"
+	case ArtifactDropBox:
+		return "dropbox"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k CrashArtifactKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// CrashArtifact is a single tombstone, ANR trace or dropbox entry.
+type CrashArtifact struct {
+	Kind CrashArtifactKind `json:"kind"`
+	// Source is the path on the device or the dropbox tag.
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+	Process string    `json:"process,omitempty"`
+	PID     int       `json:"pid,omitempty"`
+	// Name is the file name in the zip.
+	Name    string `json:"name"`
+	Content []byte `json:"-"`
+}
+
+// CrashArtifacts are the artifacts collected from a device.
+type CrashArtifacts struct {
+	Serial    string          `json:"serial"`
+	Package   string          `json:"package,omitempty"`
+	Since     time.Time       `json:"since"`
+	Collected time.Time       `json:"collected"`
+	Artifacts []CrashArtifact `json:"artifacts"`
+	// Skipped are the sources that could not be read, e.g. because of permissions.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// WriteZip writes the artifacts and a manifest.json describing them as a zip.
+func (a *CrashArtifacts) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	manifest, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	add := func(name string, modified time.Time, content []byte) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+
+		_, err = fw.Write(content)
+		return err
+	}
+
+	if err := add("manifest.json", a.Collected, manifest); err != nil {
+		return err
+	}
+
+	for _, artifact := range a.Artifacts {
+		if err := add(artifact.Name, artifact.Time, artifact.Content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// SaveZip saves the artifacts as a zip file.
+func (a *CrashArtifacts) SaveZip(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if err := a.WriteZip(file); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+var (
+	// pid: 1234, tid: 1240, name: RenderThread  >>> com.example <<<
+	tombstoneProcessRegex = regexp.MustCompile(`(?m)^pid: (\d+), tid: \d+, name: .*>>> (.+?) <<<`)
+	// Timestamp: 2023-11-14 22:13:20.123456789+0100
+	tombstoneTimeRegex = regexp.MustCompile(`(?m)^Timestamp: (\S+ \S+)$`)
+	// ----- pid 1234 at 2023-11-14 22:13:20.123456789+0100 -----
+	tracePidRegex = regexp.MustCompile(`(?m)^----- pid (\d+) at (\S+ \S+) -----$`)
+	// Cmd line: com.example
+	traceCmdLineRegex = regexp.MustCompile(`(?m)^Cmd line: (\S+)`)
+	// 2023-11-14 22:13:20 data_app_crash (text, 1234 bytes)
+	dropBoxHeaderRegex = regexp.MustCompile(`^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) (\S+)`)
+	// Process: com.example
+	dropBoxProcessRegex = regexp.MustCompile(`(?m)^Process: (\S+)`)
+	// PID: 1234
+	dropBoxPIDRegex = regexp.MustCompile(`(?m)^PID: (\d+)`)
+)
+
+// parseDeviceTime parses a time with an optional fraction and numeric zone, e.g. 2023-11-14 22:13:20.123+0100.
+func parseDeviceTime(s string) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02 15:04:05.999999999-0700", s)
+	return t, err == nil
+}
+
+// parseTombstone fills the artifact from the content of a tombstone.
+func parseTombstone(artifact *CrashArtifact) {
+	content := string(artifact.Content)
+
+	if match := tombstoneProcessRegex.FindStringSubmatch(content); match != nil {
+		artifact.PID, _ = strconv.Atoi(match[1])
+		artifact.Process = match[2]
+	}
+
+	if match := tombstoneTimeRegex.FindStringSubmatch(content); match != nil {
+		if t, ok := parseDeviceTime(match[1]); ok {
+			artifact.Time = t
+		}
+	}
+}
+
+// parseANRTrace fills the artifact from the content of an ANR trace, the first process is the one not responding.
+func parseANRTrace(artifact *CrashArtifact) {
+	content := string(artifact.Content)
+
+	if match := tracePidRegex.FindStringSubmatch(content); match != nil {
+		artifact.PID, _ = strconv.Atoi(match[1])
+		if t, ok := parseDeviceTime(match[2]); ok {
+			artifact.Time = t
+		}
+	}
+
+	if match := traceCmdLineRegex.FindStringSubmatch(content); match != nil {
+		artifact.Process = match[1]
+	}
+}
+
+// parseDropBox parses the output of 'dumpsys dropbox --print'. Times are in the zone of the device.
+func parseDropBox(out string, zone *time.Location) []CrashArtifact {
+	var artifacts []CrashArtifact
+
+	for _, entry := range strings.Split(out, dropBoxSeparator) {
+		entry = strings.Trim(entry, "\r\n")
+
+		header, body, _ := strings.Cut(entry, "\n")
+		match := dropBoxHeaderRegex.FindStringSubmatch(strings.TrimSpace(header))
+		if match == nil {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", match[1], zone)
+		if err != nil {
+			continue
+		}
+
+		artifact := CrashArtifact{
+			Kind:    ArtifactDropBox,
+			Source:  match[2],
+			Time:    t,
+			Content: []byte(body),
+		}
+
+		if match := dropBoxProcessRegex.FindStringSubmatch(body); match != nil {
+			artifact.Process = match[1]
+			if match := dropBoxPIDRegex.FindStringSubmatch(body); match != nil {
+				artifact.PID, _ = strconv.Atoi(match[1])
+			}
+		} else if match := tombstoneProcessRegex.FindStringSubmatch(body); match != nil {
+			// SYSTEM_TOMBSTONE entries are tombstones
+			artifact.PID, _ = strconv.Atoi(match[1])
+			artifact.Process = match[2]
+		}
+
+		artifacts = append(artifacts, artifact)
+	}
+
+	return artifacts
+}
+
+// matchesPackage returns true if the process belongs to the package, e.g. com.example:remote to com.example.
+func matchesPackage(process string, pkg string) bool {
+	return pkg == "" || process == pkg || strings.HasPrefix(process, pkg+":")
+}
+
+// deviceZone returns the time zone offset of the device.
+func (c *Client) deviceZone(device *Device) *time.Location {
+	out, err := c.runCommand(device, "date", "+%z")
+	if err != nil {
+		return time.Local
+	}
+
+	t, err := time.Parse("-0700", strings.TrimSpace(string(out)))
+	if err != nil {
+		return time.Local
+	}
+
+	return t.Location()
+}
+
+// readDeviceFile reads a whole file from the device.
+func readDeviceFile(d *adb.Device, path string) ([]byte, error) {
+	r, err := d.OpenRead(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+	return io.ReadAll(bufio.NewReader(r))
+}
+
+// collectDir collects the files of a directory modified since the time.
+// The sync service returns an empty directory instead of an error when it isn't readable, so ls is checked too.
+func (c *Client) collectDir(ctx context.Context, device *Device, dir string, kind CrashArtifactKind, since time.Time, artifacts *CrashArtifacts) {
+	d := c.adb.Device(adb.DeviceWithSerial(device.Serial))
+
+	entries, err := d.ListDirEntries(dir)
+	if err != nil {
+		artifacts.Skipped = append(artifacts.Skipped, fmt.Sprintf("%s: %s", dir, err))
+		return
+	}
+
+	list, err := entries.ReadAll()
+	if err != nil {
+		artifacts.Skipped = append(artifacts.Skipped, fmt.Sprintf("%s: %s", dir, err))
+		return
+	}
+
+	if len(list) == 0 {
+		if out, err := c.runCommand(device, "ls", dir); err == nil && strings.Contains(string(out), "Permission denied") {
+			artifacts.Skipped = append(artifacts.Skipped, fmt.Sprintf("%s: permission denied", dir))
+		}
+
+		return
+	}
+
+	for _, entry := range list {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Android 12+ writes a protobuf copy of each tombstone next to the text one
+		if entry.Mode.IsDir() || strings.HasSuffix(entry.Name, ".pb") || entry.ModifiedAt.Before(since) {
+			continue
+		}
+
+		src := path.Join(dir, entry.Name)
+		content, err := readDeviceFile(d, src)
+		if err != nil {
+			artifacts.Skipped = append(artifacts.Skipped, fmt.Sprintf("%s: %s", src, err))
+			continue
+		}
+
+		artifact := CrashArtifact{
+			Kind:    kind,
+			Source:  src,
+			Time:    entry.ModifiedAt,
+			Content: content,
+		}
+
+		if kind == ArtifactTombstone {
+			parseTombstone(&artifact)
+		} else {
+			parseANRTrace(&artifact)
+		}
+
+		if !matchesPackage(artifact.Process, artifacts.Package) {
+			continue
+		}
+
+		artifact.Name = path.Join(kind.String(), entry.Name)
+		artifacts.Artifacts = append(artifacts.Artifacts, artifact)
+	}
+}
+
+// CollectCrashArtifacts collects the tombstones, ANR traces and dropbox crash entries since the time.
+// If pkg is not empty only the artifacts of its processes are collected.
+// Sources that can't be read, e.g. /data/tombstones on user builds, are listed in Skipped.
+func (c *Client) CollectCrashArtifacts(ctx context.Context, device *Device, pkg string, since time.Time) (*CrashArtifacts, error) {
+	c.log.Infof("Collecting crash artifacts of %s since %s...", device.Serial, since)
+
+	artifacts := &CrashArtifacts{
+		Serial:    device.Serial,
+		Package:   pkg,
+		Since:     since,
+		Collected: time.Now(),
+	}
+
+	c.collectDir(ctx, device, TombstonesPath, ArtifactTombstone, since, artifacts)
+	c.collectDir(ctx, device, ANRTracesPath, ArtifactANRTrace, since, artifacts)
+
+	zone := c.deviceZone(device)
+	for _, tag := range DropBoxTags {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// dumpsys dropbox matches all of its arguments, so each tag is a separate call
+		out, err := c.runCommand(device, "dumpsys", "dropbox", "--print", tag)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, artifact := range parseDropBox(string(out), zone) {
+			if artifact.Time.Before(since) || !matchesPackage(artifact.Process, pkg) {
+				continue
+			}
+
+			artifact.Name = path.Join(ArtifactDropBox.String(), fmt.Sprintf("%s_%s_%d.txt", artifact.Source, artifact.Time.Format("20060102-150405"), i))
+			artifacts.Artifacts = append(artifacts.Artifacts, artifact)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.log.Infof("Collected %d crash artifacts, skipped %d sources", len(artifacts.Artifacts), len(artifacts.Skipped))
+	return artifacts, nil
+}
@@ -0,0 +1,139 @@
+package adbclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+const testTombstone = `*** *** *** *** *** *** *** *** *** *** *** *** *** *** *** ***
+Build fingerprint: 'google/sdk_gphone64_x86_64/emu64xa:13/TE1A.220922.012/9302419:userdebug/dev-keys'
+Revision: '0'
+ABI: 'x86_64'
+Timestamp: 2023-11-14 22:13:20.123456789+0100
+Process uptime: 12s
+Cmdline: com.example
+pid: 4321, tid: 4330, name: RenderThread  >>> com.example <<<
+uid: 10123
+signal 6 (SIGABRT), code -1 (SI_QUEUE), fault addr --------
+`
+
+const testTrace = `
+----- pid 4321 at 2023-11-14 22:13:20.5+0100 -----
+Cmd line: com.example:remote
+Build fingerprint: 'google/sdk_gphone64_x86_64/emu64xa:13/TE1A.220922.012/9302419:userdebug/dev-keys'
+`
+
+const testDropBox = `Drop box contents: 3 entries
+Max entries: 1000
+Searching for: data_app_crash
+
+========================================
+2023-11-14 22:13:20 data_app_crash (text, 1234 bytes)
+Process: com.example
+PID: 4321
+Flags: 0x38c8be46
+Package: com.example v1 (1.0)
+
+java.lang.RuntimeException: boom
+	at com.example.Main.onCreate(Main.java:10)
+
+========================================
+2023-11-14 23:00:00 SYSTEM_TOMBSTONE (compressed text, 2345 bytes)
+pid: 555, tid: 555, name: example  >>> com.other <<<
+
+========================================
+garbage
+`
+
+func TestParseCrashArtifacts(t *testing.T) {
+	zone := time.FixedZone("", 3600)
+	expectedTime := time.Date(2023, 11, 14, 22, 13, 20, 123456789, zone)
+
+	tombstone := CrashArtifact{Content: []byte(testTombstone)}
+	parseTombstone(&tombstone)
+	if tombstone.PID != 4321 || tombstone.Process != "com.example" || !tombstone.Time.Equal(expectedTime) {
+		t.Errorf("unexpected tombstone: %+v", tombstone)
+	}
+
+	trace := CrashArtifact{Content: []byte(testTrace)}
+	parseANRTrace(&trace)
+	if trace.PID != 4321 || trace.Process != "com.example:remote" || !trace.Time.Equal(time.Date(2023, 11, 14, 22, 13, 20, 5e8, zone)) {
+		t.Errorf("unexpected trace: %+v", trace)
+	}
+
+	entries := parseDropBox(testDropBox, zone)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 dropbox entries, got %d", len(entries))
+	}
+
+	if entries[0].Source != "data_app_crash" || entries[0].Process != "com.example" || entries[0].PID != 4321 || !entries[0].Time.Equal(time.Date(2023, 11, 14, 22, 13, 20, 0, zone)) {
+		t.Errorf("unexpected dropbox entry: %+v", entries[0])
+	}
+
+	if !bytes.Contains(entries[0].Content, []byte("java.lang.RuntimeException: boom")) {
+		t.Errorf("unexpected dropbox content: %q", entries[0].Content)
+	}
+
+	if entries[1].Source != "SYSTEM_TOMBSTONE" || entries[1].Process != "com.other" || entries[1].PID != 555 {
+		t.Errorf("unexpected dropbox entry: %+v", entries[1])
+	}
+
+	for process, expected := range map[string]bool{"com.example": true, "com.example:remote": true, "com.example2": false} {
+		if matchesPackage(process, "com.example") != expected {
+			t.Errorf("%s: expected %t", process, expected)
+		}
+	}
+}
+
+func TestCrashArtifactsWriteZip(t *testing.T) {
+	artifacts := CrashArtifacts{
+		Serial: "serial",
+		Artifacts: []CrashArtifact{
+			{Kind: ArtifactTombstone, Name: "tombstone/tombstone_00", Content: []byte(testTombstone)},
+		},
+		Skipped: []string{"/data/anr: permission denied"},
+	}
+
+	var buf bytes.Buffer
+	if err := artifacts.WriteZip(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+
+	if string(files["tombstone/tombstone_00"]) != testTombstone {
+		t.Errorf("unexpected tombstone in zip")
+	}
+
+	var manifest struct {
+		Serial    string
+		Artifacts []struct{ Kind, Name string }
+		Skipped   []string
+	}
+
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if manifest.Serial != "serial" || len(manifest.Artifacts) != 1 || manifest.Artifacts[0].Kind != "tombstone" || len(manifest.Skipped) != 1 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+}
@@ -0,0 +1,221 @@
+package adbclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultBenchmarkSize is the default number of bytes pushed and pulled by Benchmark.
+	DefaultBenchmarkSize = 64 * 1024 * 1024
+
+	// DefaultBenchmarkProbes is the default number of commands run by Benchmark to measure the latency.
+	DefaultBenchmarkProbes = 10
+)
+
+// zeroReader is a reader that reads zeros.
+type zeroReader struct{}
+
+func (r zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
+
+// NewZeroReader returns a reader of n zeros, to upload data without reading a file.
+func NewZeroReader(n int64) io.Reader {
+	return io.LimitReader(zeroReader{}, n)
+}
+
+// BenchmarkResult is the result of a transfer benchmark of a device.
+type BenchmarkResult struct {
+	Serial string    `json:"serial"`
+	USB    string    `json:"usb"`
+	Time   time.Time `json:"time"`
+	// Size is the number of bytes pushed and pulled.
+	Size int64         `json:"size"`
+	Push time.Duration `json:"push"`
+	Pull time.Duration `json:"pull"`
+	// Latency is the median round trip time of an empty shell command.
+	Latency time.Duration `json:"latency"`
+}
+
+// throughput returns the bytes per second of a transfer.
+func throughput(size int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(size) / d.Seconds()
+}
+
+// PushThroughput returns the push throughput in bytes per second.
+func (r BenchmarkResult) PushThroughput() float64 {
+	return throughput(r.Size, r.Push)
+}
+
+// PullThroughput returns the pull throughput in bytes per second.
+func (r BenchmarkResult) PullThroughput() float64 {
+	return throughput(r.Size, r.Pull)
+}
+
+func (r BenchmarkResult) String() string {
+	return fmt.Sprintf("push %.1f MB/s, pull %.1f MB/s, latency %s",
+		r.PushThroughput()/1e6, r.PullThroughput()/1e6, r.Latency.Round(time.Microsecond*100))
+}
+
+// median returns the median of the durations, zero if there are none.
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	if n := len(sorted); n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	return sorted[len(sorted)/2]
+}
+
+type benchmarkOptions struct {
+	size         int64
+	probes       int
+	progressFunc progressFunc
+}
+
+// BenchmarkOption is an option for the transfer benchmark.
+type BenchmarkOption interface {
+	apply(*benchmarkOptions) error
+}
+
+type sizeBenchmarkOption struct {
+	size int64
+}
+
+func (o sizeBenchmarkOption) apply(opts *benchmarkOptions) error {
+	if o.size <= 0 {
+		return fmt.Errorf("invalid benchmark size: %d", o.size)
+	}
+
+	opts.size = o.size
+	return nil
+}
+
+// WithBenchmarkSize sets the number of bytes pushed and pulled.
+func WithBenchmarkSize(size int64) BenchmarkOption {
+	return sizeBenchmarkOption{size}
+}
+
+type probesBenchmarkOption struct {
+	probes int
+}
+
+func (o probesBenchmarkOption) apply(opts *benchmarkOptions) error {
+	if o.probes < 1 {
+		return fmt.Errorf("invalid number of latency probes: %d", o.probes)
+	}
+
+	opts.probes = o.probes
+	return nil
+}
+
+// WithBenchmarkProbes sets the number of commands run to measure the latency.
+func WithBenchmarkProbes(n int) BenchmarkOption {
+	return probesBenchmarkOption{n}
+}
+
+type progressBenchmarkOption struct {
+	progressFunc progressFunc
+}
+
+func (o progressBenchmarkOption) apply(opts *benchmarkOptions) error {
+	opts.progressFunc = o.progressFunc
+	return nil
+}
+
+// WithBenchmarkProgress sets a progress function, called with the bytes pushed and pulled of twice the size.
+func WithBenchmarkProgress(f func(sentBytes int64, totalBytes int64)) BenchmarkOption {
+	return progressBenchmarkOption{f}
+}
+
+// Benchmark measures the push and pull throughput and the latency of the device.
+// Zeros are pushed to a temporary file and pulled back without writing them, so the disk of the computer doesn't slow them down.
+func (c *Client) Benchmark(ctx context.Context, device *Device, opts ...BenchmarkOption) (*BenchmarkResult, error) {
+	options := benchmarkOptions{size: DefaultBenchmarkSize, probes: DefaultBenchmarkProbes}
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	c.log.Infof("Benchmarking %s with %d bytes...", device.Serial, options.size)
+
+	result := &BenchmarkResult{Serial: device.Serial, USB: device.USB, Time: time.Now(), Size: options.size}
+
+	var done int64
+	progress := func(n int64) {
+		done += n
+		if options.progressFunc != nil {
+			options.progressFunc(done, 2*options.size)
+		}
+	}
+
+	probes := make([]time.Duration, 0, options.probes)
+	for i := 0; i < options.probes; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		if _, err := c.runCommand(device, "true"); err != nil {
+			return nil, err
+		}
+
+		probes = append(probes, time.Since(start))
+	}
+
+	result.Latency = median(probes)
+
+	tmp := path.Join(tempDir, fmt.Sprintf("benchmark_%d.dat", time.Now().UnixNano()))
+	defer c.RemoveFile(device, tmp)
+
+	start := time.Now()
+	if err := c.Upload(ctx, device, NewZeroReader(options.size), uint64(options.size), tmp, WithUploadProgress(func(sentBytes, totalBytes int64) {
+		progress(sentBytes - done)
+	})); err != nil {
+		return nil, err
+	}
+
+	result.Push = time.Since(start)
+
+	r, err := c.openExecStream(device, "cat "+shellQuote(tmp))
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	start = time.Now()
+	n, err := copyContext(ctx, io.Discard, r, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Pull = time.Since(start)
+
+	if n != options.size {
+		return nil, fmt.Errorf("pulled %d bytes, expected %d", n, options.size)
+	}
+
+	c.log.Infof("Benchmark of %s: %s", device.Serial, result)
+	return result, nil
+}
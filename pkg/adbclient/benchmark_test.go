@@ -0,0 +1,55 @@
+package adbclient
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+	for _, test := range []struct {
+		durations []time.Duration
+		expected  time.Duration
+	}{
+		{nil, 0},
+		{[]time.Duration{3, 1, 2}, 2},
+		{[]time.Duration{4, 1, 3, 2}, 2},
+		{[]time.Duration{5}, 5},
+	} {
+		if actual := median(test.durations); actual != test.expected {
+			t.Errorf("median(%v): expected %d, got %d", test.durations, test.expected, actual)
+		}
+	}
+}
+
+func TestBenchmarkResult(t *testing.T) {
+	result := BenchmarkResult{Size: 64 * 1000 * 1000, Push: 2 * time.Second, Pull: time.Second, Latency: 1500 * time.Microsecond}
+	if result.PushThroughput() != 32e6 || result.PullThroughput() != 64e6 {
+		t.Errorf("unexpected throughput: %f, %f", result.PushThroughput(), result.PullThroughput())
+	}
+
+	if s := result.String(); s != "push 32.0 MB/s, pull 64.0 MB/s, latency 1.5ms" {
+		t.Errorf("unexpected string: %s", s)
+	}
+
+	if (BenchmarkResult{Size: 1}).PushThroughput() != 0 {
+		t.Error("expected no throughput without a duration")
+	}
+}
+
+func TestZeroReader(t *testing.T) {
+	data, err := io.ReadAll(NewZeroReader(100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != 100000 {
+		t.Fatalf("expected 100000 bytes, got %d", len(data))
+	}
+
+	for _, b := range data {
+		if b != 0 {
+			t.Fatal("expected zeros")
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package adbclient
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+)
+
+const (
+	// DefaultBurstFrames is the default number of frames in a screenshot burst.
+	DefaultBurstFrames = 10
+
+	// DefaultBurstFPS is the default target frame rate of a screenshot burst.
+	DefaultBurstFPS = 5
+)
+
+// BurstFrame is a single frame of a screenshot burst.
+type BurstFrame struct {
+	Image     image.Image
+	Timestamp time.Time
+	Delay     time.Duration // time until the next frame
+}
+
+type burstOptions struct {
+	frames       int
+	fps          float64
+	progressFunc func(frame int, total int)
+}
+
+func (o burstOptions) String() string {
+	return fmt.Sprintf("frames:%d fps:%g", o.frames, o.fps)
+}
+
+func (o burstOptions) interval() time.Duration {
+	return time.Duration(float64(time.Second) / o.fps)
+}
+
+// BurstOption is an option for screenshot burst.
+type BurstOption interface {
+	apply(*burstOptions) error
+}
+
+type burstFramesOption struct {
+	frames int
+}
+
+func (o burstFramesOption) apply(opts *burstOptions) error {
+	if o.frames < 1 {
+		return fmt.Errorf("invalid number of frames: %d", o.frames)
+	}
+
+	opts.frames = o.frames
+	return nil
+}
+
+type burstFPSOption struct {
+	fps float64
+}
+
+func (o burstFPSOption) apply(opts *burstOptions) error {
+	if o.fps <= 0 {
+		return fmt.Errorf("invalid frame rate: %g", o.fps)
+	}
+
+	opts.fps = o.fps
+	return nil
+}
+
+type burstProgressOption struct {
+	progressFunc func(frame int, total int)
+}
+
+func (o burstProgressOption) apply(opts *burstOptions) error {
+	opts.progressFunc = o.progressFunc
+	return nil
+}
+
+// WithBurstFrames sets the number of frames to capture.
+func WithBurstFrames(frames int) BurstOption {
+	return burstFramesOption{frames}
+}
+
+// WithBurstFPS sets the target frame rate of the burst.
+// The real frame rate is limited by the speed of screencap on the device.
+func WithBurstFPS(fps float64) BurstOption {
+	return burstFPSOption{fps}
+}
+
+// WithBurstProgress sets a progress function called after each captured frame.
+func WithBurstProgress(f func(frame int, total int)) BurstOption {
+	return burstProgressOption{f}
+}
+
+// ScreenshotBurst captures a series of screenshots at the target frame rate.
+// The delay of each frame is the real time elapsed until the next frame was captured.
+func (c *Client) ScreenshotBurst(ctx context.Context, device *Device, opts ...BurstOption) ([]BurstFrame, error) {
+	options := burstOptions{
+		frames: DefaultBurstFrames,
+		fps:    DefaultBurstFPS,
+	}
+
+	for _, o := range opts {
+		if err := o.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	c.log.Infof("Capturing screenshot burst (%s)...", options)
+
+	interval := options.interval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	frames := make([]BurstFrame, 0, options.frames)
+	for i := 0; i < options.frames; i++ {
+		timestamp := time.Now()
+		img, err := c.CaptureScreen(device)
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, BurstFrame{
+			Image:     img,
+			Timestamp: timestamp,
+			Delay:     interval,
+		})
+
+		if i > 0 {
+			frames[i-1].Delay = timestamp.Sub(frames[i-1].Timestamp)
+		}
+
+		if options.progressFunc != nil {
+			options.progressFunc(i+1, options.frames)
+		}
+
+		if i == options.frames-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			c.log.Debug("Screenshot burst canceled")
+			return nil, ctx.Err()
+
+		case <-ticker.C:
+		}
+	}
+
+	return frames, nil
+}
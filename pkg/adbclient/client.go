@@ -18,6 +18,9 @@ const (
 	DefaultVideoPath      = "/sdcard/video.mp4"
 	DefaultScreenshotPath = "/sdcard/screenshot.png"
 	DefaultPort           = adb.AdbPort
+
+	// tempDir is the directory of temporary files on the device.
+	tempDir = "/data/local/tmp"
 )
 
 // Client is a ui wrapper around the adb client.
@@ -28,10 +31,15 @@ type Client struct {
 	events chan DeviceStateChangedEvent
 	port   int
 
+	displayMu sync.Mutex
+	displays  map[string]*displayState
+
 	propertyMu     sync.RWMutex
 	installPath    string
 	videoPath      string
 	screenshotPath string
+	leases         LeaseStore
+	leaseOwner     string
 }
 
 // New creates a new client.
@@ -48,11 +56,12 @@ func NewClient(port int, log logger.Logger) (*Client, error) {
 	}
 
 	return &Client{
-		adb:    adb,
-		log:    innerLog,
-		dialer: dialer,
-		port:   port,
-		events: make(chan DeviceStateChangedEvent),
+		adb:      adb,
+		log:      innerLog,
+		dialer:   dialer,
+		port:     port,
+		events:   make(chan DeviceStateChangedEvent),
+		displays: make(map[string]*displayState),
 	}, nil
 }
 
@@ -70,6 +79,8 @@ func (c *Client) Start(ctx context.Context) error {
 // Kill kills the client.
 func (c *Client) Stop() {
 	c.log.Info("Stopping ADB client...")
+	c.ResetDisplays()
+
 	if err := c.adb.KillServer(); err != nil {
 		c.log.Fatal(err)
 	}
@@ -110,7 +121,9 @@ func (c *Client) deviceWatcher(ctx context.Context) {
 
 		case event := <-watcher.C():
 			c.log.Infof("Device %s changed state to %s", event.Serial, event.NewState)
-			c.events <- NewDeviceStateChangedEvent(event)
+			stateEvent := NewDeviceStateChangedEvent(event)
+			go c.onDisplayDeviceStateChanged(stateEvent)
+			c.events <- stateEvent
 		}
 	}
 }
@@ -147,6 +160,34 @@ func (c *Client) GetAnyOnlineDevice() (*Device, error) {
 	return nil, fmt.Errorf("no online device found")
 }
 
+// GetOnlineDevices returns all online devices.
+func (c *Client) GetOnlineDevices() ([]*Device, error) {
+	deviceInfos, err := c.adb.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []*Device
+	for _, deviceInfo := range deviceInfos {
+		device := c.adb.Device(adb.DeviceWithSerial(deviceInfo.Serial))
+
+		state, err := device.State()
+		if err != nil || state != adb.StateOnline {
+			continue
+		}
+
+		d, err := NewDevice(c, device)
+		if err != nil {
+			c.log.Warnf("Skipping %s: %v", deviceInfo.Serial, err)
+			continue
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, nil
+}
+
 // SetInstallPath sets the path to install the apk.
 func (c *Client) SetInstallPath(path string) {
 	c.propertyMu.Lock()
@@ -211,6 +252,10 @@ func (c *Client) GetScreenshotPath() string {
 func (c *Client) Install(device *Device, apkPath string) (string, error) {
 	c.log.Infof("Installing %s...", apkPath)
 
+	if err := c.checkLease(device); err != nil {
+		return "", err
+	}
+
 	result, err := c.adb.Device(adb.DeviceWithSerial(device.Serial)).RunCommand("pm", "install", "-r", apkPath)
 	c.log.Debug(result)
 	if err != nil {
@@ -243,24 +288,19 @@ func getProp(device *adb.Device, prop string) (string, error) {
 	return value, nil
 }
 
+// wm returns the effective value of a 'wm' property, the override if set or the physical value otherwise.
 func wm(device *adb.Device, prop string) (string, error) {
 	result, err := device.RunCommand("wm", prop)
 	if err != nil {
 		return "", err
 	}
 
-	_, value := parseKeyVal(strings.Trim(result, " \r\n"), ":")
-	return value, nil
-}
-
-func diskUsageInKilobytes(device *adb.Device, path string) (int, error) {
-	result, err := device.RunCommand("du", "-k", path)
-	if err != nil {
-		return 0, err
+	physical, override := parseWm(result)
+	if override != "" {
+		return override, nil
 	}
 
-	key, _ := parseKeyVal(strings.Trim(result, " \r\n"), "\t")
-	return strconv.Atoi(key)
+	return physical, nil
 }
 
 // GetProp returns a property of the device.
@@ -292,12 +332,22 @@ func (c *Client) dialDevice(device *Device) (*wire.Conn, error) {
 
 // sendCommand sends a command to the device anc checks the status of the command.
 func (c *Client) sendCommand(device *Device, cmd string) (*wire.Conn, error) {
+	return c.openService(device, fmt.Sprintf("shell:%s", cmd))
+}
+
+// sendExecCommand sends a command to the device using the exec service.
+// Unlike the shell service, the output of exec is not mangled by a PTY, so it is suitable for binary data.
+func (c *Client) sendExecCommand(device *Device, cmd string) (*wire.Conn, error) {
+	return c.openService(device, fmt.Sprintf("exec:%s", cmd))
+}
+
+// openService opens a service on the device and checks the status of the request.
+func (c *Client) openService(device *Device, req string) (*wire.Conn, error) {
 	conn, err := c.dialDevice(device)
 	if err != nil {
 		return nil, err
 	}
 
-	req := fmt.Sprintf("shell:%s", cmd)
 	c.log.Debugf("Sending command: %s", req)
 	if err := wire.SendMessageString(conn, req); err != nil {
 		conn.Close()
@@ -334,10 +384,29 @@ func (c *Client) runCommand(device *Device, cmd string, args ...string) ([]byte,
 	return result, nil
 }
 
+// execCommand runs a command on the device using the exec service and returns its raw output.
+func (c *Client) execCommand(device *Device, cmd string, args ...string) ([]byte, error) {
+	if len(args) > 0 {
+		cmd = fmt.Sprintf("%s %s", cmd, strings.Join(args, " "))
+	}
+
+	conn, err := c.sendExecCommand(device, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+	return conn.ReadUntilEof()
+}
+
 // RemoveFile removes a file from the device.
 func (c *Client) RemoveFile(device *Device, path string) error {
 	c.log.Infof("Removing %s...", path)
 
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
 	resp, err := c.runCommand(device, "rm -f -v", path)
 	if err != nil {
 		return err
@@ -351,6 +420,10 @@ func (c *Client) RemoveFile(device *Device, path string) error {
 func (c *Client) SendLink(device *Device, link string) error {
 	c.log.Infof("Sending link %s...", link)
 
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
 	_, err := url.ParseRequestURI(link)
 	if err != nil {
 		return err
@@ -0,0 +1,68 @@
+package adbclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockSamples is the number of round trips of ClockOffset, the one with the shortest round trip is used.
+const clockSamples = 3
+
+// parseDeviceClock parses the output of 'date +%s.%N'. Old toolbox date doesn't support %N
+// and prints it literally, the time has a precision of one second then.
+func parseDeviceClock(out string) (time.Time, error) {
+	out = strings.TrimSpace(out)
+
+	secs, frac, _ := strings.Cut(out, ".")
+	s, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid device time: %q", out)
+	}
+
+	var ns int64
+	if frac != "" && frac != "N" {
+		// the fraction may have less than nine digits
+		for len(frac) < 9 {
+			frac += "0"
+		}
+
+		if ns, err = strconv.ParseInt(frac[:9], 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid device time: %q", out)
+		}
+	}
+
+	return time.Unix(s, ns), nil
+}
+
+// ClockOffset returns the offset of the device clock from the local clock, positive if the device is ahead.
+// The device time is read with 'date +%s.%N' and compared with the middle of the round trip.
+func (c *Client) ClockOffset(device *Device) (time.Duration, error) {
+	c.log.Infof("Measuring clock offset of %s...", device.Serial)
+
+	var offset time.Duration
+	bestRoundTrip := time.Duration(-1)
+	for i := 0; i < clockSamples; i++ {
+		before := time.Now()
+		out, err := c.runCommand(device, "date", "+%s.%N")
+		after := time.Now()
+		if err != nil {
+			return 0, err
+		}
+
+		deviceTime, err := parseDeviceClock(string(out))
+		if err != nil {
+			return 0, err
+		}
+
+		roundTrip := after.Sub(before)
+		if bestRoundTrip < 0 || roundTrip < bestRoundTrip {
+			bestRoundTrip = roundTrip
+			offset = deviceTime.Sub(before.Add(roundTrip / 2))
+		}
+	}
+
+	c.log.Debugf("Clock offset of %s: %s (round trip %s)", device.Serial, offset, bestRoundTrip)
+	return offset, nil
+}
@@ -0,0 +1,34 @@
+package adbclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDeviceClock(t *testing.T) {
+	tests := []struct {
+		out      string
+		expected time.Time
+	}{
+		{"1652875269.830123456\n", time.Unix(1652875269, 830123456)},
+		{"1652875269.83\r\n", time.Unix(1652875269, 830000000)},
+		{"1652875269.N\n", time.Unix(1652875269, 0)},
+		{"1652875269", time.Unix(1652875269, 0)},
+	}
+
+	for _, test := range tests {
+		actual, err := parseDeviceClock(test.out)
+		if err != nil {
+			t.Errorf("%q: %s", test.out, err)
+			continue
+		}
+
+		if !actual.Equal(test.expected) {
+			t.Errorf("%q: expected %s, actual %s", test.out, test.expected, actual)
+		}
+	}
+
+	if _, err := parseDeviceClock("date: unknown option"); err == nil {
+		t.Error("invalid output parsed")
+	}
+}
@@ -0,0 +1,347 @@
+package adbclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NightMode is the night mode of the UI mode manager.
+type NightMode int
+
+const (
+	NightModeAuto NightMode = iota
+	NightModeNo
+	NightModeYes
+)
+
+func (m NightMode) String() string {
+	switch m {
+	case NightModeNo:
+		return "no"
+	case NightModeYes:
+		return "yes"
+	default:
+		return "auto"
+	}
+}
+
+// ParseNightMode parses a night mode as reported by 'cmd uimode night'.
+func ParseNightMode(s string) (NightMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "auto":
+		return NightModeAuto, nil
+	case "no", "off", "light":
+		return NightModeNo, nil
+	case "yes", "on", "dark":
+		return NightModeYes, nil
+	default:
+		return NightModeAuto, fmt.Errorf("invalid night mode: %s", s)
+	}
+}
+
+// DisplayOverride is the display size and density override set with 'wm size' and 'wm density'.
+// Zero values mean no override.
+type DisplayOverride struct {
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	Density int `json:"density"`
+}
+
+func (o DisplayOverride) String() string {
+	var parts []string
+	if o.Width > 0 && o.Height > 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d", o.Width, o.Height))
+	}
+
+	if o.Density > 0 {
+		parts = append(parts, fmt.Sprintf("%d dpi", o.Density))
+	}
+
+	if len(parts) == 0 {
+		return "default"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseDisplaySize parses a display size in the WIDTHxHEIGHT format.
+func ParseDisplaySize(s string) (int, int, error) {
+	size := strings.Split(strings.TrimSpace(s), "x")
+	if len(size) != 2 {
+		return 0, 0, fmt.Errorf("invalid display size: %s", s)
+	}
+
+	width, err := strconv.Atoi(size[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid display size: %s", s)
+	}
+
+	height, err := strconv.Atoi(size[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid display size: %s", s)
+	}
+
+	return width, height, nil
+}
+
+// shellQuote quotes the argument for the device shell.
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// parseWm parses the output of 'wm size' or 'wm density' and returns the physical and the override values.
+// The override is empty if not set.
+func parseWm(out string) (string, string) {
+	var physical, override string
+	for _, line := range strings.Split(out, "\n") {
+		key, value := parseKeyVal(strings.TrimSpace(line), ":")
+		switch key {
+		case "Physical size", "Physical density":
+			physical = strings.TrimSpace(value)
+		case "Override size", "Override density":
+			override = strings.TrimSpace(value)
+		}
+	}
+
+	return physical, override
+}
+
+// GetNightMode returns the night mode of the device.
+func (c *Client) GetNightMode(device *Device) (NightMode, error) {
+	resp, err := c.runCommand(device, "cmd", "uimode", "night")
+	if err != nil {
+		return NightModeAuto, err
+	}
+
+	_, value := parseKeyVal(strings.TrimSpace(string(resp)), ":")
+	return ParseNightMode(value)
+}
+
+// SetNightMode sets the night mode of the device.
+func (c *Client) SetNightMode(device *Device, mode NightMode) error {
+	c.log.Infof("Setting night mode to %s...", mode)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	resp, err := c.runCommand(device, "cmd", "uimode", "night", mode.String())
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug(string(resp))
+	return nil
+}
+
+// GetFontScale returns the font scale of the device.
+func (c *Client) GetFontScale(device *Device) (float64, error) {
+	resp, err := c.runCommand(device, "settings", "get", "system", "font_scale")
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(resp))
+	if value == "" || value == "null" {
+		return 1, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// SetFontScale sets the font scale of the device.
+func (c *Client) SetFontScale(device *Device, scale float64) error {
+	c.log.Infof("Setting font scale to %g...", scale)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if scale <= 0 {
+		return fmt.Errorf("invalid font scale: %g", scale)
+	}
+
+	resp, err := c.runCommand(device, "settings", "put", "system", "font_scale", strconv.FormatFloat(scale, 'f', -1, 64))
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug(string(resp))
+	return nil
+}
+
+// GetAppLocales returns the per-app locales of the package, empty if the app follows the system locale.
+// Per-app locales require Android 13 (API 33).
+func (c *Client) GetAppLocales(device *Device, pkg string) (string, error) {
+	resp, err := c.runCommand(device, "cmd", "locale", "get-app-locales", pkg)
+	if err != nil {
+		return "", err
+	}
+
+	// Locales for com.example.app for user 0 are [en-US,de-DE]
+	out := strings.TrimSpace(string(resp))
+	start, end := strings.LastIndex(out, "["), strings.LastIndex(out, "]")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("could not parse app locales: %s", out)
+	}
+
+	return out[start+1 : end], nil
+}
+
+// SetAppLocales sets the per-app locales of the package. Empty locales reset the app to the system locale.
+// Per-app locales require Android 13 (API 33).
+func (c *Client) SetAppLocales(device *Device, pkg string, locales string) error {
+	c.log.Infof("Setting locales of %s to %q...", pkg, locales)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if device.SDK != 0 && device.SDK < 33 {
+		return fmt.Errorf("per-app locales require API 33, device has API %d", device.SDK)
+	}
+
+	resp, err := c.runCommand(device, "cmd", "locale", "set-app-locales", pkg, "--locales", shellQuote(locales))
+	if err != nil {
+		return err
+	}
+
+	if out := strings.TrimSpace(string(resp)); out != "" {
+		return fmt.Errorf("could not set app locales: %s", out)
+	}
+
+	return nil
+}
+
+// GetDisplayOverride returns the display size and density overrides of the device.
+func (c *Client) GetDisplayOverride(device *Device) (DisplayOverride, error) {
+	var override DisplayOverride
+
+	resp, err := c.runCommand(device, "wm", "size")
+	if err != nil {
+		return override, err
+	}
+
+	if _, size := parseWm(string(resp)); size != "" {
+		override.Width, override.Height, err = ParseDisplaySize(size)
+		if err != nil {
+			return override, err
+		}
+	}
+
+	resp, err = c.runCommand(device, "wm", "density")
+	if err != nil {
+		return override, err
+	}
+
+	if _, density := parseWm(string(resp)); density != "" {
+		override.Density, err = strconv.Atoi(density)
+		if err != nil {
+			return override, err
+		}
+	}
+
+	return override, nil
+}
+
+// SetDisplayOverride sets the display size and density overrides of the device.
+// Zero values reset the corresponding override.
+// The original display state is restored by ResetDisplay, or when the device reconnects after a disconnect.
+func (c *Client) SetDisplayOverride(device *Device, override DisplayOverride) error {
+	c.log.Infof("Setting display override to %s...", override)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if err := c.trackDisplay(device); err != nil {
+		return err
+	}
+
+	return c.setDisplayOverride(device, override)
+}
+
+func (c *Client) setDisplayOverride(device *Device, override DisplayOverride) error {
+	size := "reset"
+	if override.Width > 0 && override.Height > 0 {
+		size = fmt.Sprintf("%dx%d", override.Width, override.Height)
+	}
+
+	resp, err := c.runCommand(device, "wm", "size", size)
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug(string(resp))
+
+	density := "reset"
+	if override.Density > 0 {
+		density = strconv.Itoa(override.Density)
+	}
+
+	resp, err = c.runCommand(device, "wm", "density", density)
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug(string(resp))
+	return nil
+}
+
+// StartApp force-stops the package and starts its launcher activity, or the given activity if not empty.
+func (c *Client) StartApp(device *Device, pkg string, activity string) error {
+	c.log.Infof("Starting %s...", pkg)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if _, err := c.runCommand(device, "am", "force-stop", pkg); err != nil {
+		return err
+	}
+
+	var resp []byte
+	var err error
+	if activity != "" {
+		resp, err = c.runCommand(device, "am", "start", "-W", "-n", fmt.Sprintf("%s/%s", pkg, activity))
+	} else {
+		resp, err = c.runCommand(device, "monkey", "-p", pkg, "-c", "android.intent.category.LAUNCHER", "1")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug(string(resp))
+	if strings.Contains(string(resp), "Error") || strings.Contains(string(resp), "No activities found") {
+		return fmt.Errorf("could not start %s: %s", pkg, strings.TrimSpace(string(resp)))
+	}
+
+	return nil
+}
+
+// StartDeepLink force-stops the package and opens the deep link in it.
+func (c *Client) StartDeepLink(device *Device, pkg string, link string) error {
+	c.log.Infof("Opening %s in %s...", link, pkg)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if _, err := c.runCommand(device, "am", "force-stop", pkg); err != nil {
+		return err
+	}
+
+	resp, err := c.runCommand(device, "am", "start", "-W", "-a", "android.intent.action.VIEW", "-d", shellQuote(link), pkg)
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug(string(resp))
+	if strings.Contains(string(resp), "Error") {
+		return fmt.Errorf("could not open %s: %s", link, strings.TrimSpace(string(resp)))
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package adbclient
+
+import "testing"
+
+func TestParseWm(t *testing.T) {
+	physical, override := parseWm("Physical size: 1080x2340\r\nOverride size: 720x1560\r\n")
+	if physical != "1080x2340" || override != "720x1560" {
+		t.Errorf("expected: 1080x2340 720x1560, actual: %s %s", physical, override)
+	}
+
+	physical, override = parseWm("Physical density: 420\n")
+	if physical != "420" || override != "" {
+		t.Errorf("expected: 420 and no override, actual: %s %s", physical, override)
+	}
+}
+
+func TestParseNightMode(t *testing.T) {
+	for s, expected := range map[string]NightMode{"yes": NightModeYes, " no\n": NightModeNo, "auto": NightModeAuto} {
+		mode, err := ParseNightMode(s)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if mode != expected {
+			t.Errorf("expected: %s, actual: %s", expected, mode)
+		}
+	}
+
+	if _, err := ParseNightMode("custom"); err == nil {
+		t.Error("expected error for invalid night mode")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	expected := `'app://open?a=1&b='\''c'\'''`
+	if quoted := shellQuote("app://open?a=1&b='c'"); quoted != expected {
+		t.Errorf("expected: %s, actual: %s", expected, quoted)
+	}
+}
@@ -3,7 +3,6 @@ package adbclient
 import (
 	"fmt"
 	"strconv"
-	"strings"
 
 	adb "github.com/zach-klippenstein/goadb"
 )
@@ -59,7 +58,9 @@ type Device struct {
 	State      DeviceState   `json:"-"`
 }
 
-// NewDevice creates a new Device from an adb.Device.
+// NewDevice creates a new Device from an adb.Device. The properties and the display are read only if the
+// device is online, as the other devices can't run commands. The display can't be read while the device boots,
+// so it is left empty with a warning.
 func NewDevice(client *Client, device *adb.Device) (*Device, error) {
 	deviceInfo, err := device.DeviceInfo()
 	if err != nil {
@@ -71,41 +72,41 @@ func NewDevice(client *Client, device *adb.Device) (*Device, error) {
 		return nil, err
 	}
 
-	sRelease, _ := getProp(device, "ro.build.version.release")
-
-	sSdk, _ := getProp(device, "ro.build.version.sdk")
-	iSdk, _ := strconv.ParseInt(sSdk, 10, 64)
-
-	sABI, _ := getProp(device, "ro.product.cpu.abi")
-
-	sEGLVersion, _ := getProp(device, "ro.hardware.egl")
-
-	sSize, _ := wm(device, "size")
-	aSize := strings.Split(strings.Trim(sSize, " \n"), "x")
-
-	iWidth, _ := strconv.ParseInt(aSize[0], 10, 64)
-	iHeight, _ := strconv.ParseInt(aSize[1], 10, 64)
-
-	sDensity, _ := wm(device, "density")
-	iDensity, _ := strconv.ParseInt(strings.Trim(sDensity, " \n"), 10, 64)
-
-	return &Device{
+	d := &Device{
 		Serial:     deviceInfo.Serial,
 		Product:    deviceInfo.Product,
 		Model:      deviceInfo.Model,
 		DeviceInfo: deviceInfo.DeviceInfo,
 		USB:        deviceInfo.Usb,
 		State:      DeviceState(deviceState),
-		Release:    sRelease,
-		SDK:        int(iSdk),
-		ABI:        sABI,
-		EGLVersion: sEGLVersion,
-		Display: DisplayParams{
-			Width:   int(iWidth),
-			Height:  int(iHeight),
-			Density: int(iDensity),
-		},
-	}, nil
+	}
+
+	if deviceState != adb.StateOnline {
+		return d, nil
+	}
+
+	props, err := readProperties(device)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the properties of %s: %w", d.Serial, err)
+	}
+
+	d.Release = props["ro.build.version.release"]
+	d.ABI = props["ro.product.cpu.abi"]
+	d.EGLVersion = props["ro.hardware.egl"]
+
+	if sdk := props["ro.build.version.sdk"]; sdk != "" {
+		d.SDK, err = strconv.Atoi(sdk)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SDK version of %s: %s", d.Serial, sdk)
+		}
+	}
+
+	d.Display, err = readDisplay(device)
+	if err != nil {
+		client.log.Warnf("Could not read the display of %s: %v", d.Serial, err)
+	}
+
+	return d, nil
 }
 
 // SetState sets the state of the device.
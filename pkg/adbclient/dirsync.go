@@ -0,0 +1,550 @@
+package adbclient
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultTransferParallelism is the default number of files transferred at once by the directory transfers.
+	DefaultTransferParallelism = 4
+
+	// maxCommandLength is the maximum length of the arguments of a batched shell command.
+	maxCommandLength = 16 * 1024
+)
+
+type dirOptions struct {
+	progressFunc progressFunc
+	parallelism  int
+	checksum     bool
+	delete       bool
+}
+
+// DirOption is an option for directory transfers.
+type DirOption interface {
+	apply(*dirOptions) error
+}
+
+type progressDirOption struct {
+	progressFunc progressFunc
+}
+
+func (o progressDirOption) apply(opts *dirOptions) error {
+	opts.progressFunc = o.progressFunc
+	return nil
+}
+
+// WithDirProgress sets a progress function, called with the bytes transferred of all files.
+func WithDirProgress(f func(sentBytes int64, totalBytes int64)) DirOption {
+	return progressDirOption{f}
+}
+
+type parallelismDirOption struct {
+	parallelism int
+}
+
+func (o parallelismDirOption) apply(opts *dirOptions) error {
+	if o.parallelism < 1 {
+		return fmt.Errorf("invalid parallelism: %d", o.parallelism)
+	}
+
+	opts.parallelism = o.parallelism
+	return nil
+}
+
+// WithDirParallelism sets the number of files transferred at once.
+func WithDirParallelism(n int) DirOption {
+	return parallelismDirOption{n}
+}
+
+type checksumDirOption struct{}
+
+func (o checksumDirOption) apply(opts *dirOptions) error {
+	opts.checksum = true
+	return nil
+}
+
+// WithDirChecksum makes SyncDir compare the md5sum of files of the same size instead of their modification time.
+func WithDirChecksum() DirOption {
+	return checksumDirOption{}
+}
+
+type deleteDirOption struct{}
+
+func (o deleteDirOption) apply(opts *dirOptions) error {
+	opts.delete = true
+	return nil
+}
+
+// WithDirDelete makes SyncDir delete the files of the destination missing in the source.
+func WithDirDelete() DirOption {
+	return deleteDirOption{}
+}
+
+func newDirOptions(opts []DirOption) (dirOptions, error) {
+	options := dirOptions{parallelism: DefaultTransferParallelism}
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return options, err
+		}
+	}
+
+	return options, nil
+}
+
+// SyncResult is the result of SyncDir, the paths are relative to the directories.
+type SyncResult struct {
+	Transferred []string
+	// Deleted is the files and directories of the device removed with WithDirDelete, with their contents.
+	Deleted []string
+	// Conflicts is the local files not transferred as the device has a directory at their path,
+	// they replace the directory with WithDirDelete.
+	Conflicts []string
+	Unchanged int
+	Bytes     int64
+}
+
+// dirProgress aggregates the progress of the files of a directory transfer.
+type dirProgress struct {
+	mu    sync.Mutex
+	sent  int64
+	total int64
+	f     progressFunc
+}
+
+func newDirProgress(files []FileInfo, f progressFunc) *dirProgress {
+	p := &dirProgress{f: f}
+	for _, file := range files {
+		p.total += file.Size
+	}
+
+	return p
+}
+
+func (p *dirProgress) add(n int64) {
+	if p.f == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sent += n
+	p.f(p.sent, p.total)
+}
+
+// file returns the progress function of a file, which adds its progress to the total.
+func (p *dirProgress) file() func(sentBytes int64, totalBytes int64) {
+	var last int64
+	return func(sentBytes int64, totalBytes int64) {
+		p.add(sentBytes - last)
+		last = sentBytes
+	}
+}
+
+// walkLocal returns the files and the directories under the root, with paths relative to it.
+func walkLocal(root string) ([]FileInfo, []string, error) {
+	var files []FileInfo
+	var dirs []string
+	err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, name)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			dirs = append(dirs, rel)
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, FileInfo{Name: entry.Name(), Path: rel, Mode: info.Mode(), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+
+	return files, dirs, err
+}
+
+// listTree returns the files and the directories under the root on the device, with paths relative to it.
+func (c *Client) listTree(device *Device, root string) ([]FileInfo, []string, error) {
+	out, err := c.runFileCommand(device, fileOptions{}, fmt.Sprintf("find %s -mindepth 1 -exec stat -c %s {} +", shellQuote(root), shellQuote(statFormat)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []FileInfo
+	var dirs []string
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+
+		info, err := parseStat(line)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		info.Path = strings.TrimPrefix(info.Path, strings.TrimSuffix(root, "/")+"/")
+		switch {
+		case info.IsDir():
+			dirs = append(dirs, info.Path)
+		case info.Mode.IsRegular():
+			files = append(files, info)
+		}
+	}
+
+	return files, dirs, nil
+}
+
+// batchArgs splits the arguments in batches of at most maxCommandLength, quoted for the shell.
+func batchArgs(args []string) [][]string {
+	var batches [][]string
+	var batch []string
+	length := 0
+	for _, arg := range args {
+		quoted := shellQuote(arg)
+		if len(batch) > 0 && length+len(quoted) > maxCommandLength {
+			batches = append(batches, batch)
+			batch, length = nil, 0
+		}
+
+		batch = append(batch, quoted)
+		length += len(quoted) + 1
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// runBatched runs the command with the arguments, split in batches for long argument lists.
+func (c *Client) runBatched(device *Device, cmd string, args []string) (string, error) {
+	var out strings.Builder
+	for _, batch := range batchArgs(args) {
+		resp, err := c.runFileCommand(device, fileOptions{}, cmd+" "+strings.Join(batch, " "))
+		if err != nil {
+			return "", err
+		}
+
+		out.WriteString(resp)
+		out.WriteString("\n")
+	}
+
+	return out.String(), nil
+}
+
+func joinAll(root string, rels []string) []string {
+	paths := make([]string, len(rels))
+	for i, rel := range rels {
+		paths[i] = path.Join(root, rel)
+	}
+
+	return paths
+}
+
+// pushFiles uploads the files of the local directory in parallel, keeping their modification time.
+func (c *Client) pushFiles(ctx context.Context, device *Device, src, dst string, files []FileInfo, options dirOptions) error {
+	progress := newDirProgress(files, options.progressFunc)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(options.parallelism)
+	for _, file := range files {
+		file := file
+		g.Go(func() error {
+			return c.UploadFile(ctx, device, filepath.Join(src, filepath.FromSlash(file.Path)), path.Join(dst, file.Path),
+				WithUploadProgress(progress.file()), WithUploadModTime(file.ModTime))
+		})
+	}
+
+	return g.Wait()
+}
+
+// PushDir uploads a local directory recursively, transferring files in parallel.
+func (c *Client) PushDir(ctx context.Context, device *Device, src, dst string, opts ...DirOption) error {
+	c.log.Infof("Pushing directory %s to %s...", src, dst)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	options, err := newDirOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	files, dirs, err := walkLocal(src)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.runBatched(device, "mkdir -p", append([]string{dst}, joinAll(dst, dirs)...)); err != nil {
+		return err
+	}
+
+	c.log.Debugf("Pushing %d files...", len(files))
+	return c.pushFiles(ctx, device, src, dst, files, options)
+}
+
+// PullDir downloads a directory of the device recursively, transferring files in parallel.
+func (c *Client) PullDir(ctx context.Context, device *Device, src, dst string, opts ...DirOption) error {
+	c.log.Infof("Pulling directory %s to %s...", src, dst)
+
+	options, err := newDirOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	files, dirs, err := c.listTree(device, src)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range append([]string{""}, dirs...) {
+		if err := os.MkdirAll(filepath.Join(dst, filepath.FromSlash(dir)), 0755); err != nil {
+			return err
+		}
+	}
+
+	c.log.Debugf("Pulling %d files...", len(files))
+	progress := newDirProgress(files, options.progressFunc)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(options.parallelism)
+	for _, file := range files {
+		file := file
+		g.Go(func() error {
+			local := filepath.Join(dst, filepath.FromSlash(file.Path))
+			if err := c.DownloadFile(ctx, device, path.Join(src, file.Path), local, WithDownloadProgress(progress.file())); err != nil {
+				return err
+			}
+
+			return os.Chtimes(local, file.ModTime, file.ModTime)
+		})
+	}
+
+	return g.Wait()
+}
+
+// diffTrees compares the local files with the files of the device.
+// It returns the files to transfer, the files of the same size to compare by checksum if checksum is set,
+// and the files of the device missing locally.
+func diffTrees(local, remote []FileInfo, checksum bool) ([]FileInfo, []FileInfo, []string) {
+	remoteFiles := make(map[string]FileInfo, len(remote))
+	for _, file := range remote {
+		remoteFiles[file.Path] = file
+	}
+
+	var transfer, verify []FileInfo
+	for _, file := range local {
+		remoteFile, ok := remoteFiles[file.Path]
+		delete(remoteFiles, file.Path)
+
+		switch {
+		case !ok || remoteFile.Size != file.Size:
+			transfer = append(transfer, file)
+		case checksum:
+			verify = append(verify, file)
+		// the device keeps the modification time in seconds
+		case remoteFile.ModTime.Unix() != file.ModTime.Unix():
+			transfer = append(transfer, file)
+		}
+	}
+
+	var extra []string
+	for name := range remoteFiles {
+		extra = append(extra, name)
+	}
+
+	sort.Strings(extra)
+	return transfer, verify, extra
+}
+
+// syncDeletions returns the files and directories of the device to delete so that it matches the local tree.
+// Only the topmost ones are returned, their contents are deleted with them.
+func syncDeletions(localDirs, remoteDirs, extra []string) []string {
+	localDirSet := make(map[string]bool, len(localDirs))
+	for _, dir := range localDirs {
+		localDirSet[dir] = true
+	}
+
+	// directories of the device missing locally are deleted with their contents
+	var deleted []string
+	for _, dir := range remoteDirs {
+		if !localDirSet[dir] && (path.Dir(dir) == "." || localDirSet[path.Dir(dir)]) {
+			deleted = append(deleted, dir)
+		}
+	}
+
+	for _, name := range extra {
+		if path.Dir(name) == "." || localDirSet[path.Dir(name)] {
+			deleted = append(deleted, name)
+		}
+	}
+
+	sort.Strings(deleted)
+	return deleted
+}
+
+// syncConflicts splits the local files to transfer from the files with a directory at their path on the device.
+func syncConflicts(transfer []FileInfo, remoteDirs []string) ([]FileInfo, []string) {
+	remoteDirSet := make(map[string]bool, len(remoteDirs))
+	for _, dir := range remoteDirs {
+		remoteDirSet[dir] = true
+	}
+
+	var files []FileInfo
+	var conflicts []string
+	for _, file := range transfer {
+		if remoteDirSet[file.Path] {
+			conflicts = append(conflicts, file.Path)
+		} else {
+			files = append(files, file)
+		}
+	}
+
+	return files, conflicts
+}
+
+// parseMD5Sums parses the output of md5sum to a map of the paths to their checksums.
+func parseMD5Sums(out string) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		sum, name, ok := strings.Cut(strings.TrimRight(line, "\r"), "  ")
+		if ok && len(sum) == 32 {
+			sums[name] = sum
+		}
+	}
+
+	return sums
+}
+
+func localMD5(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SyncDir makes a directory of the device match a local directory, uploading only new and changed files.
+// Files are compared by size and modification time, or by md5sum with WithDirChecksum.
+func (c *Client) SyncDir(ctx context.Context, device *Device, src, dst string, opts ...DirOption) (*SyncResult, error) {
+	c.log.Infof("Syncing directory %s to %s...", src, dst)
+
+	if err := c.checkLease(device); err != nil {
+		return nil, err
+	}
+
+	options, err := newDirOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	local, localDirs, err := walkLocal(src)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, remoteDirs, err := c.listTree(device, dst)
+	if err != nil && !errors.Is(err, ErrFileNotFound) {
+		return nil, err
+	}
+
+	transfer, verify, extra := diffTrees(local, remote, options.checksum)
+
+	result := &SyncResult{}
+	if len(verify) > 0 {
+		out, err := c.runBatched(device, "md5sum", joinAll(dst, paths(verify)))
+		if err != nil {
+			return nil, err
+		}
+
+		sums := parseMD5Sums(out)
+		for _, file := range verify {
+			sum, err := localMD5(filepath.Join(src, filepath.FromSlash(file.Path)))
+			if err != nil {
+				return nil, err
+			}
+
+			if sums[path.Join(dst, file.Path)] != sum {
+				transfer = append(transfer, file)
+			}
+		}
+	}
+
+	if options.delete {
+		deleted := syncDeletions(localDirs, remoteDirs, extra)
+		if len(deleted) > 0 {
+			if _, err := c.runBatched(device, "rm -rf", joinAll(dst, deleted)); err != nil {
+				return nil, err
+			}
+		}
+
+		result.Deleted = deleted
+	} else {
+		transfer, result.Conflicts = syncConflicts(transfer, remoteDirs)
+	}
+
+	result.Unchanged = len(local) - len(transfer) - len(result.Conflicts)
+
+	if _, err := c.runBatched(device, "mkdir -p", append([]string{dst}, joinAll(dst, localDirs)...)); err != nil {
+		return nil, err
+	}
+
+	c.log.Debugf("Syncing %d changed files, %d unchanged...", len(transfer), result.Unchanged)
+	if err := c.pushFiles(ctx, device, src, dst, transfer, options); err != nil {
+		return nil, err
+	}
+
+	for _, file := range transfer {
+		result.Transferred = append(result.Transferred, file.Path)
+		result.Bytes += file.Size
+	}
+
+	return result, nil
+}
+
+func paths(files []FileInfo) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Path
+	}
+
+	return names
+}
@@ -0,0 +1,197 @@
+package adbclient
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWalkLocal(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "textures", "ui"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{"config.json": "{}", "textures/ui/button.png": "png"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, dirs, err := walkLocal(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(paths(files), []string{"config.json", "textures/ui/button.png"}) {
+		t.Errorf("files: %v", paths(files))
+	}
+
+	if files[1].Size != 3 || files[1].Name != "button.png" {
+		t.Errorf("file: %+v", files[1])
+	}
+
+	if !reflect.DeepEqual(dirs, []string{"empty", "textures", "textures/ui"}) {
+		t.Errorf("dirs: %v", dirs)
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+	now := time.Unix(1646128800, 0)
+	local := []FileInfo{
+		{Path: "new.txt", Size: 1, ModTime: now},
+		{Path: "resized.txt", Size: 2, ModTime: now},
+		{Path: "touched.txt", Size: 3, ModTime: now.Add(time.Minute)},
+		{Path: "same.txt", Size: 4, ModTime: now.Add(500 * time.Millisecond)},
+	}
+
+	remote := []FileInfo{
+		{Path: "resized.txt", Size: 3, ModTime: now},
+		{Path: "touched.txt", Size: 3, ModTime: now},
+		{Path: "same.txt", Size: 4, ModTime: now},
+		{Path: "old/extra.txt", Size: 5, ModTime: now},
+	}
+
+	transfer, verify, extra := diffTrees(local, remote, false)
+	if !reflect.DeepEqual(paths(transfer), []string{"new.txt", "resized.txt", "touched.txt"}) {
+		t.Errorf("transfer: %v", paths(transfer))
+	}
+
+	if len(verify) != 0 {
+		t.Errorf("verify: %v", paths(verify))
+	}
+
+	if !reflect.DeepEqual(extra, []string{"old/extra.txt"}) {
+		t.Errorf("extra: %v", extra)
+	}
+
+	transfer, verify, _ = diffTrees(local, remote, true)
+	if !reflect.DeepEqual(paths(transfer), []string{"new.txt", "resized.txt"}) {
+		t.Errorf("checksum transfer: %v", paths(transfer))
+	}
+
+	if !reflect.DeepEqual(paths(verify), []string{"touched.txt", "same.txt"}) {
+		t.Errorf("checksum verify: %v", paths(verify))
+	}
+}
+
+func TestSyncDeletions(t *testing.T) {
+	localDirs := []string{"assets", "assets/images"}
+	remoteDirs := []string{"assets", "assets/images", "assets/old", "assets/old/nested", "cache", "config.json"}
+	extra := []string{"assets/old/a.png", "assets/old/nested/b.png", "assets/stale.txt", "cache/c.bin", "root.txt"}
+
+	deleted := syncDeletions(localDirs, remoteDirs, extra)
+	expected := []string{"assets/old", "assets/stale.txt", "cache", "config.json", "root.txt"}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, deleted)
+	}
+}
+
+func TestSyncConflicts(t *testing.T) {
+	transfer := []FileInfo{{Path: "config.json"}, {Path: "assets/a.png"}}
+
+	files, conflicts := syncConflicts(transfer, []string{"assets", "config.json"})
+	if !reflect.DeepEqual(paths(files), []string{"assets/a.png"}) {
+		t.Errorf("files: %v", paths(files))
+	}
+
+	if !reflect.DeepEqual(conflicts, []string{"config.json"}) {
+		t.Errorf("conflicts: %v", conflicts)
+	}
+}
+
+func TestParseMD5Sums(t *testing.T) {
+	out := "d41d8cd98f00b204e9800998ecf8427e  /sdcard/a b.txt\r\n" +
+		"md5sum: /sdcard/missing: No such file or directory\n" +
+		"900150983cd24fb0d6963f7d28e17f72  /sdcard/abc.txt\n"
+
+	sums := parseMD5Sums(out)
+	expected := map[string]string{
+		"/sdcard/a b.txt": "d41d8cd98f00b204e9800998ecf8427e",
+		"/sdcard/abc.txt": "900150983cd24fb0d6963f7d28e17f72",
+	}
+
+	if !reflect.DeepEqual(sums, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, sums)
+	}
+}
+
+func TestBatchArgs(t *testing.T) {
+	if batches := batchArgs(nil); len(batches) != 0 {
+		t.Errorf("batches: %v", batches)
+	}
+
+	arg := strings.Repeat("a", 1000)
+
+	var args []string
+	for i := 0; i < 40; i++ {
+		args = append(args, arg)
+	}
+
+	batches := batchArgs(args)
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches, actual %d", len(batches))
+	}
+
+	total := 0
+	for _, batch := range batches {
+		if length := len(strings.Join(batch, " ")); length > maxCommandLength {
+			t.Errorf("batch too long: %d", length)
+		}
+
+		total += len(batch)
+	}
+
+	if total != len(args) || batches[0][0] != shellQuote(arg) {
+		t.Errorf("batches: %d args", total)
+	}
+}
+
+func TestDirProgress(t *testing.T) {
+	var sent, total int64
+	progress := newDirProgress([]FileInfo{{Size: 100}, {Size: 50}}, func(s, t int64) {
+		sent, total = s, t
+	})
+
+	var wg sync.WaitGroup
+	for _, size := range []int64{100, 50} {
+		f := progress.file()
+		size := size
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := int64(10); n <= size; n += 10 {
+				f(n, size)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if sent != 150 || total != 150 {
+		t.Errorf("sent: %d, total: %d", sent, total)
+	}
+}
+
+func TestDirOptions(t *testing.T) {
+	options, err := newDirOptions(nil)
+	if err != nil || options.parallelism != DefaultTransferParallelism {
+		t.Errorf("default options: %+v, %v", options, err)
+	}
+
+	if _, err := newDirOptions([]DirOption{WithDirParallelism(0)}); err == nil {
+		t.Error("expected error for parallelism 0")
+	}
+
+	options, err = newDirOptions([]DirOption{WithDirParallelism(8), WithDirChecksum(), WithDirDelete()})
+	if err != nil || options.parallelism != 8 || !options.checksum || !options.delete {
+		t.Errorf("options: %+v, %v", options, err)
+	}
+}
@@ -0,0 +1,313 @@
+package adbclient
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+// Rotation is a display rotation.
+type Rotation int
+
+const (
+	Rotation0 Rotation = iota
+	Rotation90
+	Rotation180
+	Rotation270
+)
+
+func (r Rotation) String() string {
+	switch r {
+	case Rotation0:
+		return "portrait"
+	case Rotation90:
+		return "landscape"
+	case Rotation180:
+		return "reverse portrait"
+	case Rotation270:
+		return "reverse landscape"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRotation parses a rotation in degrees or by name.
+func ParseRotation(s string) (Rotation, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "0", "portrait":
+		return Rotation0, nil
+	case "90", "landscape":
+		return Rotation90, nil
+	case "180", "reverse portrait":
+		return Rotation180, nil
+	case "270", "reverse landscape":
+		return Rotation270, nil
+	default:
+		return Rotation0, fmt.Errorf("invalid rotation: %s", s)
+	}
+}
+
+// RotationLock is the rotation lock state of the device.
+type RotationLock struct {
+	Locked   bool     `json:"locked"`
+	Rotation Rotation `json:"rotation"`
+}
+
+func (l RotationLock) String() string {
+	if !l.Locked {
+		return "auto-rotate"
+	}
+
+	return fmt.Sprintf("locked to %s", l.Rotation)
+}
+
+// displayState is the display configuration of a device before it was first overridden.
+type displayState struct {
+	override DisplayOverride
+	rotation RotationLock
+
+	// pending is set when the device disconnected before the state was restored.
+	pending bool
+}
+
+// readDisplay reads the effective display parameters of the device.
+func readDisplay(device *adb.Device) (DisplayParams, error) {
+	var display DisplayParams
+
+	size, err := wm(device, "size")
+	if err != nil {
+		return display, err
+	}
+
+	display.Width, display.Height, err = ParseDisplaySize(size)
+	if err != nil {
+		return display, err
+	}
+
+	density, err := wm(device, "density")
+	if err != nil {
+		return display, err
+	}
+
+	display.Density, err = strconv.Atoi(strings.TrimSpace(density))
+	if err != nil {
+		return display, fmt.Errorf("invalid display density: %s", density)
+	}
+
+	return display, nil
+}
+
+// RefreshDisplay re-reads the display parameters of the device, e.g. after an override.
+func (c *Client) RefreshDisplay(device *Device) error {
+	c.log.Info("Refreshing display parameters...")
+
+	display, err := readDisplay(c.adb.Device(adb.DeviceWithSerial(device.Serial)))
+	if err != nil {
+		return err
+	}
+
+	device.Display = display
+	return nil
+}
+
+// GetRotationLock returns the rotation lock state of the device.
+func (c *Client) GetRotationLock(device *Device) (RotationLock, error) {
+	var lock RotationLock
+
+	resp, err := c.runCommand(device, "settings", "get", "system", "accelerometer_rotation")
+	if err != nil {
+		return lock, err
+	}
+
+	// accelerometer_rotation is 1 if auto-rotate is enabled
+	lock.Locked = strings.TrimSpace(string(resp)) == "0"
+
+	resp, err = c.runCommand(device, "settings", "get", "system", "user_rotation")
+	if err != nil {
+		return lock, err
+	}
+
+	if value := strings.TrimSpace(string(resp)); value != "" && value != "null" {
+		rotation, err := strconv.Atoi(value)
+		if err != nil {
+			return lock, err
+		}
+
+		lock.Rotation = Rotation(rotation)
+	}
+
+	return lock, nil
+}
+
+// SetRotationLock locks the rotation of the device, or enables auto-rotate if the lock is not locked.
+func (c *Client) SetRotationLock(device *Device, lock RotationLock) error {
+	c.log.Infof("Setting rotation to %s...", lock)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if err := c.trackDisplay(device); err != nil {
+		return err
+	}
+
+	return c.setRotationLock(device, lock)
+}
+
+func (c *Client) setRotationLock(device *Device, lock RotationLock) error {
+	if !lock.Locked {
+		_, err := c.runCommand(device, "settings", "put", "system", "accelerometer_rotation", "1")
+		return err
+	}
+
+	if lock.Rotation < Rotation0 || lock.Rotation > Rotation270 {
+		return fmt.Errorf("invalid rotation: %d", lock.Rotation)
+	}
+
+	if _, err := c.runCommand(device, "settings", "put", "system", "accelerometer_rotation", "0"); err != nil {
+		return err
+	}
+
+	_, err := c.runCommand(device, "settings", "put", "system", "user_rotation", strconv.Itoa(int(lock.Rotation)))
+	return err
+}
+
+// trackDisplay saves the display state of the device before it is overridden for the first time,
+// so that it can be restored by ResetDisplay.
+func (c *Client) trackDisplay(device *Device) error {
+	c.displayMu.Lock()
+	defer c.displayMu.Unlock()
+
+	if _, ok := c.displays[device.Serial]; ok {
+		return nil
+	}
+
+	override, err := c.GetDisplayOverride(device)
+	if err != nil {
+		return err
+	}
+
+	rotation, err := c.GetRotationLock(device)
+	if err != nil {
+		return err
+	}
+
+	c.displays[device.Serial] = &displayState{override: override, rotation: rotation}
+	return nil
+}
+
+// HasDisplayOverride returns true if the display of the device was overridden and not reset yet.
+func (c *Client) HasDisplayOverride(device *Device) bool {
+	c.displayMu.Lock()
+	defer c.displayMu.Unlock()
+
+	_, ok := c.displays[device.Serial]
+	return ok
+}
+
+// ResetDisplay restores the display size, density and rotation the device had before
+// it was first overridden, and refreshes its display parameters. The display is kept if the device
+// was leased by someone else meanwhile.
+func (c *Client) ResetDisplay(device *Device) error {
+	c.displayMu.Lock()
+	state, ok := c.displays[device.Serial]
+	c.displayMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	c.log.Infof("Resetting display of %s...", device)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	if err := c.setDisplayOverride(device, state.override); err != nil {
+		return err
+	}
+
+	if err := c.setRotationLock(device, state.rotation); err != nil {
+		return err
+	}
+
+	c.displayMu.Lock()
+	delete(c.displays, device.Serial)
+	c.displayMu.Unlock()
+
+	return c.RefreshDisplay(device)
+}
+
+// ResetDisplays restores the display of all devices with overrides that are online,
+// except the devices leased by someone else.
+func (c *Client) ResetDisplays() {
+	c.displayMu.Lock()
+	serials := make([]string, 0, len(c.displays))
+	for serial := range c.displays {
+		serials = append(serials, serial)
+	}
+	c.displayMu.Unlock()
+
+	for _, serial := range serials {
+		device, err := c.GetDevice(serial)
+		if err != nil {
+			c.log.Error(err)
+			continue
+		}
+
+		if device.State != StateOnline {
+			continue
+		}
+
+		if err := c.ResetDisplay(device); err != nil {
+			c.logResetError(err)
+		}
+	}
+}
+
+// logResetError logs the error of an automatic display reset, a leased device is only skipped.
+func (c *Client) logResetError(err error) {
+	if errors.Is(err, ErrDeviceLeased) {
+		c.log.Warnf("Skipping display reset: %v", err)
+		return
+	}
+
+	c.log.Error(err)
+}
+
+// onDisplayDeviceStateChanged restores the display of a device that disconnected with overrides.
+// A device can't be configured while disconnected, so the reset is done once it is back online.
+func (c *Client) onDisplayDeviceStateChanged(event DeviceStateChangedEvent) {
+	c.displayMu.Lock()
+	state, ok := c.displays[event.Serial]
+	if !ok {
+		c.displayMu.Unlock()
+		return
+	}
+
+	if event.State != StateOnline {
+		state.pending = true
+		c.displayMu.Unlock()
+		return
+	}
+
+	pending := state.pending
+	c.displayMu.Unlock()
+
+	if !pending {
+		return
+	}
+
+	device, err := c.GetDevice(event.Serial)
+	if err != nil {
+		c.log.Error(err)
+		return
+	}
+
+	if err := c.ResetDisplay(device); err != nil {
+		c.logResetError(err)
+	}
+}
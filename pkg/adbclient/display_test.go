@@ -0,0 +1,24 @@
+package adbclient
+
+import "testing"
+
+func TestParseRotation(t *testing.T) {
+	for s, expected := range map[string]Rotation{"0": Rotation0, "landscape": Rotation90, "180": Rotation180, "Reverse Landscape": Rotation270} {
+		rotation, err := ParseRotation(s)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if rotation != expected {
+			t.Errorf("expected: %s, actual: %s", expected, rotation)
+		}
+
+		if parsed, _ := ParseRotation(expected.String()); parsed != expected {
+			t.Errorf("expected %s to round trip", expected)
+		}
+	}
+
+	if _, err := ParseRotation("45"); err == nil {
+		t.Error("expected error for invalid rotation")
+	}
+}
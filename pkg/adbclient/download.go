@@ -2,6 +2,7 @@ package adbclient
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 
@@ -10,6 +11,9 @@ import (
 
 type downloadOptions struct {
 	progressFunc progressFunc
+	runAs        string
+	resume       bool
+	verify       bool
 }
 
 // DownloadOption is an option for downloading file.
@@ -31,7 +35,47 @@ func WithDownloadProgress(f func(sentBytes int64, totalBytes int64)) DownloadOpt
 	return progressDownloadOption{f}
 }
 
+type runAsDownloadOption struct {
+	pkg string
+}
+
+func (o runAsDownloadOption) apply(opts *downloadOptions) error {
+	opts.runAs = o.pkg
+	return nil
+}
+
+// WithDownloadRunAs downloads a private file of the debuggable package.
+// The file is read with run-as, as the sync protocol has no access to it.
+func WithDownloadRunAs(pkg string) DownloadOption {
+	return runAsDownloadOption{pkg}
+}
+
+type resumeDownloadOption struct{}
+
+func (o resumeDownloadOption) apply(opts *downloadOptions) error {
+	opts.resume = true
+	return nil
+}
+
+// WithDownloadResume resumes an interrupted download, the bytes already in the destination are skipped.
+func WithDownloadResume() DownloadOption {
+	return resumeDownloadOption{}
+}
+
+type verifyDownloadOption struct{}
+
+func (o verifyDownloadOption) apply(opts *downloadOptions) error {
+	opts.verify = true
+	return nil
+}
+
+// WithDownloadVerify compares the checksum of the downloaded file with the checksum computed by the device.
+func WithDownloadVerify() DownloadOption {
+	return verifyDownloadOption{}
+}
+
 // Download downloads a file from the device.
+// The size is read with sync STAT, so the progress is exact.
 func (c *Client) DownloadFile(ctx context.Context, device *Device, src, dst string, opts ...DownloadOption) error {
 	c.log.Infof("Downloading %s to %s...", src, dst)
 
@@ -42,50 +86,86 @@ func (c *Client) DownloadFile(ctx context.Context, device *Device, src, dst stri
 		}
 	}
 
-	d := c.adb.Device(adb.DeviceWithSerial(device.Serial))
+	fileOpts := fileOptions{runAs: options.runAs}
 
-	size, err := diskUsageInKilobytes(d, src)
-	if err != nil {
-		return err
+	var statOpts []FileOption
+	if options.runAs != "" {
+		statOpts = append(statOpts, WithRunAs(options.runAs))
 	}
 
-	c.log.Debugf("Downloading %d kilobytes", size)
-
-	r, err := d.OpenRead(src)
+	info, err := c.Stat(device, src, statOpts...)
 	if err != nil {
 		return err
 	}
 
-	defer r.Close()
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", src)
+	}
+
+	// a destination larger than the source isn't a part of it
+	var offset int64
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if options.resume {
+		if fi, err := os.Stat(dst); err == nil && fi.Mode().IsRegular() && fi.Size() <= info.Size {
+			offset = fi.Size()
+			flags = os.O_WRONLY | os.O_APPEND
+		}
+	}
 
-	file, err := os.Create(dst)
+	file, err := os.OpenFile(dst, flags, 0644)
 	if err != nil {
 		return err
 	}
 
 	defer file.Close()
 
-	total := 0
-	_, err = io.Copy(file, readerFunc(func(b []byte) (int, error) {
-		select {
-		case <-ctx.Done():
-			c.log.Debug("Download canceled")
-			return 0, ctx.Err()
+	c.log.Debugf("Downloading %d bytes from %d", info.Size-offset, offset)
+
+	total := offset
+	progress := func(n int64) {
+		total += n
+		if options.progressFunc != nil {
+			options.progressFunc(total, info.Size)
+		}
+	}
 
-		default:
-			n, err := r.Read(b)
-			if err != nil {
-				return 0, err
-			}
+	switch {
+	case offset == info.Size:
+		c.log.Debug("Already downloaded")
 
-			total += n
-			if options.progressFunc != nil {
-				options.progressFunc(int64(total), int64(size*1024))
-			}
+	case offset > 0 || options.runAs != "":
+		_, err = c.downloadRange(ctx, device, src, offset, options.runAs, file, progress)
 
-			return n, err
+	default:
+		var r io.ReadCloser
+		r, err = c.adb.Device(adb.DeviceWithSerial(device.Serial)).OpenRead(src)
+		if err != nil {
+			return err
+		}
+
+		_, err = copyContext(ctx, file, r, progress)
+		r.Close()
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			c.log.Debug("Download canceled")
 		}
-	}))
 
-	return err
+		return err
+	}
+
+	if total != info.Size {
+		return fmt.Errorf("%s: downloaded %d of %d bytes", src, total, info.Size)
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if options.verify {
+		return c.verifyFile(device, dst, src, fileOpts)
+	}
+
+	return nil
 }
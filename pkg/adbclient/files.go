@@ -0,0 +1,308 @@
+package adbclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// ErrFileNotFound is returned when a file doesn't exist on the device.
+var ErrFileNotFound = fmt.Errorf("file not found")
+
+// statFormat is the format of 'stat -c': the raw mode in hex, the size, the modification time and the path.
+const statFormat = "%f %s %Y %n"
+
+// FileInfo describes a file on the device.
+type FileInfo struct {
+	Name    string
+	Path    string
+	Mode    os.FileMode
+	Size    int64
+	ModTime time.Time
+}
+
+// IsDir returns true if the file is a directory.
+func (f FileInfo) IsDir() bool {
+	return f.Mode.IsDir()
+}
+
+type fileOptions struct {
+	runAs string
+}
+
+// FileOption is an option for file operations.
+type FileOption interface {
+	apply(*fileOptions) error
+}
+
+type runAsFileOption struct {
+	pkg string
+}
+
+func (o runAsFileOption) apply(opts *fileOptions) error {
+	opts.runAs = o.pkg
+	return nil
+}
+
+// WithRunAs runs the operation as the debuggable package, to access its private directories.
+// The sync protocol has no access to them, so shell commands are used instead.
+func WithRunAs(pkg string) FileOption {
+	return runAsFileOption{pkg}
+}
+
+func newFileOptions(opts []FileOption) (fileOptions, error) {
+	var options fileOptions
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return options, err
+		}
+	}
+
+	return options, nil
+}
+
+// parseStat parses a line of 'stat -c statFormat'.
+func parseStat(line string) (FileInfo, error) {
+	fields := strings.SplitN(strings.TrimRight(line, "\r"), " ", 4)
+	if len(fields) != 4 {
+		return FileInfo{}, fmt.Errorf("invalid stat: %s", line)
+	}
+
+	mode, err := strconv.ParseUint(fields[0], 16, 32)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("invalid stat mode: %s", line)
+	}
+
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("invalid stat size: %s", line)
+	}
+
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("invalid stat time: %s", line)
+	}
+
+	return FileInfo{
+		Name:    path.Base(fields[3]),
+		Path:    fields[3],
+		Mode:    wire.ParseFileModeFromAdb(uint32(mode)),
+		Size:    size,
+		ModTime: time.Unix(mtime, 0),
+	}, nil
+}
+
+// parseExitStatus splits the output of a command followed by 'echo $?' into the output and the exit status.
+func parseExitStatus(out string) (string, int, error) {
+	out = strings.TrimRight(out, "\r\n")
+
+	i := strings.LastIndexByte(out, '\n')
+	status, err := strconv.Atoi(strings.TrimSpace(out[i+1:]))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid exit status: %s", out[i+1:])
+	}
+
+	if i < 0 {
+		return "", status, nil
+	}
+
+	return out[:i], status, nil
+}
+
+// sortFiles sorts the directories first, then by name.
+func sortFiles(files []FileInfo) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].IsDir() != files[j].IsDir() {
+			return files[i].IsDir()
+		}
+
+		return files[i].Name < files[j].Name
+	})
+}
+
+// runFileCommand runs a shell command, as the package if run-as is set, and fails with its output if it fails.
+// The shell service doesn't report the exit status, so it is echoed after the output.
+func (c *Client) runFileCommand(device *Device, options fileOptions, cmd string) (string, error) {
+	if options.runAs != "" {
+		cmd = fmt.Sprintf("run-as %s %s", shellQuote(options.runAs), cmd)
+	}
+
+	resp, err := c.runCommand(device, cmd+" 2>&1; echo $?")
+	if err != nil {
+		return "", err
+	}
+
+	out, status, err := parseExitStatus(string(resp))
+	if err != nil {
+		return "", err
+	}
+
+	if status != 0 {
+		if strings.Contains(out, "No such file") {
+			return "", fmt.Errorf("%w: %s", ErrFileNotFound, strings.TrimSpace(out))
+		}
+
+		return "", fmt.Errorf("%s", strings.TrimSpace(out))
+	}
+
+	return out, nil
+}
+
+// Stat returns the information of a file, symbolic links are not followed.
+func (c *Client) Stat(device *Device, name string, opts ...FileOption) (*FileInfo, error) {
+	c.log.Debugf("Getting info of %s...", name)
+
+	options, err := newFileOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.runAs == "" {
+		info, err := c.syncStat(device, name)
+		if !errors.Is(err, errSyncV2Unsupported) {
+			return info, err
+		}
+
+		// the version 1 of the sync protocol truncates the sizes to 32 bits, stat is used instead
+		c.log.Debugf("Falling back to stat: %v", err)
+	}
+
+	out, err := c.runFileCommand(device, options, fmt.Sprintf("stat -c %s %s", shellQuote(statFormat), shellQuote(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := parseStat(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// ListDir returns the files of a directory, directories first.
+func (c *Client) ListDir(device *Device, dir string, opts ...FileOption) ([]FileInfo, error) {
+	c.log.Debugf("Listing %s...", dir)
+
+	options, err := newFileOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.runAs == "" {
+		// the sync protocol lists an empty or unreadable directory the same way, so check it exists
+		if _, err := c.Stat(device, dir); err != nil {
+			return nil, err
+		}
+
+		files, err := c.syncList(device, dir)
+		if err == nil {
+			sortFiles(files)
+			return files, nil
+		}
+
+		if !errors.Is(err, errSyncV2Unsupported) {
+			return nil, err
+		}
+
+		// the version 1 of the sync protocol truncates the sizes to 32 bits, stat is used instead
+		c.log.Debugf("Falling back to stat: %v", err)
+	}
+
+	out, err := c.runFileCommand(device, options, fmt.Sprintf("find %s -mindepth 1 -maxdepth 1 -exec stat -c %s {} +", shellQuote(dir), shellQuote(statFormat)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FileInfo
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+
+		info, err := parseStat(line)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, info)
+	}
+
+	sortFiles(files)
+	return files, nil
+}
+
+// Mkdir creates a directory and its parents.
+func (c *Client) Mkdir(device *Device, dir string, opts ...FileOption) error {
+	c.log.Infof("Creating directory %s...", dir)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	options, err := newFileOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.runFileCommand(device, options, fmt.Sprintf("mkdir -p %s", shellQuote(dir)))
+	return err
+}
+
+// Rename renames or moves a file.
+func (c *Client) Rename(device *Device, oldPath, newPath string, opts ...FileOption) error {
+	c.log.Infof("Renaming %s to %s...", oldPath, newPath)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	options, err := newFileOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.runFileCommand(device, options, fmt.Sprintf("mv %s %s", shellQuote(oldPath), shellQuote(newPath)))
+	return err
+}
+
+// Chmod changes the permissions of a file.
+func (c *Client) Chmod(device *Device, name string, mode os.FileMode, opts ...FileOption) error {
+	c.log.Infof("Changing mode of %s to %o...", name, mode.Perm())
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	options, err := newFileOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.runFileCommand(device, options, fmt.Sprintf("chmod %o %s", mode.Perm(), shellQuote(name)))
+	return err
+}
+
+// RemoveAll removes a file or a directory with its contents.
+func (c *Client) RemoveAll(device *Device, name string, opts ...FileOption) error {
+	c.log.Infof("Removing %s...", name)
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
+	options, err := newFileOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.runFileCommand(device, options, fmt.Sprintf("rm -rf %s", shellQuote(name)))
+	return err
+}
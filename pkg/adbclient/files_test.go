@@ -0,0 +1,95 @@
+package adbclient
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseStat(t *testing.T) {
+	info, err := parseStat("41f9 3452 1646128800 /data/user/0/com.example.game/files dir\r")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Name != "files dir" || info.Path != "/data/user/0/com.example.game/files dir" {
+		t.Errorf("name: %s, path: %s", info.Name, info.Path)
+	}
+
+	if !info.IsDir() || info.Mode.Perm() != 0771 {
+		t.Errorf("mode: %v", info.Mode)
+	}
+
+	if info.Size != 3452 || !info.ModTime.Equal(time.Unix(1646128800, 0)) {
+		t.Errorf("size: %d, time: %v", info.Size, info.ModTime)
+	}
+
+	info, err = parseStat("81b0 5000000000 1646128800 /sdcard/big.obb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !info.Mode.IsRegular() || info.Mode.Perm() != 0660 || info.Size != 5000000000 {
+		t.Errorf("mode: %v, size: %d", info.Mode, info.Size)
+	}
+
+	info, err = parseStat("a1ff 21 1646128800 /sdcard/link")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Mode&os.ModeSymlink == 0 {
+		t.Errorf("mode: %v", info.Mode)
+	}
+
+	for _, line := range []string{"", "41f9 3452 /sdcard", "zz 1 1 /sdcard", "41f9 x 1 /sdcard"} {
+		if _, err := parseStat(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func TestParseExitStatus(t *testing.T) {
+	tests := []struct {
+		in     string
+		out    string
+		status int
+	}{
+		{"0\n", "", 0},
+		{"line 1\nline 2\n0\r\n", "line 1\nline 2", 0},
+		{"mkdir: '/system/x': Read-only file system\n1\n", "mkdir: '/system/x': Read-only file system", 1},
+	}
+
+	for _, test := range tests {
+		out, status, err := parseExitStatus(test.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.in, err)
+		}
+
+		if out != test.out || status != test.status {
+			t.Errorf("%q: expected %q %d, actual %q %d", test.in, test.out, test.status, out, status)
+		}
+	}
+
+	if _, _, err := parseExitStatus("no status\n"); err == nil {
+		t.Error("expected error for missing status")
+	}
+}
+
+func TestSortFiles(t *testing.T) {
+	files := []FileInfo{
+		{Name: "b.txt"},
+		{Name: "z", Mode: os.ModeDir},
+		{Name: "a.txt"},
+		{Name: "c", Mode: os.ModeDir},
+	}
+
+	sortFiles(files)
+
+	expected := []string{"c", "z", "a.txt", "b.txt"}
+	for i, name := range expected {
+		if files[i].Name != name {
+			t.Errorf("%d: expected %s, actual %s", i, name, files[i].Name)
+		}
+	}
+}
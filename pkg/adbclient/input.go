@@ -222,6 +222,10 @@ func (c InputCommand) ValidateArgs(args ...interface{}) error {
 func (c *Client) Input(device *Device, source InputSource, command InputCommand, args ...interface{}) error {
 	c.log.Infof("Sending input %s %s %v...", source, command, args)
 
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
 	if err := command.ValidateArgs(args...); err != nil {
 		return err
 	}
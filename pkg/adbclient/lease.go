@@ -0,0 +1,91 @@
+package adbclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+// ErrDeviceLeased is returned by the operations changing a device leased by another owner.
+var ErrDeviceLeased = errors.New("device is leased")
+
+// Lease is a reservation of a device by a user or a job until it expires.
+type Lease struct {
+	Serial   string    `json:"serial"`
+	Owner    string    `json:"owner"`
+	Note     string    `json:"note"`
+	Acquired time.Time `json:"acquired"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Active returns true if the lease has not expired at the time.
+func (l *Lease) Active(now time.Time) bool {
+	return now.Before(l.Expires)
+}
+
+// String implements the fmt.Stringer interface.
+func (l *Lease) String() string {
+	return fmt.Sprintf("%s until %s", l.Owner, l.Expires.Format("2006-01-02 15:04"))
+}
+
+// LeaseStore returns the lease of a device, nil if it has none.
+type LeaseStore interface {
+	GetLease(serial string) (*Lease, error)
+}
+
+// DefaultLeaseOwner returns the owner of the leases taken by this process: user@host.
+func DefaultLeaseOwner() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return name
+	}
+
+	return name + "@" + host
+}
+
+// SetLeases makes the operations changing a device fail with ErrDeviceLeased if the device is leased by
+// someone else than the owner. Leases are not checked if the store is nil.
+func (c *Client) SetLeases(store LeaseStore, owner string) {
+	c.propertyMu.Lock()
+	defer c.propertyMu.Unlock()
+
+	c.leases = store
+	c.leaseOwner = owner
+}
+
+// GetLeaseOwner returns the owner of the leases taken by the client.
+func (c *Client) GetLeaseOwner() string {
+	c.propertyMu.RLock()
+	defer c.propertyMu.RUnlock()
+
+	return c.leaseOwner
+}
+
+// checkLease returns ErrDeviceLeased if the device is leased by another owner.
+func (c *Client) checkLease(device *Device) error {
+	c.propertyMu.RLock()
+	store, owner := c.leases, c.leaseOwner
+	c.propertyMu.RUnlock()
+
+	if store == nil {
+		return nil
+	}
+
+	lease, err := store.GetLease(device.Serial)
+	if err != nil {
+		return fmt.Errorf("could not check the lease of %s: %w", device.Serial, err)
+	}
+
+	if lease == nil || !lease.Active(time.Now()) || lease.Owner == owner {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w by %s", device.Serial, ErrDeviceLeased, lease)
+}
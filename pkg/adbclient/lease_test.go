@@ -0,0 +1,59 @@
+package adbclient
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/logger/empty"
+)
+
+type leaseStore map[string]*Lease
+
+func (s leaseStore) GetLease(serial string) (*Lease, error) {
+	return s[serial], nil
+}
+
+func TestCheckLease(t *testing.T) {
+	now := time.Now()
+	client := &Client{}
+	device := &Device{Serial: "123456789"}
+
+	if err := client.checkLease(device); err != nil {
+		t.Fatalf("expected no error without a lease store, got %v", err)
+	}
+
+	store := leaseStore{}
+	client.SetLeases(store, "alice")
+
+	tests := []struct {
+		lease    *Lease
+		expected error
+	}{
+		{nil, nil},
+		{&Lease{Serial: "123456789", Owner: "alice", Expires: now.Add(time.Hour)}, nil},
+		{&Lease{Serial: "123456789", Owner: "bob", Expires: now.Add(-time.Hour)}, nil},
+		{&Lease{Serial: "123456789", Owner: "bob", Expires: now.Add(time.Hour)}, ErrDeviceLeased},
+	}
+
+	for _, test := range tests {
+		store[device.Serial] = test.lease
+		if err := client.checkLease(device); !errors.Is(err, test.expected) {
+			t.Errorf("lease %v: expected %v, actual %v", test.lease, test.expected, err)
+		}
+	}
+}
+
+func TestResetDisplayLeased(t *testing.T) {
+	device := &Device{Serial: "123456789"}
+	client := &Client{log: empty.New(), displays: map[string]*displayState{device.Serial: {}}}
+	client.SetLeases(leaseStore{device.Serial: {Serial: device.Serial, Owner: "bob", Expires: time.Now().Add(time.Hour)}}, "alice")
+
+	if err := client.ResetDisplay(device); !errors.Is(err, ErrDeviceLeased) {
+		t.Fatalf("expected %v, actual %v", ErrDeviceLeased, err)
+	}
+
+	if !client.HasDisplayOverride(device) {
+		t.Error("the display state was forgotten")
+	}
+}
@@ -11,11 +11,9 @@ import (
 	"time"
 
 	"github.com/johnnyipcom/androidtool/pkg/logger"
-	"github.com/zach-klippenstein/goadb/wire"
 )
 
 var (
-	ErrInvalidLogcatMessage  = fmt.Errorf("invalid logcat message")
 	ErrInvalidLogcatPriority = fmt.Errorf("invalid logcat priority")
 )
 
@@ -34,6 +32,8 @@ const (
 	Error
 	// Fatal represents the 'F' logcat message priority.
 	Fatal
+	// Silent represents the 'S' logcat filterspec priority, no message has it.
+	Silent
 )
 
 func (l LogcatPriority) Severity() logger.Severity {
@@ -82,12 +82,22 @@ type LogcatMessage struct {
 	ProcessID int
 	ThreadID  int
 	Message   string
+
+	// UID is the uid of the process, 0 if not reported by the device.
+	UID int
+	// Buffer is the log buffer the message was read from.
+	Buffer LogcatBuffer
 }
 
 func (m LogcatMessage) Log(log logger.Logger) {
 	log.Log(m.Severity(), fmt.Sprintf("%s: %s", m.Tag, m.Message))
 }
 
+// String returns the message in the threadtime format.
+func (m LogcatMessage) String() string {
+	return m.Format(FormatThreadtime)
+}
+
 func (m LogcatMessage) Severity() logger.Severity {
 	return m.Priority.Severity()
 }
@@ -106,46 +116,20 @@ func ParseLogcatPriority(s string) (LogcatPriority, error) {
 		return Error, nil
 	case "F":
 		return Fatal, nil
+	case "S":
+		return Silent, nil
 	default:
 		return Verbose, ErrInvalidLogcatPriority
 	}
 }
 
-var logcatMsgRegex = regexp.MustCompile(`\s*([0-9]*)-([0-9]*)\s*([0-9]*):([0-9]*):([0-9]*).([0-9]*)\s*([0-9]*)\s*([0-9]*)\s*([VDIWEF])\s*(.*):\s*(.*)`)
-
-// ParseLogcatMessage parses a logcat message.
-func ParseLogcatMessage(msg string) (LogcatMessage, error) {
-	parts := logcatMsgRegex.FindStringSubmatch(msg)
-	if len(parts) < 12 {
-		return LogcatMessage{}, ErrInvalidLogcatMessage
-	}
-	month, _ := strconv.Atoi(parts[1])
-	day, _ := strconv.Atoi(parts[2])
-	hour, _ := strconv.Atoi(parts[3])
-	minute, _ := strconv.Atoi(parts[4])
-	second, _ := strconv.Atoi(parts[5])
-	microseconds, _ := strconv.Atoi(parts[6])
-	pid, _ := strconv.Atoi(parts[7])
-	tid, _ := strconv.Atoi(parts[8])
-	priority, _ := ParseLogcatPriority(parts[9])
-	tag := parts[10]
-	message := parts[11]
-
-	return LogcatMessage{
-		Timestamp: time.Date(time.Now().Year(), time.Month(month), day, hour, minute, second, microseconds*1e6, time.Local),
-		ProcessID: pid,
-		ThreadID:  tid,
-		Priority:  priority,
-		Tag:       tag,
-		Message:   message,
-	}, nil
-}
-
-// Logcat reads logcat messages from the adb server.
+// LogcatWatcher reads binary logcat entries from the adb server.
 type LogcatWatcher struct {
-	reader io.Reader
-	conn   *wire.Conn
-	log    logger.Logger
+	reader  *bufio.Reader
+	conn    io.Closer
+	log     logger.Logger
+	format  LogcatFormat
+	pending []byte
 }
 
 // Close closes the logcat connection.
@@ -153,34 +137,34 @@ func (w *LogcatWatcher) Close() error {
 	return w.conn.Close()
 }
 
-// C is a channel of LogcatMessages.
+// Next reads and decodes the next logcat entry.
+func (w *LogcatWatcher) Next() (LogcatMessage, error) {
+	return ReadLogcatEntry(w.reader)
+}
+
+// C is a channel of LogcatMessages. The channel is closed when the context is done or the connection is closed.
 func (w *LogcatWatcher) C(ctx context.Context) <-chan LogcatMessage {
 	ch := make(chan LogcatMessage)
 
 	go func() {
+		defer close(ch)
+
 		for {
+			msg, err := w.Next()
+			if err != nil {
+				// an invalid entry can't be skipped as the stream is out of sync
+				if ctx.Err() == nil {
+					w.log.Error(err)
+				}
+
+				return
+			}
+
 			select {
+			case ch <- msg:
 			case <-ctx.Done():
 				w.log.Debugf("logcat watcher stopped")
 				return
-
-			default:
-				reader := bufio.NewReader(w.reader)
-				for {
-					line, err := reader.ReadString('\n')
-					if err != nil {
-						w.log.Error(err)
-						return
-					}
-
-					msg, err := ParseLogcatMessage(line)
-					if err != nil {
-						w.log.Error(err)
-						continue
-					}
-
-					ch <- msg
-				}
 			}
 		}
 	}()
@@ -188,15 +172,34 @@ func (w *LogcatWatcher) C(ctx context.Context) <-chan LogcatMessage {
 	return ch
 }
 
-// Read implements io.Reader.
+// Read implements io.Reader. The entries are decoded and returned as text in the format set by WithLogcatFormat.
 func (w *LogcatWatcher) Read(p []byte) (int, error) {
-	return w.reader.Read(p)
+	for len(w.pending) == 0 {
+		msg, err := w.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		w.pending = []byte(msg.Format(w.format) + "\n")
+	}
+
+	n := copy(p, w.pending)
+	w.pending = w.pending[n:]
+	return n, nil
 }
 
 type logcatOptions struct {
-	tag      string
-	pid      int
-	priority LogcatPriority
+	tag         string
+	pid         int
+	priority    LogcatPriority
+	filterSpecs []LogcatFilterSpec
+	buffers     []string
+	since       time.Time
+	tail        int
+	regex       string
+	uids        []int
+	dump        bool
+	format      LogcatFormat
 }
 
 func (o logcatOptions) String() string {
@@ -209,6 +212,43 @@ func (o logcatOptions) Options() []string {
 		options = append(options, fmt.Sprintf("--pid %d", o.pid))
 	}
 
+	for _, buffer := range o.buffers {
+		options = append(options, fmt.Sprintf("-b %s", buffer))
+	}
+
+	if o.dump {
+		options = append(options, "-d")
+	}
+
+	switch {
+	case !o.since.IsZero():
+		// the epoch format doesn't depend on the device time zone
+		options = append(options, fmt.Sprintf("-T %d.%03d", o.since.Unix(), o.since.Nanosecond()/int(time.Millisecond)))
+	case o.tail > 0:
+		options = append(options, fmt.Sprintf("-T %d", o.tail))
+	}
+
+	if o.regex != "" {
+		options = append(options, fmt.Sprintf("--regex %s", shellQuote(o.regex)))
+	}
+
+	if len(o.uids) > 0 {
+		uids := make([]string, len(o.uids))
+		for i, uid := range o.uids {
+			uids[i] = strconv.Itoa(uid)
+		}
+
+		options = append(options, fmt.Sprintf("--uid %s", strings.Join(uids, ",")))
+	}
+
+	if len(o.filterSpecs) > 0 {
+		for _, spec := range o.filterSpecs {
+			options = append(options, shellQuote(spec.String()))
+		}
+
+		return options
+	}
+
 	// '*' by itself means '*:D' and <tag> by itself means <tag>:V.
 	// If no '*' filterspec or -s on command line, all filter defaults to '*:V'.
 	//  eg: '*:S <tag>' prints only <tag>, '<tag>:S' suppresses all <tag> log messages.
@@ -220,9 +260,9 @@ func (o logcatOptions) Options() []string {
 		}
 	} else {
 		if o.priority != Debug {
-			options = append(options, fmt.Sprintf("-s *:%s", o.priority))
+			options = append(options, fmt.Sprintf("-s '*:%s'", o.priority))
 		} else {
-			options = append(options, "-s *")
+			options = append(options, "-s '*'")
 		}
 	}
 
@@ -276,9 +316,134 @@ func WithLogcatPriority(priority LogcatPriority) LogcatOption {
 	return logcatPriorityOption{priority}
 }
 
+type logcatFilterSpecsOption []LogcatFilterSpec
+
+func (o logcatFilterSpecsOption) apply(opts *logcatOptions) error {
+	opts.filterSpecs = append(opts.filterSpecs, o...)
+	return nil
+}
+
+type logcatBuffersOption []LogcatBuffer
+
+func (o logcatBuffersOption) apply(opts *logcatOptions) error {
+	for _, buffer := range o {
+		opts.buffers = append(opts.buffers, buffer.String())
+	}
+
+	return nil
+}
+
+type logcatAllBuffersOption struct{}
+
+func (o logcatAllBuffersOption) apply(opts *logcatOptions) error {
+	opts.buffers = []string{"all"}
+	return nil
+}
+
+type logcatSinceOption time.Time
+
+func (o logcatSinceOption) apply(opts *logcatOptions) error {
+	opts.since = time.Time(o)
+	return nil
+}
+
+type logcatTailOption int
+
+func (o logcatTailOption) apply(opts *logcatOptions) error {
+	if o <= 0 {
+		return fmt.Errorf("invalid logcat tail count: %d", o)
+	}
+
+	opts.tail = int(o)
+	return nil
+}
+
+type logcatRegexOption string
+
+func (o logcatRegexOption) apply(opts *logcatOptions) error {
+	// logcat uses ECMAScript regular expressions, the check only catches obvious mistakes
+	if _, err := regexp.Compile(string(o)); err != nil {
+		return err
+	}
+
+	opts.regex = string(o)
+	return nil
+}
+
+type logcatUIDsOption []int
+
+func (o logcatUIDsOption) apply(opts *logcatOptions) error {
+	opts.uids = append(opts.uids, o...)
+	return nil
+}
+
+type logcatDumpOption struct{}
+
+func (o logcatDumpOption) apply(opts *logcatOptions) error {
+	opts.dump = true
+	return nil
+}
+
+type logcatFormatOption LogcatFormat
+
+func (o logcatFormatOption) apply(opts *logcatOptions) error {
+	opts.format = LogcatFormat(o)
+	return nil
+}
+
+// WithLogcatFilterSpecs adds tag:priority filterspecs. They replace the tag and priority options.
+func WithLogcatFilterSpecs(specs ...LogcatFilterSpec) LogcatOption {
+	return logcatFilterSpecsOption(specs)
+}
+
+// WithLogcatBuffers selects the log buffers, the default buffers are main, system and crash.
+func WithLogcatBuffers(buffers ...LogcatBuffer) LogcatOption {
+	return logcatBuffersOption(buffers)
+}
+
+// WithLogcatAllBuffers selects all log buffers.
+func WithLogcatAllBuffers() LogcatOption {
+	return logcatAllBuffersOption{}
+}
+
+// WithLogcatSince prints the messages since the time.
+func WithLogcatSince(since time.Time) LogcatOption {
+	return logcatSinceOption(since)
+}
+
+// WithLogcatTail prints the most recent count messages first.
+func WithLogcatTail(count int) LogcatOption {
+	return logcatTailOption(count)
+}
+
+// WithLogcatRegex prints only the messages matching the regular expression.
+func WithLogcatRegex(regex string) LogcatOption {
+	return logcatRegexOption(regex)
+}
+
+// WithLogcatUIDs prints only the messages of the uids.
+func WithLogcatUIDs(uids ...int) LogcatOption {
+	return logcatUIDsOption(uids)
+}
+
+// WithLogcatDump dumps the log and stops instead of streaming it.
+func WithLogcatDump() LogcatOption {
+	return logcatDumpOption{}
+}
+
+// WithLogcatFormat sets the text format returned by LogcatWatcher.Read.
+func WithLogcatFormat(format LogcatFormat) LogcatOption {
+	return logcatFormatOption(format)
+}
+
 // ClearLogcat clears the logcat output.
 func (c *Client) ClearLogcat(device *Device) error {
 	c.log.Info("Clearing logcat...")
+
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
 	resp, err := c.runCommand(device, "logcat -c")
 	if err != nil {
 		return err
@@ -288,7 +453,9 @@ func (c *Client) ClearLogcat(device *Device) error {
 	return nil
 }
 
-// Logcat returns a watcher that will stream the logcat output and parse it to a LogcatMessage.
+// Logcat returns a watcher that will stream the binary logcat output and decode it to LogcatMessages.
+// The exec service is used, as the shell service would mangle the binary output. The watcher has its own
+// connection, so several watchers may run in parallel with the other operations of the client.
 func (c *Client) Logcat(device *Device, opts ...LogcatOption) (*LogcatWatcher, error) {
 	c.log.Info("Getting logcat...")
 
@@ -300,15 +467,15 @@ func (c *Client) Logcat(device *Device, opts ...LogcatOption) (*LogcatWatcher, e
 		}
 	}
 
-	conn, err := c.sendCommand(device, fmt.Sprintf("logcat -v threadtime %s", strings.Join(options.Options(), " ")))
+	conn, err := c.openExecStream(device, fmt.Sprintf("logcat -B %s", strings.Join(options.Options(), " ")))
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
 
 	return &LogcatWatcher{
-		reader: c.dialer.reader,
+		reader: bufio.NewReader(conn),
 		conn:   conn,
 		log:    c.log.WithField("device", device.Serial),
+		format: options.format,
 	}, nil
 }
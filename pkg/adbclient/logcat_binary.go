@@ -0,0 +1,283 @@
+package adbclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLogcatEntry = fmt.Errorf("invalid logcat entry")
+
+// LogcatBuffer is a log buffer of logd.
+type LogcatBuffer int
+
+const (
+	BufferMain LogcatBuffer = iota
+	BufferRadio
+	BufferEvents
+	BufferSystem
+	BufferCrash
+	BufferStats
+	BufferSecurity
+	BufferKernel
+)
+
+func (b LogcatBuffer) String() string {
+	switch b {
+	case BufferMain:
+		return "main"
+	case BufferRadio:
+		return "radio"
+	case BufferEvents:
+		return "events"
+	case BufferSystem:
+		return "system"
+	case BufferCrash:
+		return "crash"
+	case BufferStats:
+		return "stats"
+	case BufferSecurity:
+		return "security"
+	case BufferKernel:
+		return "kernel"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLogcatBuffer parses a buffer name as used by 'logcat -b'.
+func ParseLogcatBuffer(s string) (LogcatBuffer, error) {
+	for b := BufferMain; b <= BufferKernel; b++ {
+		if b.String() == s {
+			return b, nil
+		}
+	}
+
+	return BufferMain, fmt.Errorf("invalid logcat buffer: %s", s)
+}
+
+// binary returns true if the buffer contains binary event payloads instead of text.
+func (b LogcatBuffer) binary() bool {
+	return b == BufferEvents || b == BufferStats || b == BufferSecurity
+}
+
+const (
+	// logger_entry v1 has no hdr_size field, the header is 20 bytes.
+	logcatEntryV1Size = 20
+	// logger_entry v2 (euid) and v3 (lid) headers are 24 bytes.
+	logcatEntryV3Size = 24
+	// logger_entry v4 (lid, uid) header is 28 bytes.
+	logcatEntryV4Size = 28
+
+	// maxLogcatPayload is the maximum size of an entry (LOGGER_ENTRY_MAX_LEN).
+	maxLogcatPayload = 5 * 1024
+)
+
+// androidPriority converts an android_LogPriority to a LogcatPriority.
+func androidPriority(p byte) LogcatPriority {
+	switch {
+	case p <= 2: // ANDROID_LOG_UNKNOWN, ANDROID_LOG_DEFAULT and ANDROID_LOG_VERBOSE
+		return Verbose
+	case p >= 7: // ANDROID_LOG_FATAL and ANDROID_LOG_SILENT
+		return Fatal
+	default:
+		return LogcatPriority(p - 2)
+	}
+}
+
+// ReadLogcatEntry reads a single binary logger_entry, as written by 'logcat -B', and decodes it.
+// Header versions 1 to 4 are supported.
+func ReadLogcatEntry(r io.Reader) (LogcatMessage, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return LogcatMessage{}, err
+	}
+
+	payloadSize := int(binary.LittleEndian.Uint16(prefix[0:2]))
+	headerSize := int(binary.LittleEndian.Uint16(prefix[2:4]))
+
+	// v1 has padding instead of hdr_size
+	if headerSize == 0 {
+		headerSize = logcatEntryV1Size
+	}
+
+	if headerSize < logcatEntryV1Size || headerSize > 256 || payloadSize > maxLogcatPayload {
+		return LogcatMessage{}, fmt.Errorf("%w: header %d bytes, payload %d bytes", ErrInvalidLogcatEntry, headerSize, payloadSize)
+	}
+
+	entry := make([]byte, headerSize+payloadSize)
+	copy(entry, prefix[:])
+	if _, err := io.ReadFull(r, entry[len(prefix):]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return LogcatMessage{}, err
+	}
+
+	return DecodeLogcatEntry(entry)
+}
+
+// DecodeLogcatEntry decodes a complete binary logger_entry with its payload.
+func DecodeLogcatEntry(entry []byte) (LogcatMessage, error) {
+	if len(entry) < logcatEntryV1Size {
+		return LogcatMessage{}, ErrInvalidLogcatEntry
+	}
+
+	le := binary.LittleEndian
+	payloadSize := int(le.Uint16(entry[0:2]))
+	headerSize := int(le.Uint16(entry[2:4]))
+	if headerSize == 0 {
+		headerSize = logcatEntryV1Size
+	}
+
+	if len(entry) < headerSize+payloadSize {
+		return LogcatMessage{}, ErrInvalidLogcatEntry
+	}
+
+	msg := LogcatMessage{
+		ProcessID: int(int32(le.Uint32(entry[4:8]))),
+		ThreadID:  int(le.Uint32(entry[8:12])),
+		Timestamp: time.Unix(int64(le.Uint32(entry[12:16])), int64(le.Uint32(entry[16:20]))),
+	}
+
+	switch {
+	case headerSize >= logcatEntryV4Size:
+		msg.Buffer = LogcatBuffer(le.Uint32(entry[20:24]))
+		msg.UID = int(le.Uint32(entry[24:28]))
+
+	case headerSize >= logcatEntryV3Size:
+		// v2 stores the euid and v3 the log id at the same offset,
+		// a log id is always smaller than the first application uid
+		if value := le.Uint32(entry[20:24]); value <= uint32(BufferKernel) {
+			msg.Buffer = LogcatBuffer(value)
+		} else {
+			msg.UID = int(value)
+		}
+	}
+
+	payload := entry[headerSize : headerSize+payloadSize]
+	if msg.Buffer.binary() {
+		return decodeEventPayload(msg, payload)
+	}
+
+	return decodeTextPayload(msg, payload)
+}
+
+// decodeTextPayload decodes a text payload: priority, tag and message separated by NULs.
+func decodeTextPayload(msg LogcatMessage, payload []byte) (LogcatMessage, error) {
+	if len(payload) < 1 {
+		return msg, ErrInvalidLogcatEntry
+	}
+
+	msg.Priority = androidPriority(payload[0])
+
+	fields := bytes.SplitN(payload[1:], []byte{0}, 3)
+	msg.Tag = string(fields[0])
+	if len(fields) > 1 {
+		msg.Message = strings.TrimRight(string(fields[1]), "\n")
+	}
+
+	return msg, nil
+}
+
+// event payload types
+const (
+	eventTypeInt    = 0
+	eventTypeLong   = 1
+	eventTypeString = 2
+	eventTypeList   = 3
+	eventTypeFloat  = 4
+)
+
+// decodeEventPayload decodes a binary event payload: the tag number followed by a typed value.
+func decodeEventPayload(msg LogcatMessage, payload []byte) (LogcatMessage, error) {
+	if len(payload) < 4 {
+		return msg, ErrInvalidLogcatEntry
+	}
+
+	msg.Priority = Info
+	msg.Tag = strconv.FormatUint(uint64(binary.LittleEndian.Uint32(payload[0:4])), 10)
+
+	if len(payload) > 4 {
+		value, _, err := decodeEventValue(payload[4:])
+		if err != nil {
+			return msg, err
+		}
+
+		msg.Message = value
+	}
+
+	return msg, nil
+}
+
+// decodeEventValue decodes a typed event value and returns it with the number of bytes consumed.
+func decodeEventValue(data []byte) (string, int, error) {
+	if len(data) < 1 {
+		return "", 0, ErrInvalidLogcatEntry
+	}
+
+	le := binary.LittleEndian
+	switch data[0] {
+	case eventTypeInt:
+		if len(data) < 5 {
+			return "", 0, ErrInvalidLogcatEntry
+		}
+
+		return strconv.FormatInt(int64(int32(le.Uint32(data[1:5]))), 10), 5, nil
+
+	case eventTypeLong:
+		if len(data) < 9 {
+			return "", 0, ErrInvalidLogcatEntry
+		}
+
+		return strconv.FormatInt(int64(le.Uint64(data[1:9])), 10), 9, nil
+
+	case eventTypeFloat:
+		if len(data) < 5 {
+			return "", 0, ErrInvalidLogcatEntry
+		}
+
+		return strconv.FormatFloat(float64(math.Float32frombits(le.Uint32(data[1:5]))), 'g', -1, 32), 5, nil
+
+	case eventTypeString:
+		if len(data) < 5 {
+			return "", 0, ErrInvalidLogcatEntry
+		}
+
+		size := int(le.Uint32(data[1:5]))
+		if len(data) < 5+size {
+			return "", 0, ErrInvalidLogcatEntry
+		}
+
+		return string(data[5 : 5+size]), 5 + size, nil
+
+	case eventTypeList:
+		if len(data) < 2 {
+			return "", 0, ErrInvalidLogcatEntry
+		}
+
+		count := int(data[1])
+		offset := 2
+		items := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			item, n, err := decodeEventValue(data[offset:])
+			if err != nil {
+				return "", 0, err
+			}
+
+			items = append(items, item)
+			offset += n
+		}
+
+		return "[" + strings.Join(items, ",") + "]", offset, nil
+
+	default:
+		return "", 0, fmt.Errorf("%w: unknown event type %d", ErrInvalidLogcatEntry, data[0])
+	}
+}
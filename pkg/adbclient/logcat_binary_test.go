@@ -0,0 +1,166 @@
+package adbclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// logcatEntry builds a binary logger_entry with the given header size and extra header fields.
+func logcatEntry(headerSize int, extra []uint32, payload []byte) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+
+	binary.Write(&buf, le, uint16(len(payload)))
+	if headerSize == logcatEntryV1Size {
+		binary.Write(&buf, le, uint16(0))
+	} else {
+		binary.Write(&buf, le, uint16(headerSize))
+	}
+
+	binary.Write(&buf, le, int32(1234))        // pid
+	binary.Write(&buf, le, uint32(5678))       // tid
+	binary.Write(&buf, le, uint32(1700000000)) // sec
+	binary.Write(&buf, le, uint32(123456789))  // nsec
+	for _, v := range extra {
+		binary.Write(&buf, le, v)
+	}
+
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+func textPayload(priority byte, tag string, message string) []byte {
+	return append(append([]byte{priority}, tag+"\x00"...), message+"\x00"...)
+}
+
+func TestReadLogcatEntry(t *testing.T) {
+	timestamp := time.Unix(1700000000, 123456789)
+	payload := textPayload(6, "Crash:Handler", "first line\nsecond line\n")
+
+	tests := []struct {
+		name     string
+		entry    []byte
+		expected LogcatMessage
+	}{
+		{
+			name:  "v1",
+			entry: logcatEntry(logcatEntryV1Size, nil, payload),
+			expected: LogcatMessage{
+				Timestamp: timestamp, Priority: Error, Tag: "Crash:Handler", ProcessID: 1234, ThreadID: 5678,
+				Message: "first line\nsecond line",
+			},
+		},
+		{
+			name:  "v2",
+			entry: logcatEntry(logcatEntryV3Size, []uint32{10123}, payload),
+			expected: LogcatMessage{
+				Timestamp: timestamp, Priority: Error, Tag: "Crash:Handler", ProcessID: 1234, ThreadID: 5678,
+				Message: "first line\nsecond line", UID: 10123,
+			},
+		},
+		{
+			name:  "v3",
+			entry: logcatEntry(logcatEntryV3Size, []uint32{uint32(BufferSystem)}, payload),
+			expected: LogcatMessage{
+				Timestamp: timestamp, Priority: Error, Tag: "Crash:Handler", ProcessID: 1234, ThreadID: 5678,
+				Message: "first line\nsecond line", Buffer: BufferSystem,
+			},
+		},
+		{
+			name:  "v4",
+			entry: logcatEntry(logcatEntryV4Size, []uint32{uint32(BufferCrash), 10123}, payload),
+			expected: LogcatMessage{
+				Timestamp: timestamp, Priority: Error, Tag: "Crash:Handler", ProcessID: 1234, ThreadID: 5678,
+				Message: "first line\nsecond line", UID: 10123, Buffer: BufferCrash,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ReadLogcatEntry(bytes.NewReader(test.entry))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !actual.Timestamp.Equal(test.expected.Timestamp) {
+				t.Errorf("expected: %v, actual: %v", test.expected.Timestamp, actual.Timestamp)
+			}
+
+			actual.Timestamp = test.expected.Timestamp
+			if actual != test.expected {
+				t.Errorf("expected: %+v, actual: %+v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadLogcatEntryStream(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(logcatEntry(logcatEntryV4Size, []uint32{0, 0}, textPayload(3, "A", "one")))
+	stream.Write(logcatEntry(logcatEntryV4Size, []uint32{0, 0}, textPayload(5, "B", "two")))
+	stream.Write(logcatEntry(logcatEntryV4Size, []uint32{0, 0}, textPayload(4, "C", "three"))[:30])
+
+	for _, expected := range []LogcatPriority{Debug, Warning} {
+		msg, err := ReadLogcatEntry(&stream)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if msg.Priority != expected {
+			t.Errorf("expected: %s, actual: %s", expected, msg.Priority)
+		}
+	}
+
+	if _, err := ReadLogcatEntry(&stream); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	if _, err := ReadLogcatEntry(&stream); !errors.Is(err, io.EOF) {
+		t.Errorf("expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestDecodeEventPayload(t *testing.T) {
+	var payload bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&payload, le, uint32(30001)) // tag
+	payload.Write([]byte{eventTypeList, 3})
+	payload.WriteByte(eventTypeInt)
+	binary.Write(&payload, le, int32(-7))
+	payload.WriteByte(eventTypeString)
+	binary.Write(&payload, le, uint32(3))
+	payload.WriteString("app")
+	payload.WriteByte(eventTypeLong)
+	binary.Write(&payload, le, int64(1)<<40)
+
+	msg, err := ReadLogcatEntry(bytes.NewReader(logcatEntry(logcatEntryV4Size, []uint32{uint32(BufferEvents), 1000}, payload.Bytes())))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Tag != "30001" || msg.Message != "[-7,app,1099511627776]" || msg.Buffer != BufferEvents {
+		t.Errorf("unexpected event: %+v", msg)
+	}
+}
+
+func TestLogcatMessageString(t *testing.T) {
+	msg := LogcatMessage{
+		Timestamp: time.Date(2026, 5, 18, 12, 1, 9, 830*1e6, time.Local),
+		Priority:  Error,
+		Tag:       "AndroidRuntime",
+		ProcessID: 5233,
+		ThreadID:  5233,
+		Message:   "FATAL EXCEPTION: main\nProcess: com.example",
+	}
+
+	expected := "05-18 12:01:09.830  5233  5233 E AndroidRuntime: FATAL EXCEPTION: main\n" +
+		"05-18 12:01:09.830  5233  5233 E AndroidRuntime: Process: com.example"
+	if msg.String() != expected {
+		t.Errorf("expected: %q, actual: %q", expected, msg.String())
+	}
+}
@@ -0,0 +1,59 @@
+package adbclient
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LogcatFilter matches logcat messages on the client side.
+// Zero values of the fields match everything.
+type LogcatFilter struct {
+	// Tag is a case-insensitive substring of the tag.
+	Tag string
+	// PIDs are the accepted process ids.
+	PIDs []int
+	// Priority is the minimum priority.
+	Priority LogcatPriority
+	// Regex is matched against the message.
+	Regex *regexp.Regexp
+	// Search is a case-insensitive substring of the tag or the message.
+	Search string
+}
+
+// Match returns true if the message passes the filter.
+func (f LogcatFilter) Match(msg LogcatMessage) bool {
+	if msg.Priority < f.Priority {
+		return false
+	}
+
+	if f.Tag != "" && !strings.Contains(strings.ToLower(msg.Tag), strings.ToLower(f.Tag)) {
+		return false
+	}
+
+	if len(f.PIDs) > 0 {
+		found := false
+		for _, pid := range f.PIDs {
+			if msg.ProcessID == pid {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.Regex != nil && !f.Regex.MatchString(msg.Message) {
+		return false
+	}
+
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(msg.Message), search) && !strings.Contains(strings.ToLower(msg.Tag), search) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,37 @@
+package adbclient
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLogcatFilter(t *testing.T) {
+	msg := LogcatMessage{Priority: Warning, Tag: "ActivityManager", ProcessID: 1200, Message: "Slow operation: 120ms"}
+
+	tests := []struct {
+		name     string
+		filter   LogcatFilter
+		expected bool
+	}{
+		{"empty", LogcatFilter{}, true},
+		{"priority below", LogcatFilter{Priority: Info}, true},
+		{"priority above", LogcatFilter{Priority: Error}, false},
+		{"tag", LogcatFilter{Tag: "activity"}, true},
+		{"other tag", LogcatFilter{Tag: "Zygote"}, false},
+		{"pid", LogcatFilter{PIDs: []int{1, 1200}}, true},
+		{"other pid", LogcatFilter{PIDs: []int{1}}, false},
+		{"regex", LogcatFilter{Regex: regexp.MustCompile(`\d+ms$`)}, true},
+		{"other regex", LogcatFilter{Regex: regexp.MustCompile(`^ANR`)}, false},
+		{"search message", LogcatFilter{Search: "SLOW"}, true},
+		{"search tag", LogcatFilter{Search: "manager"}, true},
+		{"search missing", LogcatFilter{Search: "crash"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.filter.Match(msg); actual != test.expected {
+				t.Errorf("expected: %v, actual: %v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package adbclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogcatFormat is a text format of logcat messages, matching 'logcat -v'.
+type LogcatFormat int
+
+const (
+	FormatThreadtime LogcatFormat = iota
+	FormatBrief
+	FormatTime
+	FormatLong
+	// FormatYear is the threadtime format with the year.
+	FormatYear
+	// FormatEpoch is the threadtime format with seconds since the epoch.
+	FormatEpoch
+	// FormatUID is the threadtime format with the uid.
+	FormatUID
+)
+
+// LogcatFormats are all supported formats.
+var LogcatFormats = []LogcatFormat{FormatThreadtime, FormatBrief, FormatTime, FormatLong, FormatYear, FormatEpoch, FormatUID}
+
+func (f LogcatFormat) String() string {
+	switch f {
+	case FormatThreadtime:
+		return "threadtime"
+	case FormatBrief:
+		return "brief"
+	case FormatTime:
+		return "time"
+	case FormatLong:
+		return "long"
+	case FormatYear:
+		return "year"
+	case FormatEpoch:
+		return "epoch"
+	case FormatUID:
+		return "uid"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLogcatFormat parses a format name.
+func ParseLogcatFormat(s string) (LogcatFormat, error) {
+	for _, format := range LogcatFormats {
+		if format.String() == s {
+			return format, nil
+		}
+	}
+
+	return FormatThreadtime, fmt.Errorf("invalid logcat format: %s", s)
+}
+
+// Format returns the message in the format. Each line of a multi-line message has its own header,
+// except in the long format.
+func (m LogcatMessage) Format(format LogcatFormat) string {
+	const monthDay = "01-02 15:04:05.000"
+
+	var header string
+	switch format {
+	case FormatBrief:
+		header = fmt.Sprintf("%s/%s(%5d): ", m.Priority, m.Tag, m.ProcessID)
+	case FormatTime:
+		header = fmt.Sprintf("%s %s/%s(%5d): ", m.Timestamp.Format(monthDay), m.Priority, m.Tag, m.ProcessID)
+	case FormatLong:
+		return fmt.Sprintf("[ %s %5d:%5d %s/%s ]\n%s\n", m.Timestamp.Format(monthDay), m.ProcessID, m.ThreadID, m.Priority, m.Tag, m.Message)
+	case FormatYear:
+		header = fmt.Sprintf("%s %5d %5d %s %s: ", m.Timestamp.Format("2006-01-02 15:04:05.000"), m.ProcessID, m.ThreadID, m.Priority, m.Tag)
+	case FormatEpoch:
+		header = fmt.Sprintf("%d.%03d %5d %5d %s %s: ", m.Timestamp.Unix(), m.Timestamp.Nanosecond()/1e6, m.ProcessID, m.ThreadID, m.Priority, m.Tag)
+	case FormatUID:
+		header = fmt.Sprintf("%s %5d %5d %5d %s %s: ", m.Timestamp.Format(monthDay), m.UID, m.ProcessID, m.ThreadID, m.Priority, m.Tag)
+	default:
+		header = fmt.Sprintf("%s %5d %5d %s %s: ", m.Timestamp.Format(monthDay), m.ProcessID, m.ThreadID, m.Priority, m.Tag)
+	}
+
+	lines := strings.Split(m.Message, "\n")
+	for i := range lines {
+		lines[i] = header + lines[i]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// LogcatFilterSpec is a logcat filterspec, e.g. 'ActivityManager:I' or '*:S'.
+type LogcatFilterSpec struct {
+	Tag      string
+	Priority LogcatPriority
+}
+
+func (s LogcatFilterSpec) String() string {
+	return fmt.Sprintf("%s:%s", s.Tag, s.Priority)
+}
+
+// ParseLogcatFilterSpecs parses space or comma separated filterspecs.
+// A tag by itself means <tag>:V, as in logcat.
+func ParseLogcatFilterSpecs(s string) ([]LogcatFilterSpec, error) {
+	var specs []LogcatFilterSpec
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		spec := LogcatFilterSpec{Tag: field, Priority: Verbose}
+
+		if i := strings.LastIndex(field, ":"); i >= 0 {
+			priority, err := ParseLogcatPriority(strings.ToUpper(field[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid filterspec %s: %w", field, err)
+			}
+
+			spec = LogcatFilterSpec{Tag: field[:i], Priority: priority}
+		}
+
+		if spec.Tag == "" {
+			return nil, fmt.Errorf("invalid filterspec: %s", field)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
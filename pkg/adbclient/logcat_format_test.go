@@ -0,0 +1,93 @@
+package adbclient
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogcatMessageFormat(t *testing.T) {
+	msg := LogcatMessage{
+		Timestamp: time.Date(2026, 5, 18, 12, 1, 9, 830*1e6, time.Local),
+		Priority:  Warning,
+		Tag:       "Tag",
+		ProcessID: 12,
+		ThreadID:  34,
+		UID:       10123,
+		Message:   "one\ntwo",
+	}
+
+	tests := map[LogcatFormat]string{
+		FormatBrief:      "W/Tag(   12): one\nW/Tag(   12): two",
+		FormatTime:       "05-18 12:01:09.830 W/Tag(   12): one\n05-18 12:01:09.830 W/Tag(   12): two",
+		FormatThreadtime: "05-18 12:01:09.830    12    34 W Tag: one\n05-18 12:01:09.830    12    34 W Tag: two",
+		FormatLong:       "[ 05-18 12:01:09.830    12:   34 W/Tag ]\none\ntwo\n",
+		FormatYear:       "2026-05-18 12:01:09.830    12    34 W Tag: one\n2026-05-18 12:01:09.830    12    34 W Tag: two",
+		FormatUID:        "05-18 12:01:09.830 10123    12    34 W Tag: one\n05-18 12:01:09.830 10123    12    34 W Tag: two",
+	}
+
+	for format, expected := range tests {
+		if actual := msg.Format(format); actual != expected {
+			t.Errorf("%s: expected: %q, actual: %q", format, expected, actual)
+		}
+	}
+
+	if epoch := msg.Format(FormatEpoch); !strings.HasPrefix(epoch, "1779105669.830 ") && !strings.Contains(epoch, ".830    12    34 W Tag: one") {
+		t.Errorf("unexpected epoch format: %q", epoch)
+	}
+
+	for _, format := range LogcatFormats {
+		if parsed, err := ParseLogcatFormat(format.String()); err != nil || parsed != format {
+			t.Errorf("expected %s to round trip", format)
+		}
+	}
+}
+
+func TestParseLogcatFilterSpecs(t *testing.T) {
+	specs, err := ParseLogcatFilterSpecs("ActivityManager:I MyApp:d, *:S Tag")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []LogcatFilterSpec{{"ActivityManager", Info}, {"MyApp", Debug}, {"*", Silent}, {"Tag", Verbose}}
+	if len(specs) != len(expected) {
+		t.Fatalf("expected: %v, actual: %v", expected, specs)
+	}
+
+	for i := range expected {
+		if specs[i] != expected[i] {
+			t.Errorf("expected: %v, actual: %v", expected[i], specs[i])
+		}
+	}
+
+	for _, invalid := range []string{"Tag:X", ":W"} {
+		if _, err := ParseLogcatFilterSpecs(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestLogcatOptions(t *testing.T) {
+	var options logcatOptions
+	for _, opt := range []LogcatOption{
+		WithLogcatBuffers(BufferMain, BufferCrash),
+		WithLogcatSince(time.Unix(1700000000, 250*1e6)),
+		WithLogcatRegex("crash|anr"),
+		WithLogcatUIDs(1000, 10123),
+		WithLogcatDump(),
+		WithLogcatFilterSpecs(LogcatFilterSpec{"*", Silent}, LogcatFilterSpec{"MyApp", Debug}),
+	} {
+		if err := opt.apply(&options); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "-b main -b crash -d -T 1700000000.250 --regex 'crash|anr' --uid 1000,10123 '*:S' 'MyApp:D'"
+	if actual := strings.Join(options.Options(), " "); actual != expected {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+
+	if err := WithLogcatTail(0).apply(&options); err == nil {
+		t.Error("expected error for invalid tail count")
+	}
+}
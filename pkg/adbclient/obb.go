@@ -0,0 +1,71 @@
+package adbclient
+
+import (
+	"context"
+	"errors"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OBBDir is the directory of the APK expansion files, with a directory per package.
+const OBBDir = "/sdcard/Android/obb"
+
+// OBBPath returns the path of an expansion file of the package on the device.
+func OBBPath(pkg, name string) string {
+	return path.Join(OBBDir, pkg, name)
+}
+
+// groupOBBs groups the files of the expansion files directory by package, the files are sorted by name.
+// Only the .obb files directly in the directory of a package are kept.
+func groupOBBs(files []FileInfo) map[string][]FileInfo {
+	obbs := make(map[string][]FileInfo)
+	for _, file := range files {
+		pkg, name, found := strings.Cut(file.Path, "/")
+		if !found || strings.Contains(name, "/") || !strings.HasSuffix(name, ".obb") {
+			continue
+		}
+
+		file.Path = OBBPath(pkg, name)
+		obbs[pkg] = append(obbs[pkg], file)
+	}
+
+	for _, files := range obbs {
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	}
+
+	return obbs
+}
+
+// ListOBBs returns the expansion files on the device by package.
+func (c *Client) ListOBBs(device *Device) (map[string][]FileInfo, error) {
+	c.log.Infof("Listing expansion files...")
+
+	files, _, err := c.listTree(device, OBBDir)
+	if err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			return map[string][]FileInfo{}, nil
+		}
+
+		return nil, err
+	}
+
+	return groupOBBs(files), nil
+}
+
+// PushOBB uploads an expansion file to the directory of the package, replacing the file with the same name.
+func (c *Client) PushOBB(ctx context.Context, device *Device, pkg, src string, opts ...UploadOption) error {
+	c.log.Infof("Pushing expansion file %s for %s...", filepath.Base(src), pkg)
+
+	if err := c.Mkdir(device, path.Join(OBBDir, pkg)); err != nil {
+		return err
+	}
+
+	return c.UploadFile(ctx, device, src, OBBPath(pkg, filepath.Base(src)), opts...)
+}
+
+// DeleteOBB removes an expansion file of the package.
+func (c *Client) DeleteOBB(device *Device, pkg, name string) error {
+	return c.RemoveAll(device, OBBPath(pkg, name))
+}
@@ -0,0 +1,35 @@
+package adbclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupOBBs(t *testing.T) {
+	obbs := groupOBBs([]FileInfo{
+		{Name: "patch.3.com.example.game.obb", Path: "com.example.game/patch.3.com.example.game.obb"},
+		{Name: "main.3.com.example.game.obb", Path: "com.example.game/main.3.com.example.game.obb"},
+		{Name: "notes.txt", Path: "com.example.game/notes.txt"},
+		{Name: "main.1.com.example.game.obb", Path: "com.example.game/old/main.1.com.example.game.obb"},
+		{Name: "main.7.com.example.other.obb", Path: "com.example.other/main.7.com.example.other.obb"},
+		{Name: "stray.obb", Path: "stray.obb"},
+	})
+
+	if len(obbs) != 2 {
+		t.Fatalf("unexpected packages: %v", obbs)
+	}
+
+	var names []string
+	for _, file := range obbs["com.example.game"] {
+		names = append(names, file.Path)
+	}
+
+	expected := []string{
+		"/sdcard/Android/obb/com.example.game/main.3.com.example.game.obb",
+		"/sdcard/Android/obb/com.example.game/patch.3.com.example.game.obb",
+	}
+
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got %v, expected %v", names, expected)
+	}
+}
@@ -0,0 +1,104 @@
+package adbclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrPackageNotFound = fmt.Errorf("package not found")
+
+// Package is an installed package.
+type Package struct {
+	Name        string `json:"name"`
+	VersionName string `json:"version_name"`
+	VersionCode int    `json:"version_code"`
+}
+
+func (p Package) String() string {
+	return fmt.Sprintf("%s %s (%d)", p.Name, p.VersionName, p.VersionCode)
+}
+
+// parsePackage parses the output of 'dumpsys package <name>'.
+func parsePackage(name string, out string) (*Package, error) {
+	pkg := &Package{Name: name}
+
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		for _, field := range strings.Fields(line) {
+			key, value := parseKeyVal(field, "=")
+			switch key {
+			case "versionName":
+				if pkg.VersionName == "" {
+					pkg.VersionName = value
+					found = true
+				}
+
+			case "versionCode":
+				if pkg.VersionCode == 0 {
+					code, err := strconv.Atoi(value)
+					if err != nil {
+						return nil, err
+					}
+
+					pkg.VersionCode = code
+					found = true
+				}
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	return pkg, nil
+}
+
+// GetPackage returns the installed package with the given name.
+func (c *Client) GetPackage(device *Device, name string) (*Package, error) {
+	c.log.Infof("Getting package %s...", name)
+
+	resp, err := c.runCommand(device, "dumpsys", "package", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePackage(name, string(resp))
+}
+
+// Locale returns the current locale of the device, e.g. "en-US".
+func (c *Client) Locale(device *Device) (string, error) {
+	for _, prop := range []string{"persist.sys.locale", "ro.product.locale"} {
+		locale, err := c.GetProp(device, prop)
+		if err != nil {
+			return "", err
+		}
+
+		if locale != "" {
+			return locale, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not get locale")
+}
+
+// PidOf returns the process ids of the package, empty if it is not running.
+func (c *Client) PidOf(device *Device, name string) ([]int, error) {
+	resp, err := c.runCommand(device, "pidof", name)
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, field := range strings.Fields(string(resp)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse pidof output: %s", resp)
+		}
+
+		pids = append(pids, pid)
+	}
+
+	return pids, nil
+}
@@ -0,0 +1,32 @@
+package adbclient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePackage(t *testing.T) {
+	out := `Packages:
+  Package [com.example.game] (f3b1a7e):
+    userId=10245
+    pkg=Package{9a1c2d3 com.example.game}
+    codePath=/data/app/~~abc==/com.example.game-xyz==
+    versionCode=1042 minSdk=21 targetSdk=33
+    versionName=1.4.2
+    splits=[base]
+`
+
+	pkg, err := parsePackage("com.example.game", out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Package{Name: "com.example.game", VersionName: "1.4.2", VersionCode: 1042}
+	if *pkg != expected {
+		t.Errorf("expected: %v, actual: %v", expected, *pkg)
+	}
+
+	if _, err := parsePackage("com.example.missing", "Unable to find package: com.example.missing\n"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected %v, got %v", ErrPackageNotFound, err)
+	}
+}
@@ -0,0 +1,331 @@
+package adbclient
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// glesRegexp matches the GLES line of 'dumpsys SurfaceFlinger', e.g.
+// "GLES: Qualcomm, Adreno (TM) 640, OpenGL ES 3.2 V@415.0 (GIT@...)".
+var glesRegexp = regexp.MustCompile(`(?m)^GLES: ([^,]*), (.*), OpenGL ES ([0-9.]+)`)
+
+// VulkanVersion is a Vulkan API version packed as by VK_MAKE_VERSION.
+type VulkanVersion uint32
+
+func (v VulkanVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v>>22, (v>>12)&0x3ff, v&0xfff)
+}
+
+// DeviceProfile is the hardware and software profile of a device.
+type DeviceProfile struct {
+	Manufacturer string `json:"manufacturer"`
+	Brand        string `json:"brand"`
+	Model        string `json:"model"`
+	Board        string `json:"board"`
+	Hardware     string `json:"hardware"`
+	// SoC is the system on chip, from ro.soc.* on Android 12 and newer, the board platform otherwise.
+	SoC  string   `json:"soc"`
+	ABIs []string `json:"abis"`
+
+	CPUCores int `json:"cpu_cores"`
+	// CPUMaxFrequencies are the maximum frequencies of the cores in Hz, empty if the device doesn't expose them.
+	CPUMaxFrequencies []int64 `json:"cpu_max_frequencies"`
+
+	RAM          int64 `json:"ram"`
+	StorageTotal int64 `json:"storage_total"`
+	StorageFree  int64 `json:"storage_free"`
+
+	GPUVendor   string `json:"gpu_vendor"`
+	GPURenderer string `json:"gpu_renderer"`
+	GLESVersion string `json:"gles_version"`
+	// Vulkan is the highest supported Vulkan version, zero if Vulkan is not supported.
+	Vulkan VulkanVersion `json:"vulkan"`
+
+	Release       string `json:"release"`
+	SDK           int    `json:"sdk"`
+	SecurityPatch string `json:"security_patch"`
+	Fingerprint   string `json:"fingerprint"`
+}
+
+// CPUFrequencies returns the distinct maximum frequencies of the cores in Hz, from the highest.
+func (p *DeviceProfile) CPUFrequencies() []int64 {
+	seen := make(map[int64]bool)
+	var frequencies []int64
+	for _, f := range p.CPUMaxFrequencies {
+		if !seen[f] {
+			seen[f] = true
+			frequencies = append(frequencies, f)
+		}
+	}
+
+	sort.Slice(frequencies, func(i, j int) bool { return frequencies[i] > frequencies[j] })
+	return frequencies
+}
+
+// applyProperties fills the profile from the system properties.
+func (p *DeviceProfile) applyProperties(props map[string]string) error {
+	p.Manufacturer = props["ro.product.manufacturer"]
+	p.Brand = props["ro.product.brand"]
+	p.Model = props["ro.product.model"]
+	p.Board = props["ro.product.board"]
+	p.Hardware = props["ro.hardware"]
+	p.Release = props["ro.build.version.release"]
+	p.SecurityPatch = props["ro.build.version.security_patch"]
+	p.Fingerprint = props["ro.build.fingerprint"]
+
+	switch {
+	case props["ro.soc.model"] != "":
+		p.SoC = strings.TrimSpace(props["ro.soc.manufacturer"] + " " + props["ro.soc.model"])
+	case props["ro.board.platform"] != "":
+		p.SoC = props["ro.board.platform"]
+	default:
+		p.SoC = p.Hardware
+	}
+
+	if abis := props["ro.product.cpu.abilist"]; abis != "" {
+		p.ABIs = strings.Split(abis, ",")
+	} else if abi := props["ro.product.cpu.abi"]; abi != "" {
+		p.ABIs = []string{abi}
+	}
+
+	if sdk := props["ro.build.version.sdk"]; sdk != "" {
+		var err error
+		p.SDK, err = strconv.Atoi(sdk)
+		if err != nil {
+			return fmt.Errorf("invalid SDK version: %s", sdk)
+		}
+	}
+
+	return nil
+}
+
+// parseMemTotal parses /proc/meminfo and returns the total RAM in bytes.
+func parseMemTotal(out string) (int64, error) {
+	for _, line := range strings.Split(out, "\n") {
+		key, value := parseKeyVal(line, ":")
+		if key != "MemTotal" {
+			continue
+		}
+
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			break
+		}
+
+		kb, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid MemTotal: %s", value)
+		}
+
+		return kb * 1024, nil
+	}
+
+	return 0, fmt.Errorf("no MemTotal in meminfo: %q", truncate(out, 100))
+}
+
+// parseCPURange parses a kernel CPU list such as /sys/devices/system/cpu/possible, e.g. "0-3,6,8-9",
+// and returns the number of CPUs.
+func parseCPURange(out string) (int, error) {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return 0, fmt.Errorf("empty CPU list")
+	}
+
+	count := 0
+	for _, part := range strings.Split(out, ",") {
+		from, to := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			from, to = part[:i], part[i+1:]
+		}
+
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU list: %s", out)
+		}
+
+		last, err := strconv.Atoi(to)
+		if err != nil || last < first {
+			return 0, fmt.Errorf("invalid CPU list: %s", out)
+		}
+
+		count += last - first + 1
+	}
+
+	return count, nil
+}
+
+// parseCPUFrequencies parses the cpuinfo_max_freq files of the cores in kHz and returns them in Hz,
+// the error messages of the cores without cpufreq are ignored.
+func parseCPUFrequencies(out string) []int64 {
+	var frequencies []int64
+	for _, line := range strings.Split(out, "\n") {
+		khz, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+		if err == nil {
+			frequencies = append(frequencies, khz*1000)
+		}
+	}
+
+	return frequencies
+}
+
+// parseDf parses the output of 'df -k <path>' and returns the total and available bytes.
+func parseDf(out string) (int64, int64, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return 0, 0, fmt.Errorf("invalid df output: %q", truncate(out, 100))
+	}
+
+	// the header is "Filesystem 1K-blocks Used Available Use% Mounted on"
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, 0, fmt.Errorf("invalid df output: %q", truncate(out, 100))
+	}
+
+	total, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid df size: %s", fields[1])
+	}
+
+	free, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid df available size: %s", fields[3])
+	}
+
+	return total * 1024, free * 1024, nil
+}
+
+// parseGLES parses the GLES line of 'dumpsys SurfaceFlinger' and returns the vendor, the renderer and
+// the version of OpenGL ES. ok is false if there is no GLES line, e.g. if SurfaceFlinger is not running.
+func parseGLES(out string) (vendor, renderer, version string, ok bool) {
+	match := glesRegexp.FindStringSubmatch(out)
+	if match == nil {
+		return "", "", "", false
+	}
+
+	return match[1], match[2], match[3], true
+}
+
+// parseSystemFeatures parses the output of 'pm list features', lines of 'feature:<name>[=<version>]'.
+func parseSystemFeatures(out string) map[string]string {
+	features := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "feature:") {
+			continue
+		}
+
+		name, version := strings.TrimPrefix(line, "feature:"), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, version = name[:i], name[i+1:]
+		}
+
+		features[name] = version
+	}
+
+	return features
+}
+
+// parseVulkanVersion returns the Vulkan version of the system features, zero if Vulkan is not supported.
+func parseVulkanVersion(features map[string]string) (VulkanVersion, error) {
+	version, ok := features["android.hardware.vulkan.version"]
+	if !ok {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(version, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Vulkan version: %s", version)
+	}
+
+	return VulkanVersion(v), nil
+}
+
+// SystemFeatures returns the features of the device reported by 'pm list features', with their version if any.
+func (c *Client) SystemFeatures(device *Device) (map[string]string, error) {
+	c.log.Infof("Getting system features of %s...", device.Serial)
+
+	out, err := c.runCommand(device, "pm", "list", "features")
+	if err != nil {
+		return nil, err
+	}
+
+	features := parseSystemFeatures(string(out))
+	if len(features) == 0 {
+		return nil, fmt.Errorf("no features in pm output: %q", truncate(string(out), 100))
+	}
+
+	return features, nil
+}
+
+// Profile reads the hardware and software profile of the device.
+func (c *Client) Profile(device *Device) (*DeviceProfile, error) {
+	c.log.Infof("Getting profile of %s...", device.Serial)
+
+	props, err := c.Properties(device)
+	if err != nil {
+		return nil, fmt.Errorf("could not read properties: %w", err)
+	}
+
+	profile := &DeviceProfile{}
+	if err := profile.applyProperties(props); err != nil {
+		return nil, err
+	}
+
+	out, err := c.runCommand(device, "cat", "/proc/meminfo")
+	if err != nil {
+		return nil, fmt.Errorf("could not read meminfo: %w", err)
+	}
+
+	if profile.RAM, err = parseMemTotal(string(out)); err != nil {
+		return nil, err
+	}
+
+	out, err = c.runCommand(device, "cat", "/sys/devices/system/cpu/possible")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the CPU list: %w", err)
+	}
+
+	if profile.CPUCores, err = parseCPURange(string(out)); err != nil {
+		return nil, err
+	}
+
+	out, err = c.runCommand(device, "cat", "/sys/devices/system/cpu/cpu[0-9]*/cpufreq/cpuinfo_max_freq")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the CPU frequencies: %w", err)
+	}
+
+	profile.CPUMaxFrequencies = parseCPUFrequencies(string(out))
+
+	out, err = c.runCommand(device, "df", "-k", "/data")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the storage size: %w", err)
+	}
+
+	if profile.StorageTotal, profile.StorageFree, err = parseDf(string(out)); err != nil {
+		return nil, err
+	}
+
+	out, err = c.runCommand(device, "dumpsys", "SurfaceFlinger")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the GPU: %w", err)
+	}
+
+	var ok bool
+	if profile.GPUVendor, profile.GPURenderer, profile.GLESVersion, ok = parseGLES(string(out)); !ok {
+		c.log.Warnf("No GLES information in the SurfaceFlinger dump of %s", device.Serial)
+	}
+
+	features, err := c.SystemFeatures(device)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the system features: %w", err)
+	}
+
+	if profile.Vulkan, err = parseVulkanVersion(features); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
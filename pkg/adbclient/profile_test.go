@@ -0,0 +1,179 @@
+package adbclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	out := "[ro.build.version.sdk]: [33]\r\n" +
+		"[ro.product.model]: [Pixel 7]\n" +
+		"[persist.sys.motd]: [first line\n" +
+		"second line]\n" +
+		"[empty]: []\n" +
+		"warning: not a property\n"
+
+	props, err := parseProperties(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"ro.build.version.sdk": "33",
+		"ro.product.model":     "Pixel 7",
+		"persist.sys.motd":     "first line\nsecond line",
+		"empty":                "",
+	}
+
+	if !reflect.DeepEqual(props, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, props)
+	}
+
+	if _, err := parseProperties("/system/bin/sh: getprop: not found"); err == nil {
+		t.Error("expected error without properties")
+	}
+
+	if _, err := parseProperties("[a]: [unterminated\n"); err == nil {
+		t.Error("expected error for an unterminated value")
+	}
+}
+
+func TestApplyProperties(t *testing.T) {
+	var profile DeviceProfile
+	err := profile.applyProperties(map[string]string{
+		"ro.soc.manufacturer":    "Google",
+		"ro.soc.model":           "Tensor G2",
+		"ro.board.platform":      "gs201",
+		"ro.product.cpu.abilist": "arm64-v8a,armeabi-v7a",
+		"ro.build.version.sdk":   "33",
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if profile.SoC != "Google Tensor G2" || profile.SDK != 33 || !reflect.DeepEqual(profile.ABIs, []string{"arm64-v8a", "armeabi-v7a"}) {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	profile = DeviceProfile{}
+	if err := profile.applyProperties(map[string]string{"ro.board.platform": "msmnile", "ro.product.cpu.abi": "arm64-v8a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if profile.SoC != "msmnile" || !reflect.DeepEqual(profile.ABIs, []string{"arm64-v8a"}) {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	if err := profile.applyProperties(map[string]string{"ro.build.version.sdk": "S"}); err == nil {
+		t.Error("expected error for an invalid SDK version")
+	}
+}
+
+func TestParseMemTotal(t *testing.T) {
+	ram, err := parseMemTotal("MemTotal:        7765432 kB\nMemFree:          123456 kB\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ram != 7765432*1024 {
+		t.Errorf("expected: %d, actual: %d", 7765432*1024, ram)
+	}
+
+	if _, err := parseMemTotal("cat: /proc/meminfo: Permission denied"); err == nil {
+		t.Error("expected error without MemTotal")
+	}
+}
+
+func TestParseCPURange(t *testing.T) {
+	for out, expected := range map[string]int{"0-7\n": 8, "0": 1, "0-3,6,8-9": 7} {
+		count, err := parseCPURange(out)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", out, err)
+		}
+
+		if count != expected {
+			t.Errorf("%q: expected: %d, actual: %d", out, expected, count)
+		}
+	}
+
+	for _, out := range []string{"", "a-b", "3-1"} {
+		if _, err := parseCPURange(out); err == nil {
+			t.Errorf("expected error for %q", out)
+		}
+	}
+}
+
+func TestCPUFrequencies(t *testing.T) {
+	out := "1804800\n1804800\ncat: /sys/devices/system/cpu/cpu2/cpufreq/cpuinfo_max_freq: No such file or directory\n2841600\n"
+
+	profile := DeviceProfile{CPUMaxFrequencies: parseCPUFrequencies(out)}
+	if !reflect.DeepEqual(profile.CPUMaxFrequencies, []int64{1804800000, 1804800000, 2841600000}) {
+		t.Errorf("unexpected frequencies: %v", profile.CPUMaxFrequencies)
+	}
+
+	if !reflect.DeepEqual(profile.CPUFrequencies(), []int64{2841600000, 1804800000}) {
+		t.Errorf("unexpected distinct frequencies: %v", profile.CPUFrequencies())
+	}
+}
+
+func TestParseDf(t *testing.T) {
+	out := "Filesystem      1K-blocks     Used Available Use% Mounted on\n/dev/block/dm-8 115343360 40000000  75343360  35% /data\n"
+
+	total, free, err := parseDf(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total != 115343360*1024 || free != 75343360*1024 {
+		t.Errorf("unexpected sizes: %d, %d", total, free)
+	}
+
+	if _, _, err := parseDf("df: /data: Permission denied"); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestParseGLES(t *testing.T) {
+	out := "Display 0\nGLES: Qualcomm, Adreno (TM) 640, OpenGL ES 3.2 V@415.0 (GIT@663be55, I724753c5e3)\nRegion undefinedRegion\n"
+
+	vendor, renderer, version, ok := parseGLES(out)
+	if !ok || vendor != "Qualcomm" || renderer != "Adreno (TM) 640" || version != "3.2" {
+		t.Errorf("unexpected GLES: %q, %q, %q, %t", vendor, renderer, version, ok)
+	}
+
+	out = "GLES: Google (Intel), Android Emulator OpenGL ES Translator (Mesa Intel(R) UHD Graphics 620), OpenGL ES 3.0 (4.6 (Core Profile) Mesa 21.2.6)"
+	_, renderer, version, ok = parseGLES(out)
+	if !ok || renderer != "Android Emulator OpenGL ES Translator (Mesa Intel(R) UHD Graphics 620)" || version != "3.0" {
+		t.Errorf("unexpected emulator GLES: %q, %q, %t", renderer, version, ok)
+	}
+
+	if _, _, _, ok := parseGLES("Can't find service: SurfaceFlinger"); ok {
+		t.Error("expected no GLES")
+	}
+}
+
+func TestParseVulkanVersion(t *testing.T) {
+	features := parseSystemFeatures("feature:reqGlEsVersion=0x30002\nfeature:android.hardware.vulkan.version=4198400\nfeature:android.hardware.wifi\n")
+
+	if _, ok := features["android.hardware.wifi"]; !ok {
+		t.Error("expected the wifi feature")
+	}
+
+	version, err := parseVulkanVersion(features)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if version.String() != "1.1.0" {
+		t.Errorf("expected: 1.1.0, actual: %s", version)
+	}
+
+	if version, _ := parseVulkanVersion(map[string]string{}); version != 0 {
+		t.Errorf("expected no Vulkan, actual: %s", version)
+	}
+
+	if _, err := parseVulkanVersion(map[string]string{"android.hardware.vulkan.version": "x"}); err == nil {
+		t.Error("expected error for an invalid version")
+	}
+}
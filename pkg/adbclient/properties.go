@@ -0,0 +1,82 @@
+package adbclient
+
+import (
+	"fmt"
+	"strings"
+
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+// parseProperties parses the output of 'getprop' without arguments, lines of '[key]: [value]'.
+// Values spanning several lines are joined with newlines, other lines are ignored.
+func parseProperties(out string) (map[string]string, error) {
+	props := make(map[string]string)
+
+	var key string
+	var value []string
+	pending := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n") {
+		if pending {
+			value = append(value, line)
+			if strings.HasSuffix(line, "]") {
+				props[key] = strings.TrimSuffix(strings.Join(value, "\n"), "]")
+				pending = false
+			}
+
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		i := strings.Index(line, "]: [")
+		if i < 0 {
+			continue
+		}
+
+		key, value = line[1:i], []string{line[i+len("]: ["):]}
+		if strings.HasSuffix(line, "]") {
+			props[key] = strings.TrimSuffix(value[0], "]")
+		} else {
+			pending = true
+		}
+	}
+
+	if pending {
+		return props, fmt.Errorf("unterminated value of property %s", key)
+	}
+
+	if len(props) == 0 {
+		return nil, fmt.Errorf("no properties in getprop output: %q", truncate(out, 100))
+	}
+
+	return props, nil
+}
+
+// truncate returns at most n bytes of s.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+
+	return s
+}
+
+// readProperties reads all the properties of the device.
+func readProperties(device *adb.Device) (map[string]string, error) {
+	out, err := device.RunCommand("getprop")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProperties(out)
+}
+
+// Properties returns all the system properties of the device.
+func (c *Client) Properties(device *Device) (map[string]string, error) {
+	c.log.Infof("Getting properties of %s...", device.Serial)
+	return readProperties(c.adb.Device(adb.DeviceWithSerial(device.Serial)))
+}
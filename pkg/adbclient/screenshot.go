@@ -1,7 +1,10 @@
 package adbclient
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/png"
 	"strings"
 )
 
@@ -56,3 +59,15 @@ func (c *Client) Screenshot(device *Device, path string, opts ...ScreenshotOptio
 	c.log.Debugf("Got response: %s", resp)
 	return nil
 }
+
+// CaptureScreen takes a screenshot of the device and returns it as an image without storing it on the device.
+func (c *Client) CaptureScreen(device *Device) (image.Image, error) {
+	c.log.Debug("Capturing screen...")
+
+	resp, err := c.execCommand(device, "screencap", "-p")
+	if err != nil {
+		return nil, err
+	}
+
+	return png.Decode(bytes.NewReader(resp))
+}
@@ -0,0 +1,265 @@
+package adbclient
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// DefaultShellTerm is the default terminal type of interactive shells.
+const DefaultShellTerm = "xterm-256color"
+
+// shell protocol v2 packet ids
+const (
+	shellStdin byte = iota
+	shellStdout
+	shellStderr
+	shellExit
+	shellCloseStdin
+	shellWindowSizeChange
+)
+
+// shellHeaderSize is the size of the header of a packet, its id and the little endian length of its data.
+const shellHeaderSize = 5
+
+type shellOptions struct {
+	term       string
+	rows, cols int
+}
+
+// ShellOption is an option for interactive shells.
+type ShellOption interface {
+	apply(*shellOptions) error
+}
+
+type termShellOption struct {
+	term string
+}
+
+func (o termShellOption) apply(opts *shellOptions) error {
+	opts.term = o.term
+	return nil
+}
+
+// WithShellTerm sets the TERM of the shell, DefaultShellTerm by default.
+func WithShellTerm(term string) ShellOption {
+	return termShellOption{term}
+}
+
+type sizeShellOption struct {
+	rows, cols int
+}
+
+func (o sizeShellOption) apply(opts *shellOptions) error {
+	if o.rows < 1 || o.cols < 1 {
+		return fmt.Errorf("invalid shell size: %dx%d", o.rows, o.cols)
+	}
+
+	opts.rows, opts.cols = o.rows, o.cols
+	return nil
+}
+
+// WithShellSize sets the initial size of the terminal of the shell.
+func WithShellSize(rows, cols int) ShellOption {
+	return sizeShellOption{rows, cols}
+}
+
+// Shell is an interactive shell of a device with a PTY.
+// Reading returns the output of the terminal and writing sends keys to it.
+type Shell struct {
+	conn io.ReadWriteCloser
+	v2   bool
+
+	writeMu sync.Mutex
+
+	// remaining is the number of bytes of the current output packet not read yet
+	remaining int
+	exitCode  int
+	exited    bool
+}
+
+func newShell(conn io.ReadWriteCloser, v2 bool) *Shell {
+	return &Shell{conn: conn, v2: v2, exitCode: -1}
+}
+
+// writePacket sends a packet of the shell protocol v2.
+func (s *Shell) writePacket(id byte, data []byte) error {
+	packet := make([]byte, shellHeaderSize+len(data))
+	packet[0] = id
+	binary.LittleEndian.PutUint32(packet[1:], uint32(len(data)))
+	copy(packet[shellHeaderSize:], data)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+// Read reads the output of the terminal, io.EOF is returned when the shell exits.
+func (s *Shell) Read(p []byte) (int, error) {
+	if !s.v2 {
+		return s.conn.Read(p)
+	}
+
+	for s.remaining == 0 {
+		if s.exited {
+			return 0, io.EOF
+		}
+
+		var header [shellHeaderSize]byte
+		if _, err := io.ReadFull(s.conn, header[:]); err != nil {
+			return 0, err
+		}
+
+		length := int(binary.LittleEndian.Uint32(header[1:]))
+		switch header[0] {
+		case shellStdout, shellStderr:
+			s.remaining = length
+		case shellExit:
+			data := make([]byte, length)
+			if _, err := io.ReadFull(s.conn, data); err != nil {
+				return 0, err
+			}
+
+			if len(data) > 0 {
+				s.exitCode = int(data[0])
+			}
+
+			s.exited = true
+		default:
+			if _, err := io.CopyN(io.Discard, s.conn, int64(length)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(p) > s.remaining {
+		p = p[:s.remaining]
+	}
+
+	n, err := s.conn.Read(p)
+	s.remaining -= n
+	return n, err
+}
+
+// Write sends keys to the terminal.
+func (s *Shell) Write(p []byte) (int, error) {
+	if !s.v2 {
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+
+		return s.conn.Write(p)
+	}
+
+	if err := s.writePacket(shellStdin, p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Resize changes the size of the terminal. The legacy shell protocol has no way to do it, so it is ignored
+// by devices without shell v2 (Android 6 and older).
+func (s *Shell) Resize(rows, cols int) error {
+	if !s.v2 {
+		return nil
+	}
+
+	return s.writePacket(shellWindowSizeChange, []byte(fmt.Sprintf("%dx%d,%dx%d\x00", rows, cols, 0, 0)))
+}
+
+// V2 returns true if the shell uses the shell protocol v2, which supports resizing.
+func (s *Shell) V2() bool {
+	return s.v2
+}
+
+// ExitCode returns the exit code of the shell once it has exited. The legacy protocol doesn't report it.
+func (s *Shell) ExitCode() (int, bool) {
+	return s.exitCode, s.exited
+}
+
+// Close closes the shell.
+func (s *Shell) Close() error {
+	return s.conn.Close()
+}
+
+// features returns the features supported by both the adb server and the device, e.g. "shell_v2".
+func (c *Client) features(device *Device) ([]string, error) {
+	netConn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", c.port))
+	if err != nil {
+		return nil, err
+	}
+
+	defer netConn.Close()
+
+	conn := &wire.Conn{Scanner: wire.NewScanner(netConn), Sender: wire.NewSender(netConn)}
+	req := fmt.Sprintf("host-serial:%s:features", device.Serial)
+	if err := wire.SendMessageString(conn, req); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ReadStatus(req); err != nil {
+		return nil, err
+	}
+
+	features, err := wire.ReadMessageString(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(features, ","), nil
+}
+
+// OpenShell opens an interactive shell with a PTY. The shell protocol v2 is used if the device supports it,
+// so that the terminal can be resized, the legacy shell service otherwise.
+func (c *Client) OpenShell(device *Device, opts ...ShellOption) (*Shell, error) {
+	if err := c.checkLease(device); err != nil {
+		return nil, err
+	}
+
+	options := shellOptions{term: DefaultShellTerm}
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	v2 := false
+	features, err := c.features(device)
+	if err != nil {
+		c.log.Warnf("Could not get the features of %s: %v", device.Serial, err)
+	}
+
+	for _, feature := range features {
+		if feature == "shell_v2" {
+			v2 = true
+		}
+	}
+
+	service := "shell:"
+	if v2 {
+		service = fmt.Sprintf("shell,v2,pty,TERM=%s:", options.term)
+	}
+
+	c.log.Infof("Opening shell of %s with %s", device.Serial, service)
+	conn, err := c.openStream(device, service)
+	if err != nil {
+		return nil, err
+	}
+
+	shell := newShell(conn, v2)
+	if options.rows > 0 {
+		if err := shell.Resize(options.rows, options.cols); err != nil {
+			shell.Close()
+			return nil, err
+		}
+	}
+
+	return shell, nil
+}
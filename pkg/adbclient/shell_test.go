@@ -0,0 +1,93 @@
+package adbclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+type fakeConn struct {
+	io.Reader
+	bytes.Buffer
+}
+
+func (c *fakeConn) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func shellPacket(id byte, data string) []byte {
+	packet := []byte{id, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(packet[1:], uint32(len(data)))
+	return append(packet, data...)
+}
+
+func TestShellV2Read(t *testing.T) {
+	var input []byte
+	input = append(input, shellPacket(shellStdout, "ab")...)
+	input = append(input, shellPacket(shellStderr, "c")...)
+	input = append(input, shellPacket(42, "ignored")...)
+	input = append(input, shellPacket(shellStdout, "de")...)
+	input = append(input, shellPacket(shellExit, "\x03")...)
+
+	shell := newShell(&fakeConn{Reader: bytes.NewReader(input)}, true)
+	output, err := io.ReadAll(shell)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(output) != "abcde" {
+		t.Errorf("expected abcde, got %q", output)
+	}
+
+	if code, exited := shell.ExitCode(); !exited || code != 3 {
+		t.Errorf("exit code: %d, %v", code, exited)
+	}
+}
+
+func TestShellV2Write(t *testing.T) {
+	conn := &fakeConn{Reader: bytes.NewReader(nil)}
+	shell := newShell(conn, true)
+
+	if _, err := shell.Write([]byte("ls\r")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shell.Resize(24, 80); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := append(shellPacket(shellStdin, "ls\r"), shellPacket(shellWindowSizeChange, "24x80,0x0\x00")...)
+	if !bytes.Equal(conn.Bytes(), expected) {
+		t.Errorf("expected %q, got %q", expected, conn.Bytes())
+	}
+}
+
+func TestShellLegacy(t *testing.T) {
+	conn := &fakeConn{Reader: bytes.NewReader([]byte("$ "))}
+	shell := newShell(conn, false)
+
+	if _, err := shell.Write([]byte("ls\r")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shell.Resize(24, 80); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.String() != "ls\r" {
+		t.Errorf("expected the raw input, got %q", conn.String())
+	}
+
+	if output, _ := io.ReadAll(shell); string(output) != "$ " {
+		t.Errorf("expected the raw output, got %q", output)
+	}
+
+	if _, exited := shell.ExitCode(); exited {
+		t.Error("the legacy protocol doesn't report the exit code")
+	}
+}
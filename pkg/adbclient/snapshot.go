@@ -0,0 +1,186 @@
+package adbclient
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot sections
+const (
+	SectionProperties     = "getprop"
+	SectionFeatures       = "features"
+	SectionSettingsGlobal = "settings global"
+	SectionSettingsSystem = "settings system"
+	SectionSettingsSecure = "settings secure"
+)
+
+// SnapshotSections are the sections of a snapshot in the order they are diffed.
+var SnapshotSections = []string{SectionProperties, SectionFeatures, SectionSettingsGlobal, SectionSettingsSystem, SectionSettingsSecure}
+
+// SettingsNamespaces are the namespaces of the 'settings' command.
+var SettingsNamespaces = []string{"global", "system", "secure"}
+
+// Snapshot is the configuration of a device at some point in time: its properties, its features and its settings.
+type Snapshot struct {
+	Serial string    `json:"serial"`
+	Model  string    `json:"model"`
+	Label  string    `json:"label"`
+	Time   time.Time `json:"time"`
+	// Sections are the key/value pairs of each section, features without a version have an empty value.
+	Sections map[string]map[string]string `json:"sections"`
+}
+
+// String implements the fmt.Stringer interface.
+func (s *Snapshot) String() string {
+	str := fmt.Sprintf("%s (%s) %s", s.Serial, s.Model, s.Time.Format("2006-01-02 15:04:05"))
+	if s.Label != "" {
+		str += " " + s.Label
+	}
+
+	return str
+}
+
+// Fingerprint returns the build fingerprint of the device when the snapshot was taken.
+func (s *Snapshot) Fingerprint() string {
+	return s.Sections[SectionProperties]["ro.build.fingerprint"]
+}
+
+// parseSettings parses the output of 'settings list <namespace>', lines of 'key=value'.
+func parseSettings(out string) map[string]string {
+	settings := make(map[string]string)
+	for _, line := range strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n") {
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			continue
+		}
+
+		settings[line[:i]] = line[i+1:]
+	}
+
+	return settings
+}
+
+// Settings returns the settings of the device in the namespace: global, system or secure.
+func (c *Client) Settings(device *Device, namespace string) (map[string]string, error) {
+	c.log.Infof("Getting %s settings of %s...", namespace, device.Serial)
+
+	out, err := c.runCommand(device, "settings", "list", namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := parseSettings(string(out))
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("no %s settings in output: %q", namespace, truncate(string(out), 100))
+	}
+
+	return settings, nil
+}
+
+// Snapshot reads the properties, the features and the settings of the device.
+func (c *Client) Snapshot(device *Device, label string) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Serial:   device.Serial,
+		Model:    device.Model,
+		Label:    label,
+		Time:     time.Now(),
+		Sections: make(map[string]map[string]string),
+	}
+
+	props, err := c.Properties(device)
+	if err != nil {
+		return nil, fmt.Errorf("could not read properties: %w", err)
+	}
+
+	snapshot.Sections[SectionProperties] = props
+
+	features, err := c.SystemFeatures(device)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the system features: %w", err)
+	}
+
+	snapshot.Sections[SectionFeatures] = features
+
+	for _, namespace := range SettingsNamespaces {
+		settings, err := c.Settings(device, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s settings: %w", namespace, err)
+		}
+
+		snapshot.Sections["settings "+namespace] = settings
+	}
+
+	return snapshot, nil
+}
+
+// ChangeKind is the kind of a change between two snapshots.
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// SnapshotChange is a key that differs between two snapshots.
+type SnapshotChange struct {
+	Section string
+	Key     string
+	Kind    ChangeKind
+	// Old is the value in the first snapshot, empty if it was added.
+	Old string
+	// New is the value in the second snapshot, empty if it was removed.
+	New string
+}
+
+// DiffSnapshots returns the keys that differ from the snapshot a to the snapshot b, by section and key.
+func DiffSnapshots(a, b *Snapshot) []SnapshotChange {
+	var changes []SnapshotChange
+	for _, section := range SnapshotSections {
+		old, cur := a.Sections[section], b.Sections[section]
+
+		keys := make([]string, 0, len(old)+len(cur))
+		for key := range old {
+			keys = append(keys, key)
+		}
+
+		for key := range cur {
+			if _, ok := old[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			oldValue, inOld := old[key]
+			newValue, inNew := cur[key]
+
+			switch {
+			case !inOld:
+				changes = append(changes, SnapshotChange{Section: section, Key: key, Kind: ChangeAdded, New: newValue})
+			case !inNew:
+				changes = append(changes, SnapshotChange{Section: section, Key: key, Kind: ChangeRemoved, Old: oldValue})
+			case oldValue != newValue:
+				changes = append(changes, SnapshotChange{Section: section, Key: key, Kind: ChangeModified, Old: oldValue, New: newValue})
+			}
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,49 @@
+package adbclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSettings(t *testing.T) {
+	settings := parseSettings("adb_enabled=1\r\ndevice_name=Pixel 7\nempty=\nurl=https://example.com/?a=b\nnot a setting\n")
+
+	expected := map[string]string{
+		"adb_enabled": "1",
+		"device_name": "Pixel 7",
+		"empty":       "",
+		"url":         "https://example.com/?a=b",
+	}
+
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, settings)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	a := &Snapshot{Sections: map[string]map[string]string{
+		SectionProperties:     {"ro.build.version.sdk": "32", "ro.removed": "1", "ro.same": "x"},
+		SectionSettingsGlobal: {"adb_enabled": "1"},
+	}}
+
+	b := &Snapshot{Sections: map[string]map[string]string{
+		SectionProperties:     {"ro.build.version.sdk": "33", "ro.added": "1", "ro.same": "x"},
+		SectionFeatures:       {"android.hardware.vulkan.level": "1"},
+		SectionSettingsGlobal: {"adb_enabled": "1"},
+	}}
+
+	expected := []SnapshotChange{
+		{Section: SectionProperties, Key: "ro.added", Kind: ChangeAdded, New: "1"},
+		{Section: SectionProperties, Key: "ro.build.version.sdk", Kind: ChangeModified, Old: "32", New: "33"},
+		{Section: SectionProperties, Key: "ro.removed", Kind: ChangeRemoved, Old: "1"},
+		{Section: SectionFeatures, Key: "android.hardware.vulkan.level", Kind: ChangeAdded, New: "1"},
+	}
+
+	if changes := DiffSnapshots(a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, changes)
+	}
+
+	if changes := DiffSnapshots(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes, actual: %v", changes)
+	}
+}
@@ -0,0 +1,190 @@
+package adbclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// syncENOENT is the errno of the device for a missing file.
+const syncENOENT = 2
+
+// errSyncV2Unsupported is returned when the device doesn't support the version 2 of the sync protocol,
+// before Android 8 for the stat and Android 11 for the listing.
+var errSyncV2Unsupported = errors.New("sync v2 is not supported")
+
+// syncStatV2 is a stat of the version 2 of the sync protocol, the sizes are 64-bit unlike in the version 1.
+type syncStatV2 struct {
+	Error uint32
+	Dev   uint64
+	Ino   uint64
+	Mode  uint32
+	Nlink uint32
+	UID   uint32
+	GID   uint32
+	Size  uint64
+	Atime int64
+	Mtime int64
+	Ctime int64
+}
+
+// fileInfo converts the stat of the file at the path.
+func (s *syncStatV2) fileInfo(name string) FileInfo {
+	return FileInfo{
+		Name:    path.Base(name),
+		Path:    name,
+		Mode:    wire.ParseFileModeFromAdb(s.Mode),
+		Size:    int64(s.Size),
+		ModTime: time.Unix(s.Mtime, 0),
+	}
+}
+
+// err returns the error of the stat of the file, ErrFileNotFound if it doesn't exist.
+func (s *syncStatV2) err(name string) error {
+	switch s.Error {
+	case 0:
+		return nil
+	case syncENOENT:
+		return fmt.Errorf("%w: %s", ErrFileNotFound, name)
+	default:
+		return fmt.Errorf("%s: errno %d", name, s.Error)
+	}
+}
+
+// sendSyncRequest sends a request of the sync protocol: the id, the length of the path and the path.
+func sendSyncRequest(w io.Writer, id, name string) error {
+	req := make([]byte, 8+len(name))
+	copy(req, id)
+	binary.LittleEndian.PutUint32(req[4:], uint32(len(name)))
+	copy(req[8:], name)
+
+	_, err := w.Write(req)
+	return err
+}
+
+// readSyncID reads the id of a response of the sync protocol. errSyncV2Unsupported is returned for a failure,
+// as the device fails the requests it doesn't know.
+func readSyncID(r io.Reader) (string, error) {
+	id := make([]byte, 4)
+	if _, err := io.ReadFull(r, id); err != nil {
+		return "", err
+	}
+
+	if string(id) != "FAIL" {
+		return string(id), nil
+	}
+
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("%w: %s", errSyncV2Unsupported, msg)
+}
+
+// readSyncStat reads the response of LST2.
+func readSyncStat(r io.Reader, name string) (*FileInfo, error) {
+	id, err := readSyncID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if id != "LST2" {
+		return nil, fmt.Errorf("invalid sync stat response: %q", id)
+	}
+
+	var stat syncStatV2
+	if err := binary.Read(r, binary.LittleEndian, &stat); err != nil {
+		return nil, err
+	}
+
+	if err := stat.err(name); err != nil {
+		return nil, err
+	}
+
+	info := stat.fileInfo(name)
+	return &info, nil
+}
+
+// readSyncList reads the entries of the response of LIS2 until DONE, without . and .. and the entries that
+// could not be read.
+func readSyncList(r io.Reader, dir string) ([]FileInfo, error) {
+	var files []FileInfo
+	for {
+		id, err := readSyncID(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var stat syncStatV2
+		if err := binary.Read(r, binary.LittleEndian, &stat); err != nil {
+			return nil, err
+		}
+
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case "DONE":
+			return files, nil
+		case "DNT2":
+		default:
+			return nil, fmt.Errorf("invalid sync list response: %q", id)
+		}
+
+		name := make([]byte, length)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return nil, err
+		}
+
+		if string(name) == "." || string(name) == ".." || stat.Error != 0 {
+			continue
+		}
+
+		files = append(files, stat.fileInfo(path.Join(dir, string(name))))
+	}
+}
+
+// syncStat returns the information of a file with LST2 of the sync protocol, symbolic links are not followed.
+func (c *Client) syncStat(device *Device, name string) (*FileInfo, error) {
+	conn, err := c.openStream(device, "sync:")
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if err := sendSyncRequest(conn, "LST2", name); err != nil {
+		return nil, err
+	}
+
+	return readSyncStat(conn, name)
+}
+
+// syncList returns the files of a directory with LIS2 of the sync protocol.
+func (c *Client) syncList(device *Device, dir string) ([]FileInfo, error) {
+	conn, err := c.openStream(device, "sync:")
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if err := sendSyncRequest(conn, "LIS2", dir); err != nil {
+		return nil, err
+	}
+
+	return readSyncList(conn, dir)
+}
@@ -0,0 +1,91 @@
+package adbclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// syncStatResponse encodes a stat response of the version 2 of the sync protocol.
+func syncStatResponse(id string, stat syncStatV2) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(id)
+	binary.Write(&buf, binary.LittleEndian, stat)
+	return buf.Bytes()
+}
+
+// syncDentResponse encodes a directory entry of LIS2.
+func syncDentResponse(id, name string, stat syncStatV2) []byte {
+	buf := bytes.NewBuffer(syncStatResponse(id, stat))
+	binary.Write(buf, binary.LittleEndian, uint32(len(name)))
+	buf.WriteString(name)
+	return buf.Bytes()
+}
+
+func TestSendSyncRequest(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sendSyncRequest(&buf, "LST2", "/sdcard"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "LST2\x07\x00\x00\x00/sdcard"; buf.String() != expected {
+		t.Errorf("expected: %q, actual: %q", expected, buf.String())
+	}
+}
+
+func TestReadSyncStat(t *testing.T) {
+	const size = 5 << 30
+
+	r := bytes.NewReader(syncStatResponse("LST2", syncStatV2{Mode: 0100644, Size: size, Mtime: 1646128800}))
+	info, err := readSyncStat(r, "/sdcard/Android/obb/main.1.com.example.game.obb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Size != size || !info.Mode.IsRegular() || !info.ModTime.Equal(time.Unix(1646128800, 0)) {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	if info.Name != "main.1.com.example.game.obb" {
+		t.Errorf("name: %s", info.Name)
+	}
+
+	r = bytes.NewReader(syncStatResponse("LST2", syncStatV2{Error: syncENOENT}))
+	if _, err := readSyncStat(r, "/sdcard/missing"); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected %v, actual %v", ErrFileNotFound, err)
+	}
+
+	r = bytes.NewReader([]byte("FAIL\x0f\x00\x00\x00unknown command"))
+	if _, err := readSyncStat(r, "/sdcard"); !errors.Is(err, errSyncV2Unsupported) {
+		t.Errorf("expected %v, actual %v", errSyncV2Unsupported, err)
+	}
+}
+
+func TestReadSyncList(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(syncDentResponse("DNT2", ".", syncStatV2{Mode: 040771}))
+	buf.Write(syncDentResponse("DNT2", "..", syncStatV2{Mode: 040771}))
+	buf.Write(syncDentResponse("DNT2", "video.mp4", syncStatV2{Mode: 0100660, Size: 6 << 30}))
+	buf.Write(syncDentResponse("DNT2", "unreadable", syncStatV2{Error: 13}))
+	buf.Write(syncDentResponse("DNT2", "Movies", syncStatV2{Mode: 040771, Size: 3452}))
+	buf.Write(syncDentResponse("DONE", "", syncStatV2{}))
+
+	files, err := readSyncList(&buf, "/sdcard")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+
+	if files[0].Path != "/sdcard/video.mp4" || files[0].Size != 6<<30 || files[0].IsDir() {
+		t.Errorf("unexpected file: %+v", files[0])
+	}
+
+	if files[1].Path != "/sdcard/Movies" || !files[1].IsDir() {
+		t.Errorf("unexpected directory: %+v", files[1])
+	}
+}
@@ -0,0 +1,163 @@
+package adbclient
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// ErrChecksumMismatch is returned when a transferred file differs from the original.
+var ErrChecksumMismatch = fmt.Errorf("checksum mismatch")
+
+// openStream opens a service of the device on a connection owned by the caller, so it is safe for parallel use
+// unlike the connections of the dialer.
+func (c *Client) openStream(device *Device, service string) (net.Conn, error) {
+	netConn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", c.port))
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &wire.Conn{Scanner: wire.NewScanner(netConn), Sender: wire.NewSender(netConn)}
+	for _, req := range []string{"host:transport:" + device.Serial, service} {
+		if err := wire.SendMessageString(conn, req); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+
+		if _, err := conn.ReadStatus(req); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+
+	return netConn, nil
+}
+
+// openExecStream runs a command with the exec service and returns its raw output.
+func (c *Client) openExecStream(device *Device, cmd string) (io.ReadCloser, error) {
+	conn, err := c.openStream(device, "exec:"+cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Debugf("Streaming: %s", cmd)
+	return conn, nil
+}
+
+// rangeCommands are the commands printing a file from an offset, tail is tried first.
+func rangeCommands(name string, offset int64) []string {
+	return []string{
+		fmt.Sprintf("tail -c +%d %s", offset+1, shellQuote(name)),
+		fmt.Sprintf("dd if=%s bs=65536 skip=%d iflag=skip_bytes", shellQuote(name), offset),
+	}
+}
+
+// copyContext copies until EOF, the cancellation of the context is checked for every read.
+func copyContext(ctx context.Context, w io.Writer, r io.Reader, progress func(n int64)) (int64, error) {
+	return io.Copy(w, readerFunc(func(b []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		n, err := r.Read(b)
+		if n > 0 && progress != nil {
+			progress(int64(n))
+		}
+
+		return n, err
+	}))
+}
+
+// downloadRange copies the file of the device from the offset, with tail or dd as the sync protocol can't seek.
+// The command is run with run-as if the package is set.
+func (c *Client) downloadRange(ctx context.Context, device *Device, src string, offset int64, runAs string, w io.Writer, progress func(n int64)) (int64, error) {
+	cmds := rangeCommands(src, offset)
+	if offset == 0 {
+		cmds = []string{"cat " + shellQuote(src)}
+	}
+
+	for i, cmd := range cmds {
+		if runAs != "" {
+			cmd = fmt.Sprintf("run-as %s %s", shellQuote(runAs), cmd)
+		}
+
+		r, err := c.openExecStream(device, cmd)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := copyContext(ctx, w, r, progress)
+		r.Close()
+
+		// exec doesn't forward errors, a command that isn't supported prints nothing
+		if n > 0 || err != nil || i == len(cmds)-1 {
+			return n, err
+		}
+
+		c.log.Debugf("No output, trying the next command")
+	}
+
+	return 0, nil
+}
+
+// remoteChecksum returns the checksum of a file of the device and the hash to compare it with.
+// sha256sum is used if the device has it, md5sum otherwise.
+func (c *Client) remoteChecksum(device *Device, name string, options fileOptions) (string, hash.Hash, error) {
+	for _, tool := range []struct {
+		cmd  string
+		hash func() hash.Hash
+	}{
+		{"sha256sum", sha256.New},
+		{"md5sum", md5.New},
+	} {
+		out, err := c.runFileCommand(device, options, fmt.Sprintf("%s %s", tool.cmd, shellQuote(name)))
+		if err != nil {
+			// a missing tool is reported without the name of the file
+			if strings.Contains(err.Error(), "not found") && !strings.Contains(err.Error(), name) {
+				continue
+			}
+
+			return "", nil, err
+		}
+
+		sum, _, _ := strings.Cut(strings.TrimSpace(out), " ")
+		return sum, tool.hash(), nil
+	}
+
+	return "", nil, fmt.Errorf("no sha256sum or md5sum on the device")
+}
+
+// verifyFile compares the checksums of a local file and a file of the device.
+func (c *Client) verifyFile(device *Device, local, remote string, options fileOptions) error {
+	c.log.Infof("Verifying %s...", remote)
+
+	remoteSum, h, err := c.remoteChecksum(device, remote, options)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+
+	if localSum := fmt.Sprintf("%x", h.Sum(nil)); localSum != remoteSum {
+		return fmt.Errorf("%w: %s is %s, %s is %s", ErrChecksumMismatch, local, localSum, remote, remoteSum)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package adbclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRangeCommands(t *testing.T) {
+	cmds := rangeCommands("/sdcard/main 1.obb", 1024)
+	expected := []string{
+		`tail -c +1025 '/sdcard/main 1.obb'`,
+		`dd if='/sdcard/main 1.obb' bs=65536 skip=1024 iflag=skip_bytes`,
+	}
+
+	if len(cmds) != len(expected) {
+		t.Fatalf("expected %d commands, actual %d", len(expected), len(cmds))
+	}
+
+	for i := range expected {
+		if cmds[i] != expected[i] {
+			t.Errorf("expected: %s, actual: %s", expected[i], cmds[i])
+		}
+	}
+}
+
+func TestCopyContext(t *testing.T) {
+	data := strings.Repeat("0123456789", 10000)
+
+	var w bytes.Buffer
+	var progress int64
+	n, err := copyContext(context.Background(), &w, strings.NewReader(data), func(n int64) { progress += n })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n != int64(len(data)) || progress != n || w.String() != data {
+		t.Errorf("copied %d, progress %d", n, progress)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w.Reset()
+	if _, err := copyContext(ctx, &w, strings.NewReader(data), nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, actual %v", err)
+	}
+
+	if w.Len() != 0 {
+		t.Errorf("copied %d bytes after cancellation", w.Len())
+	}
+}
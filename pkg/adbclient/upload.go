@@ -2,8 +2,10 @@ package adbclient
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"path"
 	"time"
 
 	adb "github.com/zach-klippenstein/goadb"
@@ -13,6 +15,9 @@ type progressFunc func(sentBytes int64, totalBytes int64)
 
 type uploadOptions struct {
 	progressFunc progressFunc
+	runAs        string
+	modTime      time.Time
+	verify       bool
 }
 
 // UploadOption is an option for uploading file.
@@ -33,6 +38,47 @@ func WithUploadProgress(f func(sentBytes int64, totalBytes int64)) UploadOption
 	return progressUploadOption{f}
 }
 
+type runAsUploadOption struct {
+	pkg string
+}
+
+func (o runAsUploadOption) apply(opts *uploadOptions) error {
+	opts.runAs = o.pkg
+	return nil
+}
+
+// WithUploadRunAs uploads to a private directory of the debuggable package.
+// The file is uploaded to a temporary file first and copied with run-as, as the sync protocol has no access to it.
+func WithUploadRunAs(pkg string) UploadOption {
+	return runAsUploadOption{pkg}
+}
+
+type modTimeUploadOption struct {
+	modTime time.Time
+}
+
+func (o modTimeUploadOption) apply(opts *uploadOptions) error {
+	opts.modTime = o.modTime
+	return nil
+}
+
+// WithUploadModTime sets the modification time of the uploaded file, the upload time by default.
+func WithUploadModTime(t time.Time) UploadOption {
+	return modTimeUploadOption{t}
+}
+
+type verifyUploadOption struct{}
+
+func (o verifyUploadOption) apply(opts *uploadOptions) error {
+	opts.verify = true
+	return nil
+}
+
+// WithUploadVerify makes UploadFile compare the checksum of the uploaded file with the checksum computed by the device.
+func WithUploadVerify() UploadOption {
+	return verifyUploadOption{}
+}
+
 type readerFunc func(p []byte) (n int, err error)
 
 func (rf readerFunc) Read(p []byte) (n int, err error) {
@@ -42,6 +88,10 @@ func (rf readerFunc) Read(p []byte) (n int, err error) {
 func (c *Client) Upload(ctx context.Context, device *Device, r io.Reader, size uint64, dst string, opts ...UploadOption) error {
 	c.log.Infof("Uploading to %s...", dst)
 
+	if err := c.checkLease(device); err != nil {
+		return err
+	}
+
 	var options uploadOptions
 	for _, opt := range opts {
 		if err := opt.apply(&options); err != nil {
@@ -49,36 +99,45 @@ func (c *Client) Upload(ctx context.Context, device *Device, r io.Reader, size u
 		}
 	}
 
-	w, err := c.adb.Device(adb.DeviceWithSerial(device.Serial)).OpenWrite(dst, os.FileMode(0664), time.Now())
-	if err != nil {
-		return err
-	}
+	if options.runAs != "" {
+		tmp := path.Join(tempDir, fmt.Sprintf("upload_%d_%s", time.Now().UnixNano(), path.Base(dst)))
+		defer c.RemoveFile(device, tmp)
 
-	defer w.Close()
+		if err := c.Upload(ctx, device, r, size, tmp, WithUploadProgress(options.progressFunc)); err != nil {
+			return err
+		}
 
-	total := 0
-	_, err = io.Copy(w, readerFunc(func(b []byte) (int, error) {
-		select {
-		case <-ctx.Done():
-			c.log.Debug("Upload canceled")
-			return 0, ctx.Err()
+		_, err := c.runFileCommand(device, fileOptions{runAs: options.runAs}, fmt.Sprintf("cp %s %s", shellQuote(tmp), shellQuote(dst)))
+		return err
+	}
 
-		default:
-			n, err := r.Read(b)
-			if err != nil {
-				return 0, err
-			}
+	modTime := options.modTime
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
 
-			total += n
-			if options.progressFunc != nil {
-				options.progressFunc(int64(total), int64(size))
-			}
+	w, err := c.adb.Device(adb.DeviceWithSerial(device.Serial)).OpenWrite(dst, os.FileMode(0664), modTime)
+	if err != nil {
+		return err
+	}
 
-			return n, err
+	var total int64
+	if _, err := copyContext(ctx, w, r, func(n int64) {
+		total += n
+		if options.progressFunc != nil {
+			options.progressFunc(total, int64(size))
+		}
+	}); err != nil {
+		w.Close()
+		if ctx.Err() != nil {
+			c.log.Debug("Upload canceled")
 		}
-	}))
 
-	return err
+		return err
+	}
+
+	// the device reports a failed write when the file is closed
+	return w.Close()
 }
 
 // Upload uploads a file to the device.
@@ -98,5 +157,20 @@ func (c *Client) UploadFile(ctx context.Context, device *Device, src, dst string
 	}
 
 	c.log.Debugf("Uploading %d bytes...", fi.Size())
-	return c.Upload(ctx, device, file, uint64(fi.Size()), dst, opts...)
+	if err := c.Upload(ctx, device, file, uint64(fi.Size()), dst, opts...); err != nil {
+		return err
+	}
+
+	var options uploadOptions
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return err
+		}
+	}
+
+	if options.verify {
+		return c.verifyFile(device, src, dst, fileOptions{runAs: options.runAs})
+	}
+
+	return nil
 }
@@ -0,0 +1,80 @@
+package adbclient
+
+import (
+	"context"
+	"sync"
+)
+
+// deviceWatch is a running watch of a device.
+type deviceWatch struct {
+	cancel context.CancelFunc
+}
+
+// DeviceWatches runs a watch function for every online device. A watch is started when its device comes
+// online and cancelled when it goes away. When a watch returns by itself, e.g. because the connection
+// was lost, it is forgotten so that it is restarted on the next update.
+type DeviceWatches struct {
+	mu      sync.Mutex
+	run     func(ctx context.Context, device *Device)
+	watches map[string]*deviceWatch
+	wg      sync.WaitGroup
+}
+
+// NewDeviceWatches creates watches running the function, devices are watched once passed to Update.
+func NewDeviceWatches(run func(ctx context.Context, device *Device)) *DeviceWatches {
+	return &DeviceWatches{
+		run:     run,
+		watches: make(map[string]*deviceWatch),
+	}
+}
+
+// Update starts watching the device when it comes online and stops when it goes away.
+func (w *DeviceWatches) Update(device *Device) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	watch, watching := w.watches[device.Serial]
+	switch {
+	case device.State == StateOnline && !watching:
+		w.watches[device.Serial] = w.start(device)
+	case device.State != StateOnline && watching:
+		watch.cancel()
+		delete(w.watches, device.Serial)
+	}
+}
+
+// Close cancels all watches and waits for them to return.
+func (w *DeviceWatches) Close() {
+	w.mu.Lock()
+	for serial, watch := range w.watches {
+		watch.cancel()
+		delete(w.watches, serial)
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
+
+// start runs the watch of the device, the lock must be held.
+func (w *DeviceWatches) start(device *Device) *deviceWatch {
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := &deviceWatch{cancel: cancel}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			// forget the watch, unless it was already replaced by the watch of a reconnection
+			w.mu.Lock()
+			if w.watches[device.Serial] == watch {
+				delete(w.watches, device.Serial)
+			}
+			w.mu.Unlock()
+			cancel()
+		}()
+
+		w.run(ctx, device)
+	}()
+
+	return watch
+}
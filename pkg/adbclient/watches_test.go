@@ -0,0 +1,79 @@
+package adbclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeviceWatches(t *testing.T) {
+	started := make(chan string, 10)
+	stopped := make(chan string, 10)
+	lost := make(chan struct{})
+
+	watches := NewDeviceWatches(func(ctx context.Context, device *Device) {
+		started <- device.Serial
+		select {
+		case <-ctx.Done():
+		case <-lost:
+		}
+		stopped <- device.Serial
+	})
+
+	receive := func(ch chan string, expected string) {
+		t.Helper()
+		select {
+		case serial := <-ch:
+			if serial != expected {
+				t.Fatalf("expected %s, actual %s", expected, serial)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %s", expected)
+		}
+	}
+
+	device := &Device{Serial: "123456789", State: StateOnline}
+	watches.Update(device)
+	receive(started, device.Serial)
+
+	// already watched
+	watches.Update(device)
+
+	device.State = StateOffline
+	watches.Update(device)
+	receive(stopped, device.Serial)
+
+	// the watch returns by itself when the connection is lost, and is restarted on the next update
+	device.State = StateOnline
+	watches.Update(device)
+	receive(started, device.Serial)
+	close(lost)
+	receive(stopped, device.Serial)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		watches.mu.Lock()
+		_, watching := watches.watches[device.Serial]
+		watches.mu.Unlock()
+
+		if !watching {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the watch was not forgotten")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	watches.Update(device)
+	receive(started, device.Serial)
+
+	watches.Close()
+	receive(stopped, device.Serial)
+
+	if len(started) != 0 {
+		t.Errorf("unexpected watches started: %d", len(started))
+	}
+}
@@ -0,0 +1,226 @@
+package animation
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+var (
+	ErrNoFrames      = fmt.Errorf("no frames to encode")
+	ErrInvalidFormat = fmt.Errorf("invalid animation format")
+)
+
+// Format is an animated image format.
+type Format int
+
+const (
+	// FormatGIF is the animated GIF format.
+	FormatGIF Format = iota
+	// FormatAPNG is the animated PNG format.
+	FormatAPNG
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatGIF:
+		return "gif"
+	case FormatAPNG:
+		return "apng"
+	default:
+		return "unknown"
+	}
+}
+
+// Extension returns the file extension of the format.
+func (f Format) Extension() string {
+	switch f {
+	case FormatGIF:
+		return ".gif"
+	case FormatAPNG:
+		return ".png"
+	default:
+		return ""
+	}
+}
+
+// ParseFormat parses a format name.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "gif":
+		return FormatGIF, nil
+	case "apng", "png":
+		return FormatAPNG, nil
+	default:
+		return FormatGIF, ErrInvalidFormat
+	}
+}
+
+// FormatFromPath returns the format matching the extension of the path.
+func FormatFromPath(path string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// PaletteQuality is the quality of the palette used for paletted formats.
+type PaletteQuality int
+
+const (
+	// PaletteLow uses the fixed web-safe palette without dithering.
+	PaletteLow PaletteQuality = iota
+	// PaletteMedium uses the fixed Plan 9 palette with dithering.
+	PaletteMedium
+	// PaletteHigh uses an adaptive palette computed for each frame with dithering.
+	PaletteHigh
+)
+
+func (q PaletteQuality) String() string {
+	switch q {
+	case PaletteLow:
+		return "low"
+	case PaletteMedium:
+		return "medium"
+	case PaletteHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePaletteQuality parses a palette quality name.
+func ParsePaletteQuality(s string) (PaletteQuality, error) {
+	switch strings.ToLower(s) {
+	case "low":
+		return PaletteLow, nil
+	case "medium":
+		return PaletteMedium, nil
+	case "high":
+		return PaletteHigh, nil
+	default:
+		return PaletteHigh, fmt.Errorf("invalid palette quality: %s", s)
+	}
+}
+
+// Frame is a single frame of an animation.
+type Frame struct {
+	Image image.Image
+	Delay time.Duration
+}
+
+type options struct {
+	scale     float64
+	quality   PaletteQuality
+	loopCount int
+}
+
+func (o options) String() string {
+	return fmt.Sprintf("scale:%g quality:%s loopCount:%d", o.scale, o.quality, o.loopCount)
+}
+
+// Option is an option for encoding an animation.
+type Option interface {
+	apply(*options) error
+}
+
+type scaleOption struct {
+	scale float64
+}
+
+func (o scaleOption) apply(opts *options) error {
+	if o.scale <= 0 || o.scale > 1 {
+		return fmt.Errorf("scale must be in range (0, 1], got %g", o.scale)
+	}
+
+	opts.scale = o.scale
+	return nil
+}
+
+type paletteQualityOption struct {
+	quality PaletteQuality
+}
+
+func (o paletteQualityOption) apply(opts *options) error {
+	opts.quality = o.quality
+	return nil
+}
+
+type loopCountOption struct {
+	loopCount int
+}
+
+func (o loopCountOption) apply(opts *options) error {
+	if o.loopCount < 0 {
+		return fmt.Errorf("invalid loop count: %d", o.loopCount)
+	}
+
+	opts.loopCount = o.loopCount
+	return nil
+}
+
+// WithScale downscales every frame by the given factor.
+func WithScale(scale float64) Option {
+	return scaleOption{scale}
+}
+
+// WithPaletteQuality sets the palette quality. Only used by the GIF format.
+func WithPaletteQuality(quality PaletteQuality) Option {
+	return paletteQualityOption{quality}
+}
+
+// WithLoopCount sets how many times the animation is played, 0 means forever.
+func WithLoopCount(loopCount int) Option {
+	return loopCountOption{loopCount}
+}
+
+func newOptions(opts []Option) (options, error) {
+	o := options{
+		scale:   1,
+		quality: PaletteHigh,
+	}
+
+	for _, opt := range opts {
+		if err := opt.apply(&o); err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+// Encode writes the frames to w in the given format.
+func Encode(w io.Writer, format Format, frames []Frame, opts ...Option) error {
+	switch format {
+	case FormatGIF:
+		return EncodeGIF(w, frames, opts...)
+	case FormatAPNG:
+		return EncodeAPNG(w, frames, opts...)
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// Scale returns a copy of the image scaled by the given factor.
+func Scale(src image.Image, scale float64) image.Image {
+	bounds := src.Bounds()
+	if scale == 1 {
+		return src
+	}
+
+	width := int(float64(bounds.Dx()) * scale)
+	if width < 1 {
+		width = 1
+	}
+
+	height := int(float64(bounds.Dy()) * scale)
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,157 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func testFrames(n int, width, height int) []Frame {
+	frames := make([]Frame, n)
+	for i := range frames {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.Set(x, y, color.RGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: uint8(i * 40), A: 0xff})
+			}
+		}
+
+		frames[i] = Frame{Image: img, Delay: 200 * time.Millisecond}
+	}
+
+	return frames
+}
+
+func TestEncodeGIF(t *testing.T) {
+	frames := testFrames(3, 64, 32)
+
+	for _, quality := range []PaletteQuality{PaletteLow, PaletteMedium, PaletteHigh} {
+		t.Run(quality.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeGIF(&buf, frames, WithScale(0.5), WithPaletteQuality(quality)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			anim, err := gif.DecodeAll(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(anim.Image) != 3 {
+				t.Fatalf("expected 3 frames, got %d", len(anim.Image))
+			}
+
+			for i, img := range anim.Image {
+				if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 16 {
+					t.Errorf("frame %d: expected 32x16, got %v", i, img.Bounds())
+				}
+
+				if anim.Delay[i] != 20 {
+					t.Errorf("frame %d: expected delay 20, got %d", i, anim.Delay[i])
+				}
+			}
+
+			if anim.LoopCount != 0 {
+				t.Errorf("expected loop count 0, got %d", anim.LoopCount)
+			}
+		})
+	}
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	frames := testFrames(4, 40, 30)
+
+	var buf bytes.Buffer
+	if err := EncodeAPNG(&buf, frames, WithLoopCount(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := buf.Bytes()
+
+	// the default image must be decodable by a regular PNG decoder
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 30 {
+		t.Errorf("expected 40x30, got %v", img.Bounds())
+	}
+
+	if got, want := img.At(39, 29), frames[0].Image.At(39, 29); color.NRGBAModel.Convert(got) != color.NRGBAModel.Convert(want) {
+		t.Errorf("expected pixel %v, got %v", want, got)
+	}
+
+	chunks := map[string]int{}
+	var numFrames, numPlays uint32
+	for pos := len(pngHeader); pos < len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		name := string(data[pos+4 : pos+8])
+		if name == "acTL" {
+			numFrames = binary.BigEndian.Uint32(data[pos+8:])
+			numPlays = binary.BigEndian.Uint32(data[pos+12:])
+		}
+
+		chunks[name]++
+		pos += 12 + length
+	}
+
+	if numFrames != 4 || numPlays != 2 {
+		t.Errorf("expected acTL 4 frames 2 plays, got %d frames %d plays", numFrames, numPlays)
+	}
+
+	if chunks["fcTL"] != 4 || chunks["IDAT"] != 1 || chunks["fdAT"] != 3 {
+		t.Errorf("unexpected chunks: %v", chunks)
+	}
+}
+
+func TestEncodeNoFrames(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, FormatGIF, nil); err != ErrNoFrames {
+		t.Errorf("expected %v, got %v", ErrNoFrames, err)
+	}
+
+	if err := Encode(&buf, FormatAPNG, nil); err != ErrNoFrames {
+		t.Errorf("expected %v, got %v", ErrNoFrames, err)
+	}
+}
+
+func TestMedianCut(t *testing.T) {
+	img := testFrames(1, 128, 128)[0].Image
+
+	for _, n := range []int{2, 16, 256} {
+		p := MedianCut(img, n)
+		if len(p) == 0 || len(p) > n {
+			t.Errorf("expected at most %d colors, got %d", n, len(p))
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Format
+		err      bool
+	}{
+		{"./burst.gif", FormatGIF, false},
+		{"./burst.PNG", FormatAPNG, false},
+		{"./burst.apng", FormatAPNG, false},
+		{"./burst.mp4", FormatGIF, true},
+	}
+
+	for _, test := range tests {
+		format, err := FormatFromPath(test.path)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error: %v", test.path, err)
+		}
+
+		if err == nil && format != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.path, test.expected, format)
+		}
+	}
+}
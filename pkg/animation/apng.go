@@ -0,0 +1,203 @@
+package animation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"time"
+)
+
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// apngWriter writes the chunks of an APNG stream.
+// See https://wiki.mozilla.org/APNG_Specification for the format.
+type apngWriter struct {
+	w        io.Writer
+	sequence uint32
+	err      error
+}
+
+func (e *apngWriter) writeChunk(name string, data []byte) {
+	if e.err != nil {
+		return
+	}
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], name)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, e.err = e.w.Write(b); e.err != nil {
+			return
+		}
+	}
+}
+
+func (e *apngWriter) writeIHDR(width, height int) {
+	data := make([]byte, 13)
+	binary.BigEndian.PutUint32(data[0:], uint32(width))
+	binary.BigEndian.PutUint32(data[4:], uint32(height))
+	data[8] = 8  // bit depth
+	data[9] = 6  // color type: truecolor with alpha
+	data[10] = 0 // compression method
+	data[11] = 0 // filter method
+	data[12] = 0 // interlace method
+	e.writeChunk("IHDR", data)
+}
+
+func (e *apngWriter) writeACTL(numFrames, numPlays int) {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:], uint32(numFrames))
+	binary.BigEndian.PutUint32(data[4:], uint32(numPlays))
+	e.writeChunk("acTL", data)
+}
+
+func (e *apngWriter) writeFCTL(width, height int, delay time.Duration) {
+	ms := delay.Milliseconds()
+	if ms > math.MaxUint16 {
+		ms = math.MaxUint16
+	}
+
+	data := make([]byte, 26)
+	binary.BigEndian.PutUint32(data[0:], e.sequence)
+	binary.BigEndian.PutUint32(data[4:], uint32(width))
+	binary.BigEndian.PutUint32(data[8:], uint32(height))
+	binary.BigEndian.PutUint32(data[12:], 0) // x offset
+	binary.BigEndian.PutUint32(data[16:], 0) // y offset
+	binary.BigEndian.PutUint16(data[20:], uint16(ms))
+	binary.BigEndian.PutUint16(data[22:], 1000)
+	data[24] = 0 // dispose op: none
+	data[25] = 0 // blend op: source
+	e.writeChunk("fcTL", data)
+	e.sequence++
+}
+
+func (e *apngWriter) writeFrameData(first bool, data []byte) {
+	if first {
+		e.writeChunk("IDAT", data)
+		return
+	}
+
+	seq := make([]byte, 4)
+	binary.BigEndian.PutUint32(seq, e.sequence)
+	e.writeChunk("fdAT", append(seq, data...))
+	e.sequence++
+}
+
+// paeth implements the Paeth predictor of the PNG filter.
+func paeth(a, b, c uint8) uint8 {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	} else if pb <= pc {
+		return b
+	}
+
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+// compressImage filters and compresses the pixels of img as PNG image data.
+// Each row uses the Paeth filter, which works well for screenshots.
+func compressImage(img *image.NRGBA) ([]byte, error) {
+	const bpp = 4
+
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	prev := make([]byte, width*bpp)
+	row := make([]byte, 1+width*bpp)
+	for y := 0; y < height; y++ {
+		cur := img.Pix[y*img.Stride : y*img.Stride+width*bpp]
+
+		row[0] = 4 // filter type: Paeth
+		for i := range cur {
+			var a, c uint8
+			if i >= bpp {
+				a, c = cur[i-bpp], prev[i-bpp]
+			}
+
+			row[1+i] = cur[i] - paeth(a, prev[i], c)
+		}
+
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+
+		prev = cur
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// EncodeAPNG writes the frames to w as an animated PNG.
+// Frames smaller than the largest frame are aligned to the top left corner.
+func EncodeAPNG(w io.Writer, frames []Frame, opts ...Option) error {
+	if len(frames) == 0 {
+		return ErrNoFrames
+	}
+
+	options, err := newOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	images := make([]image.Image, len(frames))
+	var canvas image.Rectangle
+	for i, frame := range frames {
+		images[i] = Scale(frame.Image, options.scale)
+		canvas = canvas.Union(image.Rectangle{Max: images[i].Bounds().Size()})
+	}
+
+	if _, err := io.WriteString(w, pngHeader); err != nil {
+		return err
+	}
+
+	e := &apngWriter{w: w}
+	e.writeIHDR(canvas.Dx(), canvas.Dy())
+	e.writeACTL(len(frames), options.loopCount)
+
+	for i, img := range images {
+		dst := image.NewNRGBA(canvas)
+		draw.Draw(dst, img.Bounds().Sub(img.Bounds().Min), img, img.Bounds().Min, draw.Src)
+
+		data, err := compressImage(dst)
+		if err != nil {
+			return err
+		}
+
+		e.writeFCTL(canvas.Dx(), canvas.Dy(), frames[i].Delay)
+		e.writeFrameData(i == 0, data)
+	}
+
+	e.writeChunk("IEND", nil)
+	return e.err
+}
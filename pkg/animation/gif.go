@@ -0,0 +1,86 @@
+package animation
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// gifDelay converts a delay to hundredths of a second, the unit used by GIF.
+// Most viewers ignore delays below 2, so shorter delays are rounded up.
+func gifDelay(delay time.Duration) int {
+	d := int((delay + 5*time.Millisecond) / (10 * time.Millisecond))
+	if d < 2 {
+		return 2
+	}
+
+	return d
+}
+
+// gifLoopCount converts a play count to the GIF loop count,
+// where 0 means forever and -1 means to play once.
+func gifLoopCount(loopCount int) int {
+	if loopCount == 0 {
+		return 0
+	}
+
+	return loopCount - 1
+}
+
+func paletted(src image.Image, quality PaletteQuality) *image.Paletted {
+	var p color.Palette
+	var drawer draw.Drawer = draw.FloydSteinberg
+
+	switch quality {
+	case PaletteLow:
+		p = palette.WebSafe
+		drawer = draw.Src
+	case PaletteMedium:
+		p = palette.Plan9
+	default:
+		p = MedianCut(src, 256)
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), p)
+	drawer.Draw(dst, dst.Bounds(), src, bounds.Min)
+	return dst
+}
+
+// EncodeGIF writes the frames to w as an animated GIF.
+func EncodeGIF(w io.Writer, frames []Frame, opts ...Option) error {
+	if len(frames) == 0 {
+		return ErrNoFrames
+	}
+
+	options, err := newOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	anim := &gif.GIF{
+		LoopCount: gifLoopCount(options.loopCount),
+	}
+
+	for _, frame := range frames {
+		img := paletted(Scale(frame.Image, options.scale), options.quality)
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, gifDelay(frame.Delay))
+
+		// frames may differ in size if the device was rotated during capture
+		size := img.Bounds().Size()
+		if size.X > anim.Config.Width {
+			anim.Config.Width = size.X
+		}
+
+		if size.Y > anim.Config.Height {
+			anim.Config.Height = size.Y
+		}
+	}
+
+	return gif.EncodeAll(w, anim)
+}
@@ -0,0 +1,118 @@
+package animation
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// maxQuantizeSamples limits the number of pixels sampled when building a palette.
+const maxQuantizeSamples = 1 << 16
+
+type colorBox struct {
+	pixels []color.RGBA
+}
+
+// channel returns the value of the i-th channel of c.
+func channel(c color.RGBA, i int) uint8 {
+	switch i {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	default:
+		return c.B
+	}
+}
+
+// widest returns the channel with the widest range of values and that range.
+func (b colorBox) widest() (int, int) {
+	bestChannel, bestRange := 0, -1
+	for i := 0; i < 3; i++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, p := range b.pixels {
+			v := channel(p, i)
+			if v < lo {
+				lo = v
+			}
+
+			if v > hi {
+				hi = v
+			}
+		}
+
+		if int(hi)-int(lo) > bestRange {
+			bestChannel, bestRange = i, int(hi)-int(lo)
+		}
+	}
+
+	return bestChannel, bestRange
+}
+
+func (b colorBox) average() color.Color {
+	var r, g, bl uint64
+	for _, p := range b.pixels {
+		r += uint64(p.R)
+		g += uint64(p.G)
+		bl += uint64(p.B)
+	}
+
+	n := uint64(len(b.pixels))
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 0xff}
+}
+
+// MedianCut builds an adaptive palette of at most n colors for the image using the median cut algorithm.
+func MedianCut(img image.Image, n int) color.Palette {
+	bounds := img.Bounds()
+
+	step := 1
+	for bounds.Dx()*bounds.Dy()/(step*step) > maxQuantizeSamples {
+		step++
+	}
+
+	var pixels []color.RGBA
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			pixels = append(pixels, color.RGBAModel.Convert(img.At(x, y)).(color.RGBA))
+		}
+	}
+
+	if len(pixels) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	boxes := []colorBox{{pixels: pixels}}
+	for len(boxes) < n {
+		// split the box with the widest channel range
+		index, ch, rng := -1, 0, 0
+		for i, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+
+			if c, r := box.widest(); r > rng {
+				index, ch, rng = i, c, r
+			}
+		}
+
+		if index < 0 {
+			break
+		}
+
+		box := boxes[index]
+		sort.Slice(box.pixels, func(i, j int) bool {
+			return channel(box.pixels[i], ch) < channel(box.pixels[j], ch)
+		})
+
+		median := len(box.pixels) / 2
+		boxes[index] = colorBox{pixels: box.pixels[:median]}
+		boxes = append(boxes, colorBox{pixels: box.pixels[median:]})
+	}
+
+	p := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		p = append(p, box.average())
+	}
+
+	return p
+}
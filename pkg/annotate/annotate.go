@@ -0,0 +1,250 @@
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Shape is an annotation drawn over an image.
+type Shape interface {
+	Draw(dst *image.NRGBA)
+}
+
+// Render draws the shapes over a copy of the image.
+func Render(src image.Image, shapes ...Shape) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	for _, shape := range shapes {
+		shape.Draw(dst)
+	}
+
+	return dst
+}
+
+// Rect is a rectangle outline.
+type Rect struct {
+	Rect  image.Rectangle
+	Color color.Color
+	Width int
+}
+
+// Draw implements Shape.
+func (r Rect) Draw(dst *image.NRGBA) {
+	rect := r.Rect.Canon()
+	w := r.Width
+	if w < 1 {
+		w = 1
+	}
+
+	src := image.NewUniform(r.Color)
+	for _, side := range []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+w),
+		image.Rect(rect.Min.X, rect.Max.Y-w, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+w, rect.Max.Y),
+		image.Rect(rect.Max.X-w, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	} {
+		draw.Draw(dst, side.Intersect(rect), src, image.Point{}, draw.Over)
+	}
+}
+
+// Arrow is a line from From to To with an arrowhead at To.
+type Arrow struct {
+	From  image.Point
+	To    image.Point
+	Color color.Color
+	Width int
+}
+
+// Draw implements Shape.
+func (a Arrow) Draw(dst *image.NRGBA) {
+	w := float64(a.Width)
+	if w < 1 {
+		w = 1
+	}
+
+	dx, dy := float64(a.To.X-a.From.X), float64(a.To.Y-a.From.Y)
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+
+	ux, uy := dx/length, dy/length
+	headLength := math.Min(w*4, length)
+	headWidth := w * 2.5
+
+	// the shaft ends where the arrowhead begins
+	baseX, baseY := float64(a.To.X)-ux*headLength, float64(a.To.Y)-uy*headLength
+	fillPolygon(dst, a.Color, [][2]float64{
+		{float64(a.From.X) - uy*w/2, float64(a.From.Y) + ux*w/2},
+		{baseX - uy*w/2, baseY + ux*w/2},
+		{baseX + uy*w/2, baseY - ux*w/2},
+		{float64(a.From.X) + uy*w/2, float64(a.From.Y) - ux*w/2},
+	})
+
+	fillPolygon(dst, a.Color, [][2]float64{
+		{float64(a.To.X), float64(a.To.Y)},
+		{baseX - uy*headWidth, baseY + ux*headWidth},
+		{baseX + uy*headWidth, baseY - ux*headWidth},
+	})
+}
+
+// fillPolygon fills a convex polygon.
+func fillPolygon(dst *image.NRGBA, c color.Color, points [][2]float64) {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+
+	bounds := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1).Intersect(dst.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if insideConvex(points, float64(x)+0.5, float64(y)+0.5) {
+				dst.Set(x, y, c)
+			}
+		}
+	}
+}
+
+// insideConvex returns true if the point is inside the convex polygon.
+func insideConvex(points [][2]float64, x, y float64) bool {
+	sign := 0
+	for i := range points {
+		a, b := points[i], points[(i+1)%len(points)]
+		cross := (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+		switch {
+		case cross > 0:
+			if sign < 0 {
+				return false
+			}
+			sign = 1
+		case cross < 0:
+			if sign > 0 {
+				return false
+			}
+			sign = -1
+		}
+	}
+
+	return true
+}
+
+// Blur blurs a region of the image, e.g. to hide personal data.
+type Blur struct {
+	Rect   image.Rectangle
+	Radius int
+}
+
+// Draw implements Shape.
+func (b Blur) Draw(dst *image.NRGBA) {
+	rect := b.Rect.Canon().Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+
+	radius := b.Radius
+	if radius < 1 {
+		radius = 1
+	}
+
+	// three box blur passes approximate a gaussian blur
+	for i := 0; i < 3; i++ {
+		boxBlur(dst, rect, radius, true)
+		boxBlur(dst, rect, radius, false)
+	}
+}
+
+// boxBlur blurs the region horizontally or vertically.
+func boxBlur(img *image.NRGBA, rect image.Rectangle, radius int, horizontal bool) {
+	outer, inner := rect.Dy(), rect.Dx()
+	if !horizontal {
+		outer, inner = inner, outer
+	}
+
+	at := func(o, i int) int {
+		if horizontal {
+			return img.PixOffset(rect.Min.X+i, rect.Min.Y+o)
+		}
+
+		return img.PixOffset(rect.Min.X+o, rect.Min.Y+i)
+	}
+
+	line := make([]uint8, inner*4)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			copy(line[i*4:i*4+4], img.Pix[at(o, i):at(o, i)+4])
+		}
+
+		for i := 0; i < inner; i++ {
+			lo, hi := i-radius, i+radius
+			if lo < 0 {
+				lo = 0
+			}
+
+			if hi > inner-1 {
+				hi = inner - 1
+			}
+
+			var sum [4]int
+			for j := lo; j <= hi; j++ {
+				for c := 0; c < 4; c++ {
+					sum[c] += int(line[j*4+c])
+				}
+			}
+
+			n := hi - lo + 1
+			offset := at(o, i)
+			for c := 0; c < 4; c++ {
+				img.Pix[offset+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+}
+
+// Text is a text label with a background for readability.
+type Text struct {
+	At    image.Point
+	Text  string
+	Color color.Color
+	Scale int
+}
+
+// Draw implements Shape.
+func (t Text) Draw(dst *image.NRGBA) {
+	scale := t.Scale
+	if scale < 1 {
+		scale = 1
+	}
+
+	label := renderText(t.Text, t.Color, color.NRGBA{A: 0x80})
+	size := label.Bounds().Size().Mul(scale)
+	rect := image.Rectangle{Min: t.At, Max: t.At.Add(size)}
+	xdraw.NearestNeighbor.Scale(dst, rect, label, label.Bounds(), xdraw.Over, nil)
+}
+
+// renderText renders the text with the basic font over a background.
+func renderText(text string, fg color.Color, bg color.Color) *image.NRGBA {
+	const padding = 2
+
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{Face: face}
+	width := drawer.MeasureString(text).Ceil()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width+2*padding, face.Height+2*padding))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	drawer.Dst = img
+	drawer.Src = image.NewUniform(fg)
+	drawer.Dot = fixed.P(padding, padding+face.Ascent)
+	drawer.DrawString(text)
+	return img
+}
@@ -0,0 +1,143 @@
+package annotate
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var red = color.NRGBA{R: 0xff, A: 0xff}
+
+func whiteImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	return img
+}
+
+func TestRect(t *testing.T) {
+	img := Render(whiteImage(50, 50), Rect{Rect: image.Rect(10, 10, 40, 40), Color: red, Width: 2})
+
+	if img.NRGBAAt(10, 20) != red || img.NRGBAAt(39, 20) != red || img.NRGBAAt(20, 11) != red {
+		t.Error("expected red outline")
+	}
+
+	if img.NRGBAAt(25, 25) == red || img.NRGBAAt(12, 25) == red {
+		t.Error("expected white inside")
+	}
+}
+
+func TestArrow(t *testing.T) {
+	img := Render(whiteImage(100, 50), Arrow{From: image.Pt(10, 25), To: image.Pt(90, 25), Color: red, Width: 4})
+
+	for _, x := range []int{12, 50, 85} {
+		if img.NRGBAAt(x, 25) != red {
+			t.Errorf("expected red at %d,25", x)
+		}
+	}
+
+	// the arrowhead is wider than the shaft
+	if img.NRGBAAt(78, 30) != red || img.NRGBAAt(30, 30) == red {
+		t.Error("expected arrowhead to be wider than the shaft")
+	}
+}
+
+func TestBlur(t *testing.T) {
+	src := whiteImage(40, 40)
+	for x := 0; x < 40; x++ {
+		src.SetNRGBA(x, 20, color.NRGBA{A: 0xff})
+	}
+
+	img := Render(src, Blur{Rect: image.Rect(0, 10, 20, 30), Radius: 3})
+
+	if img.NRGBAAt(5, 20).R == 0 {
+		t.Error("expected blurred line inside the region")
+	}
+
+	if img.NRGBAAt(5, 19).R == 0xff {
+		t.Error("expected blurred neighbour inside the region")
+	}
+
+	if img.NRGBAAt(30, 20).R != 0 || img.NRGBAAt(30, 19).R != 0xff {
+		t.Error("expected untouched pixels outside the region")
+	}
+}
+
+func TestText(t *testing.T) {
+	img := Render(whiteImage(200, 100), Text{At: image.Pt(10, 10), Text: "Bug", Color: red, Scale: 2})
+
+	found := false
+	for y := 10; y < 50 && !found; y++ {
+		for x := 10; x < 100; x++ {
+			if img.NRGBAAt(x, y) == red {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		t.Error("expected rendered text")
+	}
+}
+
+func TestDeviceFrame(t *testing.T) {
+	display := Display{Width: 100, Height: 200, Density: 160}
+
+	framed := DeviceFrame(whiteImage(50, 100), display)
+	if framed.Bounds().Dx() != 100+2*bezelDp || framed.Bounds().Dy() != 200+2*bezelDp {
+		t.Errorf("unexpected frame size: %v", framed.Bounds())
+	}
+
+	if framed.NRGBAAt(0, 0).A != 0 {
+		t.Error("expected transparent corner")
+	}
+
+	if framed.NRGBAAt(framed.Bounds().Dx()/2, 2) != bezelColor {
+		t.Error("expected bezel")
+	}
+
+	if framed.NRGBAAt(framed.Bounds().Dx()/2, framed.Bounds().Dy()/2).R != 0xff {
+		t.Error("expected screenshot in the middle")
+	}
+
+	// landscape screenshots rotate the frame
+	framed = DeviceFrame(whiteImage(100, 50), display)
+	if framed.Bounds().Dx() != 200+2*bezelDp {
+		t.Errorf("unexpected landscape frame size: %v", framed.Bounds())
+	}
+}
+
+func TestCaptionAndExport(t *testing.T) {
+	img := Caption(whiteImage(300, 100), "SM-G991B 2026-01-02 15:04:05")
+	if img.Bounds().Dy() <= 100 {
+		t.Errorf("expected caption bar, got %v", img.Bounds())
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, img, FormatPNG); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	buf.Reset()
+	if err := Export(&buf, img, FormatJPEG); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := jpeg.Decode(&buf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := FormatFromPath("./screenshot.bmp"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,188 @@
+package annotate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	// DefaultJPEGQuality is the default quality of exported JPEG images.
+	DefaultJPEGQuality = 90
+
+	bezelDp        = 16 // thickness of the device bezel
+	cornerRadiusDp = 32 // radius of the device corners
+)
+
+var (
+	bezelColor      = color.NRGBA{R: 0x20, G: 0x20, B: 0x24, A: 0xff}
+	captionColor    = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	captionBgColor  = color.NRGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xff}
+	transparentFill = color.NRGBA{}
+)
+
+// Display describes the physical screen the device frame is sized from.
+type Display struct {
+	Width   int
+	Height  int
+	Density int
+}
+
+// dp converts density-independent pixels to pixels.
+func (d Display) dp(v int) int {
+	density := d.Density
+	if density == 0 {
+		density = 160
+	}
+
+	return v * density / 160
+}
+
+// DeviceFrame wraps the screenshot in a device frame with rounded corners.
+// The screenshot is scaled to the display size, respecting its orientation.
+func DeviceFrame(src image.Image, display Display) *image.NRGBA {
+	width, height := display.Width, display.Height
+	if width == 0 || height == 0 {
+		width, height = src.Bounds().Dx(), src.Bounds().Dy()
+	}
+
+	// display parameters are reported in the natural orientation
+	if (src.Bounds().Dx() > src.Bounds().Dy()) != (width > height) {
+		width, height = height, width
+	}
+
+	bezel, radius := display.dp(bezelDp), display.dp(cornerRadiusDp)
+	screen := image.Rect(bezel, bezel, bezel+width, bezel+height)
+	dst := image.NewNRGBA(image.Rect(0, 0, width+2*bezel, height+2*bezel))
+
+	outer := dst.Bounds()
+	for y := outer.Min.Y; y < outer.Max.Y; y++ {
+		for x := outer.Min.X; x < outer.Max.X; x++ {
+			if insideRoundedRect(outer, radius+bezel, x, y) {
+				dst.SetNRGBA(x, y, bezelColor)
+			} else {
+				dst.SetNRGBA(x, y, transparentFill)
+			}
+		}
+	}
+
+	screenImage := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.ApproxBiLinear.Scale(screenImage, screenImage.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if insideRoundedRect(screenImage.Bounds(), radius, x, y) {
+				dst.SetNRGBA(screen.Min.X+x, screen.Min.Y+y, screenImage.NRGBAAt(x, y))
+			}
+		}
+	}
+
+	return dst
+}
+
+// insideRoundedRect returns true if the pixel is inside the rectangle with rounded corners.
+func insideRoundedRect(r image.Rectangle, radius int, x, y int) bool {
+	if radius*2 > r.Dx() {
+		radius = r.Dx() / 2
+	}
+
+	if radius*2 > r.Dy() {
+		radius = r.Dy() / 2
+	}
+
+	cx, cy := x, y
+	switch {
+	case x < r.Min.X+radius:
+		cx = r.Min.X + radius
+	case x >= r.Max.X-radius:
+		cx = r.Max.X - radius - 1
+	}
+
+	switch {
+	case y < r.Min.Y+radius:
+		cy = r.Min.Y + radius
+	case y >= r.Max.Y-radius:
+		cy = r.Max.Y - radius - 1
+	}
+
+	dx, dy := x-cx, y-cy
+	return dx*dx+dy*dy <= radius*radius
+}
+
+// Caption adds a bar with the text below the image.
+func Caption(src image.Image, text string) *image.NRGBA {
+	bounds := src.Bounds()
+
+	label := renderText(text, captionColor, captionBgColor)
+	scale := bounds.Dx() / 3 / label.Bounds().Dx()
+	if scale < 1 {
+		scale = 1
+	}
+
+	barHeight := label.Bounds().Dy() * scale
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+barHeight))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(captionBgColor), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, 0, bounds.Dx(), bounds.Dy()), src, bounds.Min, draw.Src)
+
+	labelRect := image.Rect(0, bounds.Dy(), label.Bounds().Dx()*scale, bounds.Dy()+barHeight)
+	xdraw.NearestNeighbor.Scale(dst, labelRect, label, label.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// Format is an export image format.
+type Format int
+
+const (
+	FormatPNG Format = iota
+	FormatJPEG
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatPNG:
+		return "png"
+	case FormatJPEG:
+		return "jpeg"
+	default:
+		return "unknown"
+	}
+}
+
+// FormatFromPath returns the format matching the extension of the path.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return FormatPNG, nil
+	case ".jpg", ".jpeg":
+		return FormatJPEG, nil
+	default:
+		return FormatPNG, fmt.Errorf("unsupported image format: %s", filepath.Ext(path))
+	}
+}
+
+// Export writes the image to w in the given format.
+// JPEG has no transparency, so transparent pixels are drawn over white.
+func Export(w io.Writer, img image.Image, format Format) error {
+	switch format {
+	case FormatPNG:
+		return png.Encode(w, img)
+
+	case FormatJPEG:
+		bounds := img.Bounds()
+		opaque := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(opaque, opaque.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(opaque, opaque.Bounds(), img, bounds.Min, draw.Over)
+		return jpeg.Encode(w, opaque, &jpeg.Options{Quality: DefaultJPEGQuality})
+
+	default:
+		return fmt.Errorf("unsupported image format: %s", format)
+	}
+}
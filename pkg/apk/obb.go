@@ -0,0 +1,110 @@
+package apk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidOBBName = errors.New("invalid OBB name, expected <main|patch>.<versionCode>.<package>.obb")
+
+// OBBKind is the kind of an APK expansion file.
+type OBBKind int
+
+const (
+	OBBMain OBBKind = iota
+	OBBPatch
+)
+
+func (k OBBKind) String() string {
+	if k == OBBPatch {
+		return "patch"
+	}
+
+	return "main"
+}
+
+// OBB is an APK expansion file, named <main|patch>.<versionCode>.<package>.obb.
+type OBB struct {
+	Kind        OBBKind
+	VersionCode int32
+	Package     string
+}
+
+// Name returns the file name of the expansion file.
+func (o OBB) Name() string {
+	return fmt.Sprintf("%s.%d.%s.obb", o.Kind, o.VersionCode, o.Package)
+}
+
+// ParseOBBName parses the file name of an expansion file.
+func ParseOBBName(name string) (OBB, error) {
+	base := strings.TrimSuffix(filepath.Base(name), ".obb")
+	if base == filepath.Base(name) {
+		return OBB{}, fmt.Errorf("%w: %s", ErrInvalidOBBName, name)
+	}
+
+	fields := strings.SplitN(base, ".", 3)
+	if len(fields) != 3 || fields[2] == "" {
+		return OBB{}, fmt.Errorf("%w: %s", ErrInvalidOBBName, name)
+	}
+
+	var obb OBB
+	switch fields[0] {
+	case "main":
+		obb.Kind = OBBMain
+	case "patch":
+		obb.Kind = OBBPatch
+	default:
+		return OBB{}, fmt.Errorf("%w: %s", ErrInvalidOBBName, name)
+	}
+
+	versionCode, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil || versionCode <= 0 {
+		return OBB{}, fmt.Errorf("%w: %s", ErrInvalidOBBName, name)
+	}
+
+	obb.VersionCode = int32(versionCode)
+	obb.Package = fields[2]
+	return obb, nil
+}
+
+// ValidateOBBName checks that the expansion file belongs to the package and its version code isn't newer than the app's.
+// An app may use the expansion file of an older version, when it didn't change.
+func ValidateOBBName(name, pkg string, versionCode int32) (OBB, error) {
+	obb, err := ParseOBBName(name)
+	if err != nil {
+		return obb, err
+	}
+
+	if obb.Package != pkg {
+		return obb, fmt.Errorf("%s is for package %s, not %s", filepath.Base(name), obb.Package, pkg)
+	}
+
+	if obb.VersionCode > versionCode {
+		return obb, fmt.Errorf("%s is for version code %d, newer than %d", filepath.Base(name), obb.VersionCode, versionCode)
+	}
+
+	return obb, nil
+}
+
+// ValidateOBB checks that the expansion file belongs to the package and the version of the APK.
+func (a *APK) ValidateOBB(name string) (OBB, error) {
+	return ValidateOBBName(name, a.Identifier(), a.VersionCode())
+}
+
+// FindOBBs returns the paths of the main and patch expansion files of the version of the APK next to it.
+func (a *APK) FindOBBs(apkPath string) []string {
+	var paths []string
+	for _, kind := range []OBBKind{OBBMain, OBBPatch} {
+		obb := OBB{Kind: kind, VersionCode: a.VersionCode(), Package: a.Identifier()}
+		path := filepath.Join(filepath.Dir(apkPath), obb.Name())
+		if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,80 @@
+package apk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOBBName(t *testing.T) {
+	obb, err := ParseOBBName("/builds/main.314.com.example.game.obb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if obb.Kind != OBBMain || obb.VersionCode != 314 || obb.Package != "com.example.game" {
+		t.Errorf("unexpected OBB: %+v", obb)
+	}
+
+	if obb.Name() != "main.314.com.example.game.obb" {
+		t.Errorf("Name: got %s", obb.Name())
+	}
+
+	if obb, err := ParseOBBName("patch.2.com.example.game.obb"); err != nil || obb.Kind != OBBPatch {
+		t.Errorf("patch: %+v, %v", obb, err)
+	}
+
+	for _, name := range []string{
+		"main.314.com.example.game.zip",
+		"extra.314.com.example.game.obb",
+		"main.x.com.example.game.obb",
+		"main.0.com.example.game.obb",
+		"main.314.obb",
+		"main.314..obb",
+	} {
+		if _, err := ParseOBBName(name); !errors.Is(err, ErrInvalidOBBName) {
+			t.Errorf("%s: expected ErrInvalidOBBName, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateOBBName(t *testing.T) {
+	if _, err := ValidateOBBName("main.3.com.example.game.obb", "com.example.game", 5); err != nil {
+		t.Errorf("older version: %v", err)
+	}
+
+	if _, err := ValidateOBBName("main.6.com.example.game.obb", "com.example.game", 5); err == nil {
+		t.Error("newer version: expected an error")
+	}
+
+	if _, err := ValidateOBBName("main.5.com.example.other.obb", "com.example.game", 5); err == nil {
+		t.Error("other package: expected an error")
+	}
+}
+
+func TestFindOBBs(t *testing.T) {
+	apk, err := NewAPK("testdata/helloworld.apk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer apk.Close()
+
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "helloworld.apk")
+	for _, name := range []string{"main.1.com.example.helloworld.obb", "patch.2.com.example.helloworld.obb", "main.1.com.example.other.obb"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("obb"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths := apk.FindOBBs(apkPath)
+	if len(paths) != 1 || filepath.Base(paths[0]) != "main.1.com.example.helloworld.obb" {
+		t.Errorf("unexpected OBBs: %v", paths)
+	}
+
+	if _, err := apk.ValidateOBB(paths[0]); err != nil {
+		t.Errorf("ValidateOBB: %v", err)
+	}
+}
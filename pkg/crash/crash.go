@@ -0,0 +1,183 @@
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+	"github.com/johnnyipcom/androidtool/pkg/retrace"
+)
+
+// Kind is the kind of a crash.
+type Kind int
+
+const (
+	// Java is an uncaught Java exception, 'FATAL EXCEPTION' of AndroidRuntime.
+	Java Kind = iota
+	// Native is a native crash, a tombstone written by DEBUG.
+	Native
+	// ANR is an application not responding, 'ANR in' of ActivityManager.
+	ANR
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Java:
+		return "java"
+	case Native:
+		return "native"
+	case ANR:
+		return "anr"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *Kind) UnmarshalText(text []byte) error {
+	for kind := Java; kind <= ANR; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid crash kind: %s", text)
+}
+
+// Record is a crash assembled from the log.
+type Record struct {
+	Kind    Kind      `json:"kind"`
+	Serial  string    `json:"serial"`
+	Time    time.Time `json:"time"`
+	Package string    `json:"package"`
+	Process string    `json:"process"`
+	PID     int       `json:"pid"`
+	// Reason is the exception, the signal or the ANR reason.
+	Reason string `json:"reason"`
+	// Stack are the lines of the crash as logged.
+	Stack []string `json:"stack"`
+	// Context are the messages logged around the crash by other tags and processes.
+	Context []adbclient.LogcatMessage `json:"context,omitempty"`
+	// Events are true if the record was assembled from am_crash or am_anr events only.
+	Events bool `json:"events,omitempty"`
+	// VersionCode is the version code of the package installed at the time of the crash, zero if unknown.
+	VersionCode int `json:"version_code,omitempty"`
+	// Retraced is true if the stack was deobfuscated with the mapping of the build.
+	Retraced bool `json:"retraced,omitempty"`
+}
+
+// Key returns a key unique for the device, sortable by time.
+func (r Record) Key() string {
+	return fmt.Sprintf("%s-%d", r.Time.UTC().Format("20060102T150405.000000000"), r.PID)
+}
+
+// Summary returns a single line description of the crash.
+func (r Record) Summary() string {
+	name := r.Package
+	if name == "" {
+		name = r.Process
+	}
+
+	if name == "" {
+		name = "unknown process"
+	}
+
+	if r.Reason == "" {
+		return fmt.Sprintf("%s %s", name, r.Kind)
+	}
+
+	return fmt.Sprintf("%s %s: %s", name, r.Kind, r.Reason)
+}
+
+// Retrace deobfuscates the reason and the stack of a Java crash or an ANR with the mapping of the build.
+// Native crashes and records already retraced are left as they are.
+func (r *Record) Retrace(m *retrace.Mapping) {
+	if r.Kind == Native || r.Retraced || m == nil {
+		return
+	}
+
+	r.Reason = strings.Join(m.RetraceLine(r.Reason), " ")
+	r.Stack = m.RetraceLines(r.Stack)
+	r.Retraced = true
+}
+
+// WriteText writes the crash as a human readable report.
+func (r Record) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Kind:    %s\n", r.Kind)
+	fmt.Fprintf(&b, "Device:  %s\n", r.Serial)
+	fmt.Fprintf(&b, "Time:    %s\n", r.Time.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "Package: %s\n", r.Package)
+	fmt.Fprintf(&b, "Process: %s\n", r.Process)
+	fmt.Fprintf(&b, "PID:     %d\n", r.PID)
+	if r.VersionCode != 0 {
+		fmt.Fprintf(&b, "Version: %d\n", r.VersionCode)
+	}
+
+	fmt.Fprintf(&b, "Reason:  %s\n", r.Reason)
+
+	if r.Retraced {
+		b.WriteString("\n--- crash (retraced) ---\n")
+	} else {
+		b.WriteString("\n--- crash ---\n")
+	}
+	for _, line := range r.Stack {
+		b.WriteString(line + "\n")
+	}
+
+	if len(r.Context) > 0 {
+		b.WriteString("\n--- log context ---\n")
+		for _, msg := range r.Context {
+			b.WriteString(msg.String() + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Export writes the records to the path, as JSON if the extension is .json and as text otherwise.
+func Export(path string, records []Record) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			return err
+		}
+
+		return file.Close()
+	}
+
+	for i, record := range records {
+		if i > 0 {
+			if _, err := io.WriteString(file, "\n========================================\n\n"); err != nil {
+				return err
+			}
+		}
+
+		if err := record.WriteText(file); err != nil {
+			return err
+		}
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,472 @@
+package crash
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// DefaultQuietPeriod is the time without new lines after which a crash is complete.
+	DefaultQuietPeriod = 2 * time.Second
+
+	// DefaultContextLines is the number of messages kept before and after a crash.
+	DefaultContextLines = 20
+
+	// maxCrashLines limits the number of lines of a single crash.
+	maxCrashLines = 1000
+
+	// duplicateWindow is the time in which an event is considered a duplicate of a crash of the same process.
+	duplicateWindow = 10 * time.Second
+)
+
+// event log tags of ActivityManager, the binary log has the numbers instead of the names
+const (
+	amCrashTag    = "am_crash"
+	amCrashNumber = "30039"
+	amANRTag      = "am_anr"
+	amANRNumber   = "30008"
+)
+
+var (
+	javaProcessRegex   = regexp.MustCompile(`^Process: (\S+), PID: (\d+)`)
+	nativeProcessRegex = regexp.MustCompile(`pid: (\d+), tid: \d+, name: .*>>> (.+?) <<<`)
+	anrProcessRegex    = regexp.MustCompile(`^ANR in (\S+)`)
+	anrPIDRegex        = regexp.MustCompile(`^PID: (\d+)`)
+)
+
+type detectorOptions struct {
+	quietPeriod  time.Duration
+	contextLines int
+}
+
+// DetectorOption is an option of NewDetector.
+type DetectorOption interface {
+	apply(*detectorOptions) error
+}
+
+type quietPeriodOption time.Duration
+
+func (o quietPeriodOption) apply(opts *detectorOptions) error {
+	if o <= 0 {
+		return fmt.Errorf("invalid quiet period: %s", time.Duration(o))
+	}
+
+	opts.quietPeriod = time.Duration(o)
+	return nil
+}
+
+type contextLinesOption int
+
+func (o contextLinesOption) apply(opts *detectorOptions) error {
+	if o < 0 {
+		return fmt.Errorf("invalid number of context lines: %d", o)
+	}
+
+	opts.contextLines = int(o)
+	return nil
+}
+
+// WithQuietPeriod sets the time without new lines after which a crash is complete.
+func WithQuietPeriod(d time.Duration) DetectorOption {
+	return quietPeriodOption(d)
+}
+
+// WithContextLines sets the number of messages kept before and after a crash.
+func WithContextLines(n int) DetectorOption {
+	return contextLinesOption(n)
+}
+
+// block is a crash being assembled.
+type block struct {
+	record Record
+	// tag, pid and priority of the logging process, consecutive lines must match them
+	tag      string
+	pid      int
+	priority adbclient.LogcatPriority
+	last     time.Time
+	lines    []string
+	after    int
+}
+
+// Detector assembles crash records from a stream of logcat messages.
+// It recognises Java FATAL EXCEPTION blocks, native tombstones, ANRs and am_crash/am_anr events.
+type Detector struct {
+	serial  string
+	options detectorOptions
+	history []adbclient.LogcatMessage
+	blocks  []*block
+	emitted []Record
+}
+
+// NewDetector creates a new detector for the device.
+func NewDetector(serial string, opts ...DetectorOption) (*Detector, error) {
+	options := detectorOptions{
+		quietPeriod:  DefaultQuietPeriod,
+		contextLines: DefaultContextLines,
+	}
+
+	for _, opt := range opts {
+		if err := opt.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Detector{
+		serial:  serial,
+		options: options,
+	}, nil
+}
+
+// Feed adds a message and returns the crashes completed by it.
+// A crash is completed when no lines were added to it for the quiet period of log time.
+func (d *Detector) Feed(msg adbclient.LogcatMessage) []Record {
+	records := d.complete(func(b *block) bool {
+		return msg.Timestamp.Sub(b.last) > d.options.quietPeriod
+	})
+
+	if b := d.continued(msg); b != nil {
+		if len(b.lines) < maxCrashLines {
+			b.lines = append(b.lines, splitLines(msg.Message)...)
+		}
+
+		b.last = msg.Timestamp
+		return records
+	}
+
+	for _, b := range d.blocks {
+		if b.after < d.options.contextLines {
+			b.record.Context = append(b.record.Context, msg)
+			b.after++
+		}
+	}
+
+	if b := d.start(msg); b != nil {
+		d.blocks = append(d.blocks, b)
+	} else {
+		d.remember(msg)
+	}
+
+	return records
+}
+
+// Flush completes all crashes being assembled.
+func (d *Detector) Flush() []Record {
+	return d.complete(func(*block) bool { return true })
+}
+
+// Watch runs the detector on the messages. Crashes are flushed when no message arrives for the quiet period.
+// The returned channel is closed when the context is done or the messages channel is closed.
+func (d *Detector) Watch(ctx context.Context, messages <-chan adbclient.LogcatMessage) <-chan Record {
+	ch := make(chan Record)
+
+	go func() {
+		defer close(ch)
+
+		timer := time.NewTimer(d.options.quietPeriod)
+		defer timer.Stop()
+
+		send := func(records []Record) bool {
+			for _, record := range records {
+				select {
+				case ch <- record:
+				case <-ctx.Done():
+					return false
+				}
+			}
+
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case msg, ok := <-messages:
+				if !ok {
+					send(d.Flush())
+					return
+				}
+
+				if !send(d.Feed(msg)) {
+					return
+				}
+
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+
+				timer.Reset(d.options.quietPeriod)
+
+			case <-timer.C:
+				if !send(d.Flush()) {
+					return
+				}
+
+				timer.Reset(d.options.quietPeriod)
+			}
+		}
+	}()
+
+	return ch
+}
+
+// remember adds the message to the context kept for the next crash.
+func (d *Detector) remember(msg adbclient.LogcatMessage) {
+	if d.options.contextLines == 0 {
+		return
+	}
+
+	if len(d.history) >= d.options.contextLines {
+		d.history = append(d.history[:0], d.history[len(d.history)-d.options.contextLines+1:]...)
+	}
+
+	d.history = append(d.history, msg)
+}
+
+// continued returns the open block continued by the message.
+func (d *Detector) continued(msg adbclient.LogcatMessage) *block {
+	for _, b := range d.blocks {
+		if b.tag != "" && b.tag == msg.Tag && b.pid == msg.ProcessID && b.priority == msg.Priority {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// start returns a new block if the message starts a crash.
+func (d *Detector) start(msg adbclient.LogcatMessage) *block {
+	var kind Kind
+	tag := msg.Tag
+
+	switch {
+	case msg.Tag == "AndroidRuntime" && strings.HasPrefix(msg.Message, "FATAL EXCEPTION"):
+		kind = Java
+	case msg.Tag == "DEBUG" && strings.HasPrefix(msg.Message, "*** *** ***"):
+		kind = Native
+	case msg.Tag == "ActivityManager" && strings.HasPrefix(msg.Message, "ANR in "):
+		kind = ANR
+	case msg.Tag == amCrashTag || msg.Tag == amCrashNumber:
+		// events are single messages, they are never continued
+		kind, tag = Java, ""
+	case msg.Tag == amANRTag || msg.Tag == amANRNumber:
+		kind, tag = ANR, ""
+	default:
+		return nil
+	}
+
+	return &block{
+		tag:      tag,
+		pid:      msg.ProcessID,
+		priority: msg.Priority,
+		last:     msg.Timestamp,
+		lines:    splitLines(msg.Message),
+		record: Record{
+			Kind:    kind,
+			Serial:  d.serial,
+			Time:    msg.Timestamp,
+			Context: append([]adbclient.LogcatMessage(nil), d.history...),
+		},
+	}
+}
+
+// complete removes the blocks selected by done and returns their records.
+func (d *Detector) complete(done func(*block) bool) []Record {
+	var records []Record
+
+	blocks := d.blocks[:0]
+	var completed []*block
+	for _, b := range d.blocks {
+		if done(b) {
+			completed = append(completed, b)
+		} else {
+			blocks = append(blocks, b)
+		}
+	}
+
+	d.blocks = blocks
+
+	// logged crashes first, so that the events duplicating them are dropped
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completed[i].tag != "" && completed[j].tag == ""
+	})
+
+	for _, b := range completed {
+		record := b.record
+		if b.tag == "" {
+			if !parseEvent(&record, b.lines) || d.duplicate(record) {
+				continue
+			}
+		} else {
+			parse(&record, b.lines)
+		}
+
+		d.emitted = append(d.emitted, record)
+		records = append(records, record)
+	}
+
+	// forget old records, they are only needed to drop duplicate events
+	if len(d.emitted) > 0 {
+		latest := d.emitted[len(d.emitted)-1].Time
+		for len(d.emitted) > 0 && latest.Sub(d.emitted[0].Time) > duplicateWindow {
+			d.emitted = d.emitted[1:]
+		}
+	}
+
+	return records
+}
+
+// duplicate returns true if the event record was already reported by the log, or is being assembled.
+func (d *Detector) duplicate(record Record) bool {
+	for _, b := range d.blocks {
+		if b.tag != "" && b.record.Kind == record.Kind {
+			return true
+		}
+	}
+
+	for _, emitted := range d.emitted {
+		if emitted.Kind == record.Kind && emitted.PID == record.PID && !emitted.Events {
+			diff := record.Time.Sub(emitted.Time)
+			if diff > -duplicateWindow && diff < duplicateWindow {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parse fills the record from the lines of a crash.
+func parse(record *Record, lines []string) {
+	switch record.Kind {
+	case Java:
+		// FATAL EXCEPTION: main
+		// Process: com.example, PID: 1234
+		// java.lang.RuntimeException: ...
+		//     at ...
+		for i, line := range lines {
+			if match := javaProcessRegex.FindStringSubmatch(line); match != nil {
+				record.Process = match[1]
+				record.PID, _ = strconv.Atoi(match[2])
+				if i+1 < len(lines) {
+					record.Reason = strings.TrimSpace(lines[i+1])
+				}
+
+				break
+			}
+		}
+
+	case Native:
+		// pid: 1234, tid: 1234, name: Thread  >>> com.example <<<
+		// signal 11 (SIGSEGV), code 1 (SEGV_MAPERR), fault addr 0x0
+		// Abort message: '...'
+		var abort string
+		for _, line := range lines {
+			switch {
+			case record.Process == "" && nativeProcessRegex.MatchString(line):
+				match := nativeProcessRegex.FindStringSubmatch(line)
+				record.PID, _ = strconv.Atoi(match[1])
+				record.Process = match[2]
+			case record.Reason == "" && strings.HasPrefix(line, "signal "):
+				record.Reason = line
+			case strings.HasPrefix(line, "Abort message: "):
+				abort = strings.TrimPrefix(line, "Abort message: ")
+			}
+		}
+
+		if abort != "" {
+			record.Reason = strings.TrimSpace(record.Reason + " " + abort)
+		}
+
+	case ANR:
+		// ANR in com.example (com.example/.MainActivity)
+		// PID: 1234
+		// Reason: Input dispatching timed out ...
+		for _, line := range lines {
+			switch {
+			case anrProcessRegex.MatchString(line):
+				record.Process = anrProcessRegex.FindStringSubmatch(line)[1]
+			case anrPIDRegex.MatchString(line):
+				record.PID, _ = strconv.Atoi(anrPIDRegex.FindStringSubmatch(line)[1])
+			case record.Reason == "" && strings.HasPrefix(line, "Reason: "):
+				record.Reason = strings.TrimPrefix(line, "Reason: ")
+			}
+		}
+	}
+
+	record.Package = packageOf(record.Process)
+	record.Stack = lines
+}
+
+// parseEvent fills the record from an am_crash or am_anr event, it returns false if the event is malformed.
+//
+//	am_crash: [User, PID, Process Name, Flags, Exception, Message, File, Line, Recoverable]
+//	am_anr:   [User, PID, Package Name, Flags, Reason]
+func parseEvent(record *Record, lines []string) bool {
+	value := strings.Join(lines, "\n")
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return false
+	}
+
+	fields := strings.Split(value[1:len(value)-1], ",")
+	if len(fields) < 5 {
+		return false
+	}
+
+	pid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return false
+	}
+
+	record.PID = pid
+	record.Process = fields[2]
+	record.Package = packageOf(record.Process)
+	record.Events = true
+	record.Stack = []string{value}
+
+	switch record.Kind {
+	case Java:
+		// the message can contain commas, the fields after it are known,
+		// Recoverable was added in Android 11
+		trailing := 2
+		if len(fields) >= 9 {
+			trailing = 3
+		}
+
+		record.Reason = fields[4]
+		if len(fields) >= 8 {
+			if message := strings.Join(fields[5:len(fields)-trailing], ","); message != "" {
+				record.Reason += ": " + message
+			}
+		}
+	case ANR:
+		record.Reason = strings.Join(fields[4:], ",")
+	}
+
+	return true
+}
+
+// packageOf returns the package of a process name, e.g. com.example for com.example:remote.
+func packageOf(process string) string {
+	if strings.HasPrefix(process, "/") {
+		return ""
+	}
+
+	pkg, _, _ := strings.Cut(process, ":")
+	return pkg
+}
+
+func splitLines(message string) []string {
+	return strings.Split(message, "\n")
+}
@@ -0,0 +1,211 @@
+package crash
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+var start = time.Date(2026, 5, 18, 12, 0, 0, 0, time.UTC)
+
+// messages creates messages logged by the same process, 10ms apart.
+func messages(offset time.Duration, pid int, priority adbclient.LogcatPriority, tag string, lines ...string) []adbclient.LogcatMessage {
+	var msgs []adbclient.LogcatMessage
+	for i, line := range lines {
+		msgs = append(msgs, adbclient.LogcatMessage{
+			Timestamp: start.Add(offset + time.Duration(i)*10*time.Millisecond),
+			Priority:  priority,
+			Tag:       tag,
+			ProcessID: pid,
+			ThreadID:  pid,
+			Message:   line,
+		})
+	}
+
+	return msgs
+}
+
+func detect(t *testing.T, msgs ...[]adbclient.LogcatMessage) []Record {
+	t.Helper()
+
+	detector, err := NewDetector("serial", WithContextLines(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var records []Record
+	for _, group := range msgs {
+		for _, msg := range group {
+			records = append(records, detector.Feed(msg)...)
+		}
+	}
+
+	return append(records, detector.Flush()...)
+}
+
+func TestDetectJava(t *testing.T) {
+	records := detect(t,
+		messages(0, 100, adbclient.Info, "Zygote", "one", "two", "three"),
+		messages(time.Second, 4321, adbclient.Error, "AndroidRuntime",
+			"FATAL EXCEPTION: main",
+			"Process: com.example:remote, PID: 4321",
+			"java.lang.IllegalStateException: boom",
+			"\tat com.example.Main.onCreate(Main.java:10)",
+		),
+		messages(time.Second+15*time.Millisecond, 200, adbclient.Info, "Other", "interleaved"),
+		messages(time.Second+100*time.Millisecond, 1000, adbclient.Info, amCrashNumber,
+			"[0,4321,com.example:remote,1,java.lang.IllegalStateException,boom, really,Main.java,10,0]"),
+	)
+
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d: %+v", len(records), records)
+	}
+
+	record := records[0]
+	if record.Kind != Java || record.Package != "com.example" || record.Process != "com.example:remote" || record.PID != 4321 {
+		t.Errorf("unexpected record: %+v", record)
+	}
+
+	if record.Reason != "java.lang.IllegalStateException: boom" {
+		t.Errorf("unexpected reason: %s", record.Reason)
+	}
+
+	if len(record.Stack) != 4 {
+		t.Errorf("expected 4 stack lines, got %v", record.Stack)
+	}
+
+	// two messages before, the interleaved message and the event after
+	var context []string
+	for _, msg := range record.Context {
+		context = append(context, msg.Message)
+	}
+
+	if strings.Join(context, "|") != "two|three|interleaved|"+"[0,4321,com.example:remote,1,java.lang.IllegalStateException,boom, really,Main.java,10,0]" {
+		t.Errorf("unexpected context: %v", context)
+	}
+}
+
+func TestDetectNative(t *testing.T) {
+	records := detect(t, messages(0, 555, adbclient.Fatal, "DEBUG",
+		"*** *** *** *** *** *** *** *** *** *** *** *** *** *** *** ***",
+		"Build fingerprint: 'google/sdk/generic:13/TQ1A/1:userdebug/dev-keys'",
+		"pid: 4321, tid: 4330, name: RenderThread  >>> com.example <<<",
+		"signal 6 (SIGABRT), code -1 (SI_QUEUE), fault addr --------",
+		"Abort message: 'out of memory'",
+		"backtrace:",
+		"      #00 pc 000000000004e0f0  /apex/com.android.runtime/lib64/bionic/libc.so (abort+164)",
+	))
+
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.Kind != Native || record.Package != "com.example" || record.PID != 4321 {
+		t.Errorf("unexpected record: %+v", record)
+	}
+
+	if record.Reason != "signal 6 (SIGABRT), code -1 (SI_QUEUE), fault addr -------- 'out of memory'" {
+		t.Errorf("unexpected reason: %s", record.Reason)
+	}
+}
+
+func TestDetectANR(t *testing.T) {
+	// am_anr is logged before the ActivityManager message
+	records := detect(t,
+		messages(0, 1000, adbclient.Info, amANRTag, "[0,4321,com.example,952745540,Input dispatching timed out]"),
+		messages(500*time.Millisecond, 1000, adbclient.Error, "ActivityManager",
+			"ANR in com.example (com.example/.MainActivity)\nPID: 4321\nReason: Input dispatching timed out\nLoad: 0.5 / 0.3 / 0.2"),
+		messages(10*time.Second, 1000, adbclient.Info, "ActivityManager", "later"),
+	)
+
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d: %+v", len(records), records)
+	}
+
+	record := records[0]
+	if record.Kind != ANR || record.Package != "com.example" || record.PID != 4321 || record.Reason != "Input dispatching timed out" || record.Events {
+		t.Errorf("unexpected record: %+v", record)
+	}
+}
+
+func TestDetectEventOnly(t *testing.T) {
+	records := detect(t, messages(0, 1000, adbclient.Info, amCrashTag,
+		"[0,4321,com.example,1,java.lang.NullPointerException,Attempt to invoke, on null,Main.java,12,0]"))
+
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	record := records[0]
+	if !record.Events || record.Kind != Java || record.PID != 4321 || record.Reason != "java.lang.NullPointerException: Attempt to invoke, on null" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+}
+
+func TestDetectQuietPeriod(t *testing.T) {
+	detector, err := NewDetector("serial", WithQuietPeriod(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	crash := messages(0, 1, adbclient.Error, "AndroidRuntime", "FATAL EXCEPTION: main", "Process: a, PID: 1")
+	for _, msg := range crash {
+		if records := detector.Feed(msg); len(records) != 0 {
+			t.Fatalf("unexpected records: %+v", records)
+		}
+	}
+
+	if records := detector.Feed(messages(3*time.Second, 2, adbclient.Info, "Other", "x")[0]); len(records) != 1 {
+		t.Fatalf("expected the crash to complete after the quiet period, got %d records", len(records))
+	}
+
+	if _, err := NewDetector("serial", WithQuietPeriod(0)); err == nil {
+		t.Error("expected error for an invalid quiet period")
+	}
+}
+
+func TestExport(t *testing.T) {
+	records := detect(t, messages(0, 4321, adbclient.Error, "AndroidRuntime",
+		"FATAL EXCEPTION: main", "Process: com.example, PID: 4321", "java.lang.Error: boom"))
+
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "crashes.json")
+	if err := Export(jsonPath, records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded []Record
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(decoded) != 1 || decoded[0].Kind != Java || decoded[0].Key() != records[0].Key() {
+		t.Errorf("unexpected decoded records: %+v", decoded)
+	}
+
+	textPath := filepath.Join(dir, "crashes.txt")
+	if err := Export(textPath, records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text, err := os.ReadFile(textPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(string(text), "Reason:  java.lang.Error: boom") || !strings.Contains(string(text), "Process: com.example, PID: 4321") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+}
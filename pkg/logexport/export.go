@@ -0,0 +1,185 @@
+package logexport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// Format is an export format.
+type Format int
+
+const (
+	// Text is the logcat threadtime format.
+	Text Format = iota
+	// JSONL is a JSON object per line.
+	JSONL
+	// CSV is a header and a row per message.
+	CSV
+	// HTML is a self-contained report with statistics and a filterable message table.
+	HTML
+)
+
+// Formats are all export formats.
+var Formats = []Format{Text, JSONL, CSV, HTML}
+
+func (f Format) String() string {
+	switch f {
+	case JSONL:
+		return "jsonl"
+	case CSV:
+		return "csv"
+	case HTML:
+		return "html"
+	default:
+		return "text"
+	}
+}
+
+// Extension returns the file extension of the format.
+func (f Format) Extension() string {
+	if f == Text {
+		return ".log"
+	}
+
+	return "." + f.String()
+}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+
+	return Text, fmt.Errorf("invalid export format: %s", s)
+}
+
+// FormatFromPath returns the format of the file extension, text if it's unknown.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return JSONL
+	case ".csv":
+		return CSV
+	case ".html", ".htm":
+		return HTML
+	default:
+		return Text
+	}
+}
+
+// jsonMessage is a message in the JSON Lines export.
+type jsonMessage struct {
+	Time     time.Time `json:"time"`
+	Priority string    `json:"priority"`
+	Tag      string    `json:"tag"`
+	PID      int       `json:"pid"`
+	TID      int       `json:"tid"`
+	UID      int       `json:"uid,omitempty"`
+	Buffer   string    `json:"buffer"`
+	Message  string    `json:"message"`
+}
+
+func newJSONMessage(msg adbclient.LogcatMessage) jsonMessage {
+	return jsonMessage{
+		Time:     msg.Timestamp,
+		Priority: msg.Priority.String(),
+		Tag:      msg.Tag,
+		PID:      msg.ProcessID,
+		TID:      msg.ThreadID,
+		UID:      msg.UID,
+		Buffer:   msg.Buffer.String(),
+		Message:  msg.Message,
+	}
+}
+
+// WriteText writes the messages in the threadtime format.
+func WriteText(w io.Writer, msgs []adbclient.LogcatMessage) error {
+	bw := bufio.NewWriter(w)
+	for _, msg := range msgs {
+		if _, err := fmt.Fprintln(bw, msg.String()); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteJSONL writes a JSON object per message.
+func WriteJSONL(w io.Writer, msgs []adbclient.LogcatMessage) error {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	for _, msg := range msgs {
+		if err := encoder.Encode(newJSONMessage(msg)); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteCSV writes a header and a row per message.
+func WriteCSV(w io.Writer, msgs []adbclient.LogcatMessage) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "priority", "tag", "pid", "tid", "uid", "buffer", "message"}); err != nil {
+		return err
+	}
+
+	for _, msg := range msgs {
+		if err := cw.Write([]string{
+			msg.Timestamp.Format(time.RFC3339Nano),
+			msg.Priority.String(),
+			msg.Tag,
+			strconv.Itoa(msg.ProcessID),
+			strconv.Itoa(msg.ThreadID),
+			strconv.Itoa(msg.UID),
+			msg.Buffer.String(),
+			msg.Message,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// Write writes the messages in the format, the title is used by the HTML report.
+func Write(w io.Writer, format Format, msgs []adbclient.LogcatMessage, title string) error {
+	switch format {
+	case JSONL:
+		return WriteJSONL(w, msgs)
+	case CSV:
+		return WriteCSV(w, msgs)
+	case HTML:
+		return WriteHTML(w, msgs, title)
+	default:
+		return WriteText(w, msgs)
+	}
+}
+
+// Export writes the messages to the path in the format of its extension.
+func Export(path string, msgs []adbclient.LogcatMessage, title string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(file, FormatFromPath(path), msgs, title); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,144 @@
+package logexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+func testMessages() []adbclient.LogcatMessage {
+	return []adbclient.LogcatMessage{
+		testMessage(0, adbclient.Info, "GameNet", 100, "connected to \"server\""),
+		testMessage(time.Second, adbclient.Error, "Unity", 100, "exception, with comma\n  at Main"),
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]Format{
+		"log.txt":         Text,
+		"log.log":         Text,
+		"log":             Text,
+		"log.jsonl":       JSONL,
+		"dir/LOG.CSV":     CSV,
+		"report.html":     HTML,
+		"report.htm":      HTML,
+		"messages.ndjson": JSONL,
+	}
+
+	for path, want := range tests {
+		if got := FormatFromPath(path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+
+	for _, f := range Formats {
+		if got := FormatFromPath("log" + f.Extension()); got != f {
+			t.Errorf("extension of %v: got %v", f, got)
+		}
+
+		if got, err := ParseFormat(f.String()); err != nil || got != f {
+			t.Errorf("parse %v: got %v, %v", f, got, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("invalid format parsed")
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, testMessages()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines: %q", lines)
+	}
+
+	var msg jsonMessage
+	if err := json.Unmarshal([]byte(lines[1]), &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Priority != "E" || msg.Tag != "Unity" || msg.PID != 100 || msg.Message != "exception, with comma\n  at Main" || !msg.Time.Equal(testStart.Add(time.Second)) {
+		t.Errorf("message: %+v", msg)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testMessages()); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 3 || records[0][0] != "time" || records[0][7] != "message" {
+		t.Fatalf("records: %q", records)
+	}
+
+	if records[1][1] != "I" || records[1][2] != "GameNet" || records[1][7] != "connected to \"server\"" {
+		t.Errorf("record: %q", records[1])
+	}
+
+	if records[2][7] != "exception, with comma\n  at Main" {
+		t.Errorf("record: %q", records[2])
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	msgs := append(testMessages(), testMessage(2*time.Second, adbclient.Warning, "<script>", 200, "</script><b>"))
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, msgs, "Session <1>"); err != nil {
+		t.Fatal(err)
+	}
+
+	report := buf.String()
+	for _, s := range []string{"<title>Session &lt;1&gt;</title>", "3 messages", "GameNet", "Noisiest tags", "<svg"} {
+		if !strings.Contains(report, s) {
+			t.Errorf("report doesn't contain %q", s)
+		}
+	}
+
+	if strings.Contains(report, "</script><b>") {
+		t.Error("message isn't escaped")
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range Formats {
+		path := filepath.Join(dir, "log"+f.Extension())
+		if err := Export(path, testMessages(), "test"); err != nil {
+			t.Fatalf("%v: %v", f, err)
+		}
+
+		var buf bytes.Buffer
+		if err := Write(&buf, f, testMessages(), "test"); err != nil {
+			t.Fatalf("%v: %v", f, err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the HTML report contains the time it was generated
+		if f != HTML && !bytes.Equal(data, buf.Bytes()) {
+			t.Errorf("%v: export differs from write", f)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package logexport
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// reportTopN is the number of tags and processes in the tables of the report.
+	reportTopN = 20
+
+	chartWidth  = 960
+	chartHeight = 160
+)
+
+// htmlMessage is a message in the report, with short keys to keep the report small.
+type htmlMessage struct {
+	Time     string `json:"t"`
+	Priority int    `json:"p"`
+	Tag      string `json:"g"`
+	PID      int    `json:"i"`
+	TID      int    `json:"d"`
+	Message  string `json:"m"`
+}
+
+// chartBar is a bucket of the timeline chart.
+type chartBar struct {
+	X, Width         float64
+	TotalY, TotalH   float64
+	ErrorsY, ErrorsH float64
+	Title            string
+	Burst            bool
+}
+
+type reportData struct {
+	Title      string
+	Generated  time.Time
+	Stats      Stats
+	Noisiest   []TagStats
+	PIDs       []PIDStats
+	Bars       []chartBar
+	Width      int
+	Height     int
+	Priorities []string
+	Messages   []htmlMessage
+}
+
+// chart returns the bars of the timeline chart, the bars of bursts are marked.
+func chart(stats Stats) []chartBar {
+	if len(stats.Timeline) == 0 {
+		return nil
+	}
+
+	maxTotal := 1
+	for _, bucket := range stats.Timeline {
+		if bucket.Total > maxTotal {
+			maxTotal = bucket.Total
+		}
+	}
+
+	width := float64(chartWidth) / float64(len(stats.Timeline))
+	scale := float64(chartHeight) / float64(maxTotal)
+
+	var bars []chartBar
+	for i, bucket := range stats.Timeline {
+		bar := chartBar{
+			X:       float64(i) * width,
+			Width:   width,
+			TotalH:  float64(bucket.Total) * scale,
+			ErrorsH: float64(bucket.Errors) * scale,
+			Title:   fmt.Sprintf("%s: %d messages, %d errors", bucket.Start.Format("2006-01-02 15:04:05"), bucket.Total, bucket.Errors),
+		}
+
+		bar.TotalY = chartHeight - bar.TotalH
+		bar.ErrorsY = chartHeight - bar.ErrorsH
+
+		for _, burst := range stats.Bursts {
+			if !bucket.Start.Before(burst.Start) && bucket.Start.Before(burst.End) {
+				bar.Burst = true
+			}
+		}
+
+		bars = append(bars, bar)
+	}
+
+	return bars
+}
+
+// WriteHTML writes a self-contained report with the statistics and a filterable table of the messages.
+func WriteHTML(w io.Writer, msgs []adbclient.LogcatMessage, title string) error {
+	stats := ComputeStats(msgs)
+
+	data := reportData{
+		Title:     title,
+		Generated: time.Now(),
+		Stats:     stats,
+		Noisiest:  stats.NoisiestTags(reportTopN),
+		PIDs:      stats.PIDs,
+		Bars:      chart(stats),
+		Width:     chartWidth,
+		Height:    chartHeight,
+	}
+
+	if len(data.PIDs) > reportTopN {
+		data.PIDs = data.PIDs[:reportTopN]
+	}
+
+	for priority := adbclient.Verbose; priority <= adbclient.Fatal; priority++ {
+		data.Priorities = append(data.Priorities, priority.String())
+	}
+
+	for _, msg := range msgs {
+		data.Messages = append(data.Messages, htmlMessage{
+			Time:     msg.Timestamp.Format("01-02 15:04:05.000"),
+			Priority: int(msg.Priority),
+			Tag:      msg.Tag,
+			PID:      msg.ProcessID,
+			TID:      msg.ThreadID,
+			Message:  msg.Message,
+		})
+	}
+
+	return reportTemplate.Execute(w, data)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05.000")
+	},
+	"percent": func(n, total int) string {
+		if total == 0 {
+			return "0%"
+		}
+
+		return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+	},
+}).Parse(reportHTML))
+
+const reportHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 20px; color: #212121; }
+h1 { font-size: 22px; }
+h2 { font-size: 17px; margin-top: 28px; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { padding: 3px 8px; border-bottom: 1px solid #e0e0e0; text-align: left; }
+td.n { text-align: right; }
+.tables { display: flex; gap: 40px; flex-wrap: wrap; }
+.tag { cursor: pointer; color: #1565c0; }
+#filters { margin: 10px 0; display: flex; gap: 8px; }
+#log { font-family: monospace; font-size: 12px; white-space: pre-wrap; }
+#log td { border: none; padding: 0 6px; vertical-align: top; }
+.p0 { color: #9e9e9e; } .p1 { color: #1e88e5; } .p2 { color: #43a047; }
+.p3 { color: #fb8c00; } .p4 { color: #e53935; } .p5 { color: #8e24aa; font-weight: bold; }
+rect.total { fill: #bdbdbd; } rect.errors { fill: #e53935; } rect.burst { fill: #ffebee; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Stats.Total}} messages{{if .Stats.Total}} from {{time .Stats.Start}} to {{time .Stats.End}}{{end}}, generated {{time .Generated}}.</p>
+
+<h2>Priorities</h2>
+<table>
+<tr>{{range .Stats.Priorities}}<th>{{.Priority}}</th>{{end}}</tr>
+<tr>{{range .Stats.Priorities}}<td class="n">{{.Count}} ({{percent .Count $.Stats.Total}})</td>{{end}}</tr>
+</table>
+
+<h2>Errors over time</h2>
+<p>Buckets of {{.Stats.BucketSize}}, errors are E and F messages.</p>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+{{range .Bars}}{{if .Burst}}<rect class="burst" x="{{.X}}" y="0" width="{{.Width}}" height="{{$.Height}}"></rect>{{end}}
+<rect class="total" x="{{.X}}" y="{{.TotalY}}" width="{{.Width}}" height="{{.TotalH}}"><title>{{.Title}}</title></rect>
+<rect class="errors" x="{{.X}}" y="{{.ErrorsY}}" width="{{.Width}}" height="{{.ErrorsH}}"><title>{{.Title}}</title></rect>
+{{end}}</svg>
+
+{{if .Stats.Bursts}}
+<h2>Error bursts</h2>
+<table>
+<tr><th>Start</th><th>End</th><th>Errors</th></tr>
+{{range .Stats.Bursts}}<tr><td>{{time .Start}}</td><td>{{time .End}}</td><td class="n">{{.Errors}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<div class="tables">
+<div>
+<h2>Noisiest tags</h2>
+<table>
+<tr><th>Tag</th><th>Total</th>{{range .Priorities}}<th>{{.}}</th>{{end}}</tr>
+{{range .Noisiest}}<tr><td class="tag" data-tag="{{.Tag}}">{{.Tag}}</td><td class="n">{{.Total}}</td>{{range .Priorities}}<td class="n">{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</div>
+<div>
+<h2>Processes</h2>
+<table>
+<tr><th>PID</th><th>Total</th><th>Errors</th><th>Main tag</th></tr>
+{{range .PIDs}}<tr><td class="n">{{.PID}}</td><td class="n">{{.Total}}</td><td class="n">{{.Errors}}</td><td>{{.Tag}}</td></tr>
+{{end}}</table>
+</div>
+</div>
+
+<h2>Messages</h2>
+<div id="filters">
+<select id="priority">{{range $i, $p := .Priorities}}<option value="{{$i}}">{{$p}}</option>{{end}}</select>
+<input id="tag" placeholder="Tag">
+<input id="pid" placeholder="PID" size="6">
+<input id="text" placeholder="Text">
+<span id="count"></span>
+</div>
+<table id="log"></table>
+
+<script>
+const messages = {{.Messages}} || [];
+const limit = 5000;
+
+function render() {
+	const priority = Number(document.getElementById("priority").value);
+	const tag = document.getElementById("tag").value.toLowerCase();
+	const pid = document.getElementById("pid").value;
+	const text = document.getElementById("text").value.toLowerCase();
+
+	const matched = messages.filter(m =>
+		m.p >= priority &&
+		(tag === "" || m.g.toLowerCase().includes(tag)) &&
+		(pid === "" || String(m.i) === pid) &&
+		(text === "" || m.m.toLowerCase().includes(text)));
+
+	const table = document.getElementById("log");
+	table.textContent = "";
+	for (const m of matched.slice(-limit)) {
+		const row = table.insertRow();
+		row.className = "p" + m.p;
+		for (const value of [m.t, m.i, m.d, "VDIWEF"[m.p], m.g, m.m]) {
+			row.insertCell().textContent = value;
+		}
+	}
+
+	document.getElementById("count").textContent = matched.length > limit
+		? matched.length + " messages, the last " + limit + " shown"
+		: matched.length + " messages";
+}
+
+for (const id of ["priority", "tag", "pid", "text"]) {
+	document.getElementById(id).addEventListener("input", render);
+}
+
+for (const cell of document.querySelectorAll("td.tag")) {
+	cell.addEventListener("click", () => {
+		document.getElementById("tag").value = cell.dataset.tag;
+		render();
+	});
+}
+
+render();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,254 @@
+package logexport
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// maxBuckets is the maximum number of buckets of the timeline of the statistics.
+	maxBuckets = 120
+	// minBurstErrors is the minimum number of errors of a bucket to be a burst.
+	minBurstErrors = 5
+)
+
+// bucketSizes are the possible bucket sizes of the timeline, the smallest one giving at most maxBuckets is used.
+var bucketSizes = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
+
+// PriorityCount is the number of messages of a priority.
+type PriorityCount struct {
+	Priority adbclient.LogcatPriority
+	Count    int
+}
+
+// TagStats are the message counts of a tag.
+type TagStats struct {
+	Tag   string
+	Total int
+	// Priorities are the counts by priority, indexed by adbclient.LogcatPriority from Verbose to Fatal.
+	Priorities [adbclient.Fatal + 1]int
+}
+
+// PIDStats are the message counts of a process.
+type PIDStats struct {
+	PID    int
+	Total  int
+	Errors int
+	// Tag is the tag the process logged the most with.
+	Tag string
+}
+
+// Bucket is a period of the timeline.
+type Bucket struct {
+	Start  time.Time
+	Total  int
+	Errors int
+}
+
+// Burst is a period with an unusual number of errors.
+type Burst struct {
+	Start  time.Time
+	End    time.Time
+	Errors int
+}
+
+// Stats are the statistics of a log.
+type Stats struct {
+	Total int
+	Start time.Time
+	End   time.Time
+	// Priorities are the counts by priority from Verbose to Fatal.
+	Priorities []PriorityCount
+	// Tags are the counts by tag, the noisiest first.
+	Tags []TagStats
+	// PIDs are the counts by process, the noisiest first.
+	PIDs []PIDStats
+	// Timeline are the message and error counts over time.
+	Timeline   []Bucket
+	BucketSize time.Duration
+	// Bursts are the periods with unusually many errors, errors being Error and Fatal messages.
+	Bursts []Burst
+}
+
+// NoisiestTags returns the n tags with the most messages.
+func (s Stats) NoisiestTags(n int) []TagStats {
+	if n > len(s.Tags) {
+		n = len(s.Tags)
+	}
+
+	return s.Tags[:n]
+}
+
+func isError(msg adbclient.LogcatMessage) bool {
+	return msg.Priority >= adbclient.Error
+}
+
+// ComputeStats computes the statistics of the messages, they don't have to be sorted.
+func ComputeStats(msgs []adbclient.LogcatMessage) Stats {
+	stats := Stats{Total: len(msgs)}
+	if len(msgs) == 0 {
+		return stats
+	}
+
+	var priorities [adbclient.Fatal + 1]int
+	tags := make(map[string]*TagStats)
+	pids := make(map[int]*PIDStats)
+	pidTags := make(map[int]map[string]int)
+
+	stats.Start, stats.End = msgs[0].Timestamp, msgs[0].Timestamp
+	for _, msg := range msgs {
+		if msg.Timestamp.Before(stats.Start) {
+			stats.Start = msg.Timestamp
+		}
+
+		if msg.Timestamp.After(stats.End) {
+			stats.End = msg.Timestamp
+		}
+
+		priority := msg.Priority
+		if priority > adbclient.Fatal {
+			priority = adbclient.Fatal
+		}
+
+		priorities[priority]++
+
+		tag, ok := tags[msg.Tag]
+		if !ok {
+			tag = &TagStats{Tag: msg.Tag}
+			tags[msg.Tag] = tag
+		}
+
+		tag.Total++
+		tag.Priorities[priority]++
+
+		pid, ok := pids[msg.ProcessID]
+		if !ok {
+			pid = &PIDStats{PID: msg.ProcessID}
+			pids[msg.ProcessID] = pid
+			pidTags[msg.ProcessID] = make(map[string]int)
+		}
+
+		pid.Total++
+		if isError(msg) {
+			pid.Errors++
+		}
+
+		pidTags[msg.ProcessID][msg.Tag]++
+	}
+
+	for priority := adbclient.Verbose; priority <= adbclient.Fatal; priority++ {
+		stats.Priorities = append(stats.Priorities, PriorityCount{Priority: priority, Count: priorities[priority]})
+	}
+
+	for _, tag := range tags {
+		stats.Tags = append(stats.Tags, *tag)
+	}
+
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		if stats.Tags[i].Total != stats.Tags[j].Total {
+			return stats.Tags[i].Total > stats.Tags[j].Total
+		}
+
+		return stats.Tags[i].Tag < stats.Tags[j].Tag
+	})
+
+	for id, pid := range pids {
+		best := 0
+		for tag, count := range pidTags[id] {
+			if count > best || (count == best && tag < pid.Tag) {
+				best, pid.Tag = count, tag
+			}
+		}
+
+		stats.PIDs = append(stats.PIDs, *pid)
+	}
+
+	sort.Slice(stats.PIDs, func(i, j int) bool {
+		if stats.PIDs[i].Total != stats.PIDs[j].Total {
+			return stats.PIDs[i].Total > stats.PIDs[j].Total
+		}
+
+		return stats.PIDs[i].PID < stats.PIDs[j].PID
+	})
+
+	stats.computeTimeline(msgs)
+	stats.computeBursts()
+	return stats
+}
+
+// computeTimeline counts the messages and errors in buckets of a size fitting the span of the log.
+func (s *Stats) computeTimeline(msgs []adbclient.LogcatMessage) {
+	span := s.End.Sub(s.Start)
+
+	s.BucketSize = bucketSizes[len(bucketSizes)-1]
+	for _, size := range bucketSizes {
+		if span/size < maxBuckets {
+			s.BucketSize = size
+			break
+		}
+	}
+
+	start := s.Start.Truncate(s.BucketSize)
+	s.Timeline = make([]Bucket, int(s.End.Sub(start)/s.BucketSize)+1)
+	for i := range s.Timeline {
+		s.Timeline[i].Start = start.Add(time.Duration(i) * s.BucketSize)
+	}
+
+	for _, msg := range msgs {
+		bucket := &s.Timeline[int(msg.Timestamp.Sub(start)/s.BucketSize)]
+		bucket.Total++
+		if isError(msg) {
+			bucket.Errors++
+		}
+	}
+}
+
+// computeBursts finds the consecutive buckets with errors two standard deviations above the mean.
+func (s *Stats) computeBursts() {
+	if len(s.Timeline) == 0 {
+		return
+	}
+
+	var sum, squares float64
+	for _, bucket := range s.Timeline {
+		sum += float64(bucket.Errors)
+		squares += float64(bucket.Errors * bucket.Errors)
+	}
+
+	n := float64(len(s.Timeline))
+	mean := sum / n
+	stddev := math.Sqrt(math.Max(squares/n-mean*mean, 0))
+
+	threshold := mean + 2*stddev
+
+	var burst *Burst
+	for _, bucket := range s.Timeline {
+		if float64(bucket.Errors) <= threshold || bucket.Errors < minBurstErrors {
+			burst = nil
+			continue
+		}
+
+		if burst == nil {
+			s.Bursts = append(s.Bursts, Burst{Start: bucket.Start})
+			burst = &s.Bursts[len(s.Bursts)-1]
+		}
+
+		burst.End = bucket.Start.Add(s.BucketSize)
+		burst.Errors += bucket.Errors
+	}
+}
@@ -0,0 +1,113 @@
+package logexport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+var testStart = time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func testMessage(offset time.Duration, priority adbclient.LogcatPriority, tag string, pid int, message string) adbclient.LogcatMessage {
+	return adbclient.LogcatMessage{
+		Timestamp: testStart.Add(offset),
+		Priority:  priority,
+		Tag:       tag,
+		ProcessID: pid,
+		ThreadID:  pid,
+		Message:   message,
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	msgs := []adbclient.LogcatMessage{
+		testMessage(2*time.Second, adbclient.Info, "GameNet", 100, "connected"),
+		testMessage(0, adbclient.Debug, "GameNet", 100, "connecting"),
+		testMessage(time.Second, adbclient.Error, "Unity", 100, "exception"),
+		testMessage(3*time.Second, adbclient.Warning, "ActivityManager", 200, "slow"),
+		testMessage(4*time.Second, adbclient.Debug, "GameNet", 100, "ping"),
+	}
+
+	stats := ComputeStats(msgs)
+	if stats.Total != 5 {
+		t.Errorf("total: %d", stats.Total)
+	}
+
+	if !stats.Start.Equal(testStart) || !stats.End.Equal(testStart.Add(4*time.Second)) {
+		t.Errorf("span: %v - %v", stats.Start, stats.End)
+	}
+
+	if len(stats.Priorities) != int(adbclient.Fatal)+1 {
+		t.Fatalf("priorities: %v", stats.Priorities)
+	}
+
+	if stats.Priorities[adbclient.Debug].Count != 2 || stats.Priorities[adbclient.Error].Count != 1 {
+		t.Errorf("priorities: %v", stats.Priorities)
+	}
+
+	tags := stats.NoisiestTags(2)
+	if len(tags) != 2 || tags[0].Tag != "GameNet" || tags[0].Total != 3 || tags[0].Priorities[adbclient.Debug] != 2 {
+		t.Errorf("noisiest tags: %v", tags)
+	}
+
+	if tags[1].Tag != "ActivityManager" {
+		t.Errorf("ties are sorted by tag: %v", tags)
+	}
+
+	if len(stats.NoisiestTags(10)) != 3 {
+		t.Errorf("all tags: %v", stats.Tags)
+	}
+
+	if len(stats.PIDs) != 2 || stats.PIDs[0].PID != 100 || stats.PIDs[0].Total != 4 || stats.PIDs[0].Errors != 1 || stats.PIDs[0].Tag != "GameNet" {
+		t.Errorf("pids: %v", stats.PIDs)
+	}
+
+	if stats.BucketSize != time.Second || len(stats.Timeline) != 5 {
+		t.Fatalf("timeline: %v %v", stats.BucketSize, stats.Timeline)
+	}
+
+	if stats.Timeline[1].Total != 1 || stats.Timeline[1].Errors != 1 {
+		t.Errorf("timeline: %v", stats.Timeline)
+	}
+}
+
+func TestComputeStatsEmpty(t *testing.T) {
+	stats := ComputeStats(nil)
+	if stats.Total != 0 || len(stats.Timeline) != 0 || len(stats.NoisiestTags(5)) != 0 {
+		t.Errorf("stats: %v", stats)
+	}
+}
+
+func TestComputeBursts(t *testing.T) {
+	var msgs []adbclient.LogcatMessage
+	for i := 0; i < 60; i++ {
+		msgs = append(msgs, testMessage(time.Duration(i)*time.Second, adbclient.Info, "Game", 1, "tick"))
+	}
+
+	// 20 errors in the 30th and 31st seconds
+	for i := 0; i < 20; i++ {
+		msgs = append(msgs, testMessage(30*time.Second+time.Duration(i)*100*time.Millisecond, adbclient.Error, "Game", 1, "failure"))
+	}
+
+	stats := ComputeStats(msgs)
+	if len(stats.Bursts) != 1 {
+		t.Fatalf("bursts: %v", stats.Bursts)
+	}
+
+	burst := stats.Bursts[0]
+	if !burst.Start.Equal(testStart.Add(30*time.Second)) || !burst.End.Equal(testStart.Add(32*time.Second)) || burst.Errors != 20 {
+		t.Errorf("burst: %v", burst)
+	}
+}
+
+func TestComputeBurstsSteady(t *testing.T) {
+	var msgs []adbclient.LogcatMessage
+	for i := 0; i < 60; i++ {
+		msgs = append(msgs, testMessage(time.Duration(i)*time.Second, adbclient.Error, "Game", 1, "failure"))
+	}
+
+	if stats := ComputeStats(msgs); len(stats.Bursts) != 0 {
+		t.Errorf("bursts: %v", stats.Bursts)
+	}
+}
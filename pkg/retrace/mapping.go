@@ -0,0 +1,260 @@
+package retrace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// com.example.Foo -> a.b:
+	classRegex = regexp.MustCompile(`^(\S+) -> (\S+):$`)
+	// [1:3:]void [com.example.Bar.]method(int)[:10[:12]] -> a
+	methodRegex = regexp.MustCompile(`^(?:(\d+):(\d+):)?\S+ (\S+)\(([^)]*)\)(?::(\d+)(?::(\d+))?)? -> (\S+)$`)
+)
+
+// member is a method mapping of a class.
+type member struct {
+	// obfuscated line range, zero if the method has no line mapping
+	obfStart int
+	obfEnd   int
+	// original class, empty if the method belongs to the mapped class
+	class string
+	name  string
+	// original line range, zero if not mapped
+	origStart int
+	origEnd   int
+	obfName   string
+}
+
+// hasRange returns true if the member maps a range of obfuscated lines.
+func (m member) hasRange() bool {
+	return m.obfStart != 0 || m.obfEnd != 0
+}
+
+// originalLine maps an obfuscated line to the original line.
+func (m member) originalLine(line int) int {
+	switch {
+	case m.origStart == 0:
+		return line
+	case m.origEnd != 0 && m.origEnd != m.origStart && m.hasRange():
+		return m.origStart + line - m.obfStart
+	default:
+		return m.origStart
+	}
+}
+
+// class is the mapping of an obfuscated class.
+type class struct {
+	name       string
+	sourceFile string
+	members    map[string][]member
+}
+
+// Mapping is a parsed R8/ProGuard mapping.txt.
+type Mapping struct {
+	classes map[string]*class
+	// sourceFiles are the source files of the original classes, from the R8 metadata
+	sourceFiles map[string]string
+}
+
+// ParseMapping parses a mapping file.
+func ParseMapping(r io.Reader) (*Mapping, error) {
+	m := &Mapping{
+		classes:     make(map[string]*class),
+		sourceFiles: make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current *class
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			continue
+
+		case strings.HasPrefix(trimmed, "#"):
+			// R8 metadata: # {"id":"sourceFile","fileName":"Foo.kt"}
+			if current == nil {
+				continue
+			}
+
+			var meta struct {
+				ID       string `json:"id"`
+				FileName string `json:"fileName"`
+			}
+
+			if json.Unmarshal([]byte(strings.TrimSpace(trimmed[1:])), &meta) == nil && meta.ID == "sourceFile" {
+				current.sourceFile = meta.FileName
+				m.sourceFiles[current.name] = meta.FileName
+			}
+
+		case line[0] != ' ' && line[0] != '\t':
+			match := classRegex.FindStringSubmatch(trimmed)
+			if match == nil {
+				return nil, fmt.Errorf("invalid class mapping on line %d: %s", lineNumber, line)
+			}
+
+			current = &class{name: match[1], members: make(map[string][]member)}
+			m.classes[match[2]] = current
+
+		default:
+			if current == nil {
+				return nil, fmt.Errorf("member without class on line %d: %s", lineNumber, line)
+			}
+
+			match := methodRegex.FindStringSubmatch(trimmed)
+			if match == nil {
+				// fields aren't needed to retrace stack traces
+				continue
+			}
+
+			mem := member{obfName: match[7]}
+			mem.obfStart, _ = strconv.Atoi(match[1])
+			mem.obfEnd, _ = strconv.Atoi(match[2])
+			mem.origStart, _ = strconv.Atoi(match[5])
+			mem.origEnd, _ = strconv.Atoi(match[6])
+
+			// inlined methods of other classes are qualified
+			mem.name = match[3]
+			if i := strings.LastIndex(mem.name, "."); i >= 0 {
+				mem.class, mem.name = mem.name[:i], mem.name[i+1:]
+			}
+
+			current.members[mem.obfName] = append(current.members[mem.obfName], mem)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// LoadMapping loads a mapping file.
+func LoadMapping(path string) (*Mapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+	return ParseMapping(file)
+}
+
+// Len returns the number of mapped classes.
+func (m *Mapping) Len() int {
+	return len(m.classes)
+}
+
+// OriginalClass returns the original name of an obfuscated class, or the name itself if it isn't mapped.
+func (m *Mapping) OriginalClass(obfuscated string) string {
+	if c, ok := m.classes[obfuscated]; ok {
+		return c.name
+	}
+
+	return obfuscated
+}
+
+// Frame is an original stack frame.
+type Frame struct {
+	Class  string
+	Method string
+	File   string
+	Line   int
+}
+
+func (f Frame) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s.%s(%s:%d)", f.Class, f.Method, f.File, f.Line)
+	}
+
+	return fmt.Sprintf("%s.%s(%s)", f.Class, f.Method, f.File)
+}
+
+// sourceFile returns the source file of an original class. Without R8 metadata it's guessed from the outer class.
+func (m *Mapping) sourceFile(class string) string {
+	if file, ok := m.sourceFiles[class]; ok {
+		return file
+	}
+
+	name := class[strings.LastIndex(class, ".")+1:]
+	if i := strings.Index(name, "$"); i > 0 {
+		name = name[:i]
+	}
+
+	return name + ".java"
+}
+
+// Retrace maps an obfuscated frame to the original frames, the inlined ones first.
+// If the method is ambiguous each alternative is a separate slice. Line is zero if unknown.
+func (m *Mapping) Retrace(obfClass string, obfMethod string, line int) [][]Frame {
+	c, ok := m.classes[obfClass]
+	if !ok {
+		return nil
+	}
+
+	members := c.members[obfMethod]
+
+	// the members with a matching line range are the inline frames, innermost first
+	var inlined []Frame
+	if line > 0 {
+		for _, mem := range members {
+			if mem.hasRange() && mem.obfStart <= line && line <= mem.obfEnd {
+				inlined = append(inlined, m.frame(c, mem, line))
+			}
+		}
+	}
+
+	if len(inlined) > 0 {
+		return [][]Frame{inlined}
+	}
+
+	// without a matching range every method with the name is an alternative
+	var alternatives [][]Frame
+	seen := make(map[string]bool)
+	for _, mem := range members {
+		frame := m.frame(c, mem, line)
+		if mem.hasRange() {
+			// the line of an unmatched range is unknown
+			frame.Line = 0
+		}
+
+		key := frame.Class + "." + frame.Method
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		alternatives = append(alternatives, []Frame{frame})
+	}
+
+	if len(alternatives) == 0 {
+		// the method isn't mapped, only the class is
+		alternatives = [][]Frame{{{Class: c.name, Method: obfMethod, File: m.sourceFile(c.name), Line: line}}}
+	}
+
+	return alternatives
+}
+
+func (m *Mapping) frame(c *class, mem member, line int) Frame {
+	frame := Frame{Class: c.name, Method: mem.name, Line: mem.originalLine(line)}
+	if mem.class != "" {
+		frame.Class = mem.class
+	}
+
+	frame.File = m.sourceFile(frame.Class)
+	return frame
+}
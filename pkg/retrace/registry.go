@@ -0,0 +1,45 @@
+package retrace
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry associates mappings with the package and version code of the build they belong to.
+type Registry struct {
+	mu       sync.RWMutex
+	mappings map[string]*Mapping
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{mappings: make(map[string]*Mapping)}
+}
+
+func registryKey(pkg string, versionCode int) string {
+	return fmt.Sprintf("%s@%d", pkg, versionCode)
+}
+
+// Set associates the mapping with the build.
+func (r *Registry) Set(pkg string, versionCode int, m *Mapping) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mappings[registryKey(pkg, versionCode)] = m
+}
+
+// Remove removes the mapping of the build.
+func (r *Registry) Remove(pkg string, versionCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.mappings, registryKey(pkg, versionCode))
+}
+
+// Get returns the mapping of the build, or nil.
+func (r *Registry) Get(pkg string, versionCode int) *Mapping {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.mappings[registryKey(pkg, versionCode)]
+}
@@ -0,0 +1,80 @@
+package retrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 	at a.b.c(SourceFile:12), at a.b.c(Unknown Source:12), at a.b.c(:12) or at a.b.c(Native Method)
+	frameLineRegex = regexp.MustCompile(`^(\s*at )([\w$.]+)\.([\w$<>]+)\(([^)]*)\)(.*)$`)
+	// java.lang.IllegalStateException: message or Caused by: a.b: message
+	exceptionLineRegex = regexp.MustCompile(`^(\s*(?:Caused by: |Suppressed: )?)([\w$]+(?:\.[\w$]+)+|[\w$]+)(:.*)?$`)
+)
+
+// parseFrameLine returns the prefix, class, method, line and suffix of a stack frame line.
+func parseFrameLine(s string) (prefix string, class string, method string, line int, suffix string, ok bool) {
+	match := frameLineRegex.FindStringSubmatch(s)
+	if match == nil {
+		return "", "", "", 0, "", false
+	}
+
+	// the line is after the last colon of the source
+	if i := strings.LastIndex(match[4], ":"); i >= 0 {
+		line, _ = strconv.Atoi(match[4][i+1:])
+	}
+
+	return match[1], match[2], match[3], line, match[5], true
+}
+
+// RetraceLine retraces a single line of a stack trace, it returns several lines for inlined frames
+// and ambiguous methods. Lines that aren't stack trace lines are returned unchanged.
+func (m *Mapping) RetraceLine(s string) []string {
+	if prefix, class, method, line, suffix, ok := parseFrameLine(s); ok {
+		alternatives := m.Retrace(class, method, line)
+		if alternatives == nil {
+			return []string{s}
+		}
+
+		var lines []string
+		for i, frames := range alternatives {
+			for _, frame := range frames {
+				linePrefix := prefix
+				if i > 0 {
+					// the R8 retrace marker of ambiguous frames
+					linePrefix = strings.Replace(prefix, "at ", "<OR> at ", 1)
+				}
+
+				lines = append(lines, linePrefix+frame.String()+suffix)
+			}
+		}
+
+		return lines
+	}
+
+	// only names with a package or lines with a 'Caused by:' prefix are exceptions,
+	// otherwise any single word log message would be taken for an obfuscated class
+	if match := exceptionLineRegex.FindStringSubmatch(s); match != nil && (strings.Contains(match[2], ".") || strings.TrimSpace(match[1]) != "") {
+		if original := m.OriginalClass(match[2]); original != match[2] {
+			return []string{match[1] + original + match[3]}
+		}
+	}
+
+	return []string{s}
+}
+
+// RetraceLines retraces the lines of a stack trace.
+func (m *Mapping) RetraceLines(lines []string) []string {
+	var result []string
+	for _, line := range lines {
+		result = append(result, m.RetraceLine(line)...)
+	}
+
+	return result
+}
+
+// RetraceText retraces a stack trace, e.g. the message of a FATAL EXCEPTION.
+func (m *Mapping) RetraceText(text string) string {
+	return strings.Join(m.RetraceLines(strings.Split(text, "\n")), "\n")
+}
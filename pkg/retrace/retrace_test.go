@@ -0,0 +1,169 @@
+package retrace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMapping = `# compiler: R8
+# pg_map_id: 1234567
+com.example.app.MainActivity -> com.example.app.a:
+# {"id":"sourceFile","fileName":"MainActivity.kt"}
+    int counter -> a
+    1:1:void <init>():10:10 -> <init>
+    1:4:void onCreate(android.os.Bundle):20:23 -> onCreate
+    5:5:void com.example.app.Helper.check(int):42:42 -> onCreate
+    5:5:void validate(int):30 -> onCreate
+    5:5:void onCreate(android.os.Bundle):24 -> onCreate
+    void start() -> b
+    void stop() -> b
+com.example.app.Helper -> com.example.app.b:
+    1:3:void check(int):40:42 -> a
+com.example.app.Outer$Inner -> c:
+    void run() -> a
+`
+
+func parseTestMapping(t *testing.T) *Mapping {
+	m, err := ParseMapping(strings.NewReader(testMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestParseMapping(t *testing.T) {
+	m := parseTestMapping(t)
+
+	if m.Len() != 3 {
+		t.Errorf("expected 3 classes, actual: %d", m.Len())
+	}
+
+	if class := m.OriginalClass("com.example.app.a"); class != "com.example.app.MainActivity" {
+		t.Errorf("unexpected class: %s", class)
+	}
+
+	if class := m.OriginalClass("x.y"); class != "x.y" {
+		t.Errorf("unmapped class changed: %s", class)
+	}
+
+	if _, err := ParseMapping(strings.NewReader("not a mapping\n")); err == nil {
+		t.Error("invalid mapping parsed")
+	}
+}
+
+func TestRetrace(t *testing.T) {
+	m := parseTestMapping(t)
+
+	tests := []struct {
+		class    string
+		method   string
+		line     int
+		expected [][]Frame
+	}{
+		{
+			// line range
+			class:  "com.example.app.a",
+			method: "onCreate",
+			line:   3,
+			expected: [][]Frame{{
+				{Class: "com.example.app.MainActivity", Method: "onCreate", File: "MainActivity.kt", Line: 22},
+			}},
+		},
+		{
+			// inline frames, innermost first
+			class:  "com.example.app.a",
+			method: "onCreate",
+			line:   5,
+			expected: [][]Frame{{
+				{Class: "com.example.app.Helper", Method: "check", File: "Helper.java", Line: 42},
+				{Class: "com.example.app.MainActivity", Method: "validate", File: "MainActivity.kt", Line: 30},
+				{Class: "com.example.app.MainActivity", Method: "onCreate", File: "MainActivity.kt", Line: 24},
+			}},
+		},
+		{
+			// ambiguous method
+			class:  "com.example.app.a",
+			method: "b",
+			line:   7,
+			expected: [][]Frame{
+				{{Class: "com.example.app.MainActivity", Method: "start", File: "MainActivity.kt", Line: 7}},
+				{{Class: "com.example.app.MainActivity", Method: "stop", File: "MainActivity.kt", Line: 7}},
+			},
+		},
+		{
+			// unmapped method of a mapped class, the file of an inner class
+			class:  "c",
+			method: "z",
+			line:   1,
+			expected: [][]Frame{
+				{{Class: "com.example.app.Outer$Inner", Method: "z", File: "Outer.java", Line: 1}},
+			},
+		},
+		{
+			class:  "x.y",
+			method: "z",
+			line:   1,
+		},
+	}
+
+	for _, test := range tests {
+		actual := m.Retrace(test.class, test.method, test.line)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s.%s:%d expected: %v, actual: %v", test.class, test.method, test.line, test.expected, actual)
+		}
+	}
+}
+
+func TestRetraceText(t *testing.T) {
+	m := parseTestMapping(t)
+
+	text := strings.Join([]string{
+		"FATAL EXCEPTION: main",
+		"Process: com.example.app, PID: 1234",
+		"java.lang.IllegalStateException: com.example.app.b",
+		"\tat com.example.app.b.a(Unknown Source:3)",
+		"\tat com.example.app.a.onCreate(SourceFile:5)",
+		"\tat com.example.app.a.b(:7)",
+		"\tat android.app.Activity.performCreate(Activity.java:8000)",
+		"Caused by: com.example.app.b: failed",
+	}, "\n")
+
+	expected := strings.Join([]string{
+		"FATAL EXCEPTION: main",
+		"Process: com.example.app, PID: 1234",
+		"java.lang.IllegalStateException: com.example.app.b",
+		"\tat com.example.app.Helper.check(Helper.java:42)",
+		"\tat com.example.app.Helper.check(Helper.java:42)",
+		"\tat com.example.app.MainActivity.validate(MainActivity.kt:30)",
+		"\tat com.example.app.MainActivity.onCreate(MainActivity.kt:24)",
+		"\tat com.example.app.MainActivity.start(MainActivity.kt:7)",
+		"\t<OR> at com.example.app.MainActivity.stop(MainActivity.kt:7)",
+		"\tat android.app.Activity.performCreate(Activity.java:8000)",
+		"Caused by: com.example.app.Helper: failed",
+	}, "\n")
+
+	if actual := m.RetraceText(text); actual != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, actual)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	m := parseTestMapping(t)
+	r := NewRegistry()
+
+	r.Set("com.example.app", 12, m)
+	if r.Get("com.example.app", 12) != m {
+		t.Error("mapping not found")
+	}
+
+	if r.Get("com.example.app", 13) != nil {
+		t.Error("mapping of another version found")
+	}
+
+	r.Remove("com.example.app", 12)
+	if r.Get("com.example.app", 12) != nil {
+		t.Error("mapping not removed")
+	}
+}
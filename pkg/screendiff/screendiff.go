@@ -0,0 +1,276 @@
+package screendiff
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+const (
+	// DefaultTolerance is the default per-channel tolerance.
+	DefaultTolerance = 8
+
+	// DefaultMaxDiffRatio is the default ratio of differing pixels that still passes.
+	DefaultMaxDiffRatio = 0.001
+
+	// StatusBarHeightDp is the height of the Android status bar in dp.
+	StatusBarHeightDp = 24
+)
+
+var (
+	diffColor = color.NRGBA{R: 0xff, A: 0xff}
+	maskColor = color.NRGBA{B: 0xff, A: 0x60}
+)
+
+type compareOptions struct {
+	tolerance    uint8
+	maxDiffRatio float64
+	masks        []image.Rectangle
+}
+
+func (o compareOptions) String() string {
+	return fmt.Sprintf("tolerance:%d maxDiffRatio:%g masks:%v", o.tolerance, o.maxDiffRatio, o.masks)
+}
+
+func (o compareOptions) masked(p image.Point) bool {
+	for _, mask := range o.masks {
+		if p.In(mask) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CompareOption is an option for comparing screenshots.
+type CompareOption interface {
+	apply(*compareOptions) error
+}
+
+type toleranceOption struct {
+	tolerance uint8
+}
+
+func (o toleranceOption) apply(opts *compareOptions) error {
+	opts.tolerance = o.tolerance
+	return nil
+}
+
+type maxDiffRatioOption struct {
+	ratio float64
+}
+
+func (o maxDiffRatioOption) apply(opts *compareOptions) error {
+	if o.ratio < 0 || o.ratio > 1 {
+		return fmt.Errorf("max diff ratio must be in range [0, 1], got %g", o.ratio)
+	}
+
+	opts.maxDiffRatio = o.ratio
+	return nil
+}
+
+type maskOption struct {
+	masks []image.Rectangle
+}
+
+func (o maskOption) apply(opts *compareOptions) error {
+	opts.masks = append(opts.masks, o.masks...)
+	return nil
+}
+
+// WithTolerance sets the maximum difference of a color channel for pixels that are considered equal.
+func WithTolerance(tolerance uint8) CompareOption {
+	return toleranceOption{tolerance}
+}
+
+// WithMaxDiffRatio sets the maximum ratio of differing pixels for a comparison to pass.
+func WithMaxDiffRatio(ratio float64) CompareOption {
+	return maxDiffRatioOption{ratio}
+}
+
+// WithMask excludes the given regions from the comparison.
+func WithMask(masks ...image.Rectangle) CompareOption {
+	return maskOption{masks}
+}
+
+// StatusBarMask returns the region of the status bar, which contains the clock and notification icons.
+func StatusBarMask(width int, density int) image.Rectangle {
+	if density == 0 {
+		density = 160
+	}
+
+	return image.Rect(0, 0, width, StatusBarHeightDp*density/160)
+}
+
+// ParseMask parses a region in "x0,y0,x1,y1" format.
+func ParseMask(s string) (image.Rectangle, error) {
+	var r image.Rectangle
+	if _, err := fmt.Sscanf(s, "%d,%d,%d,%d", &r.Min.X, &r.Min.Y, &r.Max.X, &r.Max.Y); err != nil {
+		return r, fmt.Errorf("invalid mask %q: %w", s, err)
+	}
+
+	return r.Canon(), nil
+}
+
+// Result is the result of a screenshot comparison.
+type Result struct {
+	DiffPixels   int
+	TotalPixels  int
+	MaxDiffRatio float64
+	Diff         *image.NRGBA
+	Reason       string
+}
+
+// Ratio returns the ratio of differing pixels.
+func (r *Result) Ratio() float64 {
+	if r.TotalPixels == 0 {
+		return 0
+	}
+
+	return float64(r.DiffPixels) / float64(r.TotalPixels)
+}
+
+// Passed returns true if the screenshots match.
+func (r *Result) Passed() bool {
+	return r.Reason == "" && r.Ratio() <= r.MaxDiffRatio
+}
+
+func (r *Result) String() string {
+	status := "PASS"
+	if !r.Passed() {
+		status = "FAIL"
+	}
+
+	if r.Reason != "" {
+		return fmt.Sprintf("%s: %s", status, r.Reason)
+	}
+
+	return fmt.Sprintf("%s: %d of %d pixels differ (%.4f%%, max %.4f%%)", status, r.DiffPixels, r.TotalPixels, r.Ratio()*100, r.MaxDiffRatio*100)
+}
+
+func channelDiff(a, b uint32) uint8 {
+	a, b = a>>8, b>>8
+	if a > b {
+		return uint8(a - b)
+	}
+
+	return uint8(b - a)
+}
+
+// gray returns a faded grayscale version of c used as background of the diff image.
+func gray(c color.Color) color.NRGBA {
+	y := color.GrayModel.Convert(c).(color.Gray).Y
+	y = 0xff - (0xff-y)/4
+	return color.NRGBA{R: y, G: y, B: y, A: 0xff}
+}
+
+// Compare compares the actual screenshot with the baseline pixel by pixel.
+// The diff image shows the baseline faded out, differing pixels in red and masked regions in blue.
+func Compare(baseline, actual image.Image, opts ...CompareOption) (*Result, error) {
+	options := compareOptions{
+		tolerance:    DefaultTolerance,
+		maxDiffRatio: DefaultMaxDiffRatio,
+	}
+
+	for _, o := range opts {
+		if err := o.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	bb, ab := baseline.Bounds(), actual.Bounds()
+	result := &Result{
+		MaxDiffRatio: options.maxDiffRatio,
+		Diff:         image.NewNRGBA(image.Rect(0, 0, bb.Dx(), bb.Dy())),
+	}
+
+	if bb.Size() != ab.Size() {
+		result.Reason = fmt.Sprintf("size mismatch: baseline %dx%d, actual %dx%d", bb.Dx(), bb.Dy(), ab.Dx(), ab.Dy())
+	}
+
+	for y := 0; y < bb.Dy(); y++ {
+		for x := 0; x < bb.Dx(); x++ {
+			b := baseline.At(bb.Min.X+x, bb.Min.Y+y)
+			if options.masked(image.Pt(x, y)) {
+				result.Diff.SetNRGBA(x, y, blend(gray(b), maskColor))
+				continue
+			}
+
+			result.TotalPixels++
+
+			if x >= ab.Dx() || y >= ab.Dy() {
+				result.DiffPixels++
+				result.Diff.SetNRGBA(x, y, diffColor)
+				continue
+			}
+
+			br, bg, bbl, ba := b.RGBA()
+			ar, ag, abl, aa := actual.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			if channelDiff(br, ar) > options.tolerance ||
+				channelDiff(bg, ag) > options.tolerance ||
+				channelDiff(bbl, abl) > options.tolerance ||
+				channelDiff(ba, aa) > options.tolerance {
+				result.DiffPixels++
+				result.Diff.SetNRGBA(x, y, diffColor)
+				continue
+			}
+
+			result.Diff.SetNRGBA(x, y, gray(b))
+		}
+	}
+
+	return result, nil
+}
+
+// blend draws c over the opaque background bg.
+func blend(bg, c color.NRGBA) color.NRGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8((uint32(a)*(0xff-uint32(c.A)) + uint32(b)*uint32(c.A)) / 0xff)
+	}
+
+	return color.NRGBA{R: mix(bg.R, c.R), G: mix(bg.G, c.G), B: mix(bg.B, c.B), A: 0xff}
+}
+
+// Entry is a single comparison of a report.
+type Entry struct {
+	Key    Key
+	Result *Result
+}
+
+// Report is a summary of several comparisons.
+type Report struct {
+	Entries []Entry
+}
+
+// Add adds a comparison to the report.
+func (r *Report) Add(key Key, result *Result) {
+	r.Entries = append(r.Entries, Entry{Key: key, Result: result})
+}
+
+// Failed returns the number of failed comparisons.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, e := range r.Entries {
+		if !e.Result.Passed() {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// Passed returns true if all comparisons passed.
+func (r *Report) Passed() bool {
+	return r.Failed() == 0
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, e := range r.Entries {
+		fmt.Fprintf(&sb, "%s: %s\n", e.Key, e.Result)
+	}
+
+	fmt.Fprintf(&sb, "%d passed, %d failed", len(r.Entries)-r.Failed(), r.Failed())
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+package screendiff
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func filledImage(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	baseline := filledImage(100, 100, color.White)
+
+	t.Run("identical", func(t *testing.T) {
+		result, err := Compare(baseline, filledImage(100, 100, color.White))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !result.Passed() || result.DiffPixels != 0 || result.TotalPixels != 10000 {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		actual := filledImage(100, 100, color.NRGBA{R: 250, G: 250, B: 250, A: 0xff})
+
+		result, _ := Compare(baseline, actual, WithTolerance(5))
+		if !result.Passed() {
+			t.Errorf("expected pass with tolerance 5: %s", result)
+		}
+
+		result, _ = Compare(baseline, actual, WithTolerance(4))
+		if result.Passed() || result.DiffPixels != 10000 {
+			t.Errorf("expected fail with tolerance 4: %s", result)
+		}
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		actual := filledImage(100, 100, color.White)
+		for x := 0; x < 100; x++ {
+			actual.Set(x, 5, color.Black)
+		}
+
+		result, _ := Compare(baseline, actual)
+		if result.Passed() || result.DiffPixels != 100 {
+			t.Errorf("expected 100 differing pixels: %s", result)
+		}
+
+		if result.Diff.NRGBAAt(0, 5) != diffColor {
+			t.Errorf("expected diff color at 0,5, got %v", result.Diff.NRGBAAt(0, 5))
+		}
+
+		result, _ = Compare(baseline, actual, WithMask(StatusBarMask(100, 160)))
+		if !result.Passed() || result.TotalPixels != 100*(100-StatusBarHeightDp) {
+			t.Errorf("expected pass with status bar mask: %s", result)
+		}
+	})
+
+	t.Run("max diff ratio", func(t *testing.T) {
+		actual := filledImage(100, 100, color.White)
+		actual.Set(50, 50, color.Black)
+
+		result, _ := Compare(baseline, actual, WithMaxDiffRatio(0))
+		if result.Passed() {
+			t.Errorf("expected fail: %s", result)
+		}
+
+		result, _ = Compare(baseline, actual, WithMaxDiffRatio(0.0001))
+		if !result.Passed() {
+			t.Errorf("expected pass: %s", result)
+		}
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		result, err := Compare(baseline, filledImage(50, 100, color.White))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Passed() || result.Reason == "" {
+			t.Errorf("expected size mismatch: %s", result)
+		}
+	})
+}
+
+func TestParseMask(t *testing.T) {
+	r, err := ParseMask("10,20,0,5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r != image.Rect(0, 5, 10, 20) {
+		t.Errorf("unexpected mask: %v", r)
+	}
+
+	if _, err := ParseMask("10,20"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "baselines")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewStore(dir)
+	key := Key{App: "com.example.game", Version: "1.0", Model: "SM-G991B", Locale: "en-US", Name: "main menu"}
+
+	if _, err := store.Load(key); !errors.Is(err, ErrNoBaseline) {
+		t.Errorf("expected %v, got %v", ErrNoBaseline, err)
+	}
+
+	if err := store.Save(Key{App: "com.example.game"}, filledImage(10, 10, color.White)); err == nil {
+		t.Error("expected error for incomplete key")
+	}
+
+	if err := store.Save(key, filledImage(10, 10, color.White)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !store.Has(key) {
+		t.Error("expected baseline to exist")
+	}
+
+	if filepath.Base(store.Path(key)) != "main_menu.png" {
+		t.Errorf("unexpected path: %s", store.Path(key))
+	}
+
+	escaped := Key{App: "..", Version: ".", Model: "..", Locale: "..", Name: ".."}
+	if rel, err := filepath.Rel(dir, store.Path(escaped)); err != nil || strings.HasPrefix(rel, "..") {
+		t.Errorf("path outside of the store: %s", store.Path(escaped))
+	}
+
+	actual := filledImage(10, 10, color.Black)
+	result, err := store.Compare(key, actual)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Passed() {
+		t.Errorf("expected fail: %s", result)
+	}
+
+	actualPath, diffPath, err := SaveResult(filepath.Join(dir, "results"), key, actual, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{actualPath, diffPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+		}
+	}
+
+	var report Report
+	report.Add(key, result)
+	if report.Passed() || report.Failed() != 1 {
+		t.Errorf("unexpected report: %s", report.String())
+	}
+}
@@ -0,0 +1,165 @@
+package screendiff
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultBaselinePath is the default baseline store directory.
+	DefaultBaselinePath = "./baselines"
+
+	// DefaultResultsPath is the default directory for actual and diff images.
+	DefaultResultsPath = "./screendiff"
+)
+
+var ErrNoBaseline = fmt.Errorf("baseline not found")
+
+// Key identifies a baseline screenshot.
+type Key struct {
+	App     string `json:"app"`
+	Version string `json:"version"`
+	Model   string `json:"model"`
+	Locale  string `json:"locale"`
+	Name    string `json:"name"`
+}
+
+func (k Key) String() string {
+	return strings.Join([]string{k.App, k.Version, k.Model, k.Locale, k.Name}, "/")
+}
+
+// Validate checks that all parts of the key are set.
+func (k Key) Validate() error {
+	parts := map[string]string{
+		"app":     k.App,
+		"version": k.Version,
+		"model":   k.Model,
+		"locale":  k.Locale,
+		"name":    k.Name,
+	}
+
+	for name, value := range parts {
+		if value == "" {
+			return fmt.Errorf("baseline %s is not set", name)
+		}
+	}
+
+	return nil
+}
+
+// sanitize makes a key part safe to use as a file name, "." and ".." are escaped so the key stays
+// in the store directory.
+func sanitize(s string) string {
+	if s == "." || s == ".." {
+		return strings.Repeat("_", len(s))
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		default:
+			return r
+		}
+	}, s)
+}
+
+// path returns the path of the key relative to a store directory.
+func (k Key) path(suffix string) string {
+	return filepath.Join(sanitize(k.App), sanitize(k.Version), sanitize(k.Model), sanitize(k.Locale), sanitize(k.Name)+suffix)
+}
+
+// Store is a directory of baseline screenshots keyed by app/version/model/locale.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a new baseline store in the given directory.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Path returns the path of the baseline with the given key.
+func (s *Store) Path(key Key) string {
+	return filepath.Join(s.dir, key.path(".png"))
+}
+
+// Has returns true if a baseline exists for the key.
+func (s *Store) Has(key Key) bool {
+	_, err := os.Stat(s.Path(key))
+	return err == nil
+}
+
+// Load loads the baseline with the given key.
+func (s *Store) Load(key Key) (image.Image, error) {
+	f, err := os.Open(s.Path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNoBaseline, key)
+		}
+
+		return nil, err
+	}
+
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// Save saves the image as the baseline with the given key.
+func (s *Store) Save(key Key, img image.Image) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	return savePNG(s.Path(key), img)
+}
+
+// Compare compares the image with the baseline with the given key.
+func (s *Store) Compare(key Key, actual image.Image, opts ...CompareOption) (*Result, error) {
+	baseline, err := s.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare(baseline, actual, opts...)
+}
+
+// SaveResult saves the actual and diff images of a comparison to dir.
+// It returns the paths of the written actual and diff images.
+func SaveResult(dir string, key Key, actual image.Image, result *Result) (string, string, error) {
+	actualPath := filepath.Join(dir, key.path(".actual.png"))
+	if err := savePNG(actualPath, actual); err != nil {
+		return "", "", err
+	}
+
+	diffPath := filepath.Join(dir, key.path(".diff.png"))
+	if err := savePNG(diffPath, result.Diff); err != nil {
+		return "", "", err
+	}
+
+	return actualPath, diffPath, nil
+}
+
+func savePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	return png.Encode(f, img)
+}
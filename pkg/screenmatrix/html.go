@@ -0,0 +1,81 @@
+package screenmatrix
+
+import (
+	"html/template"
+	"io"
+)
+
+var contactSheetTemplate = template.Must(template.New("contactsheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Target.Package}} - {{.Report.Model}}</title>
+<style>
+body { font-family: sans-serif; margin: 16px; background: #fafafa; }
+h1 { font-size: 20px; }
+h2 { font-size: 16px; margin-top: 32px; }
+.grid { display: flex; flex-wrap: wrap; gap: 16px; }
+figure { margin: 0; width: 240px; background: #fff; border: 1px solid #ddd; padding: 8px; }
+figure img { width: 100%; }
+figcaption { font-size: 12px; margin-top: 4px; }
+.error { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Target.Package}} on {{.Report.Model}} ({{.Report.Device}})</h1>
+<p>{{.Report.Started.Format "2006-01-02 15:04:05"}}, {{len .Report.Shots}} configurations, {{.Report.Failed}} failed</p>
+{{range .Groups}}
+<h2>{{.Title}}</h2>
+<div class="grid">
+{{range .Shots}}
+<figure>
+{{if .Error}}<p class="error">{{.Error}}</p>{{else}}<a href="{{.Path}}"><img src="{{.Path}}" loading="lazy"></a>{{end}}
+<figcaption>{{.Config}}</figcaption>
+</figure>
+{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// shotGroup is a section of the contact sheet.
+type shotGroup struct {
+	Title string
+	Shots []Shot
+}
+
+// groupByLocale groups the shots by locale, keeping the order of first appearance.
+func groupByLocale(shots []Shot) []shotGroup {
+	var groups []shotGroup
+	index := make(map[string]int)
+	for _, shot := range shots {
+		title := shot.Config.Locale
+		if title == "" {
+			title = "system"
+		}
+
+		i, ok := index[title]
+		if !ok {
+			i = len(groups)
+			index[title] = i
+			groups = append(groups, shotGroup{Title: title})
+		}
+
+		groups[i].Shots = append(groups[i].Shots, shot)
+	}
+
+	return groups
+}
+
+// WriteContactSheet writes an HTML contact sheet of the report, with one section per locale.
+// Image paths are relative, so the sheet must be written to the output directory.
+func WriteContactSheet(w io.Writer, report *Report) error {
+	return contactSheetTemplate.Execute(w, struct {
+		Report *Report
+		Groups []shotGroup
+	}{
+		Report: report,
+		Groups: groupByLocale(report.Shots),
+	})
+}
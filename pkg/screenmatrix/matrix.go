@@ -0,0 +1,189 @@
+package screenmatrix
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// DefaultOutputPath is the default output directory of the screenshot matrix.
+	DefaultOutputPath = "./screenmatrix"
+)
+
+// Config is a single device configuration of the matrix.
+type Config struct {
+	Locale    string                    `json:"locale"`
+	NightMode adbclient.NightMode       `json:"night_mode"`
+	FontScale float64                   `json:"font_scale"`
+	Display   adbclient.DisplayOverride `json:"display"`
+}
+
+func (c Config) String() string {
+	locale := c.Locale
+	if locale == "" {
+		locale = "system"
+	}
+
+	return fmt.Sprintf("%s, night %s, font %g, display %s", locale, c.NightMode, c.FontScale, c.Display)
+}
+
+// Path returns the screenshot path of the configuration relative to the output directory.
+func (c Config) Path() string {
+	locale := c.Locale
+	if locale == "" {
+		locale = "system"
+	}
+
+	display := "default"
+	if c.Display.Width > 0 && c.Display.Height > 0 {
+		display = fmt.Sprintf("%dx%d", c.Display.Width, c.Display.Height)
+	}
+
+	if c.Display.Density > 0 {
+		display = fmt.Sprintf("%s-%ddpi", display, c.Display.Density)
+	}
+
+	name := fmt.Sprintf("night-%s_font-%g_%s.png", c.NightMode, c.FontScale, display)
+	return filepath.Join(strings.ReplaceAll(locale, ",", "_"), name)
+}
+
+// Matrix lists the values of each configuration dimension.
+// An empty dimension keeps the current value of the device.
+type Matrix struct {
+	Locales    []string                    `json:"locales"`
+	NightModes []adbclient.NightMode       `json:"night_modes"`
+	FontScales []float64                   `json:"font_scales"`
+	Displays   []adbclient.DisplayOverride `json:"displays"`
+}
+
+// Configs returns all combinations of the matrix, with empty dimensions taken from base.
+func (m Matrix) Configs(base Config) []Config {
+	locales := m.Locales
+	if len(locales) == 0 {
+		locales = []string{base.Locale}
+	}
+
+	nightModes := m.NightModes
+	if len(nightModes) == 0 {
+		nightModes = []adbclient.NightMode{base.NightMode}
+	}
+
+	fontScales := m.FontScales
+	if len(fontScales) == 0 {
+		fontScales = []float64{base.FontScale}
+	}
+
+	displays := m.Displays
+	if len(displays) == 0 {
+		displays = []adbclient.DisplayOverride{base.Display}
+	}
+
+	var configs []Config
+	for _, display := range displays {
+		for _, locale := range locales {
+			for _, nightMode := range nightModes {
+				for _, fontScale := range fontScales {
+					configs = append(configs, Config{
+						Locale:    locale,
+						NightMode: nightMode,
+						FontScale: fontScale,
+						Display:   display,
+					})
+				}
+			}
+		}
+	}
+
+	return configs
+}
+
+// splitList splits a comma separated list, ignoring empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// ParseLocales parses a comma separated list of locales, e.g. "en-US,de-DE".
+func ParseLocales(s string) []string {
+	return splitList(s)
+}
+
+// ParseNightModes parses a comma separated list of night modes, e.g. "no,yes".
+func ParseNightModes(s string) ([]adbclient.NightMode, error) {
+	var modes []adbclient.NightMode
+	for _, item := range splitList(s) {
+		mode, err := adbclient.ParseNightMode(item)
+		if err != nil {
+			return nil, err
+		}
+
+		modes = append(modes, mode)
+	}
+
+	return modes, nil
+}
+
+// ParseFontScales parses a comma separated list of font scales, e.g. "1,1.3".
+func ParseFontScales(s string) ([]float64, error) {
+	var scales []float64
+	for _, item := range splitList(s) {
+		scale, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if scale <= 0 {
+			return nil, fmt.Errorf("invalid font scale: %s", item)
+		}
+
+		scales = append(scales, scale)
+	}
+
+	return scales, nil
+}
+
+// ParseDisplays parses a comma separated list of display overrides.
+// Each item is WIDTHxHEIGHT, WIDTHxHEIGHT@DENSITY, @DENSITY or "default".
+func ParseDisplays(s string) ([]adbclient.DisplayOverride, error) {
+	var displays []adbclient.DisplayOverride
+	for _, item := range splitList(s) {
+		var display adbclient.DisplayOverride
+		if item == "default" {
+			displays = append(displays, display)
+			continue
+		}
+
+		size, density, hasDensity := strings.Cut(item, "@")
+		if size != "" {
+			width, height, err := adbclient.ParseDisplaySize(size)
+			if err != nil {
+				return nil, err
+			}
+
+			display.Width, display.Height = width, height
+		}
+
+		if hasDensity {
+			d, err := strconv.Atoi(density)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid display density: %s", item)
+			}
+
+			display.Density = d
+		}
+
+		displays = append(displays, display)
+	}
+
+	return displays, nil
+}
@@ -0,0 +1,124 @@
+package screenmatrix
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+func TestConfigs(t *testing.T) {
+	base := Config{NightMode: adbclient.NightModeAuto, FontScale: 1}
+	matrix := Matrix{
+		Locales:    []string{"en-US", "de-DE"},
+		NightModes: []adbclient.NightMode{adbclient.NightModeNo, adbclient.NightModeYes},
+	}
+
+	configs := matrix.Configs(base)
+	if len(configs) != 4 {
+		t.Fatalf("expected 4 configs, got %d", len(configs))
+	}
+
+	for _, config := range configs {
+		if config.FontScale != 1 || config.Display != base.Display {
+			t.Errorf("expected empty dimensions from base, got %v", config)
+		}
+	}
+
+	if len((Matrix{}).Configs(base)) != 1 {
+		t.Error("expected a single config for an empty matrix")
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	config := Config{
+		Locale:    "de-DE",
+		NightMode: adbclient.NightModeYes,
+		FontScale: 1.3,
+		Display:   adbclient.DisplayOverride{Width: 1080, Height: 1920, Density: 420},
+	}
+
+	expected := filepath.Join("de-DE", "night-yes_font-1.3_1080x1920-420dpi.png")
+	if config.Path() != expected {
+		t.Errorf("expected: %s, actual: %s", expected, config.Path())
+	}
+
+	expected = filepath.Join("system", "night-auto_font-1_default.png")
+	if path := (Config{FontScale: 1}).Path(); path != expected {
+		t.Errorf("expected: %s, actual: %s", expected, path)
+	}
+}
+
+func TestParse(t *testing.T) {
+	modes, err := ParseNightModes("no, yes")
+	if err != nil || len(modes) != 2 || modes[1] != adbclient.NightModeYes {
+		t.Errorf("unexpected night modes: %v, %v", modes, err)
+	}
+
+	if _, err := ParseNightModes("dusk"); err == nil {
+		t.Error("expected error for invalid night mode")
+	}
+
+	scales, err := ParseFontScales("0.85,1,1.3")
+	if err != nil || len(scales) != 3 || scales[2] != 1.3 {
+		t.Errorf("unexpected font scales: %v, %v", scales, err)
+	}
+
+	if _, err := ParseFontScales("0"); err == nil {
+		t.Error("expected error for invalid font scale")
+	}
+
+	displays, err := ParseDisplays("default,720x1280,1080x2340@420,@320")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []adbclient.DisplayOverride{
+		{},
+		{Width: 720, Height: 1280},
+		{Width: 1080, Height: 2340, Density: 420},
+		{Density: 320},
+	}
+
+	if len(displays) != len(expected) {
+		t.Fatalf("expected: %v, actual: %v", expected, displays)
+	}
+
+	for i := range expected {
+		if displays[i] != expected[i] {
+			t.Errorf("expected: %v, actual: %v", expected[i], displays[i])
+		}
+	}
+
+	if _, err := ParseDisplays("1080"); err == nil {
+		t.Error("expected error for invalid display")
+	}
+}
+
+func TestWriteContactSheet(t *testing.T) {
+	report := &Report{
+		Device:  "emulator-5554",
+		Model:   "Pixel_6",
+		Target:  Target{Package: "com.example.app"},
+		Started: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		Shots: []Shot{
+			{Config: Config{Locale: "en-US", FontScale: 1}, Path: "en-US/night-auto_font-1_default.png"},
+			{Config: Config{Locale: "de-DE", FontScale: 1}, Error: "<failed>"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteContactSheet(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+
+	html := buf.String()
+	for _, s := range []string{"com.example.app", `src="en-US/night-auto_font-1_default.png"`, "<h2>de-DE</h2>", "&lt;failed&gt;", "1 failed"} {
+		if !strings.Contains(html, s) {
+			t.Errorf("expected %q in the contact sheet", s)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package screenmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+const (
+	// DefaultSettleDelay is the default delay between launching the app and capturing the screen.
+	DefaultSettleDelay = 2 * time.Second
+
+	// ReportFileName is the name of the JSON report in the output directory.
+	ReportFileName = "report.json"
+
+	// ContactSheetFileName is the name of the HTML contact sheet in the output directory.
+	ContactSheetFileName = "index.html"
+)
+
+// Target is the screen captured for each configuration.
+type Target struct {
+	Package  string `json:"package"`
+	Activity string `json:"activity,omitempty"`
+	DeepLink string `json:"deep_link,omitempty"`
+}
+
+// Validate checks that the target can be launched.
+func (t Target) Validate() error {
+	if t.Package == "" {
+		return fmt.Errorf("target package is not set")
+	}
+
+	if t.Activity != "" && t.DeepLink != "" {
+		return fmt.Errorf("target activity and deep link are mutually exclusive")
+	}
+
+	return nil
+}
+
+// Shot is the result of a single configuration.
+type Shot struct {
+	Config Config `json:"config"`
+	Path   string `json:"path,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the result of a matrix run.
+type Report struct {
+	Device  string    `json:"device"`
+	Model   string    `json:"model"`
+	Target  Target    `json:"target"`
+	Started time.Time `json:"started"`
+	Shots   []Shot    `json:"shots"`
+}
+
+// Failed returns the number of configurations that could not be captured.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, shot := range r.Shots {
+		if shot.Error != "" {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+type runOptions struct {
+	settleDelay time.Duration
+	progress    func(i, total int, config Config)
+}
+
+// RunOption is an option of Run.
+type RunOption interface {
+	apply(*runOptions) error
+}
+
+type settleDelayOption time.Duration
+
+func (o settleDelayOption) apply(opts *runOptions) error {
+	if o < 0 {
+		return fmt.Errorf("invalid settle delay: %s", time.Duration(o))
+	}
+
+	opts.settleDelay = time.Duration(o)
+	return nil
+}
+
+// WithSettleDelay sets the delay between launching the app and capturing the screen.
+func WithSettleDelay(d time.Duration) RunOption {
+	return settleDelayOption(d)
+}
+
+type progressOption func(i, total int, config Config)
+
+func (o progressOption) apply(opts *runOptions) error {
+	opts.progress = o
+	return nil
+}
+
+// WithProgress sets a callback called before each configuration is captured.
+func WithProgress(progress func(i, total int, config Config)) RunOption {
+	return progressOption(progress)
+}
+
+// runner applies configurations to a device and captures screenshots.
+type runner struct {
+	client  *adbclient.Client
+	device  *adbclient.Device
+	target  Target
+	current Config
+	delay   time.Duration
+}
+
+// currentConfig reads the current configuration of the device.
+// Per-app locales are only read when needed, as they are not supported before Android 13.
+func currentConfig(client *adbclient.Client, device *adbclient.Device, target Target, locales bool) (Config, error) {
+	var config Config
+	var err error
+
+	if locales {
+		if config.Locale, err = client.GetAppLocales(device, target.Package); err != nil {
+			return config, err
+		}
+	}
+
+	if config.NightMode, err = client.GetNightMode(device); err != nil {
+		return config, err
+	}
+
+	if config.FontScale, err = client.GetFontScale(device); err != nil {
+		return config, err
+	}
+
+	if config.Display, err = client.GetDisplayOverride(device); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// apply changes the settings which differ from the current configuration.
+func (r *runner) apply(config Config) error {
+	if config.Locale != r.current.Locale {
+		if err := r.client.SetAppLocales(r.device, r.target.Package, config.Locale); err != nil {
+			return err
+		}
+
+		r.current.Locale = config.Locale
+	}
+
+	if config.NightMode != r.current.NightMode {
+		if err := r.client.SetNightMode(r.device, config.NightMode); err != nil {
+			return err
+		}
+
+		r.current.NightMode = config.NightMode
+	}
+
+	if config.FontScale != r.current.FontScale {
+		if err := r.client.SetFontScale(r.device, config.FontScale); err != nil {
+			return err
+		}
+
+		r.current.FontScale = config.FontScale
+	}
+
+	if config.Display != r.current.Display {
+		if err := r.client.SetDisplayOverride(r.device, config.Display); err != nil {
+			return err
+		}
+
+		r.current.Display = config.Display
+	}
+
+	return nil
+}
+
+// launch relaunches the target so that it picks up the configuration.
+func (r *runner) launch() error {
+	if r.target.DeepLink != "" {
+		return r.client.StartDeepLink(r.device, r.target.Package, r.target.DeepLink)
+	}
+
+	return r.client.StartApp(r.device, r.target.Package, r.target.Activity)
+}
+
+// capture applies the configuration, launches the target and saves a screenshot to dir.
+func (r *runner) capture(ctx context.Context, config Config, dir string) error {
+	if err := r.apply(config); err != nil {
+		return err
+	}
+
+	if err := r.launch(); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(r.delay):
+	}
+
+	img, err := r.client.CaptureScreen(r.device)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, config.Path())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// Run captures the target screen in every configuration of the matrix and writes the screenshots,
+// a JSON report and an HTML contact sheet to dir. The original settings are restored afterwards.
+// Failed configurations are recorded in the report and do not stop the run.
+func Run(ctx context.Context, client *adbclient.Client, device *adbclient.Device, target Target, matrix Matrix, dir string, opts ...RunOption) (report *Report, err error) {
+	options := runOptions{settleDelay: DefaultSettleDelay}
+	for _, o := range opts {
+		if err := o.apply(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+
+	original, err := currentConfig(client, device, target, len(matrix.Locales) > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &runner{client: client, device: device, target: target, current: original, delay: options.settleDelay}
+	defer func() {
+		if restoreErr := r.apply(original); restoreErr != nil && err == nil {
+			err = fmt.Errorf("could not restore the original settings: %w", restoreErr)
+		}
+	}()
+
+	report = &Report{
+		Device:  device.Serial,
+		Model:   device.Model,
+		Target:  target,
+		Started: time.Now(),
+	}
+
+	configs := matrix.Configs(original)
+	for i, config := range configs {
+		if options.progress != nil {
+			options.progress(i, len(configs), config)
+		}
+
+		shot := Shot{Config: config, Path: filepath.ToSlash(config.Path())}
+		if err := r.capture(ctx, config, dir); err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+
+			shot.Path = ""
+			shot.Error = err.Error()
+		}
+
+		report.Shots = append(report.Shots, shot)
+	}
+
+	if err := report.Save(dir); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// Save writes the JSON report and the HTML contact sheet to dir.
+func (r *Report) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ReportFileName), data, 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, ContactSheetFileName))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	return WriteContactSheet(f, r)
+}
@@ -0,0 +1,88 @@
+package symbolicate
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// frameRegex matches a native backtrace frame of a tombstone or logcat, e.g.
+//
+//	#00 pc 000000000004e0f0  /data/app/~~x==/com.example-y==/lib/arm64/libgame.so (Java_foo+12) (BuildId: 1a2b...)
+//	#01 pc 00000000000a1b2c  /data/app/~~x==/com.example-y==/base.apk!libgame.so (offset 0x4000) (BuildId: 1a2b...)
+var frameRegex = regexp.MustCompile(`#(\d+) pc ([0-9a-fA-F]+)\s+(\S+)(.*)$`)
+
+var (
+	frameSymbolRegex  = regexp.MustCompile(`\(([^()]*(?:\([^()]*\))?[^()]*)\+(\d+)\)`)
+	frameBuildIDRegex = regexp.MustCompile(`\(BuildId: ([0-9a-fA-F]+)\)`)
+)
+
+// Frame is a frame of a native backtrace.
+type Frame struct {
+	Index int
+	// PC is the address relative to the start of the library.
+	PC uint64
+	// Path is the path of the library on the device.
+	Path string
+	// Symbol is the symbol printed by the device, if any.
+	Symbol string
+	// BuildID is the hex build id printed by the device, if any.
+	BuildID string
+}
+
+// Library returns the file name of the library, e.g. libgame.so for base.apk!libgame.so.
+func (f Frame) Library() string {
+	if _, lib, ok := strings.Cut(f.Path, "!"); ok {
+		return path.Base(lib)
+	}
+
+	return path.Base(f.Path)
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("#%02d pc %016x  %s", f.Index, f.PC, f.Path)
+}
+
+// ParseFrame parses a backtrace frame line, the line may have a logcat prefix.
+func ParseFrame(line string) (Frame, bool) {
+	match := frameRegex.FindStringSubmatch(line)
+	if match == nil {
+		return Frame{}, false
+	}
+
+	index, err := strconv.Atoi(match[1])
+	if err != nil {
+		return Frame{}, false
+	}
+
+	pc, err := strconv.ParseUint(match[2], 16, 64)
+	if err != nil {
+		return Frame{}, false
+	}
+
+	frame := Frame{Index: index, PC: pc, Path: match[3]}
+
+	if symbol := frameSymbolRegex.FindStringSubmatch(match[4]); symbol != nil {
+		frame.Symbol = symbol[1]
+	}
+
+	if buildID := frameBuildIDRegex.FindStringSubmatch(match[4]); buildID != nil {
+		frame.BuildID = strings.ToLower(buildID[1])
+	}
+
+	return frame, true
+}
+
+// ParseFrames parses all backtrace frames of a tombstone or a log.
+func ParseFrames(text string) []Frame {
+	var frames []Frame
+	for _, line := range strings.Split(text, "\n") {
+		if frame, ok := ParseFrame(line); ok {
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames
+}
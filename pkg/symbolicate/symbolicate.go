@@ -0,0 +1,424 @@
+package symbolicate
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNoSymbols is returned if no symbol file matches the library of a frame.
+	ErrNoSymbols = errors.New("no symbols")
+	// ErrNotFound is returned if the address is not covered by the symbol file.
+	ErrNotFound = errors.New("address not found")
+)
+
+// ntGNUBuildID is the type of the build id ELF note.
+const ntGNUBuildID = 3
+
+// Symbol is the source location of an address.
+type Symbol struct {
+	Function string
+	File     string
+	Line     int
+	// Library is the local path of the symbol file.
+	Library string
+}
+
+func (s Symbol) String() string {
+	function := s.Function
+	if function == "" {
+		function = "??"
+	}
+
+	if s.File == "" {
+		return function
+	}
+
+	return fmt.Sprintf("%s at %s:%d", function, s.File, s.Line)
+}
+
+// library is a local symbol file, it is opened on first use.
+type library struct {
+	path    string
+	buildID string
+	debug   bool
+
+	once    sync.Once
+	err     error
+	dwarf   *dwarf.Data
+	symbols []elf.Symbol
+}
+
+func (l *library) load() error {
+	l.once.Do(func() {
+		f, err := elf.Open(l.path)
+		if err != nil {
+			l.err = err
+			return
+		}
+
+		defer f.Close()
+
+		if l.debug {
+			// a broken DWARF still has the symbol table
+			l.dwarf, _ = f.DWARF()
+		}
+
+		symbols, _ := f.Symbols()
+		dynamic, _ := f.DynamicSymbols()
+		for _, symbol := range append(symbols, dynamic...) {
+			if elf.ST_TYPE(symbol.Info) == elf.STT_FUNC && symbol.Value != 0 {
+				l.symbols = append(l.symbols, symbol)
+			}
+		}
+
+		sort.Slice(l.symbols, func(i, j int) bool {
+			return l.symbols[i].Value < l.symbols[j].Value
+		})
+	})
+
+	return l.err
+}
+
+// lookup returns the symbol of the address.
+func (l *library) lookup(pc uint64) (Symbol, error) {
+	if err := l.load(); err != nil {
+		return Symbol{}, err
+	}
+
+	symbol := Symbol{Library: l.path}
+
+	if l.dwarf != nil {
+		lookupDWARF(l.dwarf, pc, &symbol)
+	}
+
+	if symbol.Function == "" {
+		// the last function starting at or before the address
+		i := sort.Search(len(l.symbols), func(i int) bool { return l.symbols[i].Value > pc }) - 1
+		if i >= 0 && pc < l.symbols[i].Value+l.symbols[i].Size {
+			symbol.Function = l.symbols[i].Name
+		}
+	}
+
+	if symbol.Function == "" && symbol.File == "" {
+		return Symbol{}, ErrNotFound
+	}
+
+	return symbol, nil
+}
+
+// lookupDWARF fills the function, file and line of the address from the debug info.
+// The function is the innermost one, inlined functions included.
+func lookupDWARF(d *dwarf.Data, pc uint64, symbol *Symbol) {
+	r := d.Reader()
+	cu, err := r.SeekPC(pc)
+	if err != nil {
+		return
+	}
+
+	if lr, err := d.LineReader(cu); err == nil && lr != nil {
+		var entry dwarf.LineEntry
+		if err := lr.SeekPC(pc, &entry); err == nil && entry.File != nil {
+			symbol.File = entry.File.Name
+			symbol.Line = entry.Line
+		}
+	}
+
+	if !cu.Children {
+		return
+	}
+
+	// the entries of the unit follow it, until the depth gets back to zero
+	for depth := 1; depth > 0; {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			return
+		}
+
+		if entry.Tag == 0 {
+			depth--
+			continue
+		}
+
+		if entry.Tag == dwarf.TagSubprogram || entry.Tag == dwarf.TagInlinedSubroutine {
+			if ranges, err := d.Ranges(entry); err == nil && containsPC(ranges, pc) {
+				if name := entryName(d, entry, 0); name != "" {
+					symbol.Function = name
+				}
+			} else if entry.Children {
+				// the address can't be in the children of a function not containing it
+				r.SkipChildren()
+				continue
+			}
+		}
+
+		if entry.Children {
+			depth++
+		}
+	}
+}
+
+func containsPC(ranges [][2]uint64, pc uint64) bool {
+	for _, r := range ranges {
+		if r[0] <= pc && pc < r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// entryName returns the name of a function entry, following the abstract origin of inlined functions
+// and the specification of out-of-line definitions.
+func entryName(d *dwarf.Data, entry *dwarf.Entry, depth int) string {
+	if name, ok := entry.Val(dwarf.AttrName).(string); ok {
+		return name
+	}
+
+	if depth > 4 {
+		return ""
+	}
+
+	for _, attr := range []dwarf.Attr{dwarf.AttrAbstractOrigin, dwarf.AttrSpecification} {
+		offset, ok := entry.Val(attr).(dwarf.Offset)
+		if !ok {
+			continue
+		}
+
+		r := d.Reader()
+		r.Seek(offset)
+		if origin, err := r.Next(); err == nil && origin != nil {
+			return entryName(d, origin, depth+1)
+		}
+	}
+
+	return ""
+}
+
+// readBuildID returns the hex GNU build id of the ELF file.
+func readBuildID(f *elf.File) string {
+	for _, section := range f.Sections {
+		if section.Type != elf.SHT_NOTE {
+			continue
+		}
+
+		data, err := section.Data()
+		if err != nil {
+			continue
+		}
+
+		align := func(n uint32) uint32 { return (n + 3) &^ 3 }
+		for len(data) >= 12 {
+			nameSize := f.ByteOrder.Uint32(data[0:4])
+			descSize := f.ByteOrder.Uint32(data[4:8])
+			noteType := f.ByteOrder.Uint32(data[8:12])
+
+			descStart := 12 + align(nameSize)
+			descEnd := descStart + descSize
+			if uint64(descEnd) > uint64(len(data)) {
+				break
+			}
+
+			if noteType == ntGNUBuildID && string(data[12:12+nameSize]) == "GNU\x00" {
+				return hex.EncodeToString(data[descStart:descEnd])
+			}
+
+			data = data[align(descEnd):]
+		}
+	}
+
+	return ""
+}
+
+// Symbolicator maps native backtrace frames to functions, files and lines using local symbol files.
+type Symbolicator struct {
+	mu        sync.RWMutex
+	byBuildID map[string][]*library
+	byName    map[string][]*library
+}
+
+// NewSymbolicator creates a symbolicator with the libraries found in the directories,
+// e.g. a symbols directory or the unstripped libraries of the build output.
+func NewSymbolicator(dirs ...string) (*Symbolicator, error) {
+	s := &Symbolicator{
+		byBuildID: make(map[string][]*library),
+		byName:    make(map[string][]*library),
+	}
+
+	for _, dir := range dirs {
+		if err := s.AddDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// ParsePaths splits a list of symbol directories separated by the OS path list separator.
+func ParsePaths(s string) []string {
+	var paths []string
+	for _, path := range filepath.SplitList(s) {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// AddDir adds all ELF libraries found in the directory and its subdirectories.
+func (s *Symbolicator) AddDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !isLibrary(entry.Name()) {
+			return nil
+		}
+
+		// files that aren't ELF libraries are ignored
+		s.AddFile(path)
+		return nil
+	})
+}
+
+func isLibrary(name string) bool {
+	return strings.HasSuffix(name, ".so") || strings.HasSuffix(name, ".debug") || strings.HasSuffix(name, ".dbg")
+}
+
+// AddFile adds a single ELF library.
+func (s *Symbolicator) AddFile(path string) error {
+	f, err := elf.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	lib := &library{
+		path:    path,
+		buildID: readBuildID(f),
+		debug:   f.Section(".debug_info") != nil,
+	}
+
+	// .debug and .dbg files are named after the library
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".debug"), ".dbg")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lib.buildID != "" {
+		s.byBuildID[lib.buildID] = insertLibrary(s.byBuildID[lib.buildID], lib)
+	}
+
+	s.byName[name] = insertLibrary(s.byName[name], lib)
+	return nil
+}
+
+// insertLibrary adds the library, the ones with debug info first.
+func insertLibrary(libs []*library, lib *library) []*library {
+	if lib.debug {
+		return append([]*library{lib}, libs...)
+	}
+
+	return append(libs, lib)
+}
+
+// Len returns the number of indexed libraries.
+func (s *Symbolicator) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, libs := range s.byName {
+		n += len(libs)
+	}
+
+	return n
+}
+
+// find returns the candidate libraries of the frame. If the frame has a build id only the libraries with it match,
+// a library of the same name but a different build would give wrong results.
+func (s *Symbolicator) find(frame Frame) []*library {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if frame.BuildID != "" {
+		return s.byBuildID[frame.BuildID]
+	}
+
+	return s.byName[frame.Library()]
+}
+
+// Symbolicate returns the source location of the frame.
+func (s *Symbolicator) Symbolicate(frame Frame) (Symbol, error) {
+	libs := s.find(frame)
+	if len(libs) == 0 {
+		return Symbol{}, fmt.Errorf("%w: %s", ErrNoSymbols, frame.Library())
+	}
+
+	var lastErr error
+	for _, lib := range libs {
+		symbol, err := lib.lookup(frame.PC)
+		if err == nil {
+			return symbol, nil
+		}
+
+		lastErr = err
+	}
+
+	return Symbol{}, lastErr
+}
+
+// SymbolicateText adds the source location after each backtrace frame of a tombstone or a log, like ndk-stack.
+// Frames without symbols are left as they are.
+func (s *Symbolicator) SymbolicateText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, line)
+
+		frame, ok := ParseFrame(line)
+		if !ok {
+			continue
+		}
+
+		symbol, err := s.Symbolicate(frame)
+		if err != nil {
+			continue
+		}
+
+		indent := strings.Repeat(" ", strings.Index(line, "#")+4)
+		lines = append(lines, indent+symbol.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// exists returns true if the path exists, it's used to ignore missing configured directories.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// NewSymbolicatorFromPaths creates a symbolicator with the existing directories of a path list.
+func NewSymbolicatorFromPaths(paths string) (*Symbolicator, error) {
+	var dirs []string
+	for _, dir := range ParsePaths(paths) {
+		if exists(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return NewSymbolicator(dirs...)
+}
@@ -0,0 +1,142 @@
+package symbolicate
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testBuildID is the build id of testdata/libcrash.so
+const testBuildID = "c56f34f40bb1ca2ea36d80ffd0b5bd5d90c71d3b"
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Frame
+	}{
+		{
+			line:     "      #00 pc 000000000004e0f0  /apex/com.android.runtime/lib64/bionic/libc.so (abort+164) (BuildId: 2D4E1E5ABB6D1A7A)",
+			expected: Frame{Index: 0, PC: 0x4e0f0, Path: "/apex/com.android.runtime/lib64/bionic/libc.so", Symbol: "abort", BuildID: "2d4e1e5abb6d1a7a"},
+		},
+		{
+			line:     "05-18 12:01:09.830  5233  5233 F DEBUG   :       #01 pc 0000000000001117  /data/app/~~a==/com.example-b==/base.apk!libcrash.so (offset 0x4000) (crash_divide(int, int)+14)",
+			expected: Frame{Index: 1, PC: 0x1117, Path: "/data/app/~~a==/com.example-b==/base.apk!libcrash.so", Symbol: "crash_divide(int, int)"},
+		},
+		{
+			line:     "#02 pc 00001117 /data/app/com.example/lib/arm/libcrash.so",
+			expected: Frame{Index: 2, PC: 0x1117, Path: "/data/app/com.example/lib/arm/libcrash.so"},
+		},
+	}
+
+	for _, test := range tests {
+		frame, ok := ParseFrame(test.line)
+		if !ok {
+			t.Errorf("%q: not parsed", test.line)
+			continue
+		}
+
+		if frame != test.expected {
+			t.Errorf("expected: %+v, actual: %+v", test.expected, frame)
+		}
+
+		if frame.Library() != "libc.so" && frame.Library() != "libcrash.so" {
+			t.Errorf("unexpected library: %s", frame.Library())
+		}
+	}
+
+	if _, ok := ParseFrame("backtrace:"); ok {
+		t.Error("expected no frame")
+	}
+}
+
+func TestSymbolicate(t *testing.T) {
+	s, err := NewSymbolicator("testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Len() != 2 {
+		t.Fatalf("expected 2 libraries, got %d", s.Len())
+	}
+
+	tests := []struct {
+		frame    Frame
+		function string
+		line     int
+	}{
+		// matched by build id, the unstripped library is preferred over the stripped one
+		{Frame{PC: 0x1117, Path: "/data/app/lib/x86_64/libcrash.so", BuildID: testBuildID}, "crash_divide", 6},
+		// matched by name
+		{Frame{PC: 0x1130, Path: "/data/app/base.apk!libcrash.so"}, "crash_entry", 10},
+	}
+
+	for _, test := range tests {
+		symbol, err := s.Symbolicate(test.frame)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.frame, err)
+			continue
+		}
+
+		if symbol.Function != test.function || symbol.Line != test.line || filepath.Base(symbol.File) != "libcrash.c" {
+			t.Errorf("%s: unexpected symbol: %+v", test.frame, symbol)
+		}
+
+		if filepath.Base(symbol.Library) != "libcrash.so" {
+			t.Errorf("expected the library with debug info, got %s", symbol.Library)
+		}
+	}
+
+	if _, err := s.Symbolicate(Frame{PC: 0x1117, Path: "libcrash.so", BuildID: "00ff"}); !errors.Is(err, ErrNoSymbols) {
+		t.Errorf("expected %v for a different build, got %v", ErrNoSymbols, err)
+	}
+
+	if _, err := s.Symbolicate(Frame{PC: 0xfffffff, Path: "libcrash.so"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v, got %v", ErrNotFound, err)
+	}
+}
+
+func TestSymbolicateStripped(t *testing.T) {
+	s, err := NewSymbolicator()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.AddFile(filepath.Join("testdata", "libcrash.stripped.so")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the dynamic symbol table is kept by strip
+	symbol, err := s.Symbolicate(Frame{PC: 0x1117, Path: "libcrash.so", BuildID: testBuildID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if symbol.Function != "crash_divide" || symbol.File != "" {
+		t.Errorf("unexpected symbol: %+v", symbol)
+	}
+}
+
+func TestSymbolicateText(t *testing.T) {
+	s, err := NewSymbolicator("testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := "backtrace:\n" +
+		"  #00 pc 0000000000001117  /data/app/lib/libcrash.so (BuildId: " + testBuildID + ")\n" +
+		"  #01 pc 000000000004e0f0  /system/lib64/libc.so (abort+164)"
+
+	expected := "backtrace:\n" +
+		"  #00 pc 0000000000001117  /data/app/lib/libcrash.so (BuildId: " + testBuildID + ")\n" +
+		"      crash_divide at libcrash.c:6\n" +
+		"  #01 pc 000000000004e0f0  /system/lib64/libc.so (abort+164)"
+
+	if actual := s.SymbolicateText(text); actual != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, actual)
+	}
+
+	if frames := ParseFrames(text); len(frames) != 2 || !strings.HasSuffix(frames[1].Path, "libc.so") {
+		t.Errorf("unexpected frames: %+v", frames)
+	}
+}
@@ -0,0 +1,11 @@
+// libcrash.so is the test library of the symbolicator, rebuild it with:
+//   gcc -gdwarf-4 -O0 -fdebug-prefix-map=$PWD=. -shared -fPIC -Wl,--build-id=sha1 -o libcrash.so libcrash.c
+//   strip -o libcrash.stripped.so libcrash.so
+
+int crash_divide(int a, int b) {
+	return a / b;
+}
+
+int crash_entry(int a) {
+	return crash_divide(a, 0) + 1;
+}
@@ -0,0 +1,222 @@
+package timeline
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+// DefaultWindow is the default time a message is held back for messages of other devices logged before it.
+const DefaultWindow = 500 * time.Millisecond
+
+// Entry is a message of a device on the merged timeline.
+type Entry struct {
+	Serial string
+	// Time is the timestamp of the message corrected by the clock offset of the device, in the local clock.
+	Time time.Time
+	adbclient.LogcatMessage
+}
+
+// Format returns the message in the format with the corrected time, each line is prefixed with the serial.
+func (e Entry) Format(format adbclient.LogcatFormat) string {
+	msg := e.LogcatMessage
+	msg.Timestamp = e.Time
+
+	lines := strings.Split(msg.Format(format), "\n")
+	for i := range lines {
+		lines[i] = fmt.Sprintf("[%s] %s", e.Serial, lines[i])
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Source is the log of a device.
+type Source struct {
+	Serial string
+	// Offset is the offset of the device clock from the local clock, positive if the device is ahead.
+	Offset time.Duration
+	// Filter selects the messages of the device, the zero value accepts all.
+	Filter adbclient.LogcatFilter
+	// Messages are the messages of the device, the source is done when the channel is closed.
+	Messages <-chan adbclient.LogcatMessage
+}
+
+// entry returns the message on the timeline.
+func (s Source) entry(msg adbclient.LogcatMessage) Entry {
+	return Entry{Serial: s.Serial, Time: msg.Timestamp.Add(-s.Offset), LogcatMessage: msg}
+}
+
+// Sort sorts the entries by the corrected time, entries of the same time keep their order.
+func Sort(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+}
+
+// WriteText writes the entries in the format, one message per line.
+func WriteText(w io.Writer, entries []Entry, format adbclient.LogcatFormat) error {
+	for _, entry := range entries {
+		if _, err := fmt.Fprintln(w, entry.Format(format)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// entryHeap is a min-heap of entries by time, the sequence keeps the order of entries of the same time.
+type entryHeap []heapEntry
+
+type heapEntry struct {
+	Entry
+	seq uint64
+}
+
+func (h entryHeap) Len() int { return len(h) }
+func (h entryHeap) Less(i, j int) bool {
+	if h[i].Time.Equal(h[j].Time) {
+		return h[i].seq < h[j].seq
+	}
+
+	return h[i].Time.Before(h[j].Time)
+}
+func (h entryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x interface{}) { *h = append(*h, x.(heapEntry)) }
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// received is a message of a source, or the end of the source if done.
+type received struct {
+	source int
+	msg    adbclient.LogcatMessage
+	done   bool
+}
+
+// Merge merges the logs of the devices by the corrected time. A message is held back until every
+// active source has logged past it. A source that sent nothing for the window is idle and doesn't hold
+// back the others. The channel is closed when all sources are done or the context is done.
+func Merge(ctx context.Context, window time.Duration, sources ...Source) <-chan Entry {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	out := make(chan Entry)
+	in := make(chan received)
+
+	for i, source := range sources {
+		go func(i int, source Source) {
+			for msg := range source.Messages {
+				if !source.Filter.Match(msg) {
+					continue
+				}
+
+				select {
+				case in <- received{source: i, msg: msg}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case in <- received{source: i, done: true}:
+			case <-ctx.Done():
+			}
+		}(i, source)
+	}
+
+	go func() {
+		defer close(out)
+
+		h := &entryHeap{}
+		var seq uint64
+
+		// last is the newest corrected time of each source, zero until the first message,
+		// and active the local time of its last message, the start until the first one
+		start := time.Now()
+		last := make([]time.Time, len(sources))
+		active := make([]time.Time, len(sources))
+		open := make([]bool, len(sources))
+		for i := range sources {
+			active[i] = start
+			open[i] = true
+		}
+
+		openCount := len(sources)
+
+		ticker := time.NewTicker(window / 4)
+		defer ticker.Stop()
+
+		// emit sends the entries up to the oldest newest time of the active sources,
+		// all of them if every source is done or idle
+		emit := func() bool {
+			now := time.Now()
+
+			var watermark time.Time
+			limited := false
+			for i := range sources {
+				if !open[i] || now.Sub(active[i]) >= window {
+					continue
+				}
+
+				if !limited || last[i].Before(watermark) {
+					watermark = last[i]
+					limited = true
+				}
+			}
+
+			for h.Len() > 0 && (!limited || !(*h)[0].Time.After(watermark)) {
+				entry := heap.Pop(h).(heapEntry).Entry
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return false
+				}
+			}
+
+			return true
+		}
+
+		for openCount > 0 {
+			select {
+			case r := <-in:
+				if r.done {
+					open[r.source] = false
+					openCount--
+					break
+				}
+
+				entry := sources[r.source].entry(r.msg)
+				if entry.Time.After(last[r.source]) {
+					last[r.source] = entry.Time
+				}
+
+				active[r.source] = time.Now()
+
+				seq++
+				heap.Push(h, heapEntry{Entry: entry, seq: seq})
+
+			case <-ticker.C:
+				if !emit() {
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		emit()
+	}()
+
+	return out
+}
@@ -0,0 +1,95 @@
+package timeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnyipcom/androidtool/pkg/adbclient"
+)
+
+func messages(msgs ...adbclient.LogcatMessage) <-chan adbclient.LogcatMessage {
+	ch := make(chan adbclient.LogcatMessage, len(msgs))
+	for _, msg := range msgs {
+		ch <- msg
+	}
+
+	close(ch)
+	return ch
+}
+
+func TestMerge(t *testing.T) {
+	start := time.Date(2022, 5, 18, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	// the clock of the second device is 1s ahead
+	sources := []Source{
+		{
+			Serial: "A",
+			Messages: messages(
+				adbclient.LogcatMessage{Timestamp: at(0), Tag: "Net", Message: "a1"},
+				adbclient.LogcatMessage{Timestamp: at(200), Tag: "Net", Message: "a2"},
+				adbclient.LogcatMessage{Timestamp: at(400), Tag: "Game", Message: "a3"},
+			),
+		},
+		{
+			Serial: "B",
+			Offset: time.Second,
+			Filter: adbclient.LogcatFilter{Tag: "net"},
+			Messages: messages(
+				adbclient.LogcatMessage{Timestamp: at(1100), Tag: "Net", Message: "b1"},
+				adbclient.LogcatMessage{Timestamp: at(1150), Tag: "Other", Message: "filtered"},
+				adbclient.LogcatMessage{Timestamp: at(1300), Tag: "Net", Message: "b2"},
+			),
+		},
+	}
+
+	var actual []string
+	for entry := range Merge(context.Background(), 100*time.Millisecond, sources...) {
+		actual = append(actual, entry.Serial+":"+entry.Message)
+	}
+
+	expected := "A:a1 B:b1 A:a2 B:b2 A:a3"
+	if strings.Join(actual, " ") != expected {
+		t.Errorf("expected: %s, actual: %s", expected, strings.Join(actual, " "))
+	}
+}
+
+func TestMergeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// a source that never sends must not block the end of the merge
+	idle := make(chan adbclient.LogcatMessage)
+	ch := Merge(ctx, 0, Source{Serial: "A", Messages: idle})
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("unexpected entry")
+		}
+	case <-time.After(time.Second):
+		t.Error("merge not stopped")
+	}
+}
+
+func TestEntryFormat(t *testing.T) {
+	entry := Entry{
+		Serial: "R58M",
+		Time:   time.Date(2022, 5, 18, 12, 1, 9, 830000000, time.Local),
+		LogcatMessage: adbclient.LogcatMessage{
+			Timestamp: time.Date(2022, 5, 18, 12, 1, 10, 830000000, time.Local),
+			Priority:  adbclient.Info,
+			Tag:       "Net",
+			ProcessID: 5233,
+			ThreadID:  5278,
+			Message:   "line 1\nline 2",
+		},
+	}
+
+	expected := "[R58M] 05-18 12:01:09.830  5233  5278 I Net: line 1\n[R58M] 05-18 12:01:09.830  5233  5278 I Net: line 2"
+	if actual := entry.Format(adbclient.FormatThreadtime); actual != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,906 @@
+// Package vt100 emulates the screen of a VT100 compatible terminal, with the xterm extensions used by shells
+// and full screen programs of Android devices: colors, scroll regions, the alternate screen and cursor keys mode.
+package vt100
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultScrollback is the default number of lines kept after they are scrolled off the screen.
+const DefaultScrollback = 1000
+
+// ColorKind is the kind of a color.
+type ColorKind uint8
+
+const (
+	// ColorDefault is the default foreground or background color of the terminal.
+	ColorDefault ColorKind = iota
+	// ColorIndexed is a color of the 256 colors palette, the first 16 are the ANSI colors.
+	ColorIndexed
+	// ColorRGB is a 24-bit color.
+	ColorRGB
+)
+
+// Color is the foreground or background color of a cell.
+type Color struct {
+	Kind    ColorKind
+	Index   uint8
+	R, G, B uint8
+}
+
+// Attr are the text attributes of a cell.
+type Attr uint8
+
+const (
+	AttrBold Attr = 1 << iota
+	AttrDim
+	AttrItalic
+	AttrUnderline
+	AttrReverse
+)
+
+// Cell is a character of the screen with its colors and attributes.
+type Cell struct {
+	Rune rune
+	FG   Color
+	BG   Color
+	Attr Attr
+}
+
+// blank returns an empty cell with the background color of the cell, as erased cells keep it.
+func (c Cell) blank() Cell {
+	return Cell{Rune: ' ', BG: c.BG}
+}
+
+// LineText returns the text of a line without the trailing spaces.
+func LineText(line []Cell) string {
+	var b strings.Builder
+	for _, cell := range line {
+		if cell.Rune == 0 {
+			b.WriteRune(' ')
+		} else {
+			b.WriteRune(cell.Rune)
+		}
+	}
+
+	return strings.TrimRight(b.String(), " ")
+}
+
+// parser states
+const (
+	stateGround = iota
+	stateEscape
+	stateCharset
+	stateCSI
+	stateString
+	stateStringEscape
+)
+
+// cursor is the position of the cursor with the attributes of the next printed characters.
+type cursor struct {
+	row, col int
+	template Cell
+	// wrap is set when a character is printed in the last column, the next one is printed on the next line
+	wrap bool
+}
+
+// Screen is the screen of a terminal, written with the output of a program.
+// It is safe for concurrent use.
+type Screen struct {
+	mu sync.Mutex
+
+	rows, cols    int
+	lines         [][]Cell
+	scrollback    [][]Cell
+	maxScrollback int
+
+	// main are the lines of the main screen while the alternate screen is shown
+	main      [][]Cell
+	mainSaved cursor
+
+	cursor        cursor
+	saved         cursor
+	top, bottom   int
+	cursorVisible bool
+	autowrap      bool
+	appCursorKeys bool
+	title         string
+
+	state   int
+	params  []int
+	private byte
+	str     []byte
+	partial []byte
+
+	respond func([]byte)
+}
+
+// New creates a screen of the size.
+func New(rows, cols int) *Screen {
+	s := &Screen{maxScrollback: DefaultScrollback}
+	s.reset(rows, cols)
+	return s
+}
+
+func (s *Screen) reset(rows, cols int) {
+	if rows < 1 {
+		rows = 1
+	}
+
+	if cols < 1 {
+		cols = 1
+	}
+
+	s.rows, s.cols = rows, cols
+	s.lines = s.blankLines(rows, Cell{})
+	s.main = nil
+	s.cursor = cursor{}
+	s.saved = cursor{}
+	s.top, s.bottom = 0, rows-1
+	s.cursorVisible = true
+	s.autowrap = true
+	s.appCursorKeys = false
+	s.state = stateGround
+}
+
+// SetScrollback sets the number of lines kept after they are scrolled off the screen, 0 keeps none.
+func (s *Screen) SetScrollback(lines int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxScrollback = lines
+	s.trimScrollback()
+}
+
+// OnResponse sets the function called with the replies to the queries of the program, e.g. the cursor position report.
+// The replies must be written to the input of the program.
+func (s *Screen) OnResponse(f func([]byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.respond = f
+}
+
+// Size returns the number of rows and columns.
+func (s *Screen) Size() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rows, s.cols
+}
+
+// Cursor returns the position of the cursor and whether it is visible.
+func (s *Screen) Cursor() (int, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursor.row, s.cursor.col, s.cursorVisible
+}
+
+// AppCursorKeys returns true if the program asked for the application mode of the cursor keys.
+func (s *Screen) AppCursorKeys() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.appCursorKeys
+}
+
+// Title returns the title set by the program.
+func (s *Screen) Title() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.title
+}
+
+// Lines returns a copy of the lines of the screen.
+func (s *Screen) Lines() [][]Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyLines(s.lines)
+}
+
+// Scrollback returns a copy of the lines scrolled off the screen, oldest first.
+func (s *Screen) Scrollback() [][]Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyLines(s.scrollback)
+}
+
+// ScrollbackLen returns the number of lines scrolled off the screen.
+func (s *Screen) ScrollbackLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.scrollback)
+}
+
+// View returns a copy of the lines shown when the screen is scrolled back by offset lines into the scrollback.
+// The offset is limited to the length of the scrollback.
+func (s *Screen) View(offset int) [][]Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offset = clamp(offset, 0, len(s.scrollback))
+	first := len(s.scrollback) - offset
+	view := copyLines(s.scrollback[first:clamp(first+s.rows, 0, len(s.scrollback))])
+	return append(view, copyLines(s.lines[:s.rows-len(view)])...)
+}
+
+// String returns the text of the screen, without trailing spaces.
+func (s *Screen) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	texts := make([]string, len(s.lines))
+	for i, line := range s.lines {
+		texts[i] = LineText(line)
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+func copyLines(lines [][]Cell) [][]Cell {
+	result := make([][]Cell, len(lines))
+	for i, line := range lines {
+		result[i] = append([]Cell(nil), line...)
+	}
+
+	return result
+}
+
+func (s *Screen) blankLine(template Cell) []Cell {
+	line := make([]Cell, s.cols)
+	for i := range line {
+		line[i] = template.blank()
+	}
+
+	return line
+}
+
+func (s *Screen) blankLines(n int, template Cell) [][]Cell {
+	lines := make([][]Cell, n)
+	for i := range lines {
+		lines[i] = s.blankLine(template)
+	}
+
+	return lines
+}
+
+func (s *Screen) trimScrollback() {
+	if over := len(s.scrollback) - s.maxScrollback; over > 0 {
+		s.scrollback = append([][]Cell(nil), s.scrollback[over:]...)
+	}
+}
+
+// Resize changes the size of the screen. Lines are cut or padded, and when the screen gets shorter
+// the top lines go to the scrollback so that the cursor stays on the screen.
+func (s *Screen) Resize(rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rows < 1 {
+		rows = 1
+	}
+
+	if cols < 1 {
+		cols = 1
+	}
+
+	if rows == s.rows && cols == s.cols {
+		return
+	}
+
+	resizeLines := func(lines [][]Cell, c *cursor, scrollback bool) [][]Cell {
+		for i, line := range lines {
+			if len(line) > cols {
+				lines[i] = line[:cols]
+			}
+
+			for len(lines[i]) < cols {
+				lines[i] = append(lines[i], Cell{Rune: ' '})
+			}
+		}
+
+		if over := c.row - rows + 1; over > 0 {
+			if scrollback {
+				s.scrollback = append(s.scrollback, lines[:over]...)
+			}
+
+			lines = lines[over:]
+			c.row -= over
+		}
+
+		if len(lines) > rows {
+			lines = lines[:rows]
+		}
+
+		for len(lines) < rows {
+			line := make([]Cell, cols)
+			for i := range line {
+				line[i] = Cell{Rune: ' '}
+			}
+
+			lines = append(lines, line)
+		}
+
+		if c.col >= cols {
+			c.col = cols - 1
+		}
+
+		c.wrap = false
+		return lines
+	}
+
+	if s.main != nil {
+		s.main = resizeLines(s.main, &s.mainSaved, true)
+		s.lines = resizeLines(s.lines, &s.cursor, false)
+	} else {
+		s.lines = resizeLines(s.lines, &s.cursor, true)
+	}
+
+	if s.saved.row >= rows {
+		s.saved.row = rows - 1
+	}
+
+	if s.saved.col >= cols {
+		s.saved.col = cols - 1
+	}
+
+	s.rows, s.cols = rows, cols
+	s.top, s.bottom = 0, rows-1
+	s.trimScrollback()
+}
+
+// Write interprets the output of a program. UTF-8 sequences and escape sequences may be split between writes.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+
+	for i := 0; i < len(data); {
+		b := data[i]
+		if b < utf8.RuneSelf || s.state != stateGround {
+			s.handleByte(b)
+			i++
+			continue
+		}
+
+		if !utf8.FullRune(data[i:]) {
+			s.partial = append([]byte(nil), data[i:]...)
+			break
+		}
+
+		r, size := utf8.DecodeRune(data[i:])
+		s.print(r)
+		i += size
+	}
+
+	return len(p), nil
+}
+
+func (s *Screen) handleByte(b byte) {
+	switch s.state {
+	case stateString:
+		switch b {
+		case 0x07:
+			s.endString()
+		case 0x1b:
+			s.state = stateStringEscape
+		default:
+			s.str = append(s.str, b)
+		}
+
+		return
+
+	case stateStringEscape:
+		if b == '\\' {
+			s.endString()
+			return
+		}
+
+		s.str = append(s.str, 0x1b, b)
+		s.state = stateString
+		return
+
+	case stateCharset:
+		// the character sets are not emulated, only UTF-8
+		s.state = stateGround
+		return
+	}
+
+	switch b {
+	case 0x1b:
+		s.state = stateEscape
+		return
+	case 0x18, 0x1a:
+		s.state = stateGround
+		return
+	}
+
+	if b < 0x20 || b == 0x7f {
+		s.control(b)
+		return
+	}
+
+	switch s.state {
+	case stateGround:
+		s.print(rune(b))
+	case stateEscape:
+		s.escape(b)
+	case stateCSI:
+		s.csiByte(b)
+	}
+}
+
+func (s *Screen) endString() {
+	s.state = stateGround
+
+	// OSC 0 and 2 set the title
+	str := string(s.str)
+	if strings.HasPrefix(str, "0;") || strings.HasPrefix(str, "2;") {
+		s.title = str[2:]
+	}
+
+	s.str = nil
+}
+
+func (s *Screen) control(b byte) {
+	switch b {
+	case '\b':
+		if s.cursor.col > 0 {
+			s.cursor.col--
+		}
+
+		s.cursor.wrap = false
+	case '\t':
+		s.cursor.col = (s.cursor.col/8 + 1) * 8
+		if s.cursor.col >= s.cols {
+			s.cursor.col = s.cols - 1
+		}
+
+		s.cursor.wrap = false
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\r':
+		s.cursor.col = 0
+		s.cursor.wrap = false
+	}
+}
+
+func (s *Screen) print(r rune) {
+	if s.cursor.wrap && s.autowrap {
+		s.cursor.col = 0
+		s.lineFeed()
+	}
+
+	cell := s.cursor.template
+	cell.Rune = r
+	s.lines[s.cursor.row][s.cursor.col] = cell
+
+	if s.cursor.col == s.cols-1 {
+		s.cursor.wrap = true
+	} else {
+		s.cursor.col++
+	}
+}
+
+func (s *Screen) lineFeed() {
+	s.cursor.wrap = false
+	if s.cursor.row == s.bottom {
+		s.scrollUp(1)
+	} else if s.cursor.row < s.rows-1 {
+		s.cursor.row++
+	}
+}
+
+func (s *Screen) reverseIndex() {
+	s.cursor.wrap = false
+	if s.cursor.row == s.top {
+		s.scrollDown(1)
+	} else if s.cursor.row > 0 {
+		s.cursor.row--
+	}
+}
+
+// scrollUp scrolls the scroll region up, the lines scrolled off the top of the main screen go to the scrollback.
+func (s *Screen) scrollUp(n int) {
+	region := s.bottom - s.top + 1
+	if n > region {
+		n = region
+	}
+
+	if s.top == 0 && s.main == nil && s.maxScrollback > 0 {
+		s.scrollback = append(s.scrollback, s.lines[:n]...)
+		s.trimScrollback()
+	}
+
+	copy(s.lines[s.top:s.bottom+1], s.lines[s.top+n:s.bottom+1])
+	for i := s.bottom - n + 1; i <= s.bottom; i++ {
+		s.lines[i] = s.blankLine(s.cursor.template)
+	}
+}
+
+func (s *Screen) scrollDown(n int) {
+	region := s.bottom - s.top + 1
+	if n > region {
+		n = region
+	}
+
+	copy(s.lines[s.top+n:s.bottom+1], s.lines[s.top:s.bottom+1-n])
+	for i := s.top; i < s.top+n; i++ {
+		s.lines[i] = s.blankLine(s.cursor.template)
+	}
+}
+
+func (s *Screen) escape(b byte) {
+	s.state = stateGround
+
+	switch b {
+	case '[':
+		s.state = stateCSI
+		s.params = s.params[:0]
+		s.private = 0
+	case ']', 'P', '_', '^':
+		s.state = stateString
+		s.str = s.str[:0]
+	case '(', ')', '*', '+', '#':
+		s.state = stateCharset
+	case '7':
+		s.saved = s.cursor
+	case '8':
+		s.cursor = s.saved
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cursor.col = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		respond := s.respond
+		s.reset(s.rows, s.cols)
+		s.respond = respond
+	}
+}
+
+func (s *Screen) csiByte(b byte) {
+	switch {
+	case b >= '0' && b <= '9':
+		if len(s.params) == 0 {
+			s.params = append(s.params, 0)
+		}
+
+		last := len(s.params) - 1
+		if s.params[last] < 10000 {
+			s.params[last] = s.params[last]*10 + int(b-'0')
+		}
+	case b == ';' || b == ':':
+		if len(s.params) == 0 {
+			s.params = append(s.params, 0)
+		}
+
+		s.params = append(s.params, 0)
+	case b == '?' || b == '>' || b == '=' || b == '<':
+		s.private = b
+	case b >= 0x40 && b <= 0x7e:
+		s.state = stateGround
+		s.csi(b)
+	default:
+		// intermediate bytes are ignored
+	}
+}
+
+// param returns the parameter, the default if it is missing or 0.
+func (s *Screen) param(i, def int) int {
+	if i >= len(s.params) || s.params[i] == 0 {
+		return def
+	}
+
+	return s.params[i]
+}
+
+func (s *Screen) moveTo(row, col int) {
+	s.cursor.row = clamp(row, 0, s.rows-1)
+	s.cursor.col = clamp(col, 0, s.cols-1)
+	s.cursor.wrap = false
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+
+	if v > max {
+		return max
+	}
+
+	return v
+}
+
+func (s *Screen) reply(format string, args ...interface{}) {
+	if s.respond != nil {
+		s.respond([]byte(fmt.Sprintf(format, args...)))
+	}
+}
+
+func (s *Screen) csi(final byte) {
+	if s.private == '?' {
+		switch final {
+		case 'h':
+			s.setModes(true)
+		case 'l':
+			s.setModes(false)
+		}
+
+		return
+	}
+
+	if s.private != 0 {
+		// secondary device attributes and other queries of xterm extensions are ignored
+		return
+	}
+
+	row, col := s.cursor.row, s.cursor.col
+	switch final {
+	case '@':
+		s.insertCells(s.param(0, 1))
+	case 'A':
+		s.moveTo(row-s.param(0, 1), col)
+	case 'B', 'e':
+		s.moveTo(row+s.param(0, 1), col)
+	case 'C', 'a':
+		s.moveTo(row, col+s.param(0, 1))
+	case 'D':
+		s.moveTo(row, col-s.param(0, 1))
+	case 'E':
+		s.moveTo(row+s.param(0, 1), 0)
+	case 'F':
+		s.moveTo(row-s.param(0, 1), 0)
+	case 'G', '`':
+		s.moveTo(row, s.param(0, 1)-1)
+	case 'H', 'f':
+		s.moveTo(s.param(0, 1)-1, s.param(1, 1)-1)
+	case 'd':
+		s.moveTo(s.param(0, 1)-1, col)
+	case 'J':
+		s.eraseDisplay(s.param(0, 0))
+	case 'K':
+		s.eraseLine(s.param(0, 0))
+	case 'L':
+		s.insertLines(s.param(0, 1))
+	case 'M':
+		s.deleteLines(s.param(0, 1))
+	case 'P':
+		s.deleteCells(s.param(0, 1))
+	case 'X':
+		s.eraseCells(col, col+s.param(0, 1))
+	case 'S':
+		s.scrollUp(s.param(0, 1))
+	case 'T':
+		s.scrollDown(s.param(0, 1))
+	case 'm':
+		s.sgr()
+	case 'r':
+		top, bottom := s.param(0, 1)-1, s.param(1, s.rows)-1
+		if top < bottom && bottom < s.rows {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saved = s.cursor
+	case 'u':
+		s.cursor = s.saved
+	case 'n':
+		switch s.param(0, 0) {
+		case 5:
+			s.reply("\x1b[0n")
+		case 6:
+			s.reply("\x1b[%d;%dR", s.cursor.row+1, s.cursor.col+1)
+		}
+	case 'c':
+		s.reply("\x1b[?1;2c")
+	}
+}
+
+func (s *Screen) setModes(set bool) {
+	for _, mode := range s.params {
+		switch mode {
+		case 1:
+			s.appCursorKeys = set
+		case 7:
+			s.autowrap = set
+		case 25:
+			s.cursorVisible = set
+		case 47, 1047:
+			s.alternateScreen(set)
+		case 1048:
+			if set {
+				s.saved = s.cursor
+			} else {
+				s.cursor = s.saved
+			}
+		case 1049:
+			if set {
+				s.saved = s.cursor
+				s.alternateScreen(true)
+			} else {
+				s.alternateScreen(false)
+				s.cursor = s.saved
+			}
+		}
+	}
+}
+
+// alternateScreen switches to an empty alternate screen, or back to the main screen.
+func (s *Screen) alternateScreen(on bool) {
+	if on == (s.main != nil) {
+		return
+	}
+
+	if on {
+		s.main, s.mainSaved = s.lines, s.cursor
+		s.lines = s.blankLines(s.rows, Cell{})
+	} else {
+		s.lines, s.main = s.main, nil
+		s.cursor = s.mainSaved
+	}
+
+	s.top, s.bottom = 0, s.rows-1
+}
+
+func (s *Screen) eraseCells(from, to int) {
+	line := s.lines[s.cursor.row]
+	for i := clamp(from, 0, s.cols); i < clamp(to, 0, s.cols); i++ {
+		line[i] = s.cursor.template.blank()
+	}
+}
+
+func (s *Screen) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		s.eraseCells(s.cursor.col, s.cols)
+	case 1:
+		s.eraseCells(0, s.cursor.col+1)
+	case 2:
+		s.eraseCells(0, s.cols)
+	}
+}
+
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(0)
+		for i := s.cursor.row + 1; i < s.rows; i++ {
+			s.lines[i] = s.blankLine(s.cursor.template)
+		}
+	case 1:
+		s.eraseLine(1)
+		for i := 0; i < s.cursor.row; i++ {
+			s.lines[i] = s.blankLine(s.cursor.template)
+		}
+	case 2:
+		for i := range s.lines {
+			s.lines[i] = s.blankLine(s.cursor.template)
+		}
+	case 3:
+		s.scrollback = nil
+	}
+}
+
+func (s *Screen) insertCells(n int) {
+	line := s.lines[s.cursor.row]
+	n = clamp(n, 0, s.cols-s.cursor.col)
+	copy(line[s.cursor.col+n:], line[s.cursor.col:])
+	s.eraseCells(s.cursor.col, s.cursor.col+n)
+}
+
+func (s *Screen) deleteCells(n int) {
+	line := s.lines[s.cursor.row]
+	n = clamp(n, 0, s.cols-s.cursor.col)
+	copy(line[s.cursor.col:], line[s.cursor.col+n:])
+	s.eraseCells(s.cols-n, s.cols)
+}
+
+func (s *Screen) insertLines(n int) {
+	if s.cursor.row < s.top || s.cursor.row > s.bottom {
+		return
+	}
+
+	top := s.top
+	s.top = s.cursor.row
+	s.scrollDown(n)
+	s.top = top
+	s.cursor.col = 0
+}
+
+func (s *Screen) deleteLines(n int) {
+	if s.cursor.row < s.top || s.cursor.row > s.bottom {
+		return
+	}
+
+	// deleted lines never go to the scrollback
+	top, scrollback := s.top, s.maxScrollback
+	s.top, s.maxScrollback = s.cursor.row, 0
+	s.scrollUp(n)
+	s.top, s.maxScrollback = top, scrollback
+	s.cursor.col = 0
+}
+
+// sgr sets the colors and attributes of the next printed characters.
+func (s *Screen) sgr() {
+	t := &s.cursor.template
+	if len(s.params) == 0 {
+		*t = Cell{}
+		return
+	}
+
+	for i := 0; i < len(s.params); i++ {
+		p := s.params[i]
+		switch {
+		case p == 0:
+			*t = Cell{}
+		case p == 1:
+			t.Attr |= AttrBold
+		case p == 2:
+			t.Attr |= AttrDim
+		case p == 3:
+			t.Attr |= AttrItalic
+		case p == 4:
+			t.Attr |= AttrUnderline
+		case p == 7:
+			t.Attr |= AttrReverse
+		case p == 22:
+			t.Attr &^= AttrBold | AttrDim
+		case p == 23:
+			t.Attr &^= AttrItalic
+		case p == 24:
+			t.Attr &^= AttrUnderline
+		case p == 27:
+			t.Attr &^= AttrReverse
+		case p >= 30 && p <= 37:
+			t.FG = Color{Kind: ColorIndexed, Index: uint8(p - 30)}
+		case p == 38:
+			t.FG, i = s.extendedColor(i)
+		case p == 39:
+			t.FG = Color{}
+		case p >= 40 && p <= 47:
+			t.BG = Color{Kind: ColorIndexed, Index: uint8(p - 40)}
+		case p == 48:
+			t.BG, i = s.extendedColor(i)
+		case p == 49:
+			t.BG = Color{}
+		case p >= 90 && p <= 97:
+			t.FG = Color{Kind: ColorIndexed, Index: uint8(p - 90 + 8)}
+		case p >= 100 && p <= 107:
+			t.BG = Color{Kind: ColorIndexed, Index: uint8(p - 100 + 8)}
+		}
+	}
+}
+
+// extendedColor parses the 256 colors (5;n) or 24-bit (2;r;g;b) color after the parameter i,
+// and returns the index of its last parameter.
+func (s *Screen) extendedColor(i int) (Color, int) {
+	switch s.param(i+1, 0) {
+	case 5:
+		if i+2 < len(s.params) {
+			return Color{Kind: ColorIndexed, Index: uint8(s.params[i+2])}, i + 2
+		}
+	case 2:
+		if i+4 < len(s.params) {
+			return Color{Kind: ColorRGB, R: uint8(s.params[i+2]), G: uint8(s.params[i+3]), B: uint8(s.params[i+4])}, i + 4
+		}
+	}
+
+	return Color{}, len(s.params)
+}
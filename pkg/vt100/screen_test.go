@@ -0,0 +1,230 @@
+package vt100
+
+import (
+	"strings"
+	"testing"
+)
+
+func write(s *Screen, text string) {
+	s.Write([]byte(text))
+}
+
+func TestPrintAndWrap(t *testing.T) {
+	s := New(3, 5)
+	write(s, "hello worl\r\n!")
+
+	if expected := "hello\n worl\n!"; s.String() != expected {
+		t.Errorf("expected %q, got %q", expected, s.String())
+	}
+
+	if row, col, _ := s.Cursor(); row != 2 || col != 1 {
+		t.Errorf("cursor: %d, %d", row, col)
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	s := New(2, 10)
+	write(s, "one\r\ntwo\r\nthree\r\nfour")
+
+	if s.String() != "three\nfour" {
+		t.Errorf("screen: %q", s.String())
+	}
+
+	scrollback := s.Scrollback()
+	if len(scrollback) != 2 || LineText(scrollback[0]) != "one" || LineText(scrollback[1]) != "two" {
+		t.Errorf("scrollback: %v", scrollback)
+	}
+
+	s.SetScrollback(1)
+	if scrollback := s.Scrollback(); len(scrollback) != 1 || LineText(scrollback[0]) != "two" {
+		t.Errorf("trimmed scrollback: %v", scrollback)
+	}
+}
+
+func TestSplitSequences(t *testing.T) {
+	s := New(2, 10)
+	for _, b := range []byte("\x1b[2;3Hé\x1b[1mx") {
+		s.Write([]byte{b})
+	}
+
+	lines := s.Lines()
+	if lines[1][2].Rune != 'é' || lines[1][3].Rune != 'x' || lines[1][3].Attr != AttrBold || lines[1][2].Attr != 0 {
+		t.Errorf("unexpected cells: %+v", lines[1][:4])
+	}
+}
+
+func TestCursorMovementAndErase(t *testing.T) {
+	s := New(3, 10)
+	write(s, "abcdefghij\r\nklmnopqrst\r\nuvwxyz")
+
+	write(s, "\x1b[1;4H\x1b[K")
+	write(s, "\x1b[2;5H\x1b[1K")
+	write(s, "\x1b[3;3H\x1b[2P")
+	write(s, "\x1b[A\x1b[2C\x1b[3X")
+
+	if expected := "abc\n       rst\nuvyz"; s.String() != expected {
+		t.Errorf("expected %q, got %q", expected, s.String())
+	}
+
+	write(s, "\x1b[2J")
+	if strings.TrimSpace(s.String()) != "" {
+		t.Errorf("expected an empty screen, got %q", s.String())
+	}
+}
+
+func TestScrollRegion(t *testing.T) {
+	s := New(4, 5)
+	write(s, "1\r\n2\r\n3\r\n4")
+	write(s, "\x1b[2;3r\x1b[3;1H\n5")
+
+	if expected := "1\n3\n5\n4"; s.String() != expected {
+		t.Errorf("expected %q, got %q", expected, s.String())
+	}
+
+	// lines scrolled in a region don't go to the scrollback
+	if len(s.Scrollback()) != 0 {
+		t.Errorf("unexpected scrollback: %v", s.Scrollback())
+	}
+
+	write(s, "\x1b[2;1H\x1bM")
+	if expected := "1\n\n3\n4"; s.String() != expected {
+		t.Errorf("reverse index: expected %q, got %q", expected, s.String())
+	}
+
+	write(s, "\x1b[r\x1b[2;1H\x1b[L")
+	if expected := "1\n\n\n3"; s.String() != expected {
+		t.Errorf("insert line: expected %q, got %q", expected, s.String())
+	}
+
+	write(s, "\x1b[M\x1b[M")
+	if expected := "1\n3\n\n"; s.String() != expected {
+		t.Errorf("delete line: expected %q, got %q", expected, s.String())
+	}
+}
+
+func TestSGR(t *testing.T) {
+	s := New(1, 10)
+	write(s, "\x1b[1;31;44ma\x1b[38;5;208mb\x1b[48;2;1;2;3mc\x1b[0;7;95md\x1b[me")
+
+	cells := s.Lines()[0]
+	if cells[0].Attr != AttrBold || cells[0].FG != (Color{Kind: ColorIndexed, Index: 1}) || cells[0].BG != (Color{Kind: ColorIndexed, Index: 4}) {
+		t.Errorf("a: %+v", cells[0])
+	}
+
+	if cells[1].FG != (Color{Kind: ColorIndexed, Index: 208}) {
+		t.Errorf("b: %+v", cells[1])
+	}
+
+	if cells[2].BG != (Color{Kind: ColorRGB, R: 1, G: 2, B: 3}) || cells[2].FG.Index != 208 {
+		t.Errorf("c: %+v", cells[2])
+	}
+
+	if cells[3].Attr != AttrReverse || cells[3].FG != (Color{Kind: ColorIndexed, Index: 13}) || cells[3].BG != (Color{}) {
+		t.Errorf("d: %+v", cells[3])
+	}
+
+	if cells[4].Attr != 0 || cells[4].FG != (Color{}) {
+		t.Errorf("e: %+v", cells[4])
+	}
+}
+
+func TestAlternateScreen(t *testing.T) {
+	s := New(2, 10)
+	write(s, "prompt $ ")
+	write(s, "\x1b[?1049h\x1b[H\x1b[?1h\x1b[?25lvi")
+
+	if s.String() != "vi\n" || !s.AppCursorKeys() {
+		t.Errorf("alternate screen: %q", s.String())
+	}
+
+	if _, _, visible := s.Cursor(); visible {
+		t.Error("expected a hidden cursor")
+	}
+
+	write(s, "\x1b[?1049l\x1b[?1l\x1b[?25h")
+	if s.String() != "prompt $\n" || s.AppCursorKeys() {
+		t.Errorf("main screen: %q", s.String())
+	}
+
+	if row, col, visible := s.Cursor(); row != 0 || col != 9 || !visible {
+		t.Errorf("cursor: %d, %d, %v", row, col, visible)
+	}
+}
+
+func TestResize(t *testing.T) {
+	s := New(3, 10)
+	write(s, "one\r\ntwo\r\nthree")
+
+	s.Resize(2, 4)
+	if s.String() != "two\nthre" {
+		t.Errorf("shrunk: %q", s.String())
+	}
+
+	if len(s.Scrollback()) != 1 {
+		t.Errorf("expected the first line in the scrollback, got %v", s.Scrollback())
+	}
+
+	if row, col, _ := s.Cursor(); row != 1 || col != 3 {
+		t.Errorf("cursor: %d, %d", row, col)
+	}
+
+	s.Resize(3, 6)
+	if rows, cols := s.Size(); rows != 3 || cols != 6 {
+		t.Errorf("size: %d, %d", rows, cols)
+	}
+
+	write(s, "\r\x1b[Kabcdef")
+	if s.String() != "two\nabcdef\n" {
+		t.Errorf("grown: %q", s.String())
+	}
+}
+
+func TestResponsesAndTitle(t *testing.T) {
+	s := New(5, 10)
+
+	var responses []string
+	s.OnResponse(func(b []byte) {
+		responses = append(responses, string(b))
+	})
+
+	write(s, "\x1b]0;shell@device\x07\x1b[3;4H\x1b[6n\x1b[c\x1b]2;other\x1b\\")
+
+	if s.Title() != "other" {
+		t.Errorf("title: %q", s.Title())
+	}
+
+	if len(responses) != 2 || responses[0] != "\x1b[3;4R" || responses[1] != "\x1b[?1;2c" {
+		t.Errorf("responses: %q", responses)
+	}
+}
+
+func TestTabAndBackspace(t *testing.T) {
+	s := New(1, 20)
+	write(s, "a\tb\b\bc")
+
+	if s.String() != "a      cb" {
+		t.Errorf("got %q", s.String())
+	}
+}
+
+func TestView(t *testing.T) {
+	s := New(2, 10)
+	write(s, "zero\r\none\r\ntwo\r\nthree\r\nfour")
+
+	if s.ScrollbackLen() != 3 {
+		t.Fatalf("expected 3 lines of scrollback, got %d", s.ScrollbackLen())
+	}
+
+	for offset, expected := range map[int][]string{
+		0: {"three", "four"},
+		1: {"two", "three"},
+		2: {"one", "two"},
+		3: {"zero", "one"},
+		5: {"zero", "one"},
+	} {
+		view := s.View(offset)
+		if len(view) != 2 || LineText(view[0]) != expected[0] || LineText(view[1]) != expected[1] {
+			t.Errorf("offset %d: expected %v, got %d lines", offset, expected, len(view))
+		}
+	}
+}